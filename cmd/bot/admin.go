@@ -0,0 +1,476 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"naggingbot/internal/app"
+	"naggingbot/internal/backup"
+	"naggingbot/internal/domain"
+	"naggingbot/internal/storage/sqlite"
+	"naggingbot/internal/telegram"
+)
+
+// adminEnv is what an admin subcommand operates on.
+type adminEnv struct {
+	cfg         app.Config
+	db          *sql.DB
+	users       domain.UserStore
+	reminders   domain.ReminderStore
+	occurrences domain.OccurrenceStore
+	digests     domain.DigestStore
+	out         io.Writer
+}
+
+type adminCommand struct {
+	name  string
+	usage string
+	// needsDB opens the database and requires an up-to-date schema.
+	needsDB bool
+	run     func(ctx context.Context, env *adminEnv, args []string) error
+}
+
+var adminCommands = []adminCommand{
+	{"migrate", "migrate                      apply pending database migrations", false, cmdMigrate},
+	{"users list", "users list                   list all users", true, cmdUsersList},
+	{"reminders list", "reminders list -user ID      list a user's reminders (or -telegram ID)", true, cmdRemindersList},
+	{"occurrences requeue", "occurrences requeue          resend unanswered occurrences (-id ID | -reminder ID [-since 24h]) [-dry-run]", true, cmdOccurrencesRequeue},
+	{"broadcast", "broadcast [-dry-run] TEXT    send a message to every user who is not banned", true, cmdBroadcast},
+	{"vacuum", "vacuum                       reclaim unused space in the database file", true, cmdVacuum},
+	{"export", "export -user ID [-out FILE]  write a user's JSON backup (or -telegram ID)", true, cmdExport},
+	{"check-config", "check-config                 validate configuration and database state", false, cmdCheckConfig},
+}
+
+// runAdmin runs an operations subcommand against the configured store without
+// starting the poller or the scheduler, and returns the process exit code.
+func runAdmin(args []string) int {
+	cmd, rest := findAdminCommand(args)
+	if cmd == nil {
+		adminUsage(os.Stderr)
+		if len(args) > 0 && args[0] != "help" && args[0] != "-h" && args[0] != "--help" {
+			return 2
+		}
+		return 0
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	env := &adminEnv{out: os.Stdout}
+	if cmd.name != "check-config" {
+		cfg, err := app.LoadConfig()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to load config: %v\n", err)
+			return 1
+		}
+		env.cfg = cfg
+	}
+	if cmd.needsDB {
+		if err := env.open(ctx); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", cmd.name, err)
+			return 1
+		}
+		defer env.db.Close()
+	}
+
+	if err := cmd.run(ctx, env, rest); err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", cmd.name, err)
+		}
+		return 1
+	}
+	return 0
+}
+
+func findAdminCommand(args []string) (*adminCommand, []string) {
+	for i := range adminCommands {
+		words := strings.Fields(adminCommands[i].name)
+		if len(args) < len(words) {
+			continue
+		}
+		if strings.Join(args[:len(words)], " ") == adminCommands[i].name {
+			return &adminCommands[i], args[len(words):]
+		}
+	}
+	return nil, nil
+}
+
+func adminUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: bot [command]")
+	fmt.Fprintln(w, "\nWithout a command the bot is started. Commands:")
+	for _, cmd := range adminCommands {
+		fmt.Fprintln(w, "  "+cmd.usage)
+	}
+}
+
+// open connects to the configured database. Admin commands never migrate
+// implicitly, so an outdated schema is reported instead.
+func (e *adminEnv) open(ctx context.Context) error {
+	if _, err := os.Stat(e.cfg.DBPath); err != nil {
+		return fmt.Errorf("database %s: %w", e.cfg.DBPath, err)
+	}
+	db, err := sqlite.Open(e.cfg.DBPath)
+	if err != nil {
+		return fmt.Errorf("open database: %w", err)
+	}
+	version, err := sqlite.SchemaVersion(ctx, db)
+	if err != nil {
+		db.Close()
+		return err
+	}
+	if latest := sqlite.LatestSchemaVersion(); version != latest {
+		db.Close()
+		return fmt.Errorf("database schema is at version %d, expected %d; run `bot migrate` first", version, latest)
+	}
+
+	e.db = db
+	e.users = sqlite.NewUserStore(db)
+	e.reminders = sqlite.NewReminderStore(db)
+	e.occurrences = sqlite.NewOccurrenceStore(db)
+	e.digests = sqlite.NewDigestStore(db)
+	return nil
+}
+
+func cmdMigrate(ctx context.Context, env *adminEnv, args []string) error {
+	if len(args) > 0 {
+		return fmt.Errorf("unexpected arguments %v", args)
+	}
+
+	before := 0
+	if _, err := os.Stat(env.cfg.DBPath); err == nil {
+		db, err := sqlite.Open(env.cfg.DBPath)
+		if err != nil {
+			return fmt.Errorf("open database: %w", err)
+		}
+		before, err = sqlite.SchemaVersion(ctx, db)
+		db.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := sqlite.EnsureDB(ctx, env.cfg.DBPath); err != nil {
+		return err
+	}
+	if latest := sqlite.LatestSchemaVersion(); before == latest {
+		fmt.Fprintf(env.out, "schema is up to date (version %d)\n", latest)
+	} else {
+		fmt.Fprintf(env.out, "migrated schema from version %d to %d\n", before, latest)
+	}
+	return nil
+}
+
+func cmdUsersList(ctx context.Context, env *adminEnv, args []string) error {
+	if len(args) > 0 {
+		return fmt.Errorf("unexpected arguments %v", args)
+	}
+	users, err := env.users.List(ctx)
+	if err != nil {
+		return fmt.Errorf("list users: %w", err)
+	}
+
+	w := tabwriter.NewWriter(env.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tTELEGRAM\tUSERNAME\tNAME\tLANG\tTIME ZONE\tBANNED\tREMINDERS")
+	for _, u := range users {
+		rems, err := env.reminders.ListByUser(ctx, u.ID)
+		if err != nil {
+			return fmt.Errorf("list reminders of user %d: %w", u.ID, err)
+		}
+		name := strings.TrimSpace(u.FirstName + " " + u.LastName)
+		fmt.Fprintf(w, "%d\t%d\t%s\t%s\t%s\t%s\t%t\t%d\n", u.ID, u.TelegramID, orDash(u.Username), orDash(name), orDash(u.Language), orDash(u.TimeZone), u.Banned, len(rems))
+	}
+	return w.Flush()
+}
+
+func cmdRemindersList(ctx context.Context, env *adminEnv, args []string) error {
+	fs := flag.NewFlagSet("reminders list", flag.ContinueOnError)
+	userID := fs.Int64("user", 0, "user ID")
+	telegramID := fs.Int64("telegram", 0, "Telegram user ID")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	user, err := resolveUser(ctx, env, *userID, *telegramID)
+	if err != nil {
+		return err
+	}
+
+	rems, err := env.reminders.ListByUser(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("list reminders: %w", err)
+	}
+	w := tabwriter.NewWriter(env.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tNAME\tSTART\tEND\tTIMES\tTIME ZONE\tACTIVE")
+	for _, rem := range rems {
+		loc := rem.Location()
+		times := make([]string, 0, len(rem.TimesOfDay))
+		for _, tod := range rem.TimesOfDay {
+			times = append(times, fmt.Sprintf("%02d:%02d", tod.Hour, tod.Minute))
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\t%t\n", rem.ID, rem.Name,
+			rem.StartDate.In(loc).Format("2006-01-02"), rem.EndDate.In(loc).Format("2006-01-02"),
+			strings.Join(times, ","), rem.TimeZone, rem.IsActive)
+	}
+	return w.Flush()
+}
+
+// cmdOccurrencesRequeue puts unanswered occurrences, delivered or given up as
+// failed, back into the created state so the scheduler sends them again on
+// its next tick.
+func cmdOccurrencesRequeue(ctx context.Context, env *adminEnv, args []string) error {
+	fs := flag.NewFlagSet("occurrences requeue", flag.ContinueOnError)
+	occID := fs.Int64("id", 0, "occurrence ID")
+	reminderID := fs.Int64("reminder", 0, "requeue the reminder's unanswered occurrences")
+	since := fs.Duration("since", 24*time.Hour, "with -reminder, how far back to look")
+	dryRun := fs.Bool("dry-run", false, "only print what would be requeued")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if (*occID == 0) == (*reminderID == 0) {
+		return errors.New("exactly one of -id or -reminder is required")
+	}
+
+	var targets []*domain.Occurrence
+	if *occID != 0 {
+		occ, err := env.occurrences.GetByID(ctx, *occID)
+		if err != nil {
+			return fmt.Errorf("get occurrence: %w", err)
+		}
+		if occ == nil {
+			return fmt.Errorf("occurrence %d not found", *occID)
+		}
+		switch {
+		case occ.Status == domain.OccurrenceIgnored:
+			return fmt.Errorf("occurrence %d was ignored by the user and is not requeued", occ.ID)
+		case !requeueable(occ):
+			return fmt.Errorf("occurrence %d is %s, nothing to requeue", occ.ID, occ.Status)
+		}
+		targets = append(targets, occ)
+	} else {
+		now := time.Now().UTC()
+		occs, err := env.occurrences.ListByReminderInRange(ctx, *reminderID, now.Add(-*since), now)
+		if err != nil {
+			return fmt.Errorf("list occurrences: %w", err)
+		}
+		for _, occ := range occs {
+			if requeueable(occ) {
+				targets = append(targets, occ)
+			}
+		}
+	}
+
+	for _, occ := range targets {
+		if !*dryRun {
+			if err := env.occurrences.UpdateStatus(ctx, occ.ID, domain.OccurrenceCreated); err != nil {
+				return fmt.Errorf("requeue occurrence %d: %w", occ.ID, err)
+			}
+		}
+		fmt.Fprintf(env.out, "occurrence %d (reminder %d, %s, %s)\n", occ.ID, occ.ReminderID, occ.FireAtUtc.Format(time.RFC3339), occ.Status)
+	}
+	verb := "requeued"
+	if *dryRun {
+		verb = "would requeue"
+	}
+	fmt.Fprintf(env.out, "%s %d occurrences\n", verb, len(targets))
+	return nil
+}
+
+// requeueable reports whether occ went unanswered and can be sent again.
+// Done and ignored occurrences were answered, created ones are queued already.
+func requeueable(occ *domain.Occurrence) bool {
+	return occ.Status == domain.OccurrenceSent || occ.Status == domain.OccurrenceFailed
+}
+
+func cmdBroadcast(ctx context.Context, env *adminEnv, args []string) error {
+	fs := flag.NewFlagSet("broadcast", flag.ContinueOnError)
+	dryRun := fs.Bool("dry-run", false, "only print the recipients")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	text := strings.TrimSpace(strings.Join(fs.Args(), " "))
+	if text == "" {
+		return errors.New("message text is required")
+	}
+
+	users, err := env.users.List(ctx)
+	if err != nil {
+		return fmt.Errorf("list users: %w", err)
+	}
+	if *dryRun {
+		recipients := 0
+		for _, u := range users {
+			if !u.Banned {
+				fmt.Fprintf(env.out, "would send to %d (%s)\n", u.TelegramID, orDash(u.Username))
+				recipients++
+			}
+		}
+		fmt.Fprintf(env.out, "%d recipients\n", recipients)
+		return nil
+	}
+
+	sent, failed, err := telegram.Broadcast(ctx, telegram.NewHTTPResponder(env.cfg.BotToken, telegram.NewCallbackSigner(env.cfg.BotToken)), users, text)
+	fmt.Fprintf(env.out, "sent %d, failed %d\n", sent, failed)
+	return err
+}
+
+func cmdVacuum(ctx context.Context, env *adminEnv, args []string) error {
+	if len(args) > 0 {
+		return fmt.Errorf("unexpected arguments %v", args)
+	}
+	before, err := os.Stat(env.cfg.DBPath)
+	if err != nil {
+		return err
+	}
+	if err := sqlite.Vacuum(ctx, env.db); err != nil {
+		return fmt.Errorf("vacuum: %w", err)
+	}
+	after, err := os.Stat(env.cfg.DBPath)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(env.out, "database size %d -> %d bytes\n", before.Size(), after.Size())
+	return nil
+}
+
+func cmdExport(ctx context.Context, env *adminEnv, args []string) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	userID := fs.Int64("user", 0, "user ID")
+	telegramID := fs.Int64("telegram", 0, "Telegram user ID")
+	out := fs.String("out", "", "output file (default stdout)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	user, err := resolveUser(ctx, env, *userID, *telegramID)
+	if err != nil {
+		return err
+	}
+
+	rems, err := env.reminders.ListByUser(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("list reminders: %w", err)
+	}
+	occs := make(map[int64][]*domain.Occurrence, len(rems))
+	for _, rem := range rems {
+		if occs[rem.ID], err = env.occurrences.ListByReminder(ctx, rem.ID); err != nil {
+			return fmt.Errorf("list occurrences of reminder %d: %w", rem.ID, err)
+		}
+	}
+	digest, err := env.digests.Get(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("load digest settings: %w", err)
+	}
+
+	data, err := backup.Encode(backup.Build(user, digest, rems, occs, time.Now()))
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	if *out == "" {
+		_, err = env.out.Write(data)
+		return err
+	}
+	if err := os.WriteFile(*out, data, 0o600); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "wrote %d reminders to %s\n", len(rems), *out)
+	return nil
+}
+
+// cmdCheckConfig validates the configuration and reports the database state
+// without modifying anything.
+func cmdCheckConfig(ctx context.Context, env *adminEnv, args []string) error {
+	if len(args) > 0 {
+		return fmt.Errorf("unexpected arguments %v", args)
+	}
+	cfg, err := app.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(env.out, "BOT_TOKEN           %s\n", maskToken(cfg.BotToken))
+	fmt.Fprintf(env.out, "DB_PATH             %s\n", cfg.DBPath)
+	fmt.Fprintf(env.out, "POLL_INTERVAL       %s\n", cfg.PollInterval)
+	fmt.Fprintf(env.out, "POLL_TIMEOUT        %s\n", cfg.PollTimeout)
+	fmt.Fprintf(env.out, "SCHEDULER_INTERVAL  %s\n", cfg.SchedulerInterval)
+	fmt.Fprintf(env.out, "ADMIN_IDS           %d admins\n", len(cfg.AdminIDs))
+	fmt.Fprintf(env.out, "REGISTRATION_MODE   %s (%d allowlist entries)\n", cfg.RegistrationMode, len(cfg.Allowlist))
+	fmt.Fprintf(env.out, "QUOTAS              %d active reminders, %d times each, %d per day, %d days long\n",
+		cfg.MaxActiveReminders, cfg.MaxTimesPerReminder, cfg.MaxOccurrencesPerDay, cfg.MaxReminderDays)
+	fmt.Fprintf(env.out, "RATE_LIMIT          %d per minute\n", cfg.RateLimitPerMinute)
+	if _, err := telegram.NewRegistrationPolicy(telegram.RegistrationMode(cfg.RegistrationMode), cfg.Allowlist); err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(cfg.DBPath); errors.Is(err, os.ErrNotExist) {
+		fmt.Fprintln(env.out, "database does not exist yet; it is created on first start or by `bot migrate`")
+		return nil
+	}
+	db, err := sqlite.Open(cfg.DBPath)
+	if err != nil {
+		return fmt.Errorf("open database: %w", err)
+	}
+	defer db.Close()
+	if err := db.PingContext(ctx); err != nil {
+		return fmt.Errorf("ping database: %w", err)
+	}
+	version, err := sqlite.SchemaVersion(ctx, db)
+	if err != nil {
+		return err
+	}
+	latest := sqlite.LatestSchemaVersion()
+	switch {
+	case version < latest:
+		fmt.Fprintf(env.out, "database schema at version %d, %d migrations pending (applied on start or by `bot migrate`)\n", version, latest-version)
+	case version > latest:
+		return fmt.Errorf("database schema version %d is newer than this binary supports (%d)", version, latest)
+	default:
+		fmt.Fprintf(env.out, "database schema up to date (version %d)\n", version)
+	}
+	fmt.Fprintln(env.out, "config OK")
+	return nil
+}
+
+// resolveUser loads the user given by exactly one of an internal or a Telegram ID.
+func resolveUser(ctx context.Context, env *adminEnv, userID, telegramID int64) (*domain.User, error) {
+	var (
+		user *domain.User
+		err  error
+	)
+	switch {
+	case (userID == 0) == (telegramID == 0):
+		return nil, errors.New("exactly one of -user or -telegram is required")
+	case userID != 0:
+		user, err = env.users.GetByID(ctx, userID)
+	default:
+		user, err = env.users.GetByTelegramID(ctx, telegramID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get user: %w", err)
+	}
+	if user == nil {
+		return nil, errors.New("user not found")
+	}
+	return user, nil
+}
+
+func maskToken(token string) string {
+	if i := strings.Index(token, ":"); i > 0 {
+		return token[:i] + ":****"
+	}
+	return "****"
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
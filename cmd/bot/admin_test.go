@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"naggingbot/internal/domain"
+	"naggingbot/internal/storage/memory"
+)
+
+func TestOccurrencesRequeueFiltersStatuses(t *testing.T) {
+	ctx := context.Background()
+	occurrences := memory.NewInMemoryOccurrenceStore()
+	env := &adminEnv{occurrences: occurrences, out: &bytes.Buffer{}}
+
+	now := time.Now().UTC()
+	byStatus := make(map[domain.OccurrenceStatus]*domain.Occurrence)
+	for i, status := range []domain.OccurrenceStatus{
+		domain.OccurrenceCreated, domain.OccurrenceSent, domain.OccurrenceDone, domain.OccurrenceIgnored, domain.OccurrenceFailed,
+	} {
+		occ := &domain.Occurrence{ReminderID: 1, FireAtUtc: now.Add(-time.Duration(i+1) * time.Hour), Status: status}
+		if err := occurrences.Create(ctx, occ); err != nil {
+			t.Fatal(err)
+		}
+		byStatus[status] = occ
+	}
+	status := func(occ *domain.Occurrence) domain.OccurrenceStatus {
+		t.Helper()
+		got, err := occurrences.GetByID(ctx, occ.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return got.Status
+	}
+
+	// -reminder picks only the delivered and the failed occurrences.
+	if err := cmdOccurrencesRequeue(ctx, env, []string{"-reminder", "1", "-dry-run"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := env.out.(*bytes.Buffer).String(); !strings.HasSuffix(got, "would requeue 2 occurrences\n") {
+		t.Fatalf("dry run printed %q, want 2 occurrences", got)
+	}
+	if status(byStatus[domain.OccurrenceSent]) != domain.OccurrenceSent {
+		t.Fatal("a dry run requeued an occurrence")
+	}
+
+	// -id refuses answered and queued occurrences.
+	for _, st := range []domain.OccurrenceStatus{domain.OccurrenceCreated, domain.OccurrenceDone, domain.OccurrenceIgnored} {
+		err := cmdOccurrencesRequeue(ctx, env, []string{"-id", fmt.Sprint(byStatus[st].ID)})
+		if err == nil {
+			t.Fatalf("requeued a %s occurrence", st)
+		}
+		if st == domain.OccurrenceIgnored && !strings.Contains(err.Error(), "ignored by the user") {
+			t.Fatalf("error = %v, want the ignored refusal", err)
+		}
+		if status(byStatus[st]) != st {
+			t.Fatalf("a refused %s occurrence changed status", st)
+		}
+	}
+	for _, st := range []domain.OccurrenceStatus{domain.OccurrenceSent, domain.OccurrenceFailed} {
+		if err := cmdOccurrencesRequeue(ctx, env, []string{"-id", fmt.Sprint(byStatus[st].ID)}); err != nil {
+			t.Fatalf("requeue %s occurrence: %v", st, err)
+		}
+		if got := status(byStatus[st]); got != domain.OccurrenceCreated {
+			t.Fatalf("%s occurrence is %s after requeue, want created", st, got)
+		}
+	}
+}
@@ -2,24 +2,36 @@ package main
 
 import (
 	"context"
-	"database/sql"
 	"errors"
 	"log"
+	"os"
 
 	"naggingbot/internal/app"
+	"naggingbot/internal/domain"
+	"naggingbot/internal/email"
 	"naggingbot/internal/scheduler"
 	"naggingbot/internal/storage/sqlite"
 	"naggingbot/internal/telegram"
+	"naggingbot/internal/webhook"
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		os.Exit(runAdmin(os.Args[1:]))
+	}
+	runBot()
+}
+
+// runBot starts the Telegram poller and the scheduler and blocks.
+func runBot() {
 	log.Println("NaggingBot starting up (scheduler demo)")
 
 	cfg, err := app.LoadConfig()
 	if err != nil {
 		log.Fatalf("failed to load config: %v", err)
 	}
-	log.Printf("config loaded: poll=%s scheduler=%s db=%s", cfg.PollInterval, cfg.SchedulerInterval, cfg.DBPath)
+	log.Printf("config loaded: poll=%s scheduler=%s db=%s admins=%d registration=%s",
+		cfg.PollInterval, cfg.SchedulerInterval, cfg.DBPath, len(cfg.AdminIDs), cfg.RegistrationMode)
 
 	ctx := context.Background()
 	if err := sqlite.EnsureDB(ctx, cfg.DBPath); err != nil {
@@ -29,8 +41,14 @@ func main() {
 	if err := telegram.SetBotCommands(ctx, cfg.BotToken); err != nil {
 		log.Printf("failed to set bot commands: %v", err)
 	}
+	var botUsername string
+	if me, err := telegram.GetMe(ctx, cfg.BotToken); err != nil {
+		log.Printf("failed to get bot info, invite links disabled: %v", err)
+	} else {
+		botUsername = me.Username
+	}
 
-	db, err := sql.Open("sqlite", cfg.DBPath)
+	db, err := sqlite.Open(cfg.DBPath)
 	if err != nil {
 		log.Fatalf("failed to open database: %v", err)
 	}
@@ -39,24 +57,86 @@ func main() {
 	occurrenceStore := sqlite.NewOccurrenceStore(db)
 	userStore := sqlite.NewUserStore(db)
 	reminderStore := sqlite.NewReminderStore(db)
+	digestStore := sqlite.NewDigestStore(db)
+	inviteStore := sqlite.NewInviteStore(db)
+	webhookStore := sqlite.NewWebhookStore(db)
+	emailStore := sqlite.NewEmailStore(db)
+	uow := sqlite.NewUnitOfWork(db)
+	signer := telegram.NewCallbackSigner(cfg.BotToken)
+	responder := telegram.NewHTTPResponder(cfg.BotToken, signer)
 
-	logNotifier := &scheduler.LoggingNotifier{}
-	tgNotifier := telegram.NewNotifier(cfg.BotToken, userStore)
-	multiNotifier := scheduler.NewMultiNotifier(logNotifier, tgNotifier)
-	sched := scheduler.New(occurrenceStore, reminderStore, multiNotifier, cfg.SchedulerInterval)
+	tgNotifier := telegram.NewNotifier(cfg.BotToken, signer, userStore, occurrenceStore)
+	webhookNotifier := webhook.NewNotifier(webhook.NewClient(cfg.WebhookTimeout, cfg.WebhookAllowedNetworks...), userStore, webhookStore, cfg.WebhookAttempts)
+	router := scheduler.NewRouter(userStore, occurrenceStore)
+	router.Handle(domain.ChannelTelegram, tgNotifier)
+	router.Handle(domain.ChannelWebhook, webhookNotifier)
+	// mailer stays nil without an SMTP relay, which disables /email.
+	var mailer email.Mailer
+	if cfg.Email().Enabled() {
+		sender := email.NewSender(cfg.Email())
+		mailer = sender
+		router.Handle(domain.ChannelEmail, email.NewNotifier(sender, userStore, emailStore))
+	}
+	sched := scheduler.New(occurrenceStore, reminderStore, router, cfg.SchedulerInterval)
+	sched.SetEscalator(tgNotifier)
+	sched.SetStepSender(scheduler.StepRouter{Chat: tgNotifier, Webhook: webhookNotifier})
+	sched.SetDigester(scheduler.NewDigester(userStore, reminderStore, occurrenceStore, digestStore, telegram.NewDigestSender(responder)))
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
 	// Telegram dispatcher and polling.
 	dispatcher := telegram.NewDispatcher()
-	responder := telegram.NewHTTPResponder(cfg.BotToken)
-	dispatcher.RegisterCommand("/start", telegram.NewStartHandler(userStore, responder))
-	dispatcher.RegisterCommand("/reminder", telegram.NewReminderHandler(userStore, reminderStore, occurrenceStore, responder))
-	dispatcher.RegisterCommand("/test", telegram.NewTestHandler(userStore, reminderStore, occurrenceStore, responder, 737053478))
-	dispatcher.RegisterCommand("/list", telegram.NewListHandler(userStore, reminderStore, responder))
-	dispatcher.RegisterCommand("/delete", telegram.NewDeleteHandler(userStore, reminderStore, occurrenceStore, responder))
-	dispatcher.RegisterCallback(telegram.NewOccurrenceCallbackHandler(occurrenceStore, responder))
+	dispatcher.SetUsername(botUsername)
+	policy, err := telegram.NewRegistrationPolicy(telegram.RegistrationMode(cfg.RegistrationMode), cfg.Allowlist)
+	if err != nil {
+		log.Fatalf("invalid registration policy: %v", err)
+	}
+	auth := telegram.NewAuthorizer(cfg.AdminIDs, userStore, policy, responder)
+	metrics := telegram.NewMetrics()
+	dispatcher.Use(telegram.Recover(), telegram.Logging(), telegram.Instrument(metrics), telegram.AnswerCallbacks(responder), telegram.VerifyCallbacks(signer, responder))
+	if cfg.RateLimitPerMinute > 0 {
+		dispatcher.Use(telegram.RateLimit(telegram.NewRateLimiter(cfg.RateLimitPerMinute, responder), auth))
+	}
+	dispatcher.Use(telegram.Authorize(auth), telegram.LoadUser(userStore, responder))
+
+	dispatcher.RegisterPublicCommand("/start", telegram.NewStartHandler(uow, auth, responder))
+	dispatcher.RegisterCommand("/reminder", telegram.NewReminderHandler(uow, cfg.Limits(), responder))
+	dispatcher.RegisterAdminCommand("/test", telegram.NewTestHandler(uow, responder))
+	dispatcher.RegisterAdminCommand("/admin", telegram.NewAdminHandler(userStore, reminderStore, occurrenceStore, inviteStore, auth, metrics, responder, botUsername))
+	dispatcher.RegisterCommand("/list", telegram.NewListHandler(reminderStore, responder))
+	dispatcher.RegisterCommand("/delete", telegram.NewDeleteHandler(reminderStore, uow, responder))
+	templateHandler := telegram.NewTemplateHandler(reminderStore, responder)
+	dispatcher.RegisterCommand("/template", templateHandler)
+	dispatcher.RegisterCommand("/priority", templateHandler)
+	dispatcher.RegisterCommand("/share", telegram.NewShareHandler(reminderStore, responder))
+	assignHandler := telegram.NewAssignHandler(userStore, reminderStore, responder)
+	dispatcher.RegisterCommand("/assign", assignHandler)
+	dispatcher.RegisterCallback(telegram.AssignCallbackPrefix, assignHandler)
+	escalateHandler := telegram.NewEscalateHandler(userStore, reminderStore, responder)
+	dispatcher.RegisterCommand("/escalate", escalateHandler)
+	dispatcher.RegisterCallback(telegram.EscalateCallbackPrefix, escalateHandler)
+	dispatcher.RegisterPublicCallback(telegram.SharedCallbackPrefix, telegram.NewSharedCallbackHandler(userStore, reminderStore, occurrenceStore, responder))
+	dispatcher.RegisterCommand("/stats", telegram.NewStatsHandler(reminderStore, occurrenceStore, responder))
+	historyHandler := telegram.NewHistoryHandler(reminderStore, occurrenceStore, responder)
+	dispatcher.RegisterCommand("/history", historyHandler)
+	dispatcher.RegisterCallback(telegram.HistoryCallbackPrefix, historyHandler)
+	agendaHandler := telegram.NewAgendaHandler(reminderStore, occurrenceStore, responder)
+	dispatcher.RegisterCommand("/today", agendaHandler)
+	dispatcher.RegisterCommand("/upcoming", agendaHandler)
+	dispatcher.RegisterCallback(telegram.AgendaCallbackPrefix, agendaHandler)
+	dispatcher.RegisterCommand("/digest", telegram.NewDigestHandler(reminderStore, digestStore, responder))
+	dispatcher.RegisterCommand("/webhook", telegram.NewWebhookHandler(reminderStore, webhookStore, responder))
+	dispatcher.RegisterCommand("/email", telegram.NewEmailHandler(emailStore, mailer, responder))
+	dispatcher.RegisterCommand("/channels", telegram.NewChannelsHandler(userStore, reminderStore, occurrenceStore, emailStore, webhookStore, mailer != nil, responder))
+	importHandler := telegram.NewImportHandler(userStore, reminderStore, digestStore, uow, cfg.Limits(), responder)
+	dispatcher.RegisterCommand("/export", telegram.NewExportHandler(reminderStore, occurrenceStore, digestStore, responder))
+	dispatcher.RegisterCommand("/import", importHandler)
+	dispatcher.RegisterDocument(importHandler)
+	dispatcher.RegisterCallback(telegram.RestoreCallbackPrefix, importHandler)
+	dispatcher.RegisterCommand("/timezone", telegram.NewTimezoneHandler(userStore, reminderStore, responder))
+	dispatcher.RegisterCommand("/language", telegram.NewLanguageHandler(userStore, responder))
+	dispatcher.RegisterCallback(telegram.OccurrenceCallbackPrefix, telegram.NewOccurrenceCallbackHandler(reminderStore, occurrenceStore, responder))
 
 	tgClient := telegram.NewClient(cfg.BotToken, cfg.PollInterval, cfg.PollTimeout)
 	go func() {
@@ -64,6 +144,11 @@ func main() {
 			log.Printf("telegram poller stopped: %v", err)
 		}
 	}()
+	go func() {
+		if err := webhookNotifier.Run(ctx); err != nil && !errors.Is(err, context.Canceled) {
+			log.Printf("webhook queue stopped: %v", err)
+		}
+	}()
 
 	if err := sched.Run(ctx); err != nil && !errors.Is(err, context.Canceled) {
 		log.Printf("scheduler stopped with error: %v", err)
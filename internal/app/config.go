@@ -3,10 +3,15 @@ package app
 import (
 	"bufio"
 	"fmt"
+	"net/netip"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
+
+	"naggingbot/internal/domain"
+	"naggingbot/internal/email"
 )
 
 // Config holds runtime settings loaded from environment variables.
@@ -16,6 +21,34 @@ type Config struct {
 	PollInterval      time.Duration
 	PollTimeout       time.Duration
 	SchedulerInterval time.Duration
+	// AdminIDs are the Telegram user IDs allowed to run admin commands.
+	AdminIDs []int64
+	// RegistrationMode is "open", "allowlist" or "invite".
+	RegistrationMode string
+	// Allowlist holds Telegram user IDs and @usernames admitted in allowlist mode.
+	Allowlist []string
+	// Per-user quotas; zero disables a limit.
+	MaxActiveReminders   int
+	MaxTimesPerReminder  int
+	MaxOccurrencesPerDay int
+	MaxReminderDays      int
+	// RateLimitPerMinute caps the updates handled per user per minute; zero disables it.
+	RateLimitPerMinute int
+	// WebhookTimeout bounds one webhook request; WebhookAttempts is how often
+	// a failing escalation event is tried. Reminder events are retried by the
+	// scheduler like the other channels.
+	WebhookTimeout  time.Duration
+	WebhookAttempts int
+	// WebhookAllowedNetworks are internal networks webhooks may reach; all
+	// others are blocked.
+	WebhookAllowedNetworks []netip.Prefix
+	// SMTP relay for email notifications; email is off without SMTPHost.
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
+	SMTPFrom     string
+	SMTPStartTLS bool
 }
 
 // LoadConfig reads environment variables and validates them.
@@ -26,6 +59,23 @@ type Config struct {
 //   POLL_INTERVAL        - Cooldown between polling attempts (default: 30s)
 //   POLL_TIMEOUT         - Long-poll timeout per request (default: 10s)
 //   SCHEDULER_INTERVAL   - Scheduler tick interval (default: 1s)
+//   ADMIN_IDS            - Comma-separated Telegram user IDs with admin rights (default: none)
+//   REGISTRATION_MODE    - Who may register: open, allowlist or invite (default: open)
+//   REGISTRATION_ALLOWLIST - Comma-separated Telegram user IDs and @usernames for allowlist mode
+//   MAX_ACTIVE_REMINDERS - Active reminders per user (default: 50, 0 = unlimited)
+//   MAX_TIMES_PER_REMINDER - Times of day per reminder (default: 12, 0 = unlimited)
+//   MAX_OCCURRENCES_PER_DAY - Occurrences per user per day (default: 48, 0 = unlimited)
+//   MAX_REMINDER_DAYS    - Length of a reminder's date range (default: 366, 0 = unlimited)
+//   RATE_LIMIT_PER_MINUTE - Commands and button presses per user per minute (default: 30, 0 = unlimited)
+//   WEBHOOK_TIMEOUT      - Timeout of one webhook request (default: 10s)
+//   WEBHOOK_ATTEMPTS     - Attempts per webhook escalation event, with backoff (default: 3)
+//   WEBHOOK_ALLOWED_NETWORKS - Comma-separated CIDRs of internal networks webhooks may reach (default: none)
+//   SMTP_HOST            - SMTP relay for email notifications (default: none, email off)
+//   SMTP_PORT            - SMTP relay port (default: 587)
+//   SMTP_USERNAME        - SMTP login; empty skips authentication
+//   SMTP_PASSWORD        - SMTP password
+//   SMTP_FROM            - Sender address, required with SMTP_HOST
+//   SMTP_STARTTLS        - Require STARTTLS (default: true)
 func LoadConfig() (Config, error) {
 	// Best-effort load .env.
 	if err := loadEnvFile(".env"); err != nil {
@@ -40,6 +90,7 @@ func LoadConfig() (Config, error) {
 	cfg.PollInterval = time.Second * 30
 	cfg.PollTimeout = time.Second * 10
 	cfg.SchedulerInterval = time.Second
+	cfg.WebhookTimeout = 10 * time.Second
 
 	if v := os.Getenv("POLL_INTERVAL"); v != "" {
 		d, err := time.ParseDuration(v)
@@ -65,6 +116,81 @@ func LoadConfig() (Config, error) {
 		cfg.SchedulerInterval = d
 	}
 
+	if v := os.Getenv("WEBHOOK_TIMEOUT"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid WEBHOOK_TIMEOUT: %w", err)
+		}
+		cfg.WebhookTimeout = d
+	}
+
+	for _, entry := range strings.Split(os.Getenv("WEBHOOK_ALLOWED_NETWORKS"), ",") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+		prefix, err := netip.ParsePrefix(entry)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid WEBHOOK_ALLOWED_NETWORKS: %w", err)
+		}
+		cfg.WebhookAllowedNetworks = append(cfg.WebhookAllowedNetworks, prefix.Masked())
+	}
+
+	if v := os.Getenv("ADMIN_IDS"); v != "" {
+		ids, err := parseIDList(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid ADMIN_IDS: %w", err)
+		}
+		cfg.AdminIDs = ids
+	}
+
+	cfg.MaxActiveReminders = domain.DefaultLimits.MaxActiveReminders
+	cfg.MaxTimesPerReminder = domain.DefaultLimits.MaxTimesPerReminder
+	cfg.MaxOccurrencesPerDay = domain.DefaultLimits.MaxOccurrencesPerDay
+	cfg.MaxReminderDays = domain.DefaultLimits.MaxReminderDays
+	cfg.RateLimitPerMinute = 30
+	cfg.WebhookAttempts = 3
+	cfg.SMTPPort = 587
+	for name, dst := range map[string]*int{
+		"MAX_ACTIVE_REMINDERS":    &cfg.MaxActiveReminders,
+		"MAX_TIMES_PER_REMINDER":  &cfg.MaxTimesPerReminder,
+		"MAX_OCCURRENCES_PER_DAY": &cfg.MaxOccurrencesPerDay,
+		"MAX_REMINDER_DAYS":       &cfg.MaxReminderDays,
+		"RATE_LIMIT_PER_MINUTE":   &cfg.RateLimitPerMinute,
+		"WEBHOOK_ATTEMPTS":        &cfg.WebhookAttempts,
+		"SMTP_PORT":               &cfg.SMTPPort,
+	} {
+		if v := os.Getenv(name); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return Config{}, fmt.Errorf("invalid %s: %w", name, err)
+			}
+			*dst = n
+		}
+	}
+
+	cfg.SMTPHost = strings.TrimSpace(os.Getenv("SMTP_HOST"))
+	cfg.SMTPUsername = os.Getenv("SMTP_USERNAME")
+	cfg.SMTPPassword = os.Getenv("SMTP_PASSWORD")
+	cfg.SMTPFrom = strings.TrimSpace(os.Getenv("SMTP_FROM"))
+	cfg.SMTPStartTLS = true
+	if v := os.Getenv("SMTP_STARTTLS"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid SMTP_STARTTLS: %w", err)
+		}
+		cfg.SMTPStartTLS = b
+	}
+
+	cfg.RegistrationMode = "open"
+	if v := os.Getenv("REGISTRATION_MODE"); v != "" {
+		cfg.RegistrationMode = strings.ToLower(strings.TrimSpace(v))
+	}
+	for _, entry := range strings.Split(os.Getenv("REGISTRATION_ALLOWLIST"), ",") {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			cfg.Allowlist = append(cfg.Allowlist, entry)
+		}
+	}
+
 	if err := cfg.validate(); err != nil {
 		return Config{}, err
 	}
@@ -72,6 +198,23 @@ func LoadConfig() (Config, error) {
 	return cfg, nil
 }
 
+// parseIDList parses a comma-separated list of integer IDs.
+func parseIDList(v string) ([]int64, error) {
+	var ids []int64
+	for _, part := range strings.Split(v, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		id, err := strconv.ParseInt(part, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
 // loadEnvFile populates process env vars from a file formatted as KEY=VALUE per line.
 // It ignores blank lines and lines starting with '#'. Missing file is not an error.
 func loadEnvFile(path string) error {
@@ -144,6 +287,28 @@ func loadEnvFile(path string) error {
 	return nil
 }
 
+// Limits returns the configured per-user quotas.
+func (c Config) Limits() domain.Limits {
+	return domain.Limits{
+		MaxActiveReminders:   c.MaxActiveReminders,
+		MaxTimesPerReminder:  c.MaxTimesPerReminder,
+		MaxOccurrencesPerDay: c.MaxOccurrencesPerDay,
+		MaxReminderDays:      c.MaxReminderDays,
+	}
+}
+
+// Email returns the SMTP relay settings.
+func (c Config) Email() email.Config {
+	return email.Config{
+		Host:     c.SMTPHost,
+		Port:     c.SMTPPort,
+		Username: c.SMTPUsername,
+		Password: c.SMTPPassword,
+		From:     c.SMTPFrom,
+		StartTLS: c.SMTPStartTLS,
+	}
+}
+
 func (c Config) validate() error {
 	var problems []string
 
@@ -162,6 +327,35 @@ func (c Config) validate() error {
 	if c.SchedulerInterval <= 0 {
 		problems = append(problems, "SCHEDULER_INTERVAL must be > 0")
 	}
+	if c.MaxActiveReminders < 0 || c.MaxTimesPerReminder < 0 || c.MaxOccurrencesPerDay < 0 || c.MaxReminderDays < 0 {
+		problems = append(problems, "MAX_* limits must be >= 0")
+	}
+	if c.RateLimitPerMinute < 0 {
+		problems = append(problems, "RATE_LIMIT_PER_MINUTE must be >= 0")
+	}
+	if c.WebhookTimeout <= 0 {
+		problems = append(problems, "WEBHOOK_TIMEOUT must be > 0")
+	}
+	if c.WebhookAttempts < 1 {
+		problems = append(problems, "WEBHOOK_ATTEMPTS must be >= 1")
+	}
+	if c.SMTPHost != "" {
+		if c.SMTPPort < 1 || c.SMTPPort > 65535 {
+			problems = append(problems, "SMTP_PORT must be a port number")
+		}
+		if err := domain.ValidateEmail(c.SMTPFrom); err != nil {
+			problems = append(problems, "SMTP_FROM must be an email address")
+		}
+	}
+	switch c.RegistrationMode {
+	case "open", "invite":
+	case "allowlist":
+		if len(c.Allowlist) == 0 {
+			problems = append(problems, "REGISTRATION_ALLOWLIST is required in allowlist mode")
+		}
+	default:
+		problems = append(problems, "REGISTRATION_MODE must be open, allowlist or invite")
+	}
 
 	if len(problems) > 0 {
 		return fmt.Errorf("invalid config: %s", strings.Join(problems, "; "))
@@ -0,0 +1,318 @@
+// Package backup defines the versioned JSON document used to back up and
+// restore a user's reminders, settings and occurrence history.
+package backup
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"naggingbot/internal/domain"
+)
+
+// Version is the current document format version.
+const Version = 1
+
+// Document is the top-level backup document.
+type Document struct {
+	Version    int        `json:"version"`
+	ExportedAt time.Time  `json:"exported_at"`
+	Settings   Settings   `json:"settings"`
+	Reminders  []Reminder `json:"reminders"`
+}
+
+// Settings holds per-user preferences.
+type Settings struct {
+	TimeZone string  `json:"time_zone,omitempty"`
+	Digest   *Digest `json:"digest,omitempty"`
+}
+
+// Digest mirrors domain.DigestSettings without delivery bookkeeping.
+type Digest struct {
+	Morning string `json:"morning,omitempty"`
+	Evening string `json:"evening,omitempty"`
+	Weekly  bool   `json:"weekly,omitempty"`
+}
+
+// Reminder is a reminder with its occurrence history. ID is the ID on the
+// exporting instance and is informational only. The group a reminder is
+// posted to, the user it is assigned to, its escalation steps and its webhook
+// are not exported: a crafted backup could otherwise deliver reminders to any
+// chat, user or URL, so restored reminders go to the private chat.
+type Reminder struct {
+	ID          int64        `json:"id,omitempty"`
+	Name        string       `json:"name"`
+	Description string       `json:"description,omitempty"`
+	StartDate   time.Time    `json:"start_date"`
+	EndDate     time.Time    `json:"end_date"`
+	TimesOfDay  []string     `json:"times_of_day"`
+	TimeZone    string       `json:"time_zone"`
+	IsActive    bool         `json:"is_active"`
+	Priority    string       `json:"priority,omitempty"`
+	Template    string       `json:"template,omitempty"`
+	Channels    string       `json:"channels,omitempty"`
+	Occurrences []Occurrence `json:"occurrences,omitempty"`
+}
+
+// Occurrence is one scheduled execution. Telegram chat and message IDs are not
+// exported since they are meaningless on another account.
+type Occurrence struct {
+	FireAt   time.Time  `json:"fire_at"`
+	Status   string     `json:"status"`
+	SentAt   *time.Time `json:"sent_at,omitempty"`
+	AckedAt  *time.Time `json:"acked_at,omitempty"`
+	Attempts int        `json:"attempts,omitempty"`
+}
+
+var statusNames = map[domain.OccurrenceStatus]string{
+	domain.OccurrenceCreated: "created",
+	domain.OccurrenceSent:    "sent",
+	domain.OccurrenceDone:    "done",
+	domain.OccurrenceIgnored: "ignored",
+	domain.OccurrenceFailed:  "failed",
+}
+
+// Build assembles a document. occurrences maps reminder IDs to their history;
+// digest may be nil.
+func Build(user *domain.User, digest *domain.DigestSettings, reminders []*domain.Reminder, occurrences map[int64][]*domain.Occurrence, now time.Time) *Document {
+	doc := &Document{
+		Version:    Version,
+		ExportedAt: now.UTC(),
+		Settings:   Settings{TimeZone: user.TimeZone},
+		Reminders:  []Reminder{},
+	}
+	if digest != nil && digest.Enabled() {
+		doc.Settings.Digest = &Digest{
+			Morning: formatTimeOfDay(digest.Morning),
+			Evening: formatTimeOfDay(digest.Evening),
+			Weekly:  digest.Weekly,
+		}
+	}
+
+	for _, rem := range reminders {
+		r := Reminder{
+			ID:          rem.ID,
+			Name:        rem.Name,
+			Description: rem.Description,
+			StartDate:   rem.StartDate.UTC(),
+			EndDate:     rem.EndDate.UTC(),
+			TimeZone:    rem.TimeZone,
+			IsActive:    rem.IsActive,
+			Template:    rem.Template,
+		}
+		if rem.Priority != domain.PriorityNormal {
+			r.Priority = rem.Priority.String()
+		}
+		if rem.Channels != 0 {
+			r.Channels = rem.Channels.String()
+		}
+		for _, tod := range rem.TimesOfDay {
+			r.TimesOfDay = append(r.TimesOfDay, formatTimeOfDay(&tod))
+		}
+		for _, occ := range occurrences[rem.ID] {
+			o := Occurrence{
+				FireAt:   occ.FireAtUtc.UTC(),
+				Status:   statusNames[occ.Status],
+				Attempts: occ.Attempts,
+			}
+			if !occ.SentAtUtc.IsZero() {
+				t := occ.SentAtUtc.UTC()
+				o.SentAt = &t
+			}
+			if !occ.AckedAtUtc.IsZero() {
+				t := occ.AckedAtUtc.UTC()
+				o.AckedAt = &t
+			}
+			r.Occurrences = append(r.Occurrences, o)
+		}
+		doc.Reminders = append(doc.Reminders, r)
+	}
+	return doc
+}
+
+// Encode renders the document as indented JSON.
+func Encode(doc *Document) ([]byte, error) {
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+// Decode parses and validates a document.
+func Decode(data []byte) (*Document, error) {
+	var doc Document
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	if err := doc.Validate(); err != nil {
+		return nil, err
+	}
+	return &doc, nil
+}
+
+// Validate checks the version and every field that restore relies on.
+func (d *Document) Validate() error {
+	if d.Version != Version {
+		return fmt.Errorf("unsupported backup version %d (expected %d)", d.Version, Version)
+	}
+
+	var problems []string
+	if tz := d.Settings.TimeZone; tz != "" {
+		if _, err := time.LoadLocation(tz); err != nil {
+			problems = append(problems, fmt.Sprintf("settings: invalid time zone %q", tz))
+		}
+	}
+	if dg := d.Settings.Digest; dg != nil {
+		if _, err := parseTimeOfDay(dg.Morning); err != nil {
+			problems = append(problems, "settings: invalid digest morning time")
+		}
+		if _, err := parseTimeOfDay(dg.Evening); err != nil {
+			problems = append(problems, "settings: invalid digest evening time")
+		}
+	}
+
+	for i, r := range d.Reminders {
+		where := fmt.Sprintf("reminder %d (%q)", i+1, r.Name)
+		if strings.TrimSpace(r.Name) == "" {
+			problems = append(problems, where+": name is required")
+		}
+		if _, err := time.LoadLocation(r.TimeZone); err != nil || r.TimeZone == "" {
+			problems = append(problems, where+": invalid time zone")
+		}
+		if r.StartDate.IsZero() || r.EndDate.Before(r.StartDate) {
+			problems = append(problems, where+": invalid date range")
+		}
+		if r.Priority != "" {
+			if _, err := domain.ParsePriority(r.Priority); err != nil {
+				problems = append(problems, fmt.Sprintf("%s: invalid priority %q", where, r.Priority))
+			}
+		}
+		if r.Channels != "" {
+			if _, err := domain.ParseChannels(r.Channels); err != nil {
+				problems = append(problems, fmt.Sprintf("%s: invalid channels %q", where, r.Channels))
+			}
+		}
+		for _, t := range r.TimesOfDay {
+			if tod, err := parseTimeOfDay(t); err != nil || tod == nil {
+				problems = append(problems, fmt.Sprintf("%s: invalid time of day %q", where, t))
+			}
+		}
+		for j, o := range r.Occurrences {
+			if _, ok := parseStatus(o.Status); !ok {
+				problems = append(problems, fmt.Sprintf("%s: occurrence %d has invalid status %q", where, j+1, o.Status))
+			}
+			if o.FireAt.IsZero() {
+				problems = append(problems, fmt.Sprintf("%s: occurrence %d has no fire time", where, j+1))
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid backup: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// OccurrenceCount returns the number of occurrences across all reminders.
+func (d *Document) OccurrenceCount() int {
+	n := 0
+	for _, r := range d.Reminders {
+		n += len(r.Occurrences)
+	}
+	return n
+}
+
+// ToDomain converts a validated reminder into a domain reminder and its
+// occurrences for the given user. Occurrence ReminderIDs are left zero.
+// Occurrences that were never sent and are due before now are restored as
+// sent, so the scheduler does not deliver a burst of stale notifications;
+// they still count as missed.
+func (r Reminder) ToDomain(userID int64, now time.Time) (*domain.Reminder, []*domain.Occurrence) {
+	rem := &domain.Reminder{
+		UserID:      userID,
+		Name:        r.Name,
+		Description: r.Description,
+		StartDate:   r.StartDate.UTC(),
+		EndDate:     r.EndDate.UTC(),
+		TimeZone:    r.TimeZone,
+		IsActive:    r.IsActive,
+		Template:    r.Template,
+	}
+	if r.Priority != "" {
+		rem.Priority, _ = domain.ParsePriority(r.Priority)
+	}
+	if r.Channels != "" {
+		rem.Channels, _ = domain.ParseChannels(r.Channels)
+	}
+	for _, t := range r.TimesOfDay {
+		if tod, err := parseTimeOfDay(t); err == nil && tod != nil {
+			rem.TimesOfDay = append(rem.TimesOfDay, *tod)
+		}
+	}
+
+	occs := make([]*domain.Occurrence, 0, len(r.Occurrences))
+	for _, o := range r.Occurrences {
+		status, _ := parseStatus(o.Status)
+		occ := &domain.Occurrence{FireAtUtc: o.FireAt.UTC(), Status: status, Attempts: o.Attempts}
+		if status == domain.OccurrenceCreated && !occ.FireAtUtc.After(now) {
+			occ.Status = domain.OccurrenceSent
+		}
+		if o.SentAt != nil {
+			occ.SentAtUtc = o.SentAt.UTC()
+		}
+		if o.AckedAt != nil {
+			occ.AckedAtUtc = o.AckedAt.UTC()
+		}
+		occs = append(occs, occ)
+	}
+	return rem, occs
+}
+
+// DigestSettings converts the digest settings for the given user; nil if absent.
+func (s Settings) DigestSettings(userID int64) *domain.DigestSettings {
+	if s.Digest == nil {
+		return nil
+	}
+	morning, _ := parseTimeOfDay(s.Digest.Morning)
+	evening, _ := parseTimeOfDay(s.Digest.Evening)
+	return &domain.DigestSettings{UserID: userID, Morning: morning, Evening: evening, Weekly: s.Digest.Weekly}
+}
+
+// Key identifies a reminder's schedule so restores can skip duplicates.
+func Key(rem *domain.Reminder) string {
+	times := make([]string, 0, len(rem.TimesOfDay))
+	for _, tod := range rem.TimesOfDay {
+		times = append(times, formatTimeOfDay(&tod))
+	}
+	return strings.Join([]string{
+		rem.Name, rem.TimeZone,
+		rem.StartDate.UTC().Format(time.RFC3339), rem.EndDate.UTC().Format(time.RFC3339),
+		strings.Join(times, ";"),
+	}, "|")
+}
+
+func parseStatus(s string) (domain.OccurrenceStatus, bool) {
+	for status, name := range statusNames {
+		if name == s {
+			return status, true
+		}
+	}
+	return 0, false
+}
+
+func formatTimeOfDay(t *domain.TimeOfDay) string {
+	if t == nil {
+		return ""
+	}
+	return fmt.Sprintf("%02d:%02d", t.Hour, t.Minute)
+}
+
+// parseTimeOfDay parses "HH:MM"; an empty string yields nil.
+func parseTimeOfDay(s string) (*domain.TimeOfDay, error) {
+	if s == "" {
+		return nil, nil
+	}
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.TimeOfDay{Hour: t.Hour(), Minute: t.Minute()}, nil
+}
@@ -0,0 +1,105 @@
+package backup
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"naggingbot/internal/domain"
+)
+
+func TestRoundTrip(t *testing.T) {
+	now := time.Date(2026, 1, 21, 12, 0, 0, 0, time.UTC)
+	user := &domain.User{ID: 3, TimeZone: "Europe/Warsaw"}
+	digest := &domain.DigestSettings{UserID: 3, Morning: &domain.TimeOfDay{Hour: 7, Minute: 30}, Weekly: true}
+	rem := &domain.Reminder{
+		ID:         12,
+		UserID:     3,
+		Name:       "Pill",
+		StartDate:  time.Date(2026, 1, 19, 0, 0, 0, 0, time.UTC),
+		EndDate:    time.Date(2026, 1, 25, 23, 59, 59, 0, time.UTC),
+		TimesOfDay: []domain.TimeOfDay{{Hour: 8, Minute: 0}, {Hour: 19, Minute: 30}},
+		TimeZone:   "Europe/Warsaw",
+		IsActive:   true,
+		Priority:   domain.PriorityHigh,
+		Template:   "<b>{{.Name}}</b>",
+		Channels:   domain.ChannelTelegram | domain.ChannelEmail,
+	}
+	sent := now.Add(-26 * time.Hour)
+	occs := []*domain.Occurrence{
+		{ID: 1, ReminderID: 12, FireAtUtc: sent, Status: domain.OccurrenceDone, SentAtUtc: sent, AckedAtUtc: sent.Add(time.Minute), Attempts: 1, ChatID: 9, MessageID: 99},
+		{ID: 2, ReminderID: 12, FireAtUtc: now.Add(-time.Hour), Status: domain.OccurrenceCreated},
+		{ID: 3, ReminderID: 12, FireAtUtc: now.Add(time.Hour), Status: domain.OccurrenceCreated},
+	}
+
+	data, err := Encode(Build(user, digest, []*domain.Reminder{rem}, map[int64][]*domain.Occurrence{12: occs}, now))
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	doc, err := Decode(data)
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+
+	if doc.Settings.TimeZone != "Europe/Warsaw" {
+		t.Fatalf("time zone = %q", doc.Settings.TimeZone)
+	}
+	ds := doc.Settings.DigestSettings(5)
+	if ds == nil || ds.UserID != 5 || *ds.Morning != *digest.Morning || ds.Evening != nil || !ds.Weekly {
+		t.Fatalf("digest = %+v", ds)
+	}
+	if len(doc.Reminders) != 1 || doc.OccurrenceCount() != 3 {
+		t.Fatalf("document = %+v", doc)
+	}
+
+	got, gotOccs := doc.Reminders[0].ToDomain(5, now)
+	if got.UserID != 5 || got.ID != 0 || Key(got) != Key(rem) || got.IsActive != rem.IsActive ||
+		got.Priority != rem.Priority || got.Template != rem.Template || got.Channels != rem.Channels {
+		t.Fatalf("reminder = %+v", got)
+	}
+	if len(gotOccs) != 3 {
+		t.Fatalf("occurrences = %d, want 3", len(gotOccs))
+	}
+	first := gotOccs[0]
+	if first.Status != domain.OccurrenceDone || !first.SentAtUtc.Equal(sent) || !first.AckedAtUtc.Equal(occs[0].AckedAtUtc) || first.Attempts != 1 {
+		t.Fatalf("first occurrence = %+v", first)
+	}
+	if first.ChatID != 0 || first.MessageID != 0 {
+		t.Fatalf("message IDs should not be restored: %+v", first)
+	}
+	if gotOccs[1].Status != domain.OccurrenceSent {
+		t.Fatalf("past unsent occurrence status = %v, want sent", gotOccs[1].Status)
+	}
+	if gotOccs[2].Status != domain.OccurrenceCreated {
+		t.Fatalf("future occurrence status = %v, want created", gotOccs[2].Status)
+	}
+}
+
+func TestDecodeRejectsInvalid(t *testing.T) {
+	cases := map[string]struct {
+		doc  string
+		want string
+	}{
+		"not json":    {`{`, "invalid JSON"},
+		"version":     {`{"version": 2}`, "unsupported backup version"},
+		"time zone":   {`{"version": 1, "settings": {"time_zone": "Mars/Base"}}`, "invalid time zone"},
+		"digest time": {`{"version": 1, "settings": {"digest": {"morning": "25:00"}}}`, "digest morning"},
+		"name": {`{"version": 1, "reminders": [{"name": " ", "time_zone": "UTC",
+			"start_date": "2026-01-19T00:00:00Z", "end_date": "2026-01-20T00:00:00Z", "times_of_day": ["08:00"]}]}`, "name is required"},
+		"range": {`{"version": 1, "reminders": [{"name": "a", "time_zone": "UTC",
+			"start_date": "2026-01-20T00:00:00Z", "end_date": "2026-01-19T00:00:00Z", "times_of_day": ["08:00"]}]}`, "invalid date range"},
+		"status": {`{"version": 1, "reminders": [{"name": "a", "time_zone": "UTC",
+			"start_date": "2026-01-19T00:00:00Z", "end_date": "2026-01-20T00:00:00Z", "times_of_day": ["8am"],
+			"occurrences": [{"fire_at": "2026-01-19T08:00:00Z", "status": "snoozed"}]}]}`, `invalid status "snoozed"`},
+		"priority": {`{"version": 1, "reminders": [{"name": "a", "time_zone": "UTC", "priority": "urgent",
+			"start_date": "2026-01-19T00:00:00Z", "end_date": "2026-01-20T00:00:00Z", "times_of_day": ["08:00"]}]}`, `invalid priority "urgent"`},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := Decode([]byte(tc.doc))
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Fatalf("error = %v, want %q", err, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Channels is a set of notification channels. The zero value means "not
+// chosen": a reminder falls back to its recipient's choice, and a user to
+// the default of Telegram plus every other channel they set up, best effort.
+type Channels uint8
+
+const (
+	ChannelTelegram Channels = 1 << iota
+	ChannelEmail
+	ChannelWebhook
+)
+
+// AllChannels lists the single channels in display order.
+var AllChannels = []Channels{ChannelTelegram, ChannelEmail, ChannelWebhook}
+
+var channelNames = map[Channels]string{
+	ChannelTelegram: "telegram",
+	ChannelEmail:    "email",
+	ChannelWebhook:  "webhook",
+}
+
+// Has reports whether c includes every channel of ch.
+func (c Channels) Has(ch Channels) bool {
+	return ch != 0 && c&ch == ch
+}
+
+// String joins the channel names with "+", e.g. "telegram+email".
+func (c Channels) String() string {
+	if c == 0 {
+		return "default"
+	}
+	var names []string
+	for _, ch := range AllChannels {
+		if c.Has(ch) {
+			names = append(names, channelNames[ch])
+		}
+	}
+	if rest := c &^ (ChannelTelegram | ChannelEmail | ChannelWebhook); rest != 0 {
+		names = append(names, fmt.Sprintf("Channels(%d)", int(rest)))
+	}
+	return strings.Join(names, "+")
+}
+
+// ParseChannels parses channel names joined with "+", as returned by
+// Channels.String. "default" yields the zero value.
+func ParseChannels(s string) (Channels, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "default" {
+		return 0, nil
+	}
+	var c Channels
+	for _, part := range strings.Split(s, "+") {
+		ch, ok := Channels(0), false
+		for candidate, name := range channelNames {
+			if name == strings.TrimSpace(part) {
+				ch, ok = candidate, true
+				break
+			}
+		}
+		if !ok {
+			return 0, fmt.Errorf("unknown channel %q (use telegram, email or webhook, joined with +)", part)
+		}
+		c |= ch
+	}
+	return c, nil
+}
+
+// ChannelDelivery is the result of sending one occurrence over one channel.
+type ChannelDelivery struct {
+	ID           int64
+	OccurrenceID int64
+	Channel      Channels
+	// Required is false for channels added on a best-effort basis.
+	Required bool
+	// Error is empty for a successful delivery.
+	Error string
+	AtUtc time.Time
+}
+
+// OK reports whether the delivery succeeded.
+func (d *ChannelDelivery) OK() bool {
+	return d.Error == ""
+}
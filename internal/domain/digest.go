@@ -0,0 +1,44 @@
+package domain
+
+import "time"
+
+// DigestKind identifies one of the summary messages a user can opt into.
+type DigestKind string
+
+const (
+	DigestMorning DigestKind = "morning"
+	DigestEvening DigestKind = "evening"
+	DigestWeekly  DigestKind = "weekly"
+)
+
+// DefaultWeeklyDigestTime is used for the weekly recap when no evening time is set.
+var DefaultWeeklyDigestTime = TimeOfDay{Hour: 21, Minute: 0}
+
+// DigestSettings configures the opt-in digest messages for a user. Times are
+// wall-clock times in the user's zone (see User.Location).
+type DigestSettings struct {
+	UserID int64
+	// Morning lists the day's occurrences; nil disables it.
+	Morning *TimeOfDay
+	// Evening recaps what was done, ignored or missed today; nil disables it.
+	Evening *TimeOfDay
+	// Weekly recaps the past seven days on Sundays at the evening time.
+	Weekly bool
+	// Last*Utc record when each digest was last sent, to avoid duplicates.
+	LastMorningUtc time.Time
+	LastEveningUtc time.Time
+	LastWeeklyUtc  time.Time
+}
+
+// Enabled reports whether any digest is switched on.
+func (d *DigestSettings) Enabled() bool {
+	return d.Morning != nil || d.Evening != nil || d.Weekly
+}
+
+// WeeklyTime returns the local time the weekly recap is sent at.
+func (d *DigestSettings) WeeklyTime() TimeOfDay {
+	if d.Evening != nil {
+		return *d.Evening
+	}
+	return DefaultWeeklyDigestTime
+}
@@ -0,0 +1,45 @@
+package domain
+
+import (
+	"fmt"
+	"net/mail"
+	"time"
+)
+
+const (
+	// EmailCodeTTL is how long a verification code stays valid.
+	EmailCodeTTL = 15 * time.Minute
+	// EmailCodeResendInterval is how long a user waits before another code
+	// is mailed to them.
+	EmailCodeResendInterval = time.Minute
+	// MaxEmailCodeAttempts bounds the wrong guesses per verification code.
+	MaxEmailCodeAttempts = 5
+)
+
+// EmailAddress is a user's address for email notifications. Notifications
+// are only sent once the user proved they own it with the code mailed to it.
+type EmailAddress struct {
+	UserID  int64
+	Address string
+	// Code is the pending verification code, CodeExpiresAtUtc its expiry
+	// and CodeAttempts the wrong guesses so far.
+	Code             string
+	CodeExpiresAtUtc time.Time
+	CodeAttempts     int
+	// VerifiedAtUtc is zero until the address is verified.
+	VerifiedAtUtc time.Time
+}
+
+// Verified reports whether notifications may be sent to the address.
+func (e *EmailAddress) Verified() bool {
+	return !e.VerifiedAtUtc.IsZero()
+}
+
+// ValidateEmail checks that s is a bare address like "name@example.com".
+func ValidateEmail(s string) error {
+	addr, err := mail.ParseAddress(s)
+	if err != nil || addr.Address != s || addr.Name != "" {
+		return fmt.Errorf("invalid email address %q", s)
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+package domain
+
+import "time"
+
+// Invite is a registration code handed out by an admin. Users redeem it with
+// /start <code> when registration is invite-only.
+type Invite struct {
+	Code string
+	// CreatedBy is the Telegram ID of the admin who issued the invite.
+	CreatedBy    int64
+	CreatedAtUtc time.Time
+	// ExpiresAtUtc is zero for invites that never expire.
+	ExpiresAtUtc time.Time
+	// MaxUses is zero for unlimited invites.
+	MaxUses      int
+	Uses         int
+	RevokedAtUtc time.Time
+}
+
+// Usable reports whether the invite can still be redeemed at the given time.
+func (i *Invite) Usable(at time.Time) bool {
+	if !i.RevokedAtUtc.IsZero() {
+		return false
+	}
+	if !i.ExpiresAtUtc.IsZero() && !at.Before(i.ExpiresAtUtc) {
+		return false
+	}
+	return i.MaxUses == 0 || i.Uses < i.MaxUses
+}
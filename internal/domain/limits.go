@@ -0,0 +1,73 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// Limits bounds what a single user can schedule. A zero field disables that limit.
+type Limits struct {
+	// MaxActiveReminders caps reminders that are active and not yet over.
+	MaxActiveReminders int
+	// MaxTimesPerReminder caps the times of day of a single reminder.
+	MaxTimesPerReminder int
+	// MaxOccurrencesPerDay caps the occurrences a user's reminders may
+	// schedule on any single day.
+	MaxOccurrencesPerDay int
+	// MaxReminderDays caps the length of a reminder's date range.
+	MaxReminderDays int
+}
+
+// DefaultLimits are used unless configured otherwise.
+var DefaultLimits = Limits{
+	MaxActiveReminders:   50,
+	MaxTimesPerReminder:  12,
+	MaxOccurrencesPerDay: 48,
+	MaxReminderDays:      366,
+}
+
+// LimitError reports an exceeded limit. Its message is meant for the user;
+// Format and Args let the caller translate it.
+type LimitError struct {
+	Format string
+	Args   []any
+}
+
+func (e *LimitError) Error() string { return fmt.Sprintf(e.Format, e.Args...) }
+
+func limitErrorf(format string, args ...any) error {
+	return &LimitError{Format: format, Args: args}
+}
+
+// CheckReminder checks a reminder about to be created against the limits,
+// given the user's existing reminders. It returns a *LimitError if a limit
+// would be exceeded.
+func (l Limits) CheckReminder(rem *Reminder, existing []*Reminder, now time.Time) error {
+	if l.MaxTimesPerReminder > 0 && len(rem.TimesOfDay) > l.MaxTimesPerReminder {
+		return limitErrorf("A reminder can have at most %d times of day.", l.MaxTimesPerReminder)
+	}
+	if days := rem.Days(); l.MaxReminderDays > 0 && days > l.MaxReminderDays {
+		return limitErrorf("A reminder can span at most %d days (this one spans %d).", l.MaxReminderDays, days)
+	}
+	if !rem.IsActive || !rem.EndDate.After(now) {
+		return nil
+	}
+
+	active, perDay := 0, len(rem.TimesOfDay)
+	for _, other := range existing {
+		if !other.IsActive || !other.EndDate.After(now) {
+			continue
+		}
+		active++
+		if other.StartDate.Before(rem.EndDate) && rem.StartDate.Before(other.EndDate) {
+			perDay += len(other.TimesOfDay)
+		}
+	}
+	if l.MaxActiveReminders > 0 && active >= l.MaxActiveReminders {
+		return limitErrorf("You already have %d active reminders, the maximum. Delete one with /delete first.", active)
+	}
+	if l.MaxOccurrencesPerDay > 0 && perDay > l.MaxOccurrencesPerDay {
+		return limitErrorf("This would schedule %d reminders on some days; the maximum is %d per day.", perDay, l.MaxOccurrencesPerDay)
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLimitsCheckReminder(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	reminder := func(startDays, days, times int) *Reminder {
+		start := now.AddDate(0, 0, startDays)
+		return &Reminder{
+			StartDate:  start,
+			EndDate:    start.AddDate(0, 0, days-1),
+			TimesOfDay: make([]TimeOfDay, times),
+			IsActive:   true,
+		}
+	}
+	limits := Limits{MaxActiveReminders: 2, MaxTimesPerReminder: 4, MaxOccurrencesPerDay: 6, MaxReminderDays: 30}
+
+	ended := reminder(-60, 10, 4)
+	inactive := reminder(0, 10, 4)
+	inactive.IsActive = false
+	later := reminder(20, 5, 4)
+
+	cases := []struct {
+		name     string
+		rem      *Reminder
+		existing []*Reminder
+		wantErr  bool
+	}{
+		{"within limits", reminder(0, 10, 2), []*Reminder{ended, inactive}, false},
+		{"too many times", reminder(0, 10, 5), nil, true},
+		{"too many days", reminder(0, 31, 1), nil, true},
+		{"too many active", reminder(0, 2, 1), []*Reminder{later, reminder(40, 1, 1)}, true},
+		{"too many per day", reminder(18, 5, 3), []*Reminder{later}, true},
+		{"no overlap", reminder(0, 10, 3), []*Reminder{later}, false},
+	}
+	for _, tc := range cases {
+		err := limits.CheckReminder(tc.rem, tc.existing, now)
+		var limitErr *LimitError
+		if tc.wantErr != errors.As(err, &limitErr) {
+			t.Errorf("%s: err = %v, want limit error %v", tc.name, err, tc.wantErr)
+		}
+	}
+
+	if err := (Limits{}).CheckReminder(reminder(0, 400, 20), []*Reminder{later}, now); err != nil {
+		t.Errorf("zero limits: err = %v, want nil", err)
+	}
+}
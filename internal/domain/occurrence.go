@@ -1,6 +1,10 @@
 package domain
 
-import "time"
+import (
+	"errors"
+	"fmt"
+	"time"
+)
 
 // Occurrence represents a single scheduled reminder execution.
 type Occurrence struct {
@@ -8,6 +12,31 @@ type Occurrence struct {
 	ReminderID int64
 	FireAtUtc  time.Time
 	Status     OccurrenceStatus
+	// SentAtUtc is when the notification was delivered; zero until sent.
+	SentAtUtc time.Time
+	// AckedAtUtc is when the user pressed Done or Ignore; zero until acknowledged.
+	AckedAtUtc time.Time
+	// Attempts counts delivery attempts, including failed ones.
+	Attempts int
+	// ChatID and MessageID locate the Telegram message carrying the occurrence.
+	ChatID    int64
+	MessageID int64
+	// EscalatedAtUtc is when the owner was alerted that the recipient left
+	// the occurrence unanswered; zero until then.
+	EscalatedAtUtc time.Time
+	// EscalationStep counts the steps of the reminder's escalation chain
+	// fired for the occurrence.
+	EscalationStep int
+}
+
+// Confirmation records a chat member pressing Done on an occurrence posted to
+// a shared chat.
+type Confirmation struct {
+	OccurrenceID int64
+	TelegramID   int64
+	// Name is how the member is shown, e.g. "@alice".
+	Name  string
+	AtUtc time.Time
 }
 
 // OccurrenceStatus is the lifecycle state of an occurrence.
@@ -18,4 +47,69 @@ const (
 	OccurrenceSent
 	OccurrenceDone
 	OccurrenceIgnored
+	// OccurrenceFailed is an occurrence the scheduler gave up delivering. It
+	// may still be answered, or requeued by an admin.
+	OccurrenceFailed
 )
+
+func (s OccurrenceStatus) String() string {
+	switch s {
+	case OccurrenceCreated:
+		return "created"
+	case OccurrenceSent:
+		return "sent"
+	case OccurrenceDone:
+		return "done"
+	case OccurrenceIgnored:
+		return "ignored"
+	case OccurrenceFailed:
+		return "failed"
+	default:
+		return fmt.Sprintf("status %d", int(s))
+	}
+}
+
+// ErrInvalidTransition is returned by stores for an illegal status change.
+var ErrInvalidTransition = errors.New("invalid occurrence status transition")
+
+// occurrenceTransitions lists the legal status changes. Created occurrences
+// may be answered before delivery (from the agenda), sent and failed ones may
+// be queued again for redelivery, and Done and Ignored are final.
+var occurrenceTransitions = map[OccurrenceStatus][]OccurrenceStatus{
+	OccurrenceCreated: {OccurrenceSent, OccurrenceDone, OccurrenceIgnored, OccurrenceFailed},
+	OccurrenceSent:    {OccurrenceDone, OccurrenceIgnored, OccurrenceCreated},
+	OccurrenceFailed:  {OccurrenceDone, OccurrenceIgnored, OccurrenceCreated},
+}
+
+// CanTransition reports whether an occurrence may move from s to next.
+func (s OccurrenceStatus) CanTransition(next OccurrenceStatus) bool {
+	for _, to := range occurrenceTransitions[s] {
+		if to == next {
+			return true
+		}
+	}
+	return false
+}
+
+// TransitionSources returns the statuses an occurrence may move to next from.
+func TransitionSources(next OccurrenceStatus) []OccurrenceStatus {
+	var out []OccurrenceStatus
+	for _, from := range []OccurrenceStatus{OccurrenceCreated, OccurrenceSent, OccurrenceDone, OccurrenceIgnored, OccurrenceFailed} {
+		if from.CanTransition(next) {
+			out = append(out, from)
+		}
+	}
+	return out
+}
+
+// TransitionError reports an illegal status change; it matches ErrInvalidTransition.
+type TransitionError struct {
+	ID       int64
+	From, To OccurrenceStatus
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("occurrence %d: cannot change status from %s to %s", e.ID, e.From, e.To)
+}
+
+func (e *TransitionError) Unwrap() error { return ErrInvalidTransition }
@@ -1,6 +1,10 @@
 package domain
 
-import "time"
+import (
+	"fmt"
+	"strings"
+	"time"
+)
 
 // Reminder defines a repeating rule created by the user.
 type Reminder struct {
@@ -14,7 +18,169 @@ type Reminder struct {
 	// TimeZone stores the IANA time zone (e.g., "Europe/Moscow") used to compute occurrences.
 	// TODO: support re-computing future occurrences if the user changes their preferred time zone.
 	TimeZone string
-	IsActive    bool
+	IsActive bool
+	// Priority is shown in the notification header.
+	Priority Priority
+	// Template is a custom notification template; empty means the default.
+	Template string
+	// ChatID is the group or channel the reminder is posted to; zero means
+	// the owner's private chat.
+	ChatID int64
+	// Confirm says who in a shared chat has to press Done.
+	Confirm ConfirmMode
+	// RecipientID is the user the owner assigned the reminder to, e.g. a
+	// parent whose medication a caregiver manages; zero means the owner. The
+	// recipient gets the notifications once they accepted.
+	RecipientID       int64
+	RecipientAccepted bool
+	// EscalateAfter is how long an occurrence may stay unanswered by the
+	// recipient before the owner is alerted; zero disables the alert.
+	EscalateAfter time.Duration
+	// Escalations is the escalation chain, ordered by After.
+	Escalations []EscalationStep
+	// WebhookURL, if set, receives the reminder's notifications instead of
+	// the owner's webhook (see WebhookSettings).
+	WebhookURL string
+	// Channels are the channels the reminder is sent over; zero follows the
+	// recipient's choice.
+	Channels Channels
+}
+
+// EscalationStep is one step of a reminder's escalation chain: once an
+// occurrence has stayed unanswered for After since it was sent, the step
+// notifies a Telegram chat or calls a webhook.
+type EscalationStep struct {
+	After time.Duration
+	// ChatID is the Telegram user or group to notify; zero for webhook steps.
+	ChatID int64
+	// URL is the webhook to call; empty for Telegram steps.
+	URL string
+	// Pending marks a step notifying another user who has not accepted it
+	// yet. Pending steps are skipped.
+	Pending bool
+}
+
+// MaxEscalationSteps bounds the escalation chain of a reminder.
+const MaxEscalationSteps = 5
+
+// Validate checks the delay and that the step has exactly one target, a
+// chat or an http(s) URL.
+func (s EscalationStep) Validate() error {
+	if s.After <= 0 || s.After > MaxEscalateAfter {
+		return fmt.Errorf("escalation delay must be between 1 minute and %s", MaxEscalateAfter)
+	}
+	if (s.ChatID == 0) == (s.URL == "") {
+		return fmt.Errorf("escalation step needs either a chat or a URL")
+	}
+	if s.URL != "" {
+		return ValidateWebhookURL(s.URL)
+	}
+	return nil
+}
+
+const (
+	// DefaultEscalateAfter is the escalation deadline of newly assigned reminders.
+	DefaultEscalateAfter = 30 * time.Minute
+	// MaxEscalateAfter bounds escalation deadlines.
+	MaxEscalateAfter = 24 * time.Hour
+)
+
+// Shared reports whether the reminder is posted to a group or channel.
+func (r *Reminder) Shared() bool {
+	return r.ChatID != 0
+}
+
+// Assigned reports whether the reminder is delivered to an accepted
+// recipient instead of its owner.
+func (r *Reminder) Assigned() bool {
+	return r.RecipientID != 0 && r.RecipientAccepted
+}
+
+// Recipient returns the ID of the user the reminder is delivered to.
+func (r *Reminder) Recipient() int64 {
+	if r.Assigned() {
+		return r.RecipientID
+	}
+	return r.UserID
+}
+
+// CanAnswer reports whether the user may mark occurrences done or ignored:
+// the owner and an accepted recipient.
+func (r *Reminder) CanAnswer(userID int64) bool {
+	return userID == r.UserID || (r.Assigned() && userID == r.RecipientID)
+}
+
+// Escalates reports whether the owner is alerted about occurrences the
+// recipient leaves unanswered.
+func (r *Reminder) Escalates() bool {
+	return r.Assigned() && r.EscalateAfter > 0
+}
+
+// Priority ranks a reminder. The zero value is PriorityNormal.
+type Priority int
+
+const (
+	PriorityNormal Priority = iota
+	PriorityLow
+	PriorityHigh
+)
+
+var priorityNames = map[Priority]string{
+	PriorityNormal: "normal",
+	PriorityLow:    "low",
+	PriorityHigh:   "high",
+}
+
+func (p Priority) String() string {
+	if name, ok := priorityNames[p]; ok {
+		return name
+	}
+	return fmt.Sprintf("Priority(%d)", int(p))
+}
+
+// ParsePriority parses a priority name as returned by Priority.String.
+func ParsePriority(s string) (Priority, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	for p, name := range priorityNames {
+		if name == s {
+			return p, nil
+		}
+	}
+	return PriorityNormal, fmt.Errorf("unknown priority %q (use low, normal or high)", s)
+}
+
+// ConfirmMode decides when an occurrence posted to a shared chat is done. The
+// zero value is ConfirmAnyone.
+type ConfirmMode int
+
+const (
+	// ConfirmAnyone completes the occurrence on the first Done.
+	ConfirmAnyone ConfirmMode = iota
+	// ConfirmEveryone waits until every member of the chat pressed Done.
+	ConfirmEveryone
+)
+
+var confirmModeNames = map[ConfirmMode]string{
+	ConfirmAnyone:   "anyone",
+	ConfirmEveryone: "everyone",
+}
+
+func (m ConfirmMode) String() string {
+	if name, ok := confirmModeNames[m]; ok {
+		return name
+	}
+	return fmt.Sprintf("ConfirmMode(%d)", int(m))
+}
+
+// ParseConfirmMode parses a mode name as returned by ConfirmMode.String.
+func ParseConfirmMode(s string) (ConfirmMode, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	for m, name := range confirmModeNames {
+		if name == s {
+			return m, nil
+		}
+	}
+	return ConfirmAnyone, fmt.Errorf("unknown confirm mode %q (use anyone or everyone)", s)
 }
 
 // TimeOfDay stores a wall-clock time without a date.
@@ -22,3 +188,26 @@ type TimeOfDay struct {
 	Hour   int
 	Minute int
 }
+
+// Location returns the reminder's time zone, falling back to UTC if it is invalid.
+func (r *Reminder) Location() *time.Location {
+	loc, err := time.LoadLocation(r.TimeZone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// Days returns the number of calendar days, in the reminder's zone, covered by
+// its date range (inclusive); zero if the range is empty.
+func (r *Reminder) Days() int {
+	loc := r.Location()
+	start, end := r.StartDate.In(loc), r.EndDate.In(loc)
+	if end.Before(start) {
+		return 0
+	}
+	// Compare dates at UTC midnight so DST changes do not skew the count.
+	first := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, time.UTC)
+	last := time.Date(end.Year(), end.Month(), end.Day(), 0, 0, 0, 0, time.UTC)
+	return int(last.Sub(first).Hours()/24) + 1
+}
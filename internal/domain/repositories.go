@@ -5,11 +5,29 @@ import (
 	"time"
 )
 
+// Store contract shared by every backend (verified by storage/storetest):
+// Get* methods return (nil, nil) when the row does not exist, updates and
+// deletes of missing rows are no-ops, and list methods return rows in a
+// stable order (reminders by ID, occurrences by fire time then ID).
+
 // UserStore defines the minimal operations needed for user data.
 type UserStore interface {
 	GetByID(ctx context.Context, id int64) (*User, error)
 	GetByTelegramID(ctx context.Context, telegramID int64) (*User, error)
+	// GetByUsername finds a user by Telegram username, case-insensitively
+	// and without the leading "@".
+	GetByUsername(ctx context.Context, username string) (*User, error)
+	// List returns all users ordered by ID.
+	List(ctx context.Context) ([]*User, error)
+	// Upsert inserts the user or refreshes the Telegram profile fields (username,
+	// names, language) of an existing one; settings such as TimeZone are kept.
+	// The stored user, including its ID, is copied back into user.
 	Upsert(ctx context.Context, user *User) error
+	SetTimeZone(ctx context.Context, id int64, timeZone string) error
+	SetLocale(ctx context.Context, id int64, locale string) error
+	SetRegistered(ctx context.Context, id int64, registered bool) error
+	SetBanned(ctx context.Context, id int64, banned bool) error
+	SetChannels(ctx context.Context, id int64, channels Channels) error
 }
 
 // ReminderStore defines the minimal operations needed for reminders.
@@ -25,8 +43,114 @@ type ReminderStore interface {
 type OccurrenceStore interface {
 	GetByID(ctx context.Context, id int64) (*Occurrence, error)
 	ListByReminder(ctx context.Context, reminderID int64) ([]*Occurrence, error)
+	// ListByReminderInRange returns occurrences of any status with start <= fire time <= end.
+	ListByReminderInRange(ctx context.Context, reminderID int64, startUTC, endUTC time.Time) ([]*Occurrence, error)
+	// ListPendingInRange returns created occurrences with start <= fire time <= end.
+	// A zero start means no lower bound.
 	ListPendingInRange(ctx context.Context, startUTC, endUTC time.Time) ([]*Occurrence, error)
 	Create(ctx context.Context, occurrence *Occurrence) error
+	// CreateBatch inserts occurrences in order and assigns their IDs.
+	// Run it inside a UnitOfWork to make the batch all-or-nothing.
+	CreateBatch(ctx context.Context, occurrences []*Occurrence) error
+	// UpdateStatus, MarkSent and MarkAcked only apply legal status transitions
+	// (see OccurrenceStatus.CanTransition) and return a *TransitionError
+	// otherwise. They do nothing for a missing occurrence.
 	UpdateStatus(ctx context.Context, id int64, status OccurrenceStatus) error
+	// MarkSent sets the status to OccurrenceSent, records the send time and counts the attempt.
+	MarkSent(ctx context.Context, id int64, sentAtUTC time.Time) error
+	// IncrementAttempts counts a failed delivery attempt without changing the status.
+	IncrementAttempts(ctx context.Context, id int64) error
+	// SetMessage records the chat and message the occurrence was delivered to.
+	SetMessage(ctx context.Context, id int64, chatID, messageID int64) error
+	// MarkAcked sets a terminal status chosen by the user and records when it happened.
+	MarkAcked(ctx context.Context, id int64, status OccurrenceStatus, ackedAtUTC time.Time) error
+	// AddConfirmation records a member's Done on a shared occurrence and
+	// reports whether it is new; each member confirms an occurrence once.
+	AddConfirmation(ctx context.Context, c *Confirmation) (bool, error)
+	// ListConfirmations returns the occurrence's confirmations, oldest first.
+	ListConfirmations(ctx context.Context, occurrenceID int64) ([]*Confirmation, error)
+	// ListSentInRange returns sent, unanswered occurrences with
+	// start <= send time <= end.
+	ListSentInRange(ctx context.Context, startUTC, endUTC time.Time) ([]*Occurrence, error)
+	// MarkEscalated records that the owner was alerted about the occurrence.
+	MarkEscalated(ctx context.Context, id int64, escalatedAtUTC time.Time) error
+	// AdvanceEscalation moves a sent occurrence's escalation chain from step
+	// to step+1 and reports whether it did. Answered occurrences are not
+	// advanced, so answering cancels the rest of the chain.
+	AdvanceEscalation(ctx context.Context, id int64, step int) (bool, error)
+	// RecordDelivery appends the result of sending the occurrence over one
+	// channel and assigns its ID.
+	RecordDelivery(ctx context.Context, delivery *ChannelDelivery) error
+	// ListDeliveries returns the occurrence's channel deliveries, oldest first.
+	ListDeliveries(ctx context.Context, occurrenceID int64) ([]*ChannelDelivery, error)
+	// DeleteByReminder deletes the reminder's occurrences, their
+	// confirmations and channel deliveries.
 	DeleteByReminder(ctx context.Context, reminderID int64) error
 }
+
+// DigestStore persists per-user digest settings.
+type DigestStore interface {
+	Get(ctx context.Context, userID int64) (*DigestSettings, error)
+	// ListEnabled returns settings with at least one digest switched on, by user ID.
+	ListEnabled(ctx context.Context) ([]*DigestSettings, error)
+	// Save inserts or replaces the user's settings, including the Last*Utc fields.
+	Save(ctx context.Context, settings *DigestSettings) error
+	MarkSent(ctx context.Context, userID int64, kind DigestKind, sentAtUTC time.Time) error
+}
+
+// WebhookStore persists per-user webhook settings and the delivery log.
+type WebhookStore interface {
+	Get(ctx context.Context, userID int64) (*WebhookSettings, error)
+	// Save inserts or replaces the user's settings.
+	Save(ctx context.Context, settings *WebhookSettings) error
+	Delete(ctx context.Context, userID int64) error
+	// RecordDelivery appends to the user's delivery log, keeping the latest
+	// MaxWebhookDeliveries entries.
+	RecordDelivery(ctx context.Context, delivery *WebhookDelivery) error
+	// ListDeliveries returns up to limit of the user's latest deliveries, newest first.
+	ListDeliveries(ctx context.Context, userID int64, limit int) ([]*WebhookDelivery, error)
+	// Enqueue adds an event to the delivery queue and sets its ID.
+	Enqueue(ctx context.Context, job *WebhookJob) error
+	// ListDueJobs returns up to limit queued events due at or before at,
+	// earliest first.
+	ListDueJobs(ctx context.Context, at time.Time, limit int) ([]*WebhookJob, error)
+	// RetryJob records the failed attempts of a queued event and when it is
+	// due again.
+	RetryJob(ctx context.Context, id int64, attempts int, nextAtUTC time.Time) error
+	// DeleteJob removes a delivered or abandoned event from the queue.
+	DeleteJob(ctx context.Context, id int64) error
+}
+
+// EmailStore persists users' email addresses.
+type EmailStore interface {
+	Get(ctx context.Context, userID int64) (*EmailAddress, error)
+	// Save inserts or replaces the user's address.
+	Save(ctx context.Context, address *EmailAddress) error
+	Delete(ctx context.Context, userID int64) error
+}
+
+// InviteStore persists registration invites.
+type InviteStore interface {
+	Create(ctx context.Context, invite *Invite) error
+	Get(ctx context.Context, code string) (*Invite, error)
+	// ListUsable returns invites that can still be redeemed at the given time, oldest first.
+	ListUsable(ctx context.Context, at time.Time) ([]*Invite, error)
+	// Redeem atomically consumes one use of a usable invite and reports
+	// whether it did; unknown, revoked, expired or used-up codes yield false.
+	Redeem(ctx context.Context, code string, at time.Time) (bool, error)
+	Revoke(ctx context.Context, code string, revokedAtUTC time.Time) error
+}
+
+// Stores bundles the repositories bound to a single unit of work.
+type Stores struct {
+	Users       UserStore
+	Reminders   ReminderStore
+	Occurrences OccurrenceStore
+	Invites     InviteStore
+}
+
+// UnitOfWork runs a function atomically: either every write made through the
+// provided stores is committed, or none of them is when fn returns an error.
+type UnitOfWork interface {
+	Do(ctx context.Context, fn func(ctx context.Context, stores Stores) error) error
+}
@@ -1,5 +1,7 @@
 package domain
 
+import "time"
+
 // User represents a Telegram user that interacts with the bot.
 type User struct {
 	ID         int64
@@ -7,5 +9,40 @@ type User struct {
 	Username   string
 	FirstName  string
 	LastName   string
-	Language   string
+	// Language is the language code reported by Telegram, e.g. "en-US".
+	Language string
+	// Locale is the language the user picked with /language; empty means
+	// "follow Language".
+	Locale string
+	// TimeZone is the user's preferred IANA zone for agenda views; empty means
+	// "derive from reminders".
+	TimeZone string
+	// Registered is set once the user passed the registration policy via /start.
+	Registered bool
+	// Banned users are ignored by the bot.
+	Banned bool
+	// Channels are the channels the user's reminders are sent over unless a
+	// reminder chose its own; zero means the default.
+	Channels Channels
+}
+
+// Location resolves the zone used for the user's agenda and digests: the
+// explicit setting, else the zone of their newest reminder, else UTC.
+func (u *User) Location(reminders []*Reminder) *time.Location {
+	if u.TimeZone != "" {
+		if loc, err := time.LoadLocation(u.TimeZone); err == nil {
+			return loc
+		}
+	}
+
+	var newest *Reminder
+	for _, r := range reminders {
+		if newest == nil || r.ID > newest.ID {
+			newest = r
+		}
+	}
+	if newest != nil {
+		return newest.Location()
+	}
+	return time.UTC
 }
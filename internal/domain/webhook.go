@@ -0,0 +1,64 @@
+package domain
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// WebhookSettings configures a user's outgoing webhook. Reminders without
+// their own WebhookURL are posted to URL; every request is signed with Secret.
+type WebhookSettings struct {
+	UserID int64
+	// URL receives the user's notifications; empty if only reminders set one.
+	URL    string
+	Secret string
+}
+
+// MaxWebhookDeliveries is how many delivery log entries are kept per user.
+const MaxWebhookDeliveries = 100
+
+// WebhookDelivery logs one attempt to post an event to a webhook.
+type WebhookDelivery struct {
+	ID           int64
+	UserID       int64
+	OccurrenceID int64
+	Event        string
+	URL          string
+	Attempt      int
+	// StatusCode is the HTTP status, zero if no response arrived.
+	StatusCode int
+	// Error is empty for a delivered event.
+	Error    string
+	Duration time.Duration
+	AtUtc    time.Time
+}
+
+// OK reports whether the attempt delivered the event.
+func (d *WebhookDelivery) OK() bool {
+	return d.Error == ""
+}
+
+// WebhookJob is a webhook event waiting in the delivery queue, with the
+// retry state of its failed attempts.
+type WebhookJob struct {
+	ID           int64
+	UserID       int64
+	OccurrenceID int64
+	Event        string
+	URL          string
+	// Body is the JSON event; it is signed when posted.
+	Body []byte
+	// Attempts counts the failed attempts so far.
+	Attempts  int
+	NextAtUtc time.Time
+}
+
+// ValidateWebhookURL checks that s is an absolute http(s) URL.
+func ValidateWebhookURL(s string) error {
+	u, err := url.Parse(s)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid webhook URL %q", s)
+	}
+	return nil
+}
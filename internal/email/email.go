@@ -0,0 +1,186 @@
+// Package email sends notifications by email over SMTP.
+//
+// Messages are multipart/alternative with a plain-text and an HTML body,
+// both rendered from templates in the recipient's language. Addresses are
+// only used once their owner verified them (see domain.EmailAddress).
+package email
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"fmt"
+	"math/big"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultTimeout bounds one SMTP session.
+const DefaultTimeout = 30 * time.Second
+
+// Config configures the SMTP relay.
+type Config struct {
+	Host string
+	Port int
+	// Username and Password enable PLAIN authentication when Username is set.
+	Username string
+	Password string
+	// From is the sender address.
+	From string
+	// StartTLS requires upgrading the connection with STARTTLS before
+	// authenticating. Only local relays should run without it.
+	StartTLS bool
+	Timeout  time.Duration
+}
+
+// Enabled reports whether a relay is configured.
+func (c Config) Enabled() bool {
+	return c.Host != ""
+}
+
+// Message is an email to one recipient.
+type Message struct {
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// Sender delivers messages through an SMTP relay.
+type Sender struct {
+	cfg Config
+	// tlsConfig is used for STARTTLS; nil verifies the relay's host name.
+	tlsConfig *tls.Config
+}
+
+// NewSender constructs a sender for the relay in cfg.
+func NewSender(cfg Config) *Sender {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultTimeout
+	}
+	return &Sender{cfg: cfg}
+}
+
+// Send delivers msg in one SMTP session.
+func (s *Sender) Send(ctx context.Context, msg Message) error {
+	body, err := s.build(msg, time.Now())
+	if err != nil {
+		return err
+	}
+
+	dialer := net.Dialer{Timeout: s.cfg.Timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(s.cfg.Host, strconv.Itoa(s.cfg.Port)))
+	if err != nil {
+		return fmt.Errorf("email: dial: %w", err)
+	}
+	deadline := time.Now().Add(s.cfg.Timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		conn.Close()
+		return err
+	}
+
+	c, err := smtp.NewClient(conn, s.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("email: %w", err)
+	}
+	defer c.Close()
+
+	if s.cfg.StartTLS {
+		if ok, _ := c.Extension("STARTTLS"); !ok {
+			return fmt.Errorf("email: %s does not support STARTTLS", s.cfg.Host)
+		}
+		tlsConfig := s.tlsConfig
+		if tlsConfig == nil {
+			tlsConfig = &tls.Config{ServerName: s.cfg.Host}
+		}
+		if err := c.StartTLS(tlsConfig); err != nil {
+			return fmt.Errorf("email: starttls: %w", err)
+		}
+	}
+	if s.cfg.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", s.cfg.Username, s.cfg.Password, s.cfg.Host)); err != nil {
+			return fmt.Errorf("email: auth: %w", err)
+		}
+	}
+	if err := c.Mail(s.cfg.From); err != nil {
+		return fmt.Errorf("email: mail from: %w", err)
+	}
+	if err := c.Rcpt(msg.To); err != nil {
+		return fmt.Errorf("email: rcpt to: %w", err)
+	}
+	w, err := c.Data()
+	if err != nil {
+		return fmt.Errorf("email: data: %w", err)
+	}
+	if _, err := w.Write(body); err != nil {
+		return fmt.Errorf("email: data: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("email: data: %w", err)
+	}
+	return c.Quit()
+}
+
+// build renders msg as a MIME document with CRLF line endings.
+func (s *Sender) build(msg Message, now time.Time) ([]byte, error) {
+	var b bytes.Buffer
+	parts := multipart.NewWriter(&b)
+
+	subject := strings.Join(strings.Fields(msg.Subject), " ")
+	header := []string{
+		"From: " + s.cfg.From,
+		"To: " + msg.To,
+		"Subject: " + mime.QEncoding.Encode("utf-8", subject),
+		"Date: " + now.Format(time.RFC1123Z),
+		"MIME-Version: 1.0",
+		"Content-Type: multipart/alternative; boundary=" + parts.Boundary(),
+	}
+	var out bytes.Buffer
+	out.WriteString(strings.Join(header, "\r\n") + "\r\n\r\n")
+
+	for _, p := range []struct{ contentType, body string }{
+		{"text/plain; charset=utf-8", msg.Text},
+		{"text/html; charset=utf-8", msg.HTML},
+	} {
+		w, err := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {p.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		qp := quotedprintable.NewWriter(w)
+		if _, err := qp.Write([]byte(strings.ReplaceAll(p.body, "\n", "\r\n"))); err != nil {
+			return nil, err
+		}
+		if err := qp.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := parts.Close(); err != nil {
+		return nil, err
+	}
+	out.Write(b.Bytes())
+	return out.Bytes(), nil
+}
+
+// NewCode returns a random six-digit verification code.
+func NewCode() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%06d", n.Int64()), nil
+}
@@ -0,0 +1,273 @@
+package email
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"naggingbot/internal/domain"
+	"naggingbot/internal/i18n"
+	"naggingbot/internal/scheduler"
+	"naggingbot/internal/storage/memory"
+)
+
+// fakeSMTP is a minimal SMTP server that accepts every message. It offers
+// AUTH PLAIN but not STARTTLS.
+type fakeSMTP struct {
+	ln net.Listener
+
+	mu       sync.Mutex
+	auth     string // decoded AUTH PLAIN response
+	from     string
+	to       []string
+	messages []string
+}
+
+func newFakeSMTP(t *testing.T) *fakeSMTP {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &fakeSMTP{ln: ln}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *fakeSMTP) config() Config {
+	host, port, _ := net.SplitHostPort(s.ln.Addr().String())
+	p, _ := strconv.Atoi(port)
+	return Config{Host: host, Port: p, From: "bot@example.com", Timeout: 5 * time.Second}
+}
+
+func (s *fakeSMTP) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(line string) { io.WriteString(conn, line+"\r\n") }
+
+	reply("220 localhost ESMTP fake")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		cmd := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+		switch cmd {
+		case "EHLO", "HELO":
+			reply("250-localhost")
+			reply("250 AUTH PLAIN")
+		case "AUTH":
+			fields := strings.Fields(line)
+			if len(fields) == 3 {
+				decoded, _ := base64.StdEncoding.DecodeString(fields[2])
+				s.mu.Lock()
+				s.auth = string(decoded)
+				s.mu.Unlock()
+			}
+			reply("235 ok")
+		case "MAIL":
+			s.mu.Lock()
+			s.from = line
+			s.mu.Unlock()
+			reply("250 ok")
+		case "RCPT":
+			s.mu.Lock()
+			s.to = append(s.to, line)
+			s.mu.Unlock()
+			reply("250 ok")
+		case "DATA":
+			reply("354 go ahead")
+			var b strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				b.WriteString(strings.TrimPrefix(l, "."))
+			}
+			s.mu.Lock()
+			s.messages = append(s.messages, b.String())
+			s.mu.Unlock()
+			reply("250 queued")
+		case "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("250 ok")
+		}
+	}
+}
+
+func (s *fakeSMTP) received() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.messages...)
+}
+
+// parse splits a received message into its subject and text and HTML bodies.
+func parse(t *testing.T, raw string) (subject, text, html string) {
+	t.Helper()
+	msg, err := mail.ReadMessage(strings.NewReader(raw))
+	if err != nil {
+		t.Fatalf("read message: %v", err)
+	}
+	if subject, err = new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject")); err != nil {
+		t.Fatalf("decode subject: %v", err)
+	}
+	_, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil {
+		t.Fatalf("content type: %v", err)
+	}
+	parts := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		part, err := parts.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("next part: %v", err)
+		}
+		body, _ := io.ReadAll(part) // quoted-printable is decoded by the reader
+		switch {
+		case strings.HasPrefix(part.Header.Get("Content-Type"), "text/plain"):
+			text = string(body)
+		case strings.HasPrefix(part.Header.Get("Content-Type"), "text/html"):
+			html = string(body)
+		}
+	}
+	return subject, text, html
+}
+
+func testOccurrence() (*domain.Reminder, *domain.Occurrence) {
+	rem := &domain.Reminder{ID: 7, UserID: 1, Name: "Pill <VitC> & water", Description: "After breakfast", TimeZone: "Europe/Warsaw"}
+	occ := &domain.Occurrence{ID: 12, ReminderID: 7, FireAtUtc: time.Date(2026, 1, 19, 7, 0, 0, 0, time.UTC)}
+	return rem, occ
+}
+
+func TestSenderDeliversMessage(t *testing.T) {
+	srv := newFakeSMTP(t)
+	cfg := srv.config()
+	cfg.Username, cfg.Password = "bot", "pa55"
+
+	rem, occ := testOccurrence()
+	msg, err := NotificationMessage(i18n.For(i18n.English), "alice@example.com", rem, occ)
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	if err := NewSender(cfg).Send(context.Background(), msg); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+
+	if srv.auth != "\x00bot\x00pa55" {
+		t.Fatalf("auth = %q", srv.auth)
+	}
+	if srv.from != "MAIL FROM:<bot@example.com>" || len(srv.to) != 1 || srv.to[0] != "RCPT TO:<alice@example.com>" {
+		t.Fatalf("envelope = %q %q", srv.from, srv.to)
+	}
+	got := srv.received()
+	if len(got) != 1 {
+		t.Fatalf("messages = %d, want 1", len(got))
+	}
+	subject, text, html := parse(t, got[0])
+	if subject != "Reminder: Pill <VitC> & water" {
+		t.Fatalf("subject = %q", subject)
+	}
+	if want := "🔔 Pill <VitC> & water\r\n\r\nAfter breakfast\r\n\r\n🕒 08:00, Mon, 19 Jan 2026 (Europe/Warsaw)\r\n\r\nAnswer the reminder in Telegram with ✅ Done.\r\n"; text != want {
+		t.Fatalf("text:\n got %q\nwant %q", text, want)
+	}
+	if !strings.Contains(html, "<h2>🔔 Pill &lt;VitC&gt; &amp; water</h2>") || !strings.Contains(html, "<p>After breakfast</p>") {
+		t.Fatalf("html = %q", html)
+	}
+}
+
+func TestSenderRequiresStartTLS(t *testing.T) {
+	srv := newFakeSMTP(t)
+	cfg := srv.config()
+	cfg.StartTLS = true
+
+	err := NewSender(cfg).Send(context.Background(), Message{To: "alice@example.com", Subject: "x", Text: "x", HTML: "x"})
+	if err == nil || !strings.Contains(err.Error(), "STARTTLS") {
+		t.Fatalf("send without STARTTLS = %v, want an error", err)
+	}
+	if len(srv.received()) != 0 {
+		t.Fatal("message was sent over a plain connection")
+	}
+}
+
+func TestVerificationMessage(t *testing.T) {
+	msg, err := VerificationMessage(i18n.For(i18n.Russian), "alice@example.com", "042137")
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	if msg.Subject != "Ваш код подтверждения: 042137" || !strings.Contains(msg.Text, "\n\n042137\n\n") || !strings.Contains(msg.HTML, "<b>042137</b>") {
+		t.Fatalf("message = %+v", msg)
+	}
+}
+
+func TestNotifierSendsToVerifiedAddresses(t *testing.T) {
+	ctx := context.Background()
+	srv := newFakeSMTP(t)
+	users := memory.NewInMemoryUserStore()
+	emails := memory.NewInMemoryEmailStore()
+
+	alice := &domain.User{TelegramID: 1, Locale: "ru"}
+	bob := &domain.User{TelegramID: 2}
+	for _, u := range []*domain.User{alice, bob} {
+		if err := users.Upsert(ctx, u); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := emails.Save(ctx, &domain.EmailAddress{UserID: alice.ID, Address: "alice@example.com", VerifiedAtUtc: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	if err := emails.Save(ctx, &domain.EmailAddress{UserID: bob.ID, Address: "bob@example.com", Code: "123456"}); err != nil {
+		t.Fatal(err)
+	}
+
+	n := NewNotifier(NewSender(srv.config()), users, emails)
+	rem, occ := testOccurrence()
+	rem.UserID = bob.ID
+	if err := n.Send(ctx, scheduler.OccurrenceWithReminder{Occurrence: occ, Reminder: rem}); !errors.Is(err, scheduler.ErrNotConfigured) {
+		t.Fatalf("send to unverified = %v, want ErrNotConfigured", err)
+	}
+	if len(srv.received()) != 0 {
+		t.Fatal("mailed an unverified address")
+	}
+
+	// Assigned reminders go to the recipient, in their language.
+	rem.RecipientID, rem.RecipientAccepted = alice.ID, true
+	if err := n.Send(ctx, scheduler.OccurrenceWithReminder{Occurrence: occ, Reminder: rem}); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	got := srv.received()
+	if len(got) != 1 {
+		t.Fatalf("messages = %d, want 1", len(got))
+	}
+	if subject, _, _ := parse(t, got[0]); subject != "Напоминание: Pill <VitC> & water" {
+		t.Fatalf("subject = %q", subject)
+	}
+}
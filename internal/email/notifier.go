@@ -0,0 +1,60 @@
+package email
+
+import (
+	"context"
+	"fmt"
+
+	"naggingbot/internal/domain"
+	"naggingbot/internal/i18n"
+	"naggingbot/internal/scheduler"
+)
+
+// Mailer delivers one message; *Sender implements it.
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// Notifier emails occurrences to the verified address of the user who
+// receives the reminder: the recipient of an assigned reminder, else its
+// owner. It returns scheduler.ErrNotConfigured for users without a verified
+// address.
+type Notifier struct {
+	mailer Mailer
+	users  domain.UserStore
+	emails domain.EmailStore
+}
+
+func NewNotifier(mailer Mailer, users domain.UserStore, emails domain.EmailStore) *Notifier {
+	return &Notifier{mailer: mailer, users: users, emails: emails}
+}
+
+func (n *Notifier) Send(ctx context.Context, occ scheduler.OccurrenceWithReminder) error {
+	if occ.Reminder == nil {
+		return nil
+	}
+	userID := occ.Reminder.Recipient()
+	addr, err := n.emails.Get(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("email notifier: get address of user %d: %w", userID, err)
+	}
+	if addr == nil || !addr.Verified() {
+		return scheduler.ErrNotConfigured
+	}
+	user, err := n.users.GetByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("email notifier: get user %d: %w", userID, err)
+	}
+
+	p := i18n.For(i18n.Default)
+	if user != nil {
+		p = i18n.For(i18n.Match(user.Locale, user.Language))
+	}
+	msg, err := NotificationMessage(p, addr.Address, occ.Reminder, occ.Occurrence)
+	if err != nil {
+		return err
+	}
+	if err := n.mailer.Send(ctx, msg); err != nil {
+		return fmt.Errorf("email notifier: occurrence %d: %w", occ.Occurrence.ID, err)
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package email
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+
+	"naggingbot/internal/domain"
+	"naggingbot/internal/i18n"
+	"naggingbot/internal/render"
+)
+
+const notificationText = `{{.Header}} {{.Name}}
+{{- if .Description}}
+
+{{.Description}}
+{{- end}}
+
+🕒 {{.When}}
+
+{{.Footer}}
+`
+
+const notificationHTML = `<!DOCTYPE html>
+<html>
+<body style="font-family: sans-serif">
+<h2>{{.Header}} {{.Name}}</h2>
+{{- if .Description}}
+<p>{{.Description}}</p>
+{{- end}}
+<p>🕒 {{.When}}</p>
+<p style="color: #888">{{.Footer}}</p>
+</body>
+</html>
+`
+
+const verificationText = `{{.Intro}}
+
+{{.Code}}
+
+{{.Footer}}
+`
+
+const verificationHTML = `<!DOCTYPE html>
+<html>
+<body style="font-family: sans-serif">
+<p>{{.Intro}}</p>
+<p style="font-size: 24px; letter-spacing: 4px"><b>{{.Code}}</b></p>
+<p style="color: #888">{{.Footer}}</p>
+</body>
+</html>
+`
+
+var (
+	notificationTextTemplate = texttemplate.Must(texttemplate.New("notification").Parse(notificationText))
+	notificationHTMLTemplate = htmltemplate.Must(htmltemplate.New("notification").Parse(notificationHTML))
+	verificationTextTemplate = texttemplate.Must(texttemplate.New("verification").Parse(verificationText))
+	verificationHTMLTemplate = htmltemplate.Must(htmltemplate.New("verification").Parse(verificationHTML))
+)
+
+// notification is the data of the notification templates. Strings are raw;
+// the HTML template escapes them.
+type notification struct {
+	Header      string
+	Name        string
+	Description string
+	When        string
+	Footer      string
+}
+
+// NotificationMessage renders the email for an occurrence. Times are shown
+// in the reminder's zone.
+func NotificationMessage(p *i18n.Printer, to string, rem *domain.Reminder, occ *domain.Occurrence) (Message, error) {
+	at := occ.FireAtUtc.In(rem.Location())
+	data := notification{
+		Header:      render.PriorityHeader(rem.Priority),
+		Name:        rem.Name,
+		Description: rem.Description,
+		When:        fmt.Sprintf("%s, %s (%s)", p.Time(at), p.Date(at), at.Location()),
+		Footer:      p.T("Answer the reminder in Telegram with ✅ Done."),
+	}
+	return execute(to, p.T("Reminder: %s", rem.Name), notificationTextTemplate, notificationHTMLTemplate, data)
+}
+
+// VerificationMessage renders the email carrying an address verification code.
+func VerificationMessage(p *i18n.Printer, to, code string) (Message, error) {
+	data := struct{ Intro, Code, Footer string }{
+		Intro:  p.T("Send this code to the bot to receive reminders at this address:"),
+		Code:   code,
+		Footer: p.T("The code expires in %d minutes. If you did not ask for it, ignore this email.", int(domain.EmailCodeTTL.Minutes())),
+	}
+	return execute(to, p.T("Your verification code: %s", code), verificationTextTemplate, verificationHTMLTemplate, data)
+}
+
+func execute(to, subject string, text *texttemplate.Template, html *htmltemplate.Template, data any) (Message, error) {
+	var tb, hb bytes.Buffer
+	if err := text.Execute(&tb, data); err != nil {
+		return Message{}, fmt.Errorf("email: render text: %w", err)
+	}
+	if err := html.Execute(&hb, data); err != nil {
+		return Message{}, fmt.Errorf("email: render html: %w", err)
+	}
+	return Message{To: to, Subject: subject, Text: strings.TrimSpace(tb.String()) + "\n", HTML: hb.String()}, nil
+}
@@ -0,0 +1,17 @@
+package i18n
+
+// en has no messages: keys are English already. It only spells out plurals.
+var en = catalog{
+	name:   "English",
+	plural: pluralEnglish,
+	plurals: map[string]Forms{
+		"%d reminders":              {One: "%d reminder", Other: "%d reminders"},
+		"%d occurrences":            {One: "%d occurrence", Other: "%d occurrences"},
+		"Next %d occurrences (%s):": {One: "Next occurrence (%[2]s):", Other: "Next %d occurrences (%s):"},
+		"Skipped %d events:":        {One: "Skipped %d event:", Other: "Skipped %d events:"},
+		"%d minutes":                {One: "%d minute", Other: "%d minutes"},
+		"Imported %d reminders.":    {One: "Imported %d reminder.", Other: "Imported %d reminders."},
+	},
+	weekdays: [7]string{"Sun", "Mon", "Tue", "Wed", "Thu", "Fri", "Sat"},
+	months:   [12]string{"Jan", "Feb", "Mar", "Apr", "May", "Jun", "Jul", "Aug", "Sep", "Oct", "Nov", "Dec"},
+}
@@ -0,0 +1,211 @@
+// Package i18n translates bot messages and formats dates for the user's
+// language.
+//
+// Messages are keyed by their English text, so English needs no catalog
+// except for plurals, and a missing translation falls back to English.
+// Keys are fmt format strings; translations may reorder arguments with
+// explicit indexes such as %[2]d. Plural messages pick a form by the CLDR
+// rules of the language.
+package i18n
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Locale is a supported language, identified by its ISO 639-1 code.
+type Locale string
+
+const (
+	English Locale = "en"
+	Russian Locale = "ru"
+
+	// Default is used for users whose language is not supported.
+	Default = English
+)
+
+// Locales lists the supported languages, Default first.
+func Locales() []Locale {
+	return []Locale{English, Russian}
+}
+
+// Name returns the language's name in itself, e.g. "Русский".
+func (l Locale) Name() string {
+	return catalogs[l].name
+}
+
+// Parse returns the supported locale for a code such as "ru" or "ru-RU".
+func Parse(code string) (Locale, bool) {
+	code = strings.ToLower(strings.TrimSpace(code))
+	if idx := strings.IndexAny(code, "-_"); idx >= 0 {
+		code = code[:idx]
+	}
+	if _, ok := catalogs[Locale(code)]; ok {
+		return Locale(code), true
+	}
+	return "", false
+}
+
+// Match returns the first supported locale among codes, in order of
+// preference, or Default.
+func Match(codes ...string) Locale {
+	for _, code := range codes {
+		if l, ok := Parse(code); ok {
+			return l
+		}
+	}
+	return Default
+}
+
+// Form is a plural category.
+type Form int
+
+const (
+	One Form = iota
+	Few
+	Many
+	Other
+)
+
+// Forms holds the variants of a plural message. Forms a language does not
+// use may be left empty; Other is the fallback.
+type Forms struct {
+	One, Few, Many, Other string
+}
+
+func (f Forms) get(form Form) string {
+	var s string
+	switch form {
+	case One:
+		s = f.One
+	case Few:
+		s = f.Few
+	case Many:
+		s = f.Many
+	}
+	if s == "" {
+		s = f.Other
+	}
+	return s
+}
+
+// catalog holds the messages and date names of one language.
+type catalog struct {
+	name     string
+	messages map[string]string
+	plurals  map[string]Forms
+	plural   func(n int) Form
+	weekdays [7]string // short names, Sunday first
+	months   [12]string
+}
+
+var catalogs = map[Locale]*catalog{
+	English: &en,
+	Russian: &ru,
+}
+
+// Printer translates messages for one locale. It is safe for concurrent use.
+type Printer struct {
+	locale Locale
+	cat    *catalog
+}
+
+var printers = func() map[Locale]*Printer {
+	m := make(map[Locale]*Printer, len(catalogs))
+	for l, c := range catalogs {
+		m[l] = &Printer{locale: l, cat: c}
+	}
+	return m
+}()
+
+// For returns the printer for a locale; unsupported locales get Default.
+func For(l Locale) *Printer {
+	if p, ok := printers[l]; ok {
+		return p
+	}
+	return printers[Default]
+}
+
+// Locale returns the printer's language.
+func (p *Printer) Locale() Locale {
+	return p.locale
+}
+
+// T translates key and formats it with args like fmt.Sprintf.
+func (p *Printer) T(key string, args ...any) string {
+	msg, ok := p.cat.messages[key]
+	if !ok {
+		msg = key
+	}
+	if len(args) == 0 {
+		return msg
+	}
+	return fmt.Sprintf(msg, args...)
+}
+
+// N translates the plural message key in the form for n and formats it with
+// args, or with n alone if args are omitted.
+func (p *Printer) N(key string, n int, args ...any) string {
+	forms, ok := p.cat.plurals[key]
+	form := p.cat.plural(n)
+	if !ok {
+		forms, form = en.plurals[key], en.plural(n)
+	}
+	msg := forms.get(form)
+	if msg == "" {
+		msg = key
+	}
+	if len(args) == 0 {
+		args = []any{n}
+	}
+	return fmt.Sprintf(msg, args...)
+}
+
+// Weekday returns the short name of a weekday, e.g. "Mon".
+func (p *Printer) Weekday(d time.Weekday) string {
+	return p.cat.weekdays[d]
+}
+
+// Time formats the time of day, e.g. "08:00".
+func (p *Printer) Time(t time.Time) string {
+	return t.Format("15:04")
+}
+
+// Date formats a full date, e.g. "Mon, 19 Jan 2026".
+func (p *Printer) Date(t time.Time) string {
+	return fmt.Sprintf("%s, %d %s %d", p.Weekday(t.Weekday()), t.Day(), p.cat.months[t.Month()-1], t.Year())
+}
+
+// ShortDate formats a date within the next days, e.g. "Mon 19.01".
+func (p *Printer) ShortDate(t time.Time) string {
+	return fmt.Sprintf("%s %02d.%02d", p.Weekday(t.Weekday()), t.Day(), int(t.Month()))
+}
+
+// ShortDateTime formats a nearby date and time, e.g. "Mon 19.01 08:00".
+func (p *Printer) ShortDateTime(t time.Time) string {
+	return p.ShortDate(t) + " " + p.Time(t)
+}
+
+// pluralEnglish implements the CLDR rule for English.
+func pluralEnglish(n int) Form {
+	if n == 1 {
+		return One
+	}
+	return Other
+}
+
+// pluralRussian implements the CLDR rule for Russian integers.
+func pluralRussian(n int) Form {
+	if n < 0 {
+		n = -n
+	}
+	switch mod10, mod100 := n%10, n%100; {
+	case mod10 == 1 && mod100 != 11:
+		return One
+	case mod10 >= 2 && mod10 <= 4 && (mod100 < 12 || mod100 > 14):
+		return Few
+	default:
+		return Many
+	}
+}
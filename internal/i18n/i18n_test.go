@@ -0,0 +1,284 @@
+package i18n
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMatch(t *testing.T) {
+	cases := []struct {
+		codes []string
+		want  Locale
+	}{
+		{nil, English},
+		{[]string{""}, English},
+		{[]string{"ru"}, Russian},
+		{[]string{"ru-RU"}, Russian},
+		{[]string{"EN_gb"}, English},
+		{[]string{"de"}, English},
+		{[]string{"", "ru"}, Russian},
+		{[]string{"en", "ru"}, English},
+		{[]string{"de", "ru"}, Russian},
+	}
+	for _, tc := range cases {
+		if got := Match(tc.codes...); got != tc.want {
+			t.Errorf("Match(%q) = %s, want %s", tc.codes, got, tc.want)
+		}
+	}
+	if _, ok := Parse("xx"); ok {
+		t.Errorf("Parse accepted an unsupported code")
+	}
+}
+
+func TestPluralRules(t *testing.T) {
+	russian := map[int]Form{
+		0: Many, 1: One, 2: Few, 4: Few, 5: Many, 11: Many, 12: Many, 14: Many,
+		21: One, 22: Few, 25: Many, 101: One, 111: Many, 112: Many, 122: Few,
+	}
+	for n, want := range russian {
+		if got := pluralRussian(n); got != want {
+			t.Errorf("pluralRussian(%d) = %d, want %d", n, got, want)
+		}
+	}
+	if pluralEnglish(1) != One || pluralEnglish(0) != Other || pluralEnglish(2) != Other {
+		t.Errorf("pluralEnglish is wrong")
+	}
+}
+
+func TestPrinter(t *testing.T) {
+	en, ru := For(English), For(Russian)
+
+	if got := ru.T("Invalid id"); got != "Неверный id" {
+		t.Errorf("ru.T = %q", got)
+	}
+	if got := ru.T("no such key %d", 3); got != "no such key 3" {
+		t.Errorf("missing key = %q, want the English fallback", got)
+	}
+	if got := For("xx").Locale(); got != Default {
+		t.Errorf("For(unsupported) = %s", got)
+	}
+
+	plurals := []struct {
+		p    *Printer
+		n    int
+		want string
+	}{
+		{en, 1, "1 reminder"},
+		{en, 2, "2 reminders"},
+		{ru, 1, "1 напоминание"},
+		{ru, 3, "3 напоминания"},
+		{ru, 5, "5 напоминаний"},
+		{ru, 21, "21 напоминание"},
+	}
+	for _, tc := range plurals {
+		if got := tc.p.N("%d reminders", tc.n); got != tc.want {
+			t.Errorf("%s N(%d) = %q, want %q", tc.p.Locale(), tc.n, got, tc.want)
+		}
+	}
+	if got := en.N("Next %d occurrences (%s):", 1, 1, "UTC"); got != "Next occurrence (UTC):" {
+		t.Errorf("reordered plural = %q", got)
+	}
+
+	// 2026-01-19 is a Monday.
+	at := time.Date(2026, 1, 19, 8, 5, 0, 0, time.UTC)
+	if got := en.Date(at); got != "Mon, 19 Jan 2026" {
+		t.Errorf("en.Date = %q", got)
+	}
+	if got := ru.Date(at); got != "пн, 19 янв 2026" {
+		t.Errorf("ru.Date = %q", got)
+	}
+	if got := ru.ShortDateTime(at); got != "пн 19.01 08:05" {
+		t.Errorf("ru.ShortDateTime = %q", got)
+	}
+}
+
+// sources are the packages whose user-facing strings go through the catalog.
+var sources = []string{"../telegram", "../render", "../scheduler", "../domain", "../email"}
+
+// verbRe matches fmt verbs, with optional explicit argument indexes.
+var verbRe = regexp.MustCompile(`%(\[\d+\])?[-+# 0]*\d*(\.\d+)?[a-zA-Z%]`)
+
+// verbs counts the arguments a format string consumes.
+func verbs(format string) int {
+	n, next := 0, 1
+	for _, m := range verbRe.FindAllStringSubmatch(format, -1) {
+		if strings.HasSuffix(m[0], "%") {
+			continue
+		}
+		idx := next
+		if m[1] != "" {
+			idx, _ = strconv.Atoi(strings.Trim(m[1], "[]"))
+		}
+		n = max(n, idx)
+		next = idx + 1
+	}
+	return n
+}
+
+// sourceKeys collects the catalog keys used in sources: the literal or
+// constant format passed to T, N and limitErrorf, and the descriptions of
+// botCommands.
+func sourceKeys(t *testing.T) (messages, plurals map[string]bool) {
+	t.Helper()
+	messages, plurals = map[string]bool{}, map[string]bool{}
+	for _, dir := range sources {
+		fset := token.NewFileSet()
+		files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var parsed []*ast.File
+		consts := map[string]string{}
+		for _, name := range files {
+			if strings.HasSuffix(name, "_test.go") {
+				continue
+			}
+			f, err := parser.ParseFile(fset, name, nil, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			parsed = append(parsed, f)
+			for _, obj := range f.Scope.Objects {
+				if obj.Kind != ast.Con {
+					continue
+				}
+				spec := obj.Decl.(*ast.ValueSpec)
+				for i, id := range spec.Names {
+					if i < len(spec.Values) {
+						if s, ok := stringValue(spec.Values[i]); ok {
+							consts[id.Name] = s
+						}
+					}
+				}
+			}
+		}
+
+		key := func(e ast.Expr) (string, bool) {
+			if id, ok := e.(*ast.Ident); ok {
+				s, ok := consts[id.Name]
+				return s, ok
+			}
+			return stringValue(e)
+		}
+		for _, f := range parsed {
+			ast.Inspect(f, func(n ast.Node) bool {
+				switch n := n.(type) {
+				case *ast.CallExpr:
+					if len(n.Args) == 0 {
+						return true
+					}
+					var name string
+					switch fn := n.Fun.(type) {
+					case *ast.SelectorExpr:
+						name = fn.Sel.Name
+					case *ast.Ident:
+						name = fn.Name
+					}
+					switch name {
+					case "T", "limitErrorf":
+						if s, ok := key(n.Args[0]); ok {
+							messages[s] = true
+						}
+					case "N":
+						if s, ok := key(n.Args[0]); ok {
+							plurals[s] = true
+						}
+					}
+				case *ast.ValueSpec:
+					if len(n.Names) == 1 && n.Names[0].Name == "botCommands" && len(n.Values) == 1 {
+						for _, elt := range n.Values[0].(*ast.CompositeLit).Elts {
+							fields := elt.(*ast.CompositeLit).Elts
+							if s, ok := stringValue(fields[len(fields)-1]); ok {
+								messages[s] = true
+							}
+						}
+					}
+				}
+				return true
+			})
+		}
+	}
+	return messages, plurals
+}
+
+// stringValue evaluates a string literal or a concatenation of literals.
+func stringValue(e ast.Expr) (string, bool) {
+	switch e := e.(type) {
+	case *ast.BasicLit:
+		if e.Kind != token.STRING {
+			return "", false
+		}
+		s, err := strconv.Unquote(e.Value)
+		return s, err == nil
+	case *ast.BinaryExpr:
+		if e.Op != token.ADD {
+			return "", false
+		}
+		x, ok1 := stringValue(e.X)
+		y, ok2 := stringValue(e.Y)
+		return x + y, ok1 && ok2
+	case *ast.ParenExpr:
+		return stringValue(e.X)
+	}
+	return "", false
+}
+
+func TestCatalogsComplete(t *testing.T) {
+	messages, plurals := sourceKeys(t)
+	if len(messages) < 100 || len(plurals) < 5 {
+		t.Fatalf("found only %d messages and %d plurals; is the extraction broken?", len(messages), len(plurals))
+	}
+
+	for l, cat := range catalogs {
+		var missing []string
+		for key := range messages {
+			if l != English {
+				msg, ok := cat.messages[key]
+				if !ok {
+					missing = append(missing, key)
+					continue
+				}
+				if verbs(msg) != verbs(key) {
+					t.Errorf("%s: %q uses %d arguments, its key %d", l, msg, verbs(msg), verbs(key))
+				}
+			}
+		}
+		for key := range plurals {
+			forms, ok := cat.plurals[key]
+			if !ok {
+				missing = append(missing, "plural "+key)
+				continue
+			}
+			for _, msg := range []string{forms.One, forms.Few, forms.Many, forms.Other} {
+				if msg != "" && verbs(msg) > verbs(key) {
+					t.Errorf("%s: plural %q uses more arguments than its key", l, msg)
+				}
+			}
+			if forms.Other == "" {
+				t.Errorf("%s: plural %q has no Other form", l, key)
+			}
+		}
+		for key := range cat.messages {
+			if !messages[key] {
+				t.Errorf("%s: unused message %q", l, key)
+			}
+		}
+		for key := range cat.plurals {
+			if !plurals[key] {
+				t.Errorf("%s: unused plural %q", l, key)
+			}
+		}
+		sort.Strings(missing)
+		for _, key := range missing {
+			t.Errorf("%s: missing %q", l, key)
+		}
+	}
+}
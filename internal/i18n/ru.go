@@ -0,0 +1,310 @@
+package i18n
+
+// ru is the Russian catalog.
+var ru = catalog{
+	name:     "Русский",
+	plural:   pluralRussian,
+	weekdays: [7]string{"вс", "пн", "вт", "ср", "чт", "пт", "сб"},
+	months:   [12]string{"янв", "фев", "мар", "апр", "мая", "июн", "июл", "авг", "сен", "окт", "ноя", "дек"},
+	messages: map[string]string{
+		"#%d %s | %s to %s | %s | %s":                        "#%d %s | с %s по %s | %s | %s",
+		"#%d %s: %d/%d done (%.0f%%), %d ignored, %d missed": "#%d %s: выполнено %d/%d (%.0f%%), пропущено %d, без ответа %d",
+		"%d. after %d min: %s":                               "%d. через %d мин: %s",
+		"%d. after %d min: %s (waiting for acceptance)":      "%d. через %d мин: %s (ждёт согласия)",
+		"%dh%02dm":                       "%dч%02dм",
+		"%dm":                            "%dм",
+		"%s (extra)":                     "%s (дополнительно)",
+		"%s accepted reminder #%d “%s”.": "%s принял(а) напоминание #%d «%s».",
+		"%s accepted the escalation step of reminder #%d “%s”.":                           "%s согласился на шаг эскалации напоминания #%d «%s».",
+		"%s asks you to be told when their reminder “%s” stays unanswered for %d min.":    "%s просит сообщать вам, если на напоминание «%s» не ответят в течение %d мин.",
+		"%s asks you to receive the reminder “%s” and answer it with ✅ Done.":             "%s просит вас получать напоминание «%s» и отвечать на него кнопкой ✅ Выполнено.",
+		"%s declined reminder #%d “%s”.":                                                  "%s отказался(ась) от напоминания #%d «%s».",
+		"%s declined the escalation step of reminder #%d “%s”; it was removed.":           "%s отказался от шага эскалации напоминания #%d «%s»; шаг удалён.",
+		"%s has to open the bot and send /start first.":                                   "%s должен(на) сначала открыть бота и отправить /start.",
+		"%s is already verified.":                                                         "%s уже подтверждён.",
+		"%s is not verified yet. Send the code from the email with /email verify <code>.": "%s ещё не подтверждён. Отправьте код из письма командой /email verify <код>.",
+		"%s is verified. Reminders will be emailed there too.":                            "%s подтверждён. Напоминания будут приходить и туда.",
+		"%s no longer sends you the reminder “%s”.":                                       "%s больше не присылает вам напоминание «%s».",
+		"%s stopped receiving reminder #%d “%s”.":                                         "%s больше не получает напоминание #%d «%s».",
+		"%s. Import into Google, Apple or Thunderbird calendars.":                         "%s. Импортируйте файл в календарь Google, Apple или Thunderbird.",
+		"(latest up to 20):": "(последние, до 20):",
+		"(paused)":           "(на паузе)",
+		"A code was mailed just now. Try again in %d seconds.":                                     "Код только что отправлен. Попробуйте снова через %d с.",
+		"A reminder can have at most %d escalation steps.":                                         "У напоминания может быть не больше %d шагов эскалации.",
+		"A reminder can have at most %d times of day.":                                             "У напоминания может быть не больше %d времён в день.",
+		"A reminder can span at most %d days (this one spans %d).":                                 "Напоминание может длиться не больше %d дн. (это — %d дн.).",
+		"A verification code was sent to %s. Send it with /email verify <code> within %d minutes.": "Код подтверждения отправлен на %s. Отправьте его командой /email verify <код> в течение %d минут.",
+		"Added an escalation step to reminder #%d after %d min: %s":                                "К напоминанию #%d добавлен шаг эскалации через %d мин: %s",
+		"Adherence statistics":                         "Статистика выполнения",
+		"Already handled.":                             "Уже обработано.",
+		"Already marked done.":                         "Уже отмечено как выполненное.",
+		"Already marked ignored.":                      "Уже отмечено как пропущенное.",
+		"Answer the reminder in Telegram with ✅ Done.": "Ответьте на напоминание в Telegram кнопкой ✅ Выполнено.",
+		"Asked %s to accept reminder #%d. Until they do, you keep receiving it.":               "Отправили %s запрос на напоминание #%d. Пока запрос не принят, напоминание приходит вам.",
+		"Asked %s to accept the escalation step of reminder #%d. It is skipped until they do.": "%s получил запрос на шаг эскалации напоминания #%d. До согласия шаг пропускается.",
+		"Backup from %s: %s, %s.":                                            "Резервная копия от %s: %s, %s.",
+		"Backup merged: added %s, skipped %d already present.":               "Резервная копия объединена: добавлено — %s, пропущено уже существующих — %d.",
+		"Backup not restored, nothing was changed. %q: %s":                   "Резервная копия не восстановлена, ничего не изменено. %q: %s",
+		"Backup not restored, nothing was changed. %q: invalid template: %v": "Резервная копия не восстановлена, ничего не изменено. %q: неверный шаблон: %v",
+		"Backup of %s and %s. Send it back with /import json to restore.":    "Резервная копия: %s и %s. Чтобы восстановить, отправьте её обратно с подписью /import json.",
+		"Backup restored: removed %s, restored %d.":                          "Резервная копия восстановлена: удалено — %s, восстановлено — %d.",
+		"Cancel":                                              "Отмена",
+		"Cannot delete reminder of another user":              "Нельзя удалить чужое напоминание",
+		"Choose notification channels":                        "Каналы уведомлений",
+		"Confirmed by %s (%d/%d)":                             "Подтвердили: %s (%d/%d)",
+		"Confirmed, waiting for %d more.":                     "Принято, ждём ещё %d.",
+		"Could not check chat %d. Add the bot to it first.":   "Не удалось проверить чат %d. Сначала добавьте в него бота.",
+		"Could not read backup: %v":                           "Не удалось прочитать резервную копию: %v",
+		"Could not read calendar: %v":                         "Не удалось прочитать календарь: %v",
+		"Could not send the request to %s, please try again.": "Не удалось отправить запрос %s, попробуйте ещё раз.",
+		"Create reminder":                                     "Создать напоминание",
+		"Custom template of #%d:":                             "Свой шаблон #%d:",
+		"Customize notification template":                     "Настроить шаблон уведомления",
+		"Daily and weekly digests":                            "Ежедневные и еженедельные сводки",
+		"Default template of #%d:":                            "Стандартный шаблон #%d:",
+		"Delete reminder":                                     "Удалить напоминание",
+		"Digests (%s):\nmorning: %s\nevening: %s\nweekly: %s": "Сводки (%s):\nутренняя: %s\nвечерняя: %s\nеженедельная: %s",
+		"Digests: enabled":                                    "Сводки: включены",
+		"Done by %s":                                          "Выполнили: %s",
+		"Email is not set up yet, see /email.":                "Почта ещё не настроена, см. /email.",
+		"Email notifications are not available on this bot.":  "В этом боте уведомления по почте недоступны.",
+		"Escalate unanswered reminders":                       "Эскалация напоминаний без ответа",
+		"Escalation steps of reminder #%d:":                   "Шаги эскалации напоминания #%d:",
+		"Export reminders (ics, json)":                        "Экспорт напоминаний (ics, json)",
+		"Failed to check the invite code, please try again.":  "Не удалось проверить код приглашения, попробуйте ещё раз.",
+		"Failed to create reminder":                           "Не удалось создать напоминание",
+		"Failed to delete":                                    "Не удалось удалить",
+		"Failed to delete reminder":                           "Не удалось удалить напоминание",
+		"Failed to download file":                             "Не удалось скачать файл",
+		"Failed to export":                                    "Не удалось выполнить экспорт",
+		"Failed to import reminders":                          "Не удалось импортировать напоминания",
+		"Failed to load agenda":                               "Не удалось загрузить расписание",
+		"Failed to load digest settings":                      "Не удалось загрузить настройки сводок",
+		"Failed to load history":                              "Не удалось загрузить историю",
+		"Failed to load reminder":                             "Не удалось загрузить напоминание",
+		"Failed to load stats":                                "Не удалось загрузить статистику",
+		"Failed to load the delivery log":                     "Не удалось загрузить журнал доставки",
+		"Failed to load the reminder, please try again.":      "Не удалось загрузить напоминание, попробуйте ещё раз.",
+		"Failed to load webhook settings":                     "Не удалось загрузить настройки вебхука",
+		"Failed to load your email address":                   "Не удалось загрузить ваш адрес почты",
+		"Failed to look up %s, please try again.":             "Не удалось найти %s, попробуйте ещё раз.",
+		"Failed to prepare restore":                           "Не удалось подготовить восстановление",
+		"Failed to read your current reminders":               "Не удалось прочитать ваши текущие напоминания",
+		"Failed to register, please try again.":               "Не удалось зарегистрироваться, попробуйте ещё раз.",
+		"Failed to restore backup. Nothing was changed.":      "Не удалось восстановить резервную копию. Ничего не изменено.",
+		"Failed to save channels":                             "Не удалось сохранить каналы",
+		"Failed to save digest settings":                      "Не удалось сохранить настройки сводок",
+		"Failed to save language":                             "Не удалось сохранить язык",
+		"Failed to save priority":                             "Не удалось сохранить приоритет",
+		"Failed to save sharing settings":                     "Не удалось сохранить настройки публикации",
+		"Failed to save template":                             "Не удалось сохранить шаблон",
+		"Failed to save the assignment":                       "Не удалось сохранить назначение",
+		"Failed to save the escalation steps":                 "Не удалось сохранить шаги эскалации",
+		"Failed to save time zone":                            "Не удалось сохранить часовой пояс",
+		"Failed to save webhook settings":                     "Не удалось сохранить настройки вебхука",
+		"Failed to save your email address":                   "Не удалось сохранить ваш адрес почты",
+		"Failed to save, please try again.":                   "Не удалось сохранить, попробуйте ещё раз.",
+		"Failed to send export file":                          "Не удалось отправить файл экспорта",
+		"Failed to send the verification email. Check the address and try again.": "Не удалось отправить письмо с кодом. Проверьте адрес и попробуйте ещё раз.",
+		"File is too large (max 1 MB).":                                           "Файл слишком большой (максимум 1 МБ).",
+		"History of #%d %s (page %d/%d, %s):":                                     "История #%d %s (стр. %d/%d, %s):",
+		"Ignored 🚫":                                                               "Пропущено 🚫",
+		"Import reminders from a file":                                            "Импорт напоминаний из файла",
+		"Invalid date range. Use DD.MM.YYYY_DD.MM.YYYY (inclusive)":               "Неверный диапазон дат. Используйте ДД.ММ.ГГГГ_ДД.ММ.ГГГГ (включительно)",
+		"Invalid email address":                                                   "Неверный адрес почты",
+		"Invalid escalation step: %v":                                             "Неверный шаг эскалации: %v",
+		"Invalid format. Expected: /reminder Name_Description_StartDate_EndDate_HH:MM;HH:MM_TimeZone": "Неверный формат. Ожидается: /reminder Название_Описание_НачальнаяДата_КонечнаяДата_ЧЧ:ММ;ЧЧ:ММ_ЧасовойПояс",
+		"Invalid id":                                      "Неверный id",
+		"Invalid time. Use HH:MM or off":                  "Неверное время. Используйте ЧЧ:ММ или off",
+		"Invalid times. Use HH:MM;HH:MM":                  "Неверное время. Используйте ЧЧ:ММ;ЧЧ:ММ",
+		"Invalid timezone. Use IANA, e.g., Europe/Moscow": "Неверный часовой пояс. Используйте IANA, например Europe/Moscow",
+		"Language set to %s.":                             "Язык: %s.",
+		"Language: %s (%s)\nUsage: /language <%s|auto>":   "Язык: %s (%s)\nИспользование: /language <%s|auto>",
+		"Latest deliveries:":                              "Последние доставки:",
+		"Latest webhook deliveries:":                      "Последние отправки на вебхук:",
+		"Let someone else receive a reminder":             "Поручить напоминание другому",
+		"List reminders":                                  "Список напоминаний",
+		"Marked done ✅":                                   "Выполнено ✅",
+		"Merge":                                           "Объединить",
+		"Merge: add %s, skip %d already present, keep your current settings.": "Объединить: добавить %s, пропустить уже существующие (%d), сохранить текущие настройки.",
+		"New signing secret: %s":                                     "Новый секрет для подписи: %s",
+		"Next ▶":                                                     "Далее ▶",
+		"No deliveries yet.":                                         "Доставок пока не было.",
+		"No email address is set up.":                                "Адрес почты не задан.",
+		"No past occurrences yet.":                                   "Прошедших событий пока нет.",
+		"No reminders found.":                                        "Напоминаний нет.",
+		"No webhook deliveries yet.":                                 "Отправок на вебхук пока не было.",
+		"No webhook is set up yet, see /webhook.":                    "Вебхук ещё не настроен, см. /webhook.",
+		"No webhook is set up.":                                      "Вебхук не настроен.",
+		"Nothing has been changed yet. Choose within %s.":            "Пока ничего не изменено. Выберите в течение %s.",
+		"Nothing scheduled.":                                         "Ничего не запланировано.",
+		"Nothing was imported. %q: %s":                               "Ничего не импортировано. %q: %s",
+		"Occurrence history":                                         "История событий",
+		"Only administrators of chat %d can post reminders there.":   "Публиковать напоминания в чате %d могут только его администраторы.",
+		"Only members of chat %d can send escalations there.":        "Отправлять эскалации в чат %d могут только его участники.",
+		"Only reminders with their own URL are posted to a webhook.": "На вебхук отправляются только напоминания со своим URL.",
+		"Post a reminder to a group":                                 "Публиковать напоминание в группе",
+		"Post notifications to a URL":                                "Отправлять уведомления на URL",
+		"Preview failed: %s":                                         "Не удалось показать пример: %s",
+		"Preview:":                                                   "Пример:",
+		"Priority of #%d set to %s.":                                 "Приоритет #%d: %s.",
+		"Receive reminders by email":                                 "Напоминания по почте",
+		"Register":                                                   "Регистрация",
+		"Reminder #%d follows your channels again.":                  "Напоминание #%d снова использует ваши каналы.",
+		"Reminder #%d has no escalation steps.":                      "У напоминания #%d нет шагов эскалации.",
+		"Reminder #%d is assigned to another user; stop that with /assign %d off first.":        "Напоминание #%d поручено другому человеку; сначала отмените это: /assign %d off.",
+		"Reminder #%d is delivered to %s.":                                                      "Напоминание #%d приходит %s.",
+		"Reminder #%d is delivered to %s; the owner is alerted after %d min without an answer.": "Напоминание #%d приходит %s; владелец получит оповещение, если ответа нет %d мин.",
+		"Reminder #%d is no longer assigned.":                                                   "Напоминание #%d больше никому не поручено.",
+		"Reminder #%d is not assigned to anyone.":                                               "Напоминание #%d никому не поручено.",
+		"Reminder #%d is posted to a group; stop that with /share %d off first.":                "Напоминание #%d публикуется в группе; сначала отмените это: /share %d off.",
+		"Reminder #%d is posted to chat %d; %s.":                                                "Напоминание #%d публикуется в чате %d; %s.",
+		"Reminder #%d is sent over: %s.":                                                        "Напоминание #%d отправляется: %s.",
+		"Reminder #%d is sent to your private chat.":                                            "Напоминание #%d приходит вам в личный чат.",
+		"Reminder #%d no longer escalates.":                                                     "Эскалация напоминания #%d отключена.",
+		"Reminder #%d uses your webhook settings again.":                                        "Напоминание #%d снова использует ваши настройки вебхука.",
+		"Reminder #%d waits for %s to accept it.":                                               "Напоминание #%d ждёт, пока %s примет запрос.",
+		"Reminder #%d will be posted to %s, signed with the secret %s.":                         "Напоминание #%d будет отправляться на %s с подписью секретом %s.",
+		"Reminder #%d will be posted to chat %d; %s.":                                           "Напоминание #%d будет публиковаться в чате %d; %s.",
+		"Reminder #%d will be posted to this chat; %s.":                                         "Напоминание #%d будет публиковаться в этом чате; %s.",
+		"Reminder #%d will be sent to your private chat again.":                                 "Напоминание #%d снова будет приходить вам в личный чат.",
+		"Reminder #%d “%s” is sent over: %s.":                                                   "Напоминание #%d «%s» отправляется: %s.",
+		"Reminder #%d “%s”: %s":                                                                 "Напоминание #%d «%s»: %s",
+		"Reminder created: %s (%s) in %s":                                                       "Напоминание создано: %s (%s), %s",
+		"Reminder deleted":                                                                      "Напоминание удалено",
+		"Reminder not found":                                                                    "Напоминание не найдено",
+		"Reminder: %s":                                                                          "Напоминание: %s",
+		"Reminders are emailed to %s.":                                                          "Напоминания отправляются на %s.",
+		"Reminders are no longer emailed to %s.":                                                "Напоминания больше не отправляются на %s.",
+		"Replace":                                                                               "Заменить",
+		"Replace: delete your %s and their history, then restore everything from the backup.":   "Заменить: удалить ваши напоминания (%s) и их историю, затем восстановить всё из резервной копии.",
+		"Restore cancelled":                                                                     "Восстановление отменено",
+		"Restore cancelled. Nothing was changed.":                                               "Восстановление отменено. Ничего не изменено.",
+		"Restore expired":                                                                       "Время восстановления истекло",
+		"Restoring backup…":                                                                     "Восстанавливаю резервную копию…",
+		"Saved.":                                                                                "Сохранено.",
+		"Send an .ics calendar or a .json backup with the caption /import (or just upload it).": "Отправьте календарь .ics или резервную копию .json с подписью /import (или просто загрузите файл).",
+		"Send this code to the bot to receive reminders at this address:":                       "Отправьте этот код боту, чтобы получать напоминания на этот адрес:",
+		"Set reminder priority":   "Задать приоритет напоминания",
+		"Set up a webhook first.": "Сначала настройте вебхук.",
+		"Settings could not be restored; set them again with /timezone and /digest.": "Не удалось восстановить настройки; задайте их заново через /timezone и /digest.",
+		"Show or set language":                                 "Показать или выбрать язык",
+		"Show or set time zone":                                "Показать или задать часовой пояс",
+		"Signing secret: %s":                                   "Секрет для подписи: %s",
+		"Something went wrong, please try again later.":        "Что-то пошло не так, попробуйте позже.",
+		"Stats for all time:":                                  "Статистика за всё время:",
+		"Stats for the last %s:":                               "Статистика за последние %s:",
+		"Status: ✅ Done":                                       "Статус: ✅ Выполнено",
+		"Status: 🚫 Ignored":                                    "Статус: 🚫 Пропущено",
+		"Sundays at %02d:%02d":                                 "по воскресеньям в %02d:%02d",
+		"Telegram, plus email and webhook if set up (default)": "Telegram, а также почта и вебхук, если настроены (по умолчанию)",
+		"Template not saved: %v":                               "Шаблон не сохранён: %v",
+		"The alert delay must be 0 to %d minutes.":             "Задержка оповещения должна быть от 0 до %d минут.",
+		"The code expires in %d minutes. If you did not ask for it, ignore this email.": "Код действует %d минут. Если вы его не запрашивали, просто проигнорируйте это письмо.",
+		"The code has expired. Send /email %s to get a new one.":                        "Код больше недействителен. Отправьте /email %s, чтобы получить новый.",
+		"The escalation delay must be 1 to %d minutes.":                                 "Задержка эскалации должна быть от 1 до %d минут.",
+		"The recipient": "Получатель",
+		"They are alerted if you do not answer within %d min.":                      "Если вы не ответите за %d мин, он(а) получит оповещение.",
+		"This bot is invite-only. Open your invite link or send /start <code>.":     "Этот бот работает по приглашениям. Откройте ссылку-приглашение или отправьте /start <код>.",
+		"This bot is private. Ask its owner to add you.":                            "Это закрытый бот. Попросите владельца добавить вас.",
+		"This bot is private. Send /start to check whether you have access.":        "Это закрытый бот. Отправьте /start, чтобы проверить, есть ли у вас доступ.",
+		"This button belongs to someone else.":                                      "Эта кнопка принадлежит другому пользователю.",
+		"This button has expired. Open the menu again.":                             "Срок действия кнопки истёк. Откройте меню заново.",
+		"This button is not valid.":                                                 "Эта кнопка недействительна.",
+		"This invite code is invalid, expired or already used.":                     "Код приглашения неверен, истёк или уже использован.",
+		"This occurrence is not due yet.":                                           "Это событие ещё не наступило.",
+		"This reminder no longer exists.":                                           "Этого напоминания больше нет.",
+		"This request is no longer valid.":                                          "Этот запрос больше не действует.",
+		"This restore is no longer available. Upload the backup again.":             "Это восстановление больше недоступно. Загрузите резервную копию ещё раз.",
+		"This would schedule %d reminders on some days; the maximum is %d per day.": "В некоторые дни получится %d напоминаний; максимум — %d в день.",
+		"Time zone set to %s":                                                       "Часовой пояс: %s",
+		"Time zone: %s":                                                             "Часовой пояс: %s",
+		"Today's agenda":                                                            "Расписание на сегодня",
+		"Today, %s (%s):":                                                           "Сегодня, %s (%s):",
+		"Unknown mode, use anyone or everyone.":                                     "Неизвестный режим, используйте anyone или everyone.",
+		"Unsupported file. Send an iCalendar (.ics) file or a JSON backup.":         "Неподдерживаемый файл. Отправьте файл iCalendar (.ics) или резервную копию JSON.",
+		"Unsupported language. Use /language <%s|auto>":                             "Язык не поддерживается. Используйте /language <%s|auto>",
+		"Upcoming in the next %dh (%s):":                                            "Ближайшие %d ч (%s):",
+		"Upcoming occurrences":                                                      "Ближайшие события",
+		"Usage:\n/assign <id> @username [minutes]: let another user receive a reminder; you are alerted if they leave it unanswered for that long (default 30, 0 turns alerts off)\n/assign <id> off: stop the assignment (the recipient may do that too)\n/assign <id>: show who receives a reminder":                                                                                                                                                                                                                                                                              "Использование:\n/assign <id> @username [минуты]: поручить напоминание другому человеку; вы получите оповещение, если он не ответит за это время (по умолчанию 30, 0 отключает оповещения)\n/assign <id> off: отменить поручение (получатель тоже может это сделать)\n/assign <id>: показать, кому приходит напоминание",
+		"Usage:\n/channels telegram|telegram+email|webhook|...: choose where your reminders are sent\n/channels <id> telegram|telegram+email|webhook|...: choose it for one reminder\n/channels [<id>] default: go back to the default\n/channels <id>: show the channels and latest deliveries of a reminder\nBy default reminders go to Telegram, and also to your email and webhook if you set them up. A reminder counts as delivered when any chosen channel succeeds.":                                                                                                        "Использование:\n/channels telegram|telegram+email|webhook|...: выбрать, куда отправлять напоминания\n/channels <id> telegram|telegram+email|webhook|...: выбрать для одного напоминания\n/channels [<id>] default: вернуть настройку по умолчанию\n/channels <id>: показать каналы и последние доставки напоминания\nПо умолчанию напоминания приходят в Telegram, а также на почту и вебхук, если они настроены. Напоминание считается доставленным, если сработал хотя бы один выбранный канал.",
+		"Usage:\n/digest - show settings\n/digest morning <HH:MM|off> - list of the day's reminders\n/digest evening <HH:MM|off> - recap of done, ignored and missed\n/digest weekly <on|off> - weekly recap on Sundays":                                                                                                                                                                                                                                                                                                                                                            "Использование:\n/digest - показать настройки\n/digest morning <ЧЧ:ММ|off> - список напоминаний на день\n/digest evening <ЧЧ:ММ|off> - итоги: выполнено, пропущено, без ответа\n/digest weekly <on|off> - недельные итоги по воскресеньям",
+		"Usage:\n/email <address>: receive reminders by email too\n/email verify <code>: confirm the address with the code mailed to it\n/email off: stop emailing reminders\n/email: show the address":                                                                                                                                                                                                                                                                                                                                                                             "Использование:\n/email <адрес>: получать напоминания и по почте\n/email verify <код>: подтвердить адрес кодом из письма\n/email off: больше не присылать напоминания по почте\n/email: показать адрес",
+		"Usage:\n/escalate <id> <minutes> @username: tell another user, once they accept, if an occurrence stays unanswered that long\n/escalate <id> <minutes> <chat id>: tell a group you are a member of\n/escalate <id> <minutes> <https://…>: call a webhook\n/escalate <id> clear: remove all escalation steps\n/escalate <id>: show the escalation steps":                                                                                                                                                                                                                    "Использование:\n/escalate <id> <минуты> @username: сообщить другому пользователю (после его согласия), если событие столько времени остаётся без ответа\n/escalate <id> <минуты> <id чата>: сообщить в группу, участником которой вы являетесь\n/escalate <id> <минуты> <https://…>: вызвать вебхук\n/escalate <id> clear: удалить все шаги эскалации\n/escalate <id>: показать шаги эскалации",
+		"Usage:\n/share <id> [anyone|everyone] in a group: post the reminder to that group\n/share <id> <chat id> [anyone|everyone]: post it to a group or channel you administer\n/share <id> off: send it to your private chat again\nanyone: the first Done completes it; everyone: every member has to press Done.":                                                                                                                                                                                                                                                             "Использование:\n/share <id> [anyone|everyone] в группе: публиковать напоминание в этой группе\n/share <id> <id чата> [anyone|everyone]: публиковать в группе или канале, где вы администратор\n/share <id> off: снова присылать в личный чат\nanyone: достаточно первого «Выполнено»; everyone: «Выполнено» должен нажать каждый участник.",
+		"Usage:\n/template <id> shows the notification template of a reminder and a preview\n/template <id> <template> sets a custom template\n/template <id> reset restores the default\n/priority <id> low|normal|high sets the priority shown in the header\n\nTemplates use Go template syntax (fields, {{if}} and {{with}}) and Telegram HTML (<b>, <i>, <u>, <s>, <code>, <a href=\"...\">). Fields: {{.Header}} {{.Name}} {{.Description}} {{.Time}} {{.Date}} {{.Zone}} {{.Priority}} {{.OccurrenceID}}, and {{.At}} for the fire time, e.g. {{.At.Format \"Mon 15:04\"}}.": "Использование:\n/template <id> показывает шаблон уведомления и пример\n/template <id> <шаблон> задаёт свой шаблон\n/template <id> reset возвращает стандартный\n/priority <id> low|normal|high задаёт приоритет, показываемый в заголовке\n\nШаблоны используют синтаксис Go templates (поля, {{if}} и {{with}}) и Telegram HTML (<b>, <i>, <u>, <s>, <code>, <a href=\"...\">). Поля: {{.Header}} {{.Name}} {{.Description}} {{.Time}} {{.Date}} {{.Zone}} {{.Priority}} {{.OccurrenceID}} и {{.At}} — время срабатывания, например {{.At.Format \"15:04\"}}.",
+		"Usage:\n/webhook <url>: post all your notifications to a URL\n/webhook <id> <url>: post the notifications of one reminder to another URL\n/webhook <id> off: use your URL for that reminder again\n/webhook off: stop posting notifications\n/webhook secret: make a new signing secret\n/webhook log: show the latest deliveries\n/webhook: show the settings":                                                                                                                                                                                                            "Использование:\n/webhook <url>: отправлять все уведомления на URL\n/webhook <id> <url>: отправлять уведомления одного напоминания на другой URL\n/webhook <id> off: снова использовать ваш URL для этого напоминания\n/webhook off: не отправлять уведомления\n/webhook secret: создать новый секрет для подписи\n/webhook log: показать последние отправки\n/webhook: показать настройки",
+		"Usage: /delete <reminder_id>":          "Использование: /delete <id_напоминания>",
+		"Usage: /export [ics|json]":             "Использование: /export [ics|json]",
+		"Usage: /history <reminder_id>":         "Использование: /history <id_напоминания>",
+		"Usage: /priority <id> low|normal|high": "Использование: /priority <id> low|normal|high",
+		"Usage: /reminder Name_Description_StartDate_EndDate_HH:MM;HH:MM_TimeZone\nExample: /reminder Pill_VitC_19.01.2026_20.01.2026_08:00;13:00;19:00_Europe/Warsaw": "Использование: /reminder Название_Описание_НачальнаяДата_КонечнаяДата_ЧЧ:ММ;ЧЧ:ММ_ЧасовойПояс\nПример: /reminder Таблетка_ВитС_19.01.2026_20.01.2026_08:00;13:00;19:00_Europe/Moscow",
+		"Usage: /stats [reminder_id] [7d|4w|all]":                           "Использование: /stats [id_напоминания] [7d|4w|all]",
+		"Usage: /upcoming [count|hours], e.g. /upcoming 5 or /upcoming 12h": "Использование: /upcoming [количество|часы], например /upcoming 5 или /upcoming 12h",
+		"Use /digest weekly on or /digest weekly off":                       "Используйте /digest weekly on или /digest weekly off",
+		"Wrong code.":            "Неверный код.",
+		"You already confirmed.": "Вы уже подтвердили.",
+		"You already have %d active reminders, the maximum. Delete one with /delete first.": "У вас уже %d активных напоминаний — это максимум. Сначала удалите одно через /delete.",
+		"You already receive your own reminders.":                                           "Ваши напоминания и так приходят вам.",
+		"You are registered.\n\nCommands:\n/reminder <name>_<description>_<DD.MM.YYYY>_<DD.MM.YYYY>_<HH:MM;HH:MM>_<IANA timezone> - create reminder\n/list - list latest reminders (up to 20)\n/delete <id> - delete reminder and occurrences\n/stats [id] [7d|4w|all] - adherence statistics\n/history <id> - past occurrences, mark missed ones\n/today - today's occurrences\n/upcoming [n|Nh] - next occurrences\n/timezone [IANA timezone] - show or set your time zone\n/language [en|ru|auto] - show or set your language\n/digest - configure morning, evening and weekly digests\n/webhook [<id>] <url> - post notifications to a URL, e.g. Home Assistant\n/email <address> - receive reminders by email too\n/channels [<id>] telegram+email|webhook|... - choose where reminders are sent\n/template <id> - customize the notifications of a reminder\n/priority <id> low|normal|high - set the priority of a reminder\n/share <id> [anyone|everyone] - post a reminder to a group or channel\n/assign <id> @username [minutes] - let someone else receive a reminder, alert you if they miss it\n/escalate <id> <minutes> @user|<chat id>|<url> - notify someone else or call a webhook if a reminder stays unanswered\n/export ics - download reminders as a calendar file\n/export json - download a full backup with settings and history\n/import - upload an .ics file or a .json backup\n\nExample:\n/reminder Pill_VitC_19.01.2026_20.01.2026_08:00;13:00;19:00_Europe/Warsaw": "Вы зарегистрированы.\n\nКоманды:\n/reminder <название>_<описание>_<ДД.ММ.ГГГГ>_<ДД.ММ.ГГГГ>_<ЧЧ:ММ;ЧЧ:ММ>_<часовой пояс IANA> - создать напоминание\n/list - последние напоминания (до 20)\n/delete <id> - удалить напоминание и его события\n/stats [id] [7d|4w|all] - статистика выполнения\n/history <id> - прошедшие события, отметить пропущенные\n/today - события на сегодня\n/upcoming [n|Nh] - ближайшие события\n/timezone [часовой пояс IANA] - показать или задать часовой пояс\n/language [en|ru|auto] - показать или выбрать язык\n/digest - настроить утренние, вечерние и недельные сводки\n/webhook [<id>] <url> - отправлять уведомления на URL, например в Home Assistant\n/email <адрес> - получать напоминания и по почте\n/channels [<id>] telegram+email|webhook|... - выбрать, куда отправлять напоминания\n/template <id> - настроить уведомления напоминания\n/priority <id> low|normal|high - задать приоритет напоминания\n/share <id> [anyone|everyone] - публиковать напоминание в группе или канале\n/assign <id> @username [минуты] - поручить напоминание другому человеку и узнать, если он его пропустит\n/escalate <id> <минуты> @user|<id чата>|<url> - сообщить другому человеку или вызвать вебхук, если напоминание остаётся без ответа\n/export ics - скачать напоминания файлом календаря\n/export json - скачать полную резервную копию с настройками и историей\n/import - загрузить файл .ics или резервную копию .json\n\nПример:\n/reminder Таблетка_ВитС_19.01.2026_20.01.2026_08:00;13:00;19:00_Europe/Moscow",
+		"You are sending commands too quickly. Please wait a minute.":                                   "Вы отправляете команды слишком часто. Подождите минуту.",
+		"You declined the escalation step of the reminder “%s”.":                                        "Вы отказались от шага эскалации напоминания «%s».",
+		"You declined the reminder “%s”.":                                                               "Вы отказались от напоминания «%s».",
+		"You now receive reminder #%d “%s”. Send /assign %d off to stop.":                               "Теперь вы получаете напоминание #%d «%s». Чтобы отказаться, отправьте /assign %d off.",
+		"You will be told when the reminder “%s” stays unanswered.":                                     "Вам сообщат, если на напоминание «%s» не ответят.",
+		"Your notifications are no longer posted to a webhook. Reminders with their own URL still are.": "Уведомления больше не отправляются на вебхук. Напоминания со своим URL по-прежнему отправляются.",
+		"Your notifications are not posted to a webhook.":                                               "Уведомления не отправляются на вебхук.",
+		"Your notifications are posted to %s.":                                                          "Уведомления отправляются на %s.",
+		"Your notifications will be posted to %s, signed with the secret %s.":                           "Уведомления будут отправляться на %s с подписью секретом %s.",
+		"Your reminders":                    "Ваши напоминания",
+		"Your reminders are sent over: %s.": "Ваши напоминания отправляются: %s.",
+		"Your time zone: %s (%s)\nUsage: /timezone <IANA zone>, e.g. /timezone Europe/Warsaw": "Ваш часовой пояс: %s (%s)\nИспользование: /timezone <пояс IANA>, например /timezone Europe/Moscow",
+		"Your verification code: %s":                     "Ваш код подтверждения: %s",
+		"anyone can confirm it":                          "подтвердить может любой",
+		"avg response %s":                                "среднее время ответа %s",
+		"chat %d":                                        "чат %d",
+		"custom template":                                "свой шаблон",
+		"derived from your reminders":                    "по вашим напоминаниям",
+		"done %d | ignored %d | missed %d | rate %.0f%%": "выполнено %d | пропущено %d | без ответа %d | доля %.0f%%",
+		"email":                      "почта",
+		"everyone has to confirm it": "подтвердить должен каждый",
+		"from your Telegram app":     "из настроек Telegram",
+		"no past occurrences":        "прошедших событий нет",
+		"off":                        "выкл.",
+		"set explicitly":             "задан вручную",
+		"streak %d (best %d)":        "серия %d (лучшая %d)",
+		"user %d":                    "пользователь %d",
+		"webhook":                    "вебхук",
+		"webhook %s":                 "вебхук %s",
+		"⏭ Skip #%d":                 "⏭ Пропустить #%d",
+		"⏳ scheduled":                "⏳ запланировано",
+		"◀ Prev":                     "◀ Назад",
+		"☀️ Today, %s:":              "☀️ Сегодня, %s:",
+		"⚠️ %s has not answered within %d min:":  "⚠️ %s не ответил(а) за %d мин:",
+		"⚠️ Nobody has confirmed within %d min:": "⚠️ Никто не подтвердил в течение %d мин:",
+		"⚠️ missed":                            "⚠️ без ответа",
+		"✅ %s %s":                              "✅ %s %s",
+		"✅ %s %s #%d, attempt %d: %d in %d ms": "✅ %s %s #%d, попытка %d: %d за %d мс",
+		"✅ Accept":                             "✅ Принять",
+		"✅ Done":                               "✅ Выполнено",
+		"✅ Done (%d/%d)":                       "✅ Выполнено (%d/%d)",
+		"✅ done":                               "✅ выполнено",
+		"❌ %s %s #%d, attempt %d: %s":          "❌ %s %s #%d, попытка %d: %s",
+		"❌ %s %s: %s":                          "❌ %s %s: %s",
+		"❌ Decline":                            "❌ Отказаться",
+		"❗ not delivered":                      "❗ не доставлено",
+		"🌙 Recap for %s: %d done, %d ignored, %d missed": "🌙 Итоги за %s: выполнено %d, пропущено %d, без ответа %d",
+		"👥 posted to a group":                            "👥 публикуется в группе",
+		"📅 Your week:":                                   "📅 Ваша неделя:",
+		"🚫 Ignore":                                       "🚫 Пропустить",
+		"🚫 ignored":                                      "🚫 пропущено",
+	},
+	plurals: map[string]Forms{
+		"%d minutes":                {One: "%d минуту", Few: "%d минуты", Many: "%d минут", Other: "%d минут"},
+		"%d occurrences":            {One: "%d событие", Few: "%d события", Many: "%d событий", Other: "%d событий"},
+		"%d reminders":              {One: "%d напоминание", Few: "%d напоминания", Many: "%d напоминаний", Other: "%d напоминаний"},
+		"Imported %d reminders.":    {One: "Импортировано %d напоминание.", Few: "Импортировано %d напоминания.", Many: "Импортировано %d напоминаний.", Other: "Импортировано %d напоминаний."},
+		"Next %d occurrences (%s):": {One: "Ближайшее событие (%[2]s):", Few: "Ближайшие %d события (%s):", Many: "Ближайшие %d событий (%s):", Other: "Ближайшие %d событий (%s):"},
+		"Skipped %d events:":        {One: "Пропущено %d событие:", Few: "Пропущено %d события:", Many: "Пропущено %d событий:", Other: "Пропущено %d событий:"},
+	},
+}
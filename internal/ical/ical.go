@@ -0,0 +1,351 @@
+// Package ical converts reminders to and from iCalendar (RFC 5545) documents.
+//
+// Each time of day of a reminder becomes its own VEVENT with a daily RRULE and
+// a VALARM at the start time. Events exported by the bot carry an
+// X-NAGGINGBOT-REMINDER property so they are merged back into one reminder on
+// import. Only daily recurrence is supported on import; other events are
+// reported as skipped.
+package ical
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"naggingbot/internal/domain"
+)
+
+const (
+	prodID = "-//naggingbot//reminders//EN"
+	// reminderProp groups events that belong to one reminder.
+	reminderProp = "X-NAGGINGBOT-REMINDER"
+
+	utcLayout   = "20060102T150405Z"
+	localLayout = "20060102T150405"
+	dateLayout  = "20060102"
+
+	// maxOpenEndedDays bounds recurring events without UNTIL or COUNT.
+	maxOpenEndedDays = 365
+)
+
+// Encode renders reminders as a VCALENDAR document. Reminders without times
+// of day have no recurring schedule and are left out.
+func Encode(reminders []*domain.Reminder, now time.Time) []byte {
+	var w writer
+	w.line("BEGIN:VCALENDAR")
+	w.line("VERSION:2.0")
+	w.line("PRODID:" + prodID)
+	w.line("CALSCALE:GREGORIAN")
+
+	stamp := now.UTC().Format(utcLayout)
+	for _, rem := range reminders {
+		loc := rem.Location()
+		startDay := rem.StartDate.In(loc)
+		for _, tod := range rem.TimesOfDay {
+			start := time.Date(startDay.Year(), startDay.Month(), startDay.Day(), tod.Hour, tod.Minute, 0, 0, loc)
+
+			w.line("BEGIN:VEVENT")
+			w.line(fmt.Sprintf("UID:reminder-%d-%02d%02d@naggingbot", rem.ID, tod.Hour, tod.Minute))
+			w.line("DTSTAMP:" + stamp)
+			if loc == time.UTC {
+				w.line("DTSTART:" + start.UTC().Format(utcLayout))
+			} else {
+				w.line("DTSTART;TZID=" + loc.String() + ":" + start.Format(localLayout))
+			}
+			w.line("RRULE:FREQ=DAILY;UNTIL=" + rem.EndDate.UTC().Format(utcLayout))
+			w.line("SUMMARY:" + escapeText(rem.Name))
+			if rem.Description != "" {
+				w.line("DESCRIPTION:" + escapeText(rem.Description))
+			}
+			w.line(fmt.Sprintf("%s:%d", reminderProp, rem.ID))
+			w.line("BEGIN:VALARM")
+			w.line("ACTION:DISPLAY")
+			w.line("DESCRIPTION:" + escapeText(rem.Name))
+			w.line("TRIGGER:PT0M")
+			w.line("END:VALARM")
+			w.line("END:VEVENT")
+		}
+	}
+
+	w.line("END:VCALENDAR")
+	return []byte(w.String())
+}
+
+// ImportResult holds reminders parsed from a document. Reminders have no ID or
+// UserID yet; Skipped explains every event that could not be mapped.
+type ImportResult struct {
+	Reminders []*domain.Reminder
+	Skipped   []string
+}
+
+// Decode parses a VCALENDAR document into reminders.
+func Decode(data []byte) (*ImportResult, error) {
+	events, err := parseEvents(string(data))
+	if err != nil {
+		return nil, err
+	}
+	if len(events) == 0 {
+		return nil, fmt.Errorf("no events found")
+	}
+
+	res := &ImportResult{}
+	groups := make(map[string]*domain.Reminder)
+	for _, ev := range events {
+		rem, err := ev.reminder()
+		if err != nil {
+			res.Skipped = append(res.Skipped, fmt.Sprintf("%q: %v", ev.get("SUMMARY"), err))
+			continue
+		}
+
+		key := ev.get(reminderProp)
+		if key == "" {
+			key = "uid:" + ev.get("UID")
+		}
+		if existing, ok := groups[key]; ok && key != "uid:" && sameSchedule(existing, rem) {
+			existing.TimesOfDay = append(existing.TimesOfDay, rem.TimesOfDay...)
+			continue
+		}
+		groups[key] = rem
+		res.Reminders = append(res.Reminders, rem)
+	}
+
+	return res, nil
+}
+
+func sameSchedule(a, b *domain.Reminder) bool {
+	return a.Name == b.Name && a.TimeZone == b.TimeZone && a.StartDate.Equal(b.StartDate) && a.EndDate.Equal(b.EndDate)
+}
+
+// property is a content line: NAME;PARAM=VALUE:VALUE.
+type property struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+type event struct {
+	props []property
+}
+
+func (e *event) prop(name string) (property, bool) {
+	for _, p := range e.props {
+		if p.name == name {
+			return p, true
+		}
+	}
+	return property{}, false
+}
+
+func (e *event) get(name string) string {
+	p, _ := e.prop(name)
+	return p.value
+}
+
+// reminder maps the event onto a domain reminder.
+func (e *event) reminder() (*domain.Reminder, error) {
+	summary := unescapeText(e.get("SUMMARY"))
+	if summary == "" {
+		summary = "Imported event"
+	}
+
+	dtstart, ok := e.prop("DTSTART")
+	if !ok {
+		return nil, fmt.Errorf("missing DTSTART")
+	}
+	if dtstart.params["VALUE"] == "DATE" || len(dtstart.value) == len(dateLayout) {
+		return nil, fmt.Errorf("all-day events are not supported")
+	}
+	start, loc, err := parseDateTime(dtstart)
+	if err != nil {
+		return nil, fmt.Errorf("DTSTART: %w", err)
+	}
+
+	firstDay := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, loc)
+	lastDay := firstDay
+	if rule, ok := e.prop("RRULE"); ok {
+		lastDay, err = ruleLastDay(rule.value, firstDay, loc)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &domain.Reminder{
+		Name:        summary,
+		Description: unescapeText(e.get("DESCRIPTION")),
+		StartDate:   firstDay.UTC(),
+		EndDate:     time.Date(lastDay.Year(), lastDay.Month(), lastDay.Day(), 23, 59, 59, 0, loc).UTC(),
+		TimesOfDay:  []domain.TimeOfDay{{Hour: start.Hour(), Minute: start.Minute()}},
+		TimeZone:    loc.String(),
+		IsActive:    true,
+	}, nil
+}
+
+// ruleLastDay returns the local day of the last occurrence of a daily RRULE.
+func ruleLastDay(rule string, firstDay time.Time, loc *time.Location) (time.Time, error) {
+	parts := make(map[string]string)
+	for _, kv := range strings.Split(rule, ";") {
+		k, v, _ := strings.Cut(kv, "=")
+		parts[strings.ToUpper(k)] = v
+	}
+
+	if parts["FREQ"] != "DAILY" {
+		return time.Time{}, fmt.Errorf("unsupported recurrence FREQ=%s (only DAILY)", parts["FREQ"])
+	}
+	if iv := parts["INTERVAL"]; iv != "" && iv != "1" {
+		return time.Time{}, fmt.Errorf("unsupported recurrence INTERVAL=%s", iv)
+	}
+	for _, k := range []string{"BYDAY", "BYMONTH", "BYMONTHDAY", "BYSETPOS"} {
+		if parts[k] != "" {
+			return time.Time{}, fmt.Errorf("unsupported recurrence %s", k)
+		}
+	}
+
+	switch {
+	case parts["UNTIL"] != "":
+		until, _, err := parseDateTime(property{value: parts["UNTIL"], params: map[string]string{}})
+		if err != nil {
+			return time.Time{}, fmt.Errorf("UNTIL: %w", err)
+		}
+		last := until.In(loc)
+		if last.Before(firstDay) {
+			return time.Time{}, fmt.Errorf("UNTIL before DTSTART")
+		}
+		return last, nil
+	case parts["COUNT"] != "":
+		var count int
+		if _, err := fmt.Sscanf(parts["COUNT"], "%d", &count); err != nil || count <= 0 {
+			return time.Time{}, fmt.Errorf("invalid COUNT %q", parts["COUNT"])
+		}
+		return firstDay.AddDate(0, 0, count-1), nil
+	default:
+		return firstDay.AddDate(0, 0, maxOpenEndedDays-1), nil
+	}
+}
+
+// parseDateTime parses DATE-TIME values in UTC ("Z"), with TZID, or floating
+// (treated as UTC). A plain DATE is accepted for UNTIL and means end of day.
+func parseDateTime(p property) (time.Time, *time.Location, error) {
+	v := p.value
+	if tzid := p.params["TZID"]; tzid != "" {
+		loc, err := time.LoadLocation(strings.Trim(tzid, `"`))
+		if err != nil {
+			return time.Time{}, nil, fmt.Errorf("unknown time zone %q", tzid)
+		}
+		t, err := time.ParseInLocation(localLayout, v, loc)
+		return t, loc, err
+	}
+	if strings.HasSuffix(v, "Z") {
+		t, err := time.Parse(utcLayout, v)
+		return t, time.UTC, err
+	}
+	if len(v) == len(dateLayout) {
+		t, err := time.Parse(dateLayout, v)
+		return t.Add(24*time.Hour - time.Second), time.UTC, err
+	}
+	t, err := time.ParseInLocation(localLayout, v, time.UTC)
+	return t, time.UTC, err
+}
+
+// parseEvents unfolds content lines and collects top-level VEVENT properties.
+// Nested components such as VALARM are skipped.
+func parseEvents(doc string) ([]*event, error) {
+	doc = strings.ReplaceAll(doc, "\r\n", "\n")
+	var lines []string
+	for _, raw := range strings.Split(doc, "\n") {
+		if (strings.HasPrefix(raw, " ") || strings.HasPrefix(raw, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += raw[1:]
+			continue
+		}
+		if strings.TrimSpace(raw) != "" {
+			lines = append(lines, raw)
+		}
+	}
+	if len(lines) == 0 || !strings.EqualFold(strings.TrimSpace(lines[0]), "BEGIN:VCALENDAR") {
+		return nil, fmt.Errorf("not an iCalendar document")
+	}
+
+	var events []*event
+	var cur *event
+	depth := 0
+	for _, line := range lines {
+		p, err := parseProperty(line)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case p.name == "BEGIN" && strings.EqualFold(p.value, "VEVENT") && cur == nil:
+			cur = &event{}
+		case p.name == "BEGIN" && cur != nil:
+			depth++
+		case p.name == "END" && cur != nil && depth > 0:
+			depth--
+		case p.name == "END" && strings.EqualFold(p.value, "VEVENT") && cur != nil:
+			events = append(events, cur)
+			cur = nil
+		case cur != nil && depth == 0:
+			cur.props = append(cur.props, p)
+		}
+	}
+	return events, nil
+}
+
+func parseProperty(line string) (property, error) {
+	// The value starts after the first colon that is not inside a quoted parameter.
+	inQuotes := false
+	split := -1
+	for i, r := range line {
+		if r == '"' {
+			inQuotes = !inQuotes
+		}
+		if r == ':' && !inQuotes {
+			split = i
+			break
+		}
+	}
+	if split < 0 {
+		return property{}, fmt.Errorf("invalid content line %q", line)
+	}
+
+	head := strings.Split(line[:split], ";")
+	p := property{
+		name:   strings.ToUpper(strings.TrimSpace(head[0])),
+		params: make(map[string]string),
+		value:  line[split+1:],
+	}
+	for _, param := range head[1:] {
+		k, v, _ := strings.Cut(param, "=")
+		p.params[strings.ToUpper(k)] = v
+	}
+	return p, nil
+}
+
+func escapeText(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`)
+	return r.Replace(s)
+}
+
+func unescapeText(s string) string {
+	r := strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n", `\N`, "\n")
+	return r.Replace(s)
+}
+
+// writer emits CRLF-terminated content lines folded at 75 octets.
+type writer struct {
+	strings.Builder
+}
+
+func (w *writer) line(s string) {
+	// Continuation lines start with a space, which counts towards the limit.
+	limit := 75
+	for len(s) > limit {
+		cut := limit
+		// Do not split a UTF-8 sequence.
+		for cut > 0 && s[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		w.WriteString(s[:cut] + "\r\n ")
+		s = s[cut:]
+		limit = 74
+	}
+	w.WriteString(s + "\r\n")
+}
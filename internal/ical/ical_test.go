@@ -0,0 +1,111 @@
+package ical
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"naggingbot/internal/domain"
+)
+
+func TestRoundTrip(t *testing.T) {
+	loc, _ := time.LoadLocation("Europe/Warsaw")
+	rem := &domain.Reminder{
+		ID:          12,
+		Name:        "Pill; VitC, daily",
+		Description: strings.Repeat("long description ", 10),
+		StartDate:   time.Date(2026, 1, 19, 0, 0, 0, 0, loc).UTC(),
+		EndDate:     time.Date(2026, 1, 25, 23, 59, 59, 0, loc).UTC(),
+		TimesOfDay:  []domain.TimeOfDay{{Hour: 8, Minute: 0}, {Hour: 19, Minute: 30}},
+		TimeZone:    "Europe/Warsaw",
+		IsActive:    true,
+	}
+
+	doc := Encode([]*domain.Reminder{rem}, time.Date(2026, 1, 18, 0, 0, 0, 0, time.UTC))
+	for _, line := range strings.Split(string(doc), "\r\n") {
+		if len(line) > 75 {
+			t.Fatalf("line longer than 75 octets: %q", line)
+		}
+	}
+	if !strings.Contains(string(doc), "DTSTART;TZID=Europe/Warsaw:20260119T193000") {
+		t.Fatalf("missing local DTSTART:\n%s", doc)
+	}
+
+	res, err := Decode(doc)
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(res.Skipped) != 0 || len(res.Reminders) != 1 {
+		t.Fatalf("decode result = %+v", res)
+	}
+	got := res.Reminders[0]
+	if got.Name != rem.Name || got.Description != rem.Description || got.TimeZone != rem.TimeZone ||
+		!got.StartDate.Equal(rem.StartDate) || !got.EndDate.Equal(rem.EndDate) {
+		t.Fatalf("decoded reminder = %+v\nwant %+v", got, rem)
+	}
+	if len(got.TimesOfDay) != 2 || got.TimesOfDay[0] != rem.TimesOfDay[0] || got.TimesOfDay[1] != rem.TimesOfDay[1] {
+		t.Fatalf("decoded times = %v", got.TimesOfDay)
+	}
+}
+
+func TestDecodeForeignCalendar(t *testing.T) {
+	doc := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"BEGIN:VEVENT",
+		"UID:a@example.com",
+		"DTSTART:20260301T070000Z",
+		"RRULE:FREQ=DAILY;COUNT=3",
+		"SUMMARY:Stretch",
+		"BEGIN:VALARM",
+		"TRIGGER:-PT5M",
+		"DESCRIPTION:alarm text",
+		"END:VALARM",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:b@example.com",
+		"DTSTART;TZID=America/New_York:20260302T090000",
+		"SUMMARY:Dentist",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:c@example.com",
+		"DTSTART;VALUE=DATE:20260303",
+		"SUMMARY:Holiday",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:d@example.com",
+		"DTSTART:20260301T070000Z",
+		"RRULE:FREQ=WEEKLY;BYDAY=MO",
+		"SUMMARY:Standup",
+		"END:VEVENT",
+		"END:VCALENDAR",
+	}, "\r\n")
+
+	res, err := Decode([]byte(doc))
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(res.Reminders) != 2 || len(res.Skipped) != 2 {
+		t.Fatalf("decode result = %+v", res)
+	}
+
+	stretch := res.Reminders[0]
+	if stretch.Name != "Stretch" || stretch.TimeZone != "UTC" ||
+		!stretch.EndDate.Equal(time.Date(2026, 3, 3, 23, 59, 59, 0, time.UTC)) ||
+		stretch.TimesOfDay[0] != (domain.TimeOfDay{Hour: 7}) {
+		t.Fatalf("stretch = %+v", stretch)
+	}
+
+	dentist := res.Reminders[1]
+	ny, _ := time.LoadLocation("America/New_York")
+	if dentist.TimeZone != "America/New_York" || !dentist.StartDate.Equal(time.Date(2026, 3, 2, 0, 0, 0, 0, ny)) ||
+		!dentist.EndDate.Equal(time.Date(2026, 3, 2, 23, 59, 59, 0, ny)) || dentist.TimesOfDay[0] != (domain.TimeOfDay{Hour: 9}) {
+		t.Fatalf("dentist = %+v", dentist)
+	}
+}
+
+func TestDecodeRejectsGarbage(t *testing.T) {
+	if _, err := Decode([]byte("hello")); err == nil {
+		t.Fatal("expected error for non-calendar input")
+	}
+}
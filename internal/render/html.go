@@ -0,0 +1,85 @@
+package render
+
+import (
+	"fmt"
+	"strings"
+)
+
+// allowedTags are the tags Telegram's HTML parse mode supports.
+var allowedTags = map[string]bool{
+	"b": true, "strong": true,
+	"i": true, "em": true,
+	"u": true, "ins": true,
+	"s": true, "strike": true, "del": true,
+	"span": true, "tg-spoiler": true,
+	"a": true, "code": true, "pre": true,
+	"blockquote": true,
+}
+
+// namedEntities are the only named entities Telegram understands.
+var namedEntities = map[string]bool{"lt": true, "gt": true, "amp": true, "quot": true}
+
+// CheckHTML reports whether Telegram accepts s as an HTML message: only
+// supported tags, properly nested, and '<', '>' and '&' escaped elsewhere.
+func CheckHTML(s string) error {
+	var open []string
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '<':
+			end := strings.IndexByte(s[i:], '>')
+			if end < 0 {
+				return fmt.Errorf("unclosed tag at offset %d", i)
+			}
+			tag := s[i+1 : i+end]
+			i += end
+
+			closing := strings.HasPrefix(tag, "/")
+			name := strings.ToLower(strings.TrimPrefix(tag, "/"))
+			if sp := strings.IndexAny(name, " \t\r\n"); sp >= 0 {
+				if closing {
+					return fmt.Errorf("malformed closing tag <%s>", tag)
+				}
+				name = name[:sp]
+			}
+			if !allowedTags[name] {
+				return fmt.Errorf("unsupported tag <%s>", name)
+			}
+			if !closing {
+				open = append(open, name)
+				continue
+			}
+			if len(open) == 0 || open[len(open)-1] != name {
+				return fmt.Errorf("unexpected closing tag </%s>", name)
+			}
+			open = open[:len(open)-1]
+		case '>':
+			return fmt.Errorf("unescaped '>' at offset %d, use &gt;", i)
+		case '&':
+			end := strings.IndexByte(s[i:], ';')
+			if end < 0 || !validEntity(s[i+1:i+end]) {
+				return fmt.Errorf("unescaped '&' at offset %d, use &amp;", i)
+			}
+			i += end
+		}
+	}
+	if len(open) > 0 {
+		return fmt.Errorf("unclosed tag <%s>", open[len(open)-1])
+	}
+	return nil
+}
+
+// validEntity checks the name of an entity between '&' and ';'.
+func validEntity(name string) bool {
+	if namedEntities[name] {
+		return true
+	}
+	digits, ok := strings.CutPrefix(name, "#")
+	if !ok || digits == "" {
+		return false
+	}
+	set := "0123456789"
+	if hex, ok := strings.CutPrefix(digits, "x"); ok {
+		digits, set = hex, "0123456789abcdefABCDEF"
+	}
+	return digits != "" && strings.Trim(digits, set) == ""
+}
@@ -0,0 +1,390 @@
+// Package render formats bot messages as Telegram HTML (parse_mode "HTML")
+// with text/template.
+//
+// Templates never see raw user input: every string handed to them is already
+// HTML-escaped, so markup only comes from the template itself. Times are shown
+// in the reminder's time zone and, like all fixed text, in the language of the
+// i18n.Printer passed in. Reminders may carry a custom notification
+// template; it is checked with ValidateTemplate when it is set and the default
+// is used if it fails at send time anyway. Custom templates may only use
+// fields, variables, if and with, so they cannot loop, and their output is cut
+// off at MaxMessageLen.
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+	"text/template"
+	tparse "text/template/parse"
+	"time"
+	"unicode/utf8"
+
+	"naggingbot/internal/domain"
+	"naggingbot/internal/i18n"
+)
+
+const (
+	// MaxTemplateLen bounds custom templates, in bytes.
+	MaxTemplateLen = 1024
+	// MaxMessageLen is Telegram's limit for a message, in characters.
+	MaxMessageLen = 4096
+)
+
+// DefaultNotification is the template for reminders without a custom one.
+const DefaultNotification = `{{.Header}} <b>{{.Name}}</b>
+{{- if .Description}}
+{{.Description}}
+{{- end}}
+🕒 {{.Time}}, {{.Date}} ({{.Zone}})`
+
+const statusText = `{{.Notification}}
+
+{{.Status}}`
+
+const listText = `{{.Title}}
+{{- range .Items}}
+
+{{.Header}} <b>#{{.ID}} {{.Name}}</b>{{if not .Active}} {{$.Paused}}{{end}}
+{{- if .Description}}
+{{.Description}}
+{{- end}}
+📅 {{.Start}} – {{.End}}
+🕒 {{.Times}} ({{.Zone}}){{if .Custom}} · {{$.Custom}}{{end}}{{if .Shared}} · {{$.Shared}}{{end}}
+{{- end}}`
+
+var (
+	defaultNotification = template.Must(template.New("notification").Parse(DefaultNotification))
+	statusTemplate      = template.Must(template.New("status").Parse(statusText))
+	listTemplate        = template.Must(template.New("list").Parse(listText))
+)
+
+// Notification is the data available to notification templates. Strings are
+// HTML-escaped already and must not be escaped again.
+type Notification struct {
+	ReminderID   int64
+	OccurrenceID int64
+	Name         string
+	Description  string
+	// Priority is "low", "normal" or "high"; Header is its emoji.
+	Priority string
+	Header   string
+	// At is the fire time in the reminder's zone; Time, Date and Zone are
+	// preformatted parts of it.
+	At   time.Time
+	Time string
+	Date string
+	Zone string
+}
+
+// NewNotification collects the template data for an occurrence.
+func NewNotification(p *i18n.Printer, rem *domain.Reminder, occ *domain.Occurrence) Notification {
+	at := occ.FireAtUtc.In(rem.Location())
+	return Notification{
+		ReminderID:   rem.ID,
+		OccurrenceID: occ.ID,
+		Name:         html.EscapeString(rem.Name),
+		Description:  html.EscapeString(rem.Description),
+		Priority:     rem.Priority.String(),
+		Header:       PriorityHeader(rem.Priority),
+		At:           at,
+		Time:         p.Time(at),
+		Date:         p.Date(at),
+		Zone:         html.EscapeString(at.Location().String()),
+	}
+}
+
+// PriorityHeader returns the emoji that leads messages of the given priority.
+func PriorityHeader(p domain.Priority) string {
+	switch p {
+	case domain.PriorityHigh:
+		return "🚨"
+	case domain.PriorityLow:
+		return "🔹"
+	default:
+		return "🔔"
+	}
+}
+
+// RenderNotification renders the occurrence with the reminder's template, or
+// the default one. Unlike NotificationHTML it reports a failing custom
+// template instead of falling back.
+func RenderNotification(p *i18n.Printer, rem *domain.Reminder, occ *domain.Occurrence) (string, error) {
+	if rem.Template == "" {
+		return execute(defaultNotification, NewNotification(p, rem, occ), 0)
+	}
+	tmpl, err := parse(rem.Template)
+	if err != nil {
+		return "", err
+	}
+	text, err := execute(tmpl, NewNotification(p, rem, occ), MaxMessageLen)
+	if err != nil {
+		return "", err
+	}
+	if text == "" {
+		return "", fmt.Errorf("template renders an empty message")
+	}
+	if n := len([]rune(text)); n > MaxMessageLen {
+		return "", fmt.Errorf("message is too long (%d characters, at most %d)", n, MaxMessageLen)
+	}
+	return text, nil
+}
+
+// NotificationHTML renders the message for an occurrence. A custom template
+// that fails is replaced by the default, so the reminder is still delivered.
+func NotificationHTML(p *i18n.Printer, rem *domain.Reminder, occ *domain.Occurrence) string {
+	text, err := RenderNotification(p, rem, occ)
+	if err == nil {
+		return text
+	}
+	text, err = execute(defaultNotification, NewNotification(p, rem, occ), 0)
+	if err != nil {
+		// The default template only fails on a programming error.
+		panic(fmt.Sprintf("render: default notification template: %v", err))
+	}
+	return text
+}
+
+// StatusHTML renders the notification of an answered occurrence with its
+// status appended. Unanswered occurrences render as the plain notification.
+func StatusHTML(p *i18n.Printer, rem *domain.Reminder, occ *domain.Occurrence) string {
+	text := NotificationHTML(p, rem, occ)
+	var status string
+	switch occ.Status {
+	case domain.OccurrenceDone:
+		status = p.T("Status: ✅ Done")
+	case domain.OccurrenceIgnored:
+		status = p.T("Status: 🚫 Ignored")
+	default:
+		return text
+	}
+	out, err := execute(statusTemplate, struct {
+		Notification string
+		Status       string
+	}{text, html.EscapeString(status)}, 0)
+	if err != nil {
+		return text
+	}
+	return out
+}
+
+// SharedStatusHTML renders an occurrence posted to a group or channel: its
+// status as StatusHTML does, followed by who confirmed it. Until it is done
+// the line also counts the confirmations against required.
+func SharedStatusHTML(p *i18n.Printer, rem *domain.Reminder, occ *domain.Occurrence, confirmed []string, required int) string {
+	text := StatusHTML(p, rem, occ)
+	if len(confirmed) == 0 {
+		return text
+	}
+	names := strings.Join(confirmed, ", ")
+	if occ.Status == domain.OccurrenceDone {
+		return text + "\n" + html.EscapeString(p.T("Done by %s", names))
+	}
+	sep := "\n"
+	if occ.Status != domain.OccurrenceIgnored {
+		sep = "\n\n"
+	}
+	return text + sep + html.EscapeString(p.T("Confirmed by %s (%d/%d)", names, len(confirmed), required))
+}
+
+// ValidateTemplate checks a custom notification template: it must parse,
+// render a sample occurrence and produce HTML that Telegram accepts. The
+// sample is rendered in English; translations only change preformatted
+// fields, which are escaped.
+func ValidateTemplate(text string) error {
+	if len(text) > MaxTemplateLen {
+		return fmt.Errorf("template is too long (%d bytes, at most %d)", len(text), MaxTemplateLen)
+	}
+	if strings.TrimSpace(text) == "" {
+		return fmt.Errorf("template is empty")
+	}
+	rem := &domain.Reminder{
+		ID:          1,
+		Name:        "Pill <VitC> & water",
+		Description: "Take it with \"food\"",
+		TimeZone:    "Europe/Warsaw",
+		Template:    text,
+	}
+	occ := &domain.Occurrence{ID: 1, ReminderID: 1, FireAtUtc: time.Date(2026, 1, 19, 7, 0, 0, 0, time.UTC)}
+	_, err := RenderNotification(i18n.For(i18n.Default), rem, occ)
+	return err
+}
+
+// reminderItem is one entry of the reminder list.
+type reminderItem struct {
+	ID          int64
+	Name        string
+	Description string
+	Header      string
+	Start, End  string
+	Times       string
+	Zone        string
+	Active      bool
+	Custom      bool
+	Shared      bool
+}
+
+// ReminderListHTML renders reminders for /list, in the given order. Dates are
+// shown in each reminder's zone. The caller limits the number of reminders.
+func ReminderListHTML(p *i18n.Printer, rems []*domain.Reminder) string {
+	items := make([]reminderItem, 0, len(rems))
+	for _, r := range rems {
+		loc := r.Location()
+		items = append(items, reminderItem{
+			ID:          r.ID,
+			Name:        html.EscapeString(r.Name),
+			Description: html.EscapeString(r.Description),
+			Header:      PriorityHeader(r.Priority),
+			Start:       r.StartDate.In(loc).Format("02.01.2006"),
+			End:         r.EndDate.In(loc).Format("02.01.2006"),
+			Times:       formatTimes(r.TimesOfDay),
+			Zone:        html.EscapeString(r.TimeZone),
+			Active:      r.IsActive,
+			Custom:      r.Template != "",
+			Shared:      r.Shared(),
+		})
+	}
+	text, err := execute(listTemplate, struct {
+		Title, Paused, Custom, Shared string
+		Items                         []reminderItem
+	}{
+		Title:  "<b>" + html.EscapeString(p.T("Your reminders")) + "</b> " + html.EscapeString(p.T("(latest up to 20):")),
+		Paused: html.EscapeString(p.T("(paused)")),
+		Custom: html.EscapeString(p.T("custom template")),
+		Shared: html.EscapeString(p.T("👥 posted to a group")),
+		Items:  items,
+	}, 0)
+	if err != nil {
+		panic(fmt.Sprintf("render: list template: %v", err))
+	}
+	return text
+}
+
+func formatTimes(t []domain.TimeOfDay) string {
+	if len(t) == 0 {
+		return "n/a"
+	}
+	parts := make([]string, 0, len(t))
+	for _, v := range t {
+		parts = append(parts, fmt.Sprintf("%02d:%02d", v.Hour, v.Minute))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func parse(text string) (*template.Template, error) {
+	tmpl, err := template.New("custom").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template: %w", err)
+	}
+	if err := checkNode(tmpl.Tree.Root); err != nil {
+		return nil, fmt.Errorf("invalid template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// checkNode rejects the actions a custom template could loop or call code
+// with: range, calls of other templates and functions. Fields, their
+// methods, variables, if and with are allowed.
+func checkNode(node tparse.Node) error {
+	switch n := node.(type) {
+	case *tparse.ListNode:
+		if n == nil {
+			return nil
+		}
+		for _, child := range n.Nodes {
+			if err := checkNode(child); err != nil {
+				return err
+			}
+		}
+	case *tparse.TextNode, *tparse.CommentNode:
+	case *tparse.ActionNode:
+		return checkPipe(n.Pipe)
+	case *tparse.IfNode:
+		return checkBranch(&n.BranchNode)
+	case *tparse.WithNode:
+		return checkBranch(&n.BranchNode)
+	case *tparse.RangeNode:
+		return fmt.Errorf("range is not allowed")
+	case *tparse.TemplateNode:
+		return fmt.Errorf("calling templates is not allowed")
+	default:
+		return fmt.Errorf("%s is not allowed", node)
+	}
+	return nil
+}
+
+func checkBranch(n *tparse.BranchNode) error {
+	if err := checkPipe(n.Pipe); err != nil {
+		return err
+	}
+	if err := checkNode(n.List); err != nil {
+		return err
+	}
+	return checkNode(n.ElseList)
+}
+
+func checkPipe(pipe *tparse.PipeNode) error {
+	if pipe == nil {
+		return nil
+	}
+	for _, cmd := range pipe.Cmds {
+		for _, arg := range cmd.Args {
+			if err := checkArg(arg); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func checkArg(arg tparse.Node) error {
+	switch n := arg.(type) {
+	case *tparse.FieldNode, *tparse.VariableNode, *tparse.DotNode,
+		*tparse.StringNode, *tparse.NumberNode, *tparse.BoolNode, *tparse.NilNode:
+		return nil
+	case *tparse.ChainNode:
+		return checkArg(n.Node)
+	case *tparse.PipeNode:
+		return checkPipe(n)
+	case *tparse.IdentifierNode:
+		return fmt.Errorf("function %s is not allowed", n.Ident)
+	default:
+		return fmt.Errorf("%s is not allowed", arg)
+	}
+}
+
+// limitWriter fails once more than max characters are written to it, so a
+// template cannot build an unbounded message.
+type limitWriter struct {
+	w   io.Writer
+	max int
+	n   int
+}
+
+func (w *limitWriter) Write(p []byte) (int, error) {
+	w.n += utf8.RuneCount(p)
+	if w.n > w.max {
+		return 0, fmt.Errorf("message is too long (more than %d characters)", w.max)
+	}
+	return w.w.Write(p)
+}
+
+// execute renders tmpl and checks that the result is HTML Telegram accepts.
+// A positive limit aborts rendering once the output has more characters.
+func execute(tmpl *template.Template, data any, limit int) (string, error) {
+	var b bytes.Buffer
+	var w io.Writer = &b
+	if limit > 0 {
+		w = &limitWriter{w: &b, max: limit}
+	}
+	if err := tmpl.Execute(w, data); err != nil {
+		return "", fmt.Errorf("render template: %w", err)
+	}
+	text := strings.TrimSpace(b.String())
+	if err := CheckHTML(text); err != nil {
+		return "", err
+	}
+	return text, nil
+}
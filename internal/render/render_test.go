@@ -0,0 +1,200 @@
+package render
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"naggingbot/internal/domain"
+	"naggingbot/internal/i18n"
+)
+
+var english = i18n.For(i18n.English)
+
+func testReminder() (*domain.Reminder, *domain.Occurrence) {
+	rem := &domain.Reminder{
+		ID:          7,
+		Name:        "Pill <VitC> & water",
+		Description: "After breakfast",
+		TimeZone:    "Europe/Warsaw",
+		StartDate:   time.Date(2026, 1, 18, 23, 0, 0, 0, time.UTC),
+		EndDate:     time.Date(2026, 1, 25, 22, 59, 59, 0, time.UTC),
+		TimesOfDay:  []domain.TimeOfDay{{Hour: 8, Minute: 0}, {Hour: 19, Minute: 30}},
+		IsActive:    true,
+	}
+	// 07:00 UTC is 08:00 in Warsaw.
+	occ := &domain.Occurrence{ID: 123, ReminderID: 7, FireAtUtc: time.Date(2026, 1, 19, 7, 0, 0, 0, time.UTC)}
+	return rem, occ
+}
+
+func TestNotificationHTML(t *testing.T) {
+	rem, occ := testReminder()
+
+	want := "🔔 <b>Pill &lt;VitC&gt; &amp; water</b>\nAfter breakfast\n🕒 08:00, Mon, 19 Jan 2026 (Europe/Warsaw)"
+	if got := NotificationHTML(english, rem, occ); got != want {
+		t.Fatalf("default:\n got %q\nwant %q", got, want)
+	}
+
+	rem.Description = ""
+	rem.Priority = domain.PriorityHigh
+	want = "🚨 <b>Pill &lt;VitC&gt; &amp; water</b>\n🕒 08:00, Mon, 19 Jan 2026 (Europe/Warsaw)"
+	if got := NotificationHTML(english, rem, occ); got != want {
+		t.Fatalf("high priority:\n got %q\nwant %q", got, want)
+	}
+
+	rem.Template = `<i>{{.Priority}}</i> {{.Name}} #{{.OccurrenceID}} {{.At.Format "Jan 2 15:04"}}`
+	want = "<i>high</i> Pill &lt;VitC&gt; &amp; water #123 Jan 19 08:00"
+	if got := NotificationHTML(english, rem, occ); got != want {
+		t.Fatalf("custom:\n got %q\nwant %q", got, want)
+	}
+
+	// A broken custom template falls back to the default.
+	rem.Template = "<b>{{.Name}}"
+	if _, err := RenderNotification(english, rem, occ); err == nil {
+		t.Fatalf("RenderNotification accepted an unclosed tag")
+	}
+	if got := NotificationHTML(english, rem, occ); !strings.HasPrefix(got, "🚨 <b>Pill") {
+		t.Fatalf("fallback = %q", got)
+	}
+}
+
+func TestStatusHTML(t *testing.T) {
+	rem, occ := testReminder()
+	occ.Status = domain.OccurrenceSent
+	if got, want := StatusHTML(english, rem, occ), NotificationHTML(english, rem, occ); got != want {
+		t.Fatalf("unanswered:\n got %q\nwant %q", got, want)
+	}
+	occ.Status = domain.OccurrenceDone
+	if got := StatusHTML(english, rem, occ); !strings.HasSuffix(got, "\n\nStatus: ✅ Done") {
+		t.Fatalf("done = %q", got)
+	}
+	occ.Status = domain.OccurrenceIgnored
+	if got := StatusHTML(english, rem, occ); !strings.HasSuffix(got, "\n\nStatus: 🚫 Ignored") {
+		t.Fatalf("ignored = %q", got)
+	}
+}
+
+func TestSharedStatusHTML(t *testing.T) {
+	rem, occ := testReminder()
+	occ.Status = domain.OccurrenceSent
+	if got, want := SharedStatusHTML(english, rem, occ, nil, 3), NotificationHTML(english, rem, occ); got != want {
+		t.Fatalf("unconfirmed:\n got %q\nwant %q", got, want)
+	}
+	if got := SharedStatusHTML(english, rem, occ, []string{"@alice", "Bob <3"}, 3); !strings.HasSuffix(got, "\n\nConfirmed by @alice, Bob &lt;3 (2/3)") {
+		t.Fatalf("pending = %q", got)
+	}
+	occ.Status = domain.OccurrenceDone
+	if got := SharedStatusHTML(english, rem, occ, []string{"@alice"}, 1); !strings.HasSuffix(got, "\n\nStatus: ✅ Done\nDone by @alice") {
+		t.Fatalf("done = %q", got)
+	}
+}
+
+func TestRussian(t *testing.T) {
+	russian := i18n.For(i18n.Russian)
+	rem, occ := testReminder()
+	occ.Status = domain.OccurrenceDone
+
+	want := "🔔 <b>Pill &lt;VitC&gt; &amp; water</b>\nAfter breakfast\n🕒 08:00, пн, 19 янв 2026 (Europe/Warsaw)\n\nСтатус: ✅ Выполнено"
+	if got := StatusHTML(russian, rem, occ); got != want {
+		t.Fatalf("status:\n got %q\nwant %q", got, want)
+	}
+	rem.IsActive = false
+	if got := ReminderListHTML(russian, []*domain.Reminder{rem}); !strings.HasPrefix(got, "<b>Ваши напоминания</b> (последние, до 20):\n\n🔔 <b>#7 Pill &lt;VitC&gt; &amp; water</b> (на паузе)") {
+		t.Fatalf("list = %q", got)
+	}
+}
+
+func TestReminderListHTML(t *testing.T) {
+	rem, _ := testReminder()
+	paused := &domain.Reminder{ID: 3, Name: "Walk", TimeZone: "UTC", Priority: domain.PriorityLow, Template: "{{.Name}}",
+		StartDate: time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC), EndDate: time.Date(2026, 2, 2, 0, 0, 0, 0, time.UTC)}
+
+	want := "<b>Your reminders</b> (latest up to 20):\n\n" +
+		"🔔 <b>#7 Pill &lt;VitC&gt; &amp; water</b>\nAfter breakfast\n📅 19.01.2026 – 25.01.2026\n🕒 08:00, 19:30 (Europe/Warsaw)\n\n" +
+		"🔹 <b>#3 Walk</b> (paused)\n📅 01.02.2026 – 02.02.2026\n🕒 n/a (UTC) · custom template"
+	if got := ReminderListHTML(english, []*domain.Reminder{rem, paused}); got != want {
+		t.Fatalf("list:\n got %q\nwant %q", got, want)
+	}
+}
+
+func TestValidateTemplate(t *testing.T) {
+	cases := map[string]struct {
+		tmpl string
+		want string // error substring, "" if valid
+	}{
+		"plain":     {"{{.Header}} {{.Name}}", ""},
+		"markup":    {`<b>{{.Name}}</b> <a href="https://example.com">link</a> &amp; {{.Time}}`, ""},
+		"empty":     {"  \n ", "template is empty"},
+		"syntax":    {"{{.Name", "invalid template"},
+		"field":     {"{{.Nope}}", "render template"},
+		"tag":       {"<div>{{.Name}}</div>", "unsupported tag <div>"},
+		"nesting":   {"<i><b>{{.Name}}</i></b>", "unexpected closing tag </i>"},
+		"unclosed":  {"<i>{{.Name}}", "unclosed tag <i>"},
+		"ampersand": {"{{.Name}} & co", "unescaped '&'"},
+		"too long":  {strings.Repeat("x", MaxTemplateLen+1), "template is too long"},
+		"if/with":   {`{{if .Description}}{{.Description}}{{else}}-{{end}} {{with $n := .Name}}{{$n}}{{end}}`, ""},
+		"method":    {`{{.At.Format "15:04"}}`, ""},
+		"range":     {"{{range 9223372036854775807}}{{end}}x", "range is not allowed"},
+		"function":  {`{{printf "%0999999999d" 1}}`, "function printf is not allowed"},
+		"pipeline":  {"{{.Name | len}}", "function len is not allowed"},
+		"nested":    {"{{if .Name}}{{range .Name}}{{end}}{{end}}", "range is not allowed"},
+		"call":      {`{{define "t"}}x{{end}}{{template "t"}}`, "calling templates is not allowed"},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			err := ValidateTemplate(tc.tmpl)
+			if tc.want == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Fatalf("error = %v, want %q", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestCheckHTML(t *testing.T) {
+	valid := []string{
+		"plain text",
+		"<b>bold <i>both</i></b>",
+		`<a href="https://example.com/?a=1&amp;b=2">x</a>`,
+		"&lt;&gt;&amp;&quot;&#38;&#x26;",
+		`<span class="tg-spoiler">x</span>`,
+	}
+	for _, s := range valid {
+		if err := CheckHTML(s); err != nil {
+			t.Errorf("CheckHTML(%q) = %v", s, err)
+		}
+	}
+	invalid := []string{"a < b", "a > b", "Tom & Jerry", "&nbsp;", "&#;", "<b>x", "</b>", "<script>x</script>", "<b>x</i>"}
+	for _, s := range invalid {
+		if err := CheckHTML(s); err == nil {
+			t.Errorf("CheckHTML(%q) accepted invalid HTML", s)
+		}
+	}
+}
+
+func TestCustomTemplateOutputIsBounded(t *testing.T) {
+	rem, occ := testReminder()
+	rem.Template = "{{.Description}}"
+	rem.Description = strings.Repeat("й", MaxMessageLen)
+	if _, err := RenderNotification(english, rem, occ); err != nil {
+		t.Fatalf("a message of exactly %d characters failed: %v", MaxMessageLen, err)
+	}
+	rem.Description += "й"
+	if _, err := RenderNotification(english, rem, occ); err == nil || !strings.Contains(err.Error(), "message is too long") {
+		t.Fatalf("error = %v, want the message to be too long", err)
+	}
+
+	// The writer stops the template as soon as the limit is passed.
+	w := &limitWriter{w: &strings.Builder{}, max: 3}
+	if _, err := w.Write([]byte("ab")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte("cd")); err == nil {
+		t.Fatal("the writer accepted more than its limit")
+	}
+}
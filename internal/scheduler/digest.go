@@ -0,0 +1,230 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"naggingbot/internal/domain"
+	"naggingbot/internal/i18n"
+	"naggingbot/internal/stats"
+)
+
+// digestGrace bounds how late a digest is still sent, e.g. after a restart;
+// a morning digest is useless in the evening.
+const digestGrace = 2 * time.Hour
+
+// DigestSender delivers digest text to a user.
+type DigestSender interface {
+	SendDigest(ctx context.Context, user *domain.User, text string) error
+}
+
+// Digester composes and sends the opt-in morning, evening and weekly digests.
+type Digester struct {
+	users       domain.UserStore
+	reminders   domain.ReminderStore
+	occurrences domain.OccurrenceStore
+	digests     domain.DigestStore
+	sender      DigestSender
+}
+
+// NewDigester constructs a digester.
+func NewDigester(users domain.UserStore, reminders domain.ReminderStore, occurrences domain.OccurrenceStore, digests domain.DigestStore, sender DigestSender) *Digester {
+	return &Digester{
+		users:       users,
+		reminders:   reminders,
+		occurrences: occurrences,
+		digests:     digests,
+		sender:      sender,
+	}
+}
+
+// SendDue sends every enabled digest whose local send time has passed and
+// that has not been sent for that slot yet.
+func (d *Digester) SendDue(ctx context.Context, now time.Time) error {
+	settings, err := d.digests.ListEnabled(ctx)
+	if err != nil {
+		return err
+	}
+	for _, st := range settings {
+		if err := d.safeSendForUser(ctx, st, now); err != nil {
+			log.Printf("digest for user %d failed: %v", st.UserID, err)
+		}
+	}
+	return nil
+}
+
+func (d *Digester) safeSendForUser(ctx context.Context, st *domain.DigestSettings, now time.Time) (err error) {
+	defer recoverTo(&err, "sending digest to user %d", st.UserID)
+	return d.sendForUser(ctx, st, now)
+}
+
+func (d *Digester) sendForUser(ctx context.Context, st *domain.DigestSettings, now time.Time) error {
+	user, err := d.users.GetByID(ctx, st.UserID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return nil
+	}
+	rems, err := d.reminders.ListByUser(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	local := now.In(user.Location(rems))
+	p := i18n.For(i18n.Match(user.Locale, user.Language))
+
+	if st.Morning != nil && digestDue(local, *st.Morning, st.LastMorningUtc) {
+		text, err := d.morningText(ctx, p, rems, local)
+		if err != nil {
+			return err
+		}
+		if err := d.send(ctx, user, domain.DigestMorning, text, now); err != nil {
+			return err
+		}
+	}
+	if st.Evening != nil && digestDue(local, *st.Evening, st.LastEveningUtc) {
+		text, err := d.eveningText(ctx, p, rems, local)
+		if err != nil {
+			return err
+		}
+		if err := d.send(ctx, user, domain.DigestEvening, text, now); err != nil {
+			return err
+		}
+	}
+	if st.Weekly && local.Weekday() == time.Sunday && digestDue(local, st.WeeklyTime(), st.LastWeeklyUtc) {
+		text, err := d.weeklyText(ctx, p, rems, local)
+		if err != nil {
+			return err
+		}
+		if err := d.send(ctx, user, domain.DigestWeekly, text, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// send delivers non-empty text and records the slot as handled either way,
+// so an empty day is not re-evaluated on every tick.
+func (d *Digester) send(ctx context.Context, user *domain.User, kind domain.DigestKind, text string, now time.Time) error {
+	if text != "" {
+		if err := d.sender.SendDigest(ctx, user, text); err != nil {
+			return fmt.Errorf("send %s digest: %w", kind, err)
+		}
+	}
+	return d.digests.MarkSent(ctx, user.ID, kind, now)
+}
+
+// digestDue reports whether the local send time for today has passed within
+// the grace window and the digest was not sent since.
+func digestDue(local time.Time, at domain.TimeOfDay, lastSent time.Time) bool {
+	sendAt := time.Date(local.Year(), local.Month(), local.Day(), at.Hour, at.Minute, 0, 0, local.Location())
+	return !local.Before(sendAt) && local.Sub(sendAt) < digestGrace && lastSent.Before(sendAt)
+}
+
+type digestEntry struct {
+	occ *domain.Occurrence
+	rem *domain.Reminder
+}
+
+// dayEntries lists occurrences of active reminders that fire on the local day of
+// local, up to and including until.
+func (d *Digester) dayEntries(ctx context.Context, rems []*domain.Reminder, local, until time.Time) ([]digestEntry, error) {
+	dayStart := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, local.Location())
+
+	var out []digestEntry
+	for _, rem := range rems {
+		if !rem.IsActive {
+			continue
+		}
+		occs, err := d.occurrences.ListByReminderInRange(ctx, rem.ID, dayStart.UTC(), until.UTC())
+		if err != nil {
+			return nil, err
+		}
+		for _, occ := range occs {
+			out = append(out, digestEntry{occ: occ, rem: rem})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		a, b := out[i].occ, out[j].occ
+		if !a.FireAtUtc.Equal(b.FireAtUtc) {
+			return a.FireAtUtc.Before(b.FireAtUtc)
+		}
+		return a.ID < b.ID
+	})
+	return out, nil
+}
+
+func (d *Digester) morningText(ctx context.Context, p *i18n.Printer, rems []*domain.Reminder, local time.Time) (string, error) {
+	dayEnd := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, local.Location()).AddDate(0, 0, 1).Add(-time.Nanosecond)
+	entries, err := d.dayEntries(ctx, rems, local, dayEnd)
+	if err != nil || len(entries) == 0 {
+		return "", err
+	}
+
+	var b strings.Builder
+	b.WriteString(p.T("☀️ Today, %s:", p.ShortDate(local)) + "\n")
+	for _, e := range entries {
+		fmt.Fprintf(&b, "\n%s %s", p.Time(e.occ.FireAtUtc.In(local.Location())), e.rem.Name)
+	}
+	return b.String(), nil
+}
+
+func (d *Digester) eveningText(ctx context.Context, p *i18n.Printer, rems []*domain.Reminder, local time.Time) (string, error) {
+	entries, err := d.dayEntries(ctx, rems, local, local)
+	if err != nil || len(entries) == 0 {
+		return "", err
+	}
+
+	var done, ignored, missed int
+	var lines strings.Builder
+	for _, e := range entries {
+		var label string
+		switch e.occ.Status {
+		case domain.OccurrenceDone:
+			done++
+			label = "✅"
+		case domain.OccurrenceIgnored:
+			ignored++
+			label = "🚫"
+		case domain.OccurrenceFailed:
+			// Not delivered, so not the user's miss.
+			label = "❗"
+		default:
+			missed++
+			label = "⚠️"
+		}
+		fmt.Fprintf(&lines, "\n%s %s %s", label, p.Time(e.occ.FireAtUtc.In(local.Location())), e.rem.Name)
+	}
+
+	var b strings.Builder
+	b.WriteString(p.T("🌙 Recap for %s: %d done, %d ignored, %d missed", p.ShortDate(local), done, ignored, missed) + "\n")
+	b.WriteString(lines.String())
+	return b.String(), nil
+}
+
+func (d *Digester) weeklyText(ctx context.Context, p *i18n.Printer, rems []*domain.Reminder, local time.Time) (string, error) {
+	now := local.UTC()
+	since := now.AddDate(0, 0, -7)
+
+	var lines strings.Builder
+	for _, rem := range rems {
+		occs, err := d.occurrences.ListByReminderInRange(ctx, rem.ID, since, now)
+		if err != nil {
+			return "", err
+		}
+		s := stats.Compute(occs, rem.Location(), since, now)
+		if s.Total() == 0 {
+			continue
+		}
+		lines.WriteString("\n" + p.T("#%d %s: %d/%d done (%.0f%%), %d ignored, %d missed",
+			rem.ID, rem.Name, s.Done, s.Total(), s.CompletionRate()*100, s.Ignored, s.Missed))
+	}
+	if lines.Len() == 0 {
+		return "", nil
+	}
+	return p.T("📅 Your week:") + "\n" + lines.String(), nil
+}
@@ -0,0 +1,85 @@
+package scheduler
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"naggingbot/internal/domain"
+	"naggingbot/internal/storage/memory"
+)
+
+type recordingDigestSender struct {
+	texts []string
+}
+
+func (s *recordingDigestSender) SendDigest(ctx context.Context, user *domain.User, text string) error {
+	s.texts = append(s.texts, text)
+	return nil
+}
+
+func TestDigesterSendsEachSlotOnce(t *testing.T) {
+	ctx := context.Background()
+	users := memory.NewInMemoryUserStore()
+	reminders := memory.NewInMemoryReminderStore()
+	occurrences := memory.NewInMemoryOccurrenceStore()
+	digests := memory.NewInMemoryDigestStore()
+	sender := &recordingDigestSender{}
+	d := NewDigester(users, reminders, occurrences, digests, sender)
+
+	user := &domain.User{TelegramID: 1, TimeZone: "Europe/Warsaw"}
+	if err := users.Upsert(ctx, user); err != nil {
+		t.Fatal(err)
+	}
+	rem := &domain.Reminder{UserID: user.ID, Name: "Pill", TimeZone: "Europe/Warsaw", IsActive: true}
+	if err := reminders.Create(ctx, rem); err != nil {
+		t.Fatal(err)
+	}
+
+	loc, _ := time.LoadLocation("Europe/Warsaw")
+	// Sunday 2026-01-18.
+	day := time.Date(2026, 1, 18, 0, 0, 0, 0, loc)
+	done := &domain.Occurrence{ReminderID: rem.ID, FireAtUtc: day.Add(9 * time.Hour).UTC(), Status: domain.OccurrenceDone}
+	missed := &domain.Occurrence{ReminderID: rem.ID, FireAtUtc: day.Add(13 * time.Hour).UTC(), Status: domain.OccurrenceSent}
+	if err := occurrences.CreateBatch(ctx, []*domain.Occurrence{done, missed}); err != nil {
+		t.Fatal(err)
+	}
+
+	morning := domain.TimeOfDay{Hour: 8, Minute: 0}
+	evening := domain.TimeOfDay{Hour: 21, Minute: 0}
+	if err := digests.Save(ctx, &domain.DigestSettings{UserID: user.ID, Morning: &morning, Evening: &evening, Weekly: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	steps := []struct {
+		at   time.Time
+		want int
+	}{
+		{day.Add(7 * time.Hour), 0},                   // before the morning slot
+		{day.Add(8*time.Hour + time.Minute), 1},       // morning
+		{day.Add(8*time.Hour + 2*time.Minute), 1},     // already sent
+		{day.Add(21 * time.Hour), 3},                  // evening and weekly
+		{day.Add(21*time.Hour + 30*time.Minute), 3},   // already sent
+		{day.AddDate(0, 0, 1).Add(12 * time.Hour), 3}, // next morning is past the grace window
+	}
+	for _, step := range steps {
+		if err := d.SendDue(ctx, step.at.UTC()); err != nil {
+			t.Fatalf("send due at %s: %v", step.at, err)
+		}
+		if len(sender.texts) != step.want {
+			t.Fatalf("after %s: %d digests sent, want %d: %q", step.at, len(sender.texts), step.want, sender.texts)
+		}
+	}
+
+	if !strings.Contains(sender.texts[0], "09:00 Pill") || !strings.Contains(sender.texts[0], "13:00 Pill") {
+		t.Fatalf("morning digest = %q", sender.texts[0])
+	}
+	if !strings.Contains(sender.texts[1], "1 done, 0 ignored, 1 missed") {
+		t.Fatalf("evening digest = %q", sender.texts[1])
+	}
+	// The weekly stats leave out the 13:00 occurrence, which may still be answered.
+	if !strings.Contains(sender.texts[2], "1/1 done") {
+		t.Fatalf("weekly digest = %q", sender.texts[2])
+	}
+}
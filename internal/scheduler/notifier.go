@@ -2,7 +2,10 @@ package scheduler
 
 import (
 	"context"
+	"fmt"
 	"log"
+
+	"naggingbot/internal/domain"
 )
 
 // Notifier sends reminder messages to the user.
@@ -10,6 +13,34 @@ type Notifier interface {
 	Send(ctx context.Context, occ OccurrenceWithReminder) error
 }
 
+// Escalator alerts the owner of an assigned reminder that its recipient left
+// an occurrence unanswered past the reminder's deadline.
+type Escalator interface {
+	Escalate(ctx context.Context, occ OccurrenceWithReminder) error
+}
+
+// StepSender fires one step of a reminder's escalation chain.
+type StepSender interface {
+	SendStep(ctx context.Context, occ OccurrenceWithReminder, step domain.EscalationStep) error
+}
+
+// StepRouter sends webhook steps with Webhook and chat steps with Chat.
+type StepRouter struct {
+	Chat    StepSender
+	Webhook StepSender
+}
+
+func (r StepRouter) SendStep(ctx context.Context, occ OccurrenceWithReminder, step domain.EscalationStep) error {
+	next := r.Chat
+	if step.URL != "" {
+		next = r.Webhook
+	}
+	if next == nil {
+		return fmt.Errorf("no sender for escalation step %+v", step)
+	}
+	return next.SendStep(ctx, occ, step)
+}
+
 // LoggingNotifier logs outgoing notifications.
 type LoggingNotifier struct{}
 
@@ -23,7 +54,8 @@ func (n *LoggingNotifier) Send(ctx context.Context, occ OccurrenceWithReminder)
 	return nil
 }
 
-// MultiNotifier dispatches to multiple notifiers.
+// MultiNotifier dispatches to multiple notifiers and only logs their errors.
+// Use Router to send over chosen channels and fail when they all fail.
 type MultiNotifier struct {
 	inner []Notifier
 }
@@ -0,0 +1,132 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"naggingbot/internal/domain"
+)
+
+// ErrNotConfigured is returned by a channel's notifier when the user did not
+// set the channel up, e.g. has no verified email address.
+var ErrNotConfigured = errors.New("channel not set up")
+
+// defaultOptional are the channels added, best effort, to Telegram when
+// neither the reminder nor its recipient chose channels.
+const defaultOptional = domain.ChannelEmail | domain.ChannelWebhook
+
+// Router sends occurrences over the channels chosen for them: the reminder's
+// Channels, else its recipient's, else Telegram plus every other channel the
+// user set up. The result of each channel is recorded with the occurrence.
+//
+// An occurrence fails only when all of its required channels fail, so the
+// scheduler retries it; channels that were not chosen explicitly never fail
+// it. A retry skips the channels that already delivered the occurrence; once
+// it was sent, e.g. when an admin requeues it, every channel sends again.
+type Router struct {
+	users       domain.UserStore
+	occurrences domain.OccurrenceStore
+	channels    map[domain.Channels]Notifier
+}
+
+func NewRouter(users domain.UserStore, occurrences domain.OccurrenceStore) *Router {
+	return &Router{users: users, occurrences: occurrences, channels: make(map[domain.Channels]Notifier)}
+}
+
+// Handle registers the notifier of a single channel. Channels without one
+// fail when they are required.
+func (r *Router) Handle(channel domain.Channels, n Notifier) {
+	r.channels[channel] = n
+}
+
+func (r *Router) Send(ctx context.Context, occ OccurrenceWithReminder) error {
+	required, optional := r.plan(ctx, occ)
+	done := r.delivered(ctx, occ)
+
+	delivered := false
+	var errs []error
+	for _, ch := range domain.AllChannels {
+		isRequired := required.Has(ch)
+		if !isRequired && !optional.Has(ch) {
+			continue
+		}
+		if done.Has(ch) {
+			delivered = delivered || isRequired
+			continue
+		}
+		err := r.sendOne(ctx, ch, occ)
+		if err != nil && !isRequired && errors.Is(err, ErrNotConfigured) {
+			continue
+		}
+
+		d := &domain.ChannelDelivery{OccurrenceID: occ.Occurrence.ID, Channel: ch, Required: isRequired, AtUtc: time.Now().UTC()}
+		if err != nil {
+			d.Error = err.Error()
+			log.Printf("router: send occurrence %d over %s failed: %v", occ.Occurrence.ID, ch, err)
+			if isRequired {
+				errs = append(errs, fmt.Errorf("%s: %w", ch, err))
+			}
+		} else if isRequired {
+			delivered = true
+		}
+		if err := r.occurrences.RecordDelivery(ctx, d); err != nil {
+			log.Printf("router: record %s delivery of occurrence %d: %v", ch, occ.Occurrence.ID, err)
+		}
+	}
+
+	if delivered {
+		return nil
+	}
+	return fmt.Errorf("all channels failed for occurrence %d: %w", occ.Occurrence.ID, errors.Join(errs...))
+}
+
+// sendOne sends over one channel; a panicking notifier fails only its channel.
+func (r *Router) sendOne(ctx context.Context, ch domain.Channels, occ OccurrenceWithReminder) (err error) {
+	defer recoverTo(&err, "sending occurrence %d over %s", occ.Occurrence.ID, ch)
+	n := r.channels[ch]
+	if n == nil {
+		return fmt.Errorf("no notifier: %w", ErrNotConfigured)
+	}
+	return n.Send(ctx, occ)
+}
+
+// delivered returns the channels that already delivered an occurrence which
+// was never marked sent, i.e. is being retried.
+func (r *Router) delivered(ctx context.Context, occ OccurrenceWithReminder) domain.Channels {
+	if !occ.Occurrence.SentAtUtc.IsZero() {
+		return 0
+	}
+	list, err := r.occurrences.ListDeliveries(ctx, occ.Occurrence.ID)
+	if err != nil {
+		log.Printf("router: list deliveries of occurrence %d: %v", occ.Occurrence.ID, err)
+		return 0
+	}
+	var done domain.Channels
+	for _, d := range list {
+		if d.OK() {
+			done |= d.Channel
+		}
+	}
+	return done
+}
+
+// plan returns the channels an occurrence must and may be sent over.
+func (r *Router) plan(ctx context.Context, occ OccurrenceWithReminder) (required, optional domain.Channels) {
+	if occ.Reminder == nil {
+		return domain.ChannelTelegram, 0
+	}
+	if occ.Reminder.Channels != 0 {
+		return occ.Reminder.Channels, 0
+	}
+	userID := occ.Reminder.Recipient()
+	user, err := r.users.GetByID(ctx, userID)
+	if err != nil {
+		log.Printf("router: get user %d: %v", userID, err)
+	} else if user != nil && user.Channels != 0 {
+		return user.Channels, 0
+	}
+	return domain.ChannelTelegram, defaultOptional
+}
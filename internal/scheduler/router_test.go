@@ -0,0 +1,205 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"naggingbot/internal/domain"
+	"naggingbot/internal/storage/memory"
+)
+
+// channelNotifier records its sends and returns err.
+type channelNotifier struct {
+	err  error
+	sent int
+}
+
+func (n *channelNotifier) Send(ctx context.Context, occ OccurrenceWithReminder) error {
+	n.sent++
+	return n.err
+}
+
+type routerFixture struct {
+	router      *Router
+	users       *memory.InMemoryUserStore
+	occurrences *memory.InMemoryOccurrenceStore
+	telegram    *channelNotifier
+	email       *channelNotifier
+	webhook     *channelNotifier
+	user        *domain.User
+	occ         OccurrenceWithReminder
+}
+
+func newRouterFixture(t *testing.T) *routerFixture {
+	t.Helper()
+	ctx := context.Background()
+	f := &routerFixture{
+		users:       memory.NewInMemoryUserStore(),
+		occurrences: memory.NewInMemoryOccurrenceStore(),
+		telegram:    &channelNotifier{},
+		email:       &channelNotifier{},
+		webhook:     &channelNotifier{},
+		user:        &domain.User{TelegramID: 100},
+	}
+	if err := f.users.Upsert(ctx, f.user); err != nil {
+		t.Fatal(err)
+	}
+	f.occ = OccurrenceWithReminder{Reminder: &domain.Reminder{ID: 1, UserID: f.user.ID}}
+	f.nextOccurrence(t)
+
+	f.router = NewRouter(f.users, f.occurrences)
+	f.router.Handle(domain.ChannelTelegram, f.telegram)
+	f.router.Handle(domain.ChannelEmail, f.email)
+	f.router.Handle(domain.ChannelWebhook, f.webhook)
+	return f
+}
+
+// nextOccurrence replaces the fixture's occurrence with a new one that has no
+// deliveries yet.
+func (f *routerFixture) nextOccurrence(t *testing.T) {
+	t.Helper()
+	occ := &domain.Occurrence{FireAtUtc: time.Now().UTC(), Status: domain.OccurrenceCreated}
+	if err := f.occurrences.Create(context.Background(), occ); err != nil {
+		t.Fatal(err)
+	}
+	f.occ.Occurrence = occ
+}
+
+// deliveries returns the recorded channels, with "!" marking failures and
+// "?" best-effort channels.
+func (f *routerFixture) deliveries(t *testing.T) []string {
+	t.Helper()
+	list, err := f.occurrences.ListDeliveries(context.Background(), f.occ.Occurrence.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out []string
+	for _, d := range list {
+		s := d.Channel.String()
+		if !d.OK() {
+			s += "!"
+		}
+		if !d.Required {
+			s += "?"
+		}
+		out = append(out, s)
+	}
+	return out
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestRouterDefaultChannels(t *testing.T) {
+	f := newRouterFixture(t)
+	f.email.err = ErrNotConfigured
+	f.webhook.err = errors.New("timeout")
+
+	// Extra channels are best effort: unset ones are not recorded and
+	// failing ones do not fail the occurrence.
+	if err := f.router.Send(context.Background(), f.occ); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	if got, want := f.deliveries(t), []string{"telegram", "webhook!?"}; !equalStrings(got, want) {
+		t.Fatalf("deliveries = %v, want %v", got, want)
+	}
+
+	f.telegram.err = errors.New("blocked")
+	f.nextOccurrence(t)
+	if err := f.router.Send(context.Background(), f.occ); err == nil {
+		t.Fatal("send succeeded although Telegram failed")
+	}
+}
+
+func TestRouterChosenChannels(t *testing.T) {
+	ctx := context.Background()
+	f := newRouterFixture(t)
+	if err := f.users.SetChannels(ctx, f.user.ID, domain.ChannelTelegram|domain.ChannelEmail); err != nil {
+		t.Fatal(err)
+	}
+
+	// One required channel is enough.
+	f.telegram.err = errors.New("blocked")
+	if err := f.router.Send(ctx, f.occ); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	if f.webhook.sent != 0 {
+		t.Fatal("sent over a channel the user did not choose")
+	}
+	if got, want := f.deliveries(t), []string{"telegram!", "email"}; !equalStrings(got, want) {
+		t.Fatalf("deliveries = %v, want %v", got, want)
+	}
+
+	// A required channel that is not set up counts as failed.
+	f.email.err = ErrNotConfigured
+	f.nextOccurrence(t)
+	if err := f.router.Send(ctx, f.occ); err == nil || !errors.Is(err, ErrNotConfigured) {
+		t.Fatalf("send = %v, want failure", err)
+	}
+
+	// The reminder's choice wins over the user's.
+	f.occ.Reminder.Channels = domain.ChannelWebhook
+	f.nextOccurrence(t)
+	f.telegram.sent, f.email.sent = 0, 0
+	if err := f.router.Send(ctx, f.occ); err != nil {
+		t.Fatalf("send webhook only: %v", err)
+	}
+	if f.telegram.sent != 0 || f.email.sent != 0 || f.webhook.sent != 1 {
+		t.Fatalf("sent telegram=%d email=%d webhook=%d, want the webhook only", f.telegram.sent, f.email.sent, f.webhook.sent)
+	}
+}
+
+func TestRouterRecoversChannelPanic(t *testing.T) {
+	f := newRouterFixture(t)
+	f.router.Handle(domain.ChannelTelegram, &panickingNotifier{poisoned: f.occ.Occurrence.ID})
+	f.occ.Reminder.Channels = domain.ChannelTelegram | domain.ChannelWebhook
+
+	if err := f.router.Send(context.Background(), f.occ); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	if got, want := f.deliveries(t), []string{"telegram!", "webhook"}; !equalStrings(got, want) {
+		t.Fatalf("deliveries = %v, want %v", got, want)
+	}
+}
+
+func TestRouterRetrySkipsDeliveredChannels(t *testing.T) {
+	ctx := context.Background()
+	f := newRouterFixture(t)
+
+	f.telegram.err = errors.New("blocked")
+	if err := f.router.Send(ctx, f.occ); err == nil {
+		t.Fatal("send succeeded although Telegram failed")
+	}
+
+	// The retry only sends over the channel that failed.
+	f.telegram.err = nil
+	if err := f.router.Send(ctx, f.occ); err != nil {
+		t.Fatalf("retry: %v", err)
+	}
+	if f.telegram.sent != 2 || f.email.sent != 1 || f.webhook.sent != 1 {
+		t.Fatalf("sent telegram=%d email=%d webhook=%d, want 2, 1 and 1", f.telegram.sent, f.email.sent, f.webhook.sent)
+	}
+	if got, want := f.deliveries(t), []string{"telegram!", "email?", "webhook?", "telegram"}; !equalStrings(got, want) {
+		t.Fatalf("deliveries = %v, want %v", got, want)
+	}
+
+	// A requeued occurrence that was sent goes out over every channel again.
+	f.occ.Occurrence.SentAtUtc = time.Now().UTC()
+	if err := f.router.Send(ctx, f.occ); err != nil {
+		t.Fatalf("resend: %v", err)
+	}
+	if f.telegram.sent != 3 || f.webhook.sent != 2 {
+		t.Fatalf("sent telegram=%d webhook=%d, want 3 and 2", f.telegram.sent, f.webhook.sent)
+	}
+}
@@ -2,7 +2,9 @@ package scheduler
 
 import (
 	"context"
+	"fmt"
 	"log"
+	"runtime/debug"
 	"time"
 
 	"naggingbot/internal/domain"
@@ -14,8 +16,35 @@ type Scheduler struct {
 	reminderStore   domain.ReminderStore
 	notifier        Notifier
 	interval        time.Duration
+
+	digester        *Digester
+	lastDigestCheck time.Time
+
+	escalator           Escalator
+	steps               StepSender
+	lastEscalationCheck time.Time
+
+	// retries tracks occurrences whose delivery failed; retryBackoff is the
+	// wait after the first failure, doubled after each further one.
+	retries      map[int64]*retryState
+	retryBackoff time.Duration
+}
+
+// retryState is the failed deliveries of one occurrence and when it is due
+// again.
+type retryState struct {
+	failures int
+	next     time.Time
 }
 
+const (
+	// MaxSendAttempts bounds the deliveries tried for an occurrence; after
+	// the last one fails it is given up as failed.
+	MaxSendAttempts = 5
+	// defaultRetryBackoff waits 30s, 1m, 2m and 4m between the attempts.
+	defaultRetryBackoff = 30 * time.Second
+)
+
 // New constructs a scheduler with a polling interval.
 func New(occurrences domain.OccurrenceStore, reminders domain.ReminderStore, notifier Notifier, interval time.Duration) *Scheduler {
 	if interval <= 0 {
@@ -27,9 +56,39 @@ func New(occurrences domain.OccurrenceStore, reminders domain.ReminderStore, not
 		reminderStore:   reminders,
 		notifier:        notifier,
 		interval:        interval,
+		retries:         make(map[int64]*retryState),
+		retryBackoff:    defaultRetryBackoff,
 	}
 }
 
+// digestCheckInterval throttles digest checks; send times have minute precision.
+const digestCheckInterval = time.Minute
+
+// SetDigester enables digest messages, checked on ticks at most once a minute.
+func (s *Scheduler) SetDigester(d *Digester) {
+	s.digester = d
+}
+
+// escalationWindow bounds how long after delivery an occurrence is still
+// escalated, so old missed occurrences are not scanned.
+const escalationWindow = domain.MaxEscalateAfter + time.Hour
+
+// escalationCheckInterval throttles escalation checks, which scan the whole
+// window; escalation delays have minute precision.
+const escalationCheckInterval = time.Minute
+
+// SetEscalator enables alerts to owners of assigned reminders whose recipient
+// leaves an occurrence unanswered past the reminder's deadline.
+func (s *Scheduler) SetEscalator(e Escalator) {
+	s.escalator = e
+}
+
+// SetStepSender enables the escalation chains of reminders. Chains use the
+// same window as owner alerts.
+func (s *Scheduler) SetStepSender(st StepSender) {
+	s.steps = st
+}
+
 // Run starts the scheduler loop.
 func (s *Scheduler) Run(ctx context.Context) error {
 	ticker := time.NewTicker(s.interval)
@@ -40,13 +99,46 @@ func (s *Scheduler) Run(ctx context.Context) error {
 		case <-ctx.Done():
 			return ctx.Err()
 		case <-ticker.C:
-			if err := s.tick(ctx); err != nil {
+			if err := s.safeTick(ctx); err != nil {
 				log.Printf("scheduler tick error: %v", err)
 			}
 		}
 	}
 }
 
+// recoverTo is deferred to turn a panic into *errp, reported with its stack,
+// so a bug triggered by one occurrence or user does not stop the scheduler.
+func recoverTo(errp *error, format string, args ...any) {
+	if r := recover(); r != nil {
+		what := fmt.Sprintf(format, args...)
+		log.Printf("scheduler: panic %s: %v\n%s", what, r, debug.Stack())
+		*errp = fmt.Errorf("panic %s: %v", what, r)
+	}
+}
+
+func (s *Scheduler) safeTick(ctx context.Context) (err error) {
+	defer recoverTo(&err, "in tick")
+	return s.tick(ctx)
+}
+
+// send delivers one occurrence; a panicking notifier counts as a failed attempt.
+func (s *Scheduler) send(ctx context.Context, payload OccurrenceWithReminder) (err error) {
+	defer recoverTo(&err, "sending occurrence %d", payload.Occurrence.ID)
+	return s.notifier.Send(ctx, payload)
+}
+
+// sendEscalation alerts the owner about one occurrence, recovering panics like send.
+func (s *Scheduler) sendEscalation(ctx context.Context, payload OccurrenceWithReminder) (err error) {
+	defer recoverTo(&err, "escalating occurrence %d", payload.Occurrence.ID)
+	return s.escalator.Escalate(ctx, payload)
+}
+
+// sendStep fires one step of an escalation chain, recovering panics like send.
+func (s *Scheduler) sendStep(ctx context.Context, payload OccurrenceWithReminder, step domain.EscalationStep) (err error) {
+	defer recoverTo(&err, "escalating occurrence %d", payload.Occurrence.ID)
+	return s.steps.SendStep(ctx, payload, step)
+}
+
 func (s *Scheduler) tick(ctx context.Context) error {
 	log.Println("scheduler tick")
 
@@ -57,6 +149,11 @@ func (s *Scheduler) tick(ctx context.Context) error {
 	}
 
 	for _, occ := range due {
+		retry := s.retries[occ.ID]
+		if retry != nil && nowUTC.Before(retry.next) {
+			continue
+		}
+
 		payload := OccurrenceWithReminder{Occurrence: occ}
 		if occ.ReminderID != 0 {
 			if rem, err := s.reminderStore.GetByID(ctx, occ.ReminderID); err == nil {
@@ -64,16 +161,112 @@ func (s *Scheduler) tick(ctx context.Context) error {
 			}
 		}
 
-		if err := s.notifier.Send(ctx, payload); err != nil {
+		if err := s.send(ctx, payload); err != nil {
 			log.Printf("send occurrence %d failed: %v", occ.ID, err)
+			if err := s.occurrenceStore.IncrementAttempts(ctx, occ.ID); err != nil {
+				log.Printf("record attempt for occurrence %d failed: %v", occ.ID, err)
+			}
+			s.retryLater(ctx, occ.ID, nowUTC)
 			continue
 		}
+		delete(s.retries, occ.ID)
 
-		if err := s.occurrenceStore.UpdateStatus(ctx, occ.ID, domain.OccurrenceSent); err != nil {
-			log.Printf("update occurrence %d status failed: %v", occ.ID, err)
+		if err := s.occurrenceStore.MarkSent(ctx, occ.ID, time.Now().UTC()); err != nil {
+			log.Printf("mark occurrence %d sent failed: %v", occ.ID, err)
 		}
 	}
 
+	if (s.escalator != nil || s.steps != nil) && nowUTC.Sub(s.lastEscalationCheck) >= escalationCheckInterval {
+		s.lastEscalationCheck = nowUTC
+		if err := s.escalate(ctx, nowUTC); err != nil {
+			log.Printf("escalation check failed: %v", err)
+		}
+	}
+
+	if s.digester != nil && nowUTC.Sub(s.lastDigestCheck) >= digestCheckInterval {
+		s.lastDigestCheck = nowUTC
+		if err := s.digester.SendDue(ctx, nowUTC); err != nil {
+			log.Printf("digest check failed: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// retryLater schedules the next delivery of a failed occurrence with
+// exponential backoff, or gives it up after MaxSendAttempts failures. The
+// backoff is kept in memory only, so a restart retries at once.
+func (s *Scheduler) retryLater(ctx context.Context, id int64, nowUTC time.Time) {
+	retry := s.retries[id]
+	if retry == nil {
+		retry = &retryState{}
+		s.retries[id] = retry
+	}
+	retry.failures++
+	if retry.failures >= MaxSendAttempts {
+		delete(s.retries, id)
+		log.Printf("giving up occurrence %d after %d failed attempts", id, retry.failures)
+		if err := s.occurrenceStore.UpdateStatus(ctx, id, domain.OccurrenceFailed); err != nil {
+			log.Printf("give up occurrence %d failed: %v", id, err)
+		}
+		return
+	}
+	retry.next = nowUTC.Add(s.retryBackoff << (retry.failures - 1))
+}
+
+// escalate alerts owners about occurrences of assigned reminders that are
+// still unanswered past their deadline, and fires the due steps of escalation
+// chains. A failed owner alert is retried next check; a chain step is claimed
+// before it is sent, so it fires at most once and a failure is only logged.
+func (s *Scheduler) escalate(ctx context.Context, nowUTC time.Time) error {
+	sent, err := s.occurrenceStore.ListSentInRange(ctx, nowUTC.Add(-escalationWindow), nowUTC)
+	if err != nil {
+		return err
+	}
+
+	reminders := make(map[int64]*domain.Reminder)
+	for _, occ := range sent {
+		rem, ok := reminders[occ.ReminderID]
+		if !ok {
+			if rem, err = s.reminderStore.GetByID(ctx, occ.ReminderID); err != nil {
+				log.Printf("get reminder %d for escalation failed: %v", occ.ReminderID, err)
+				continue
+			}
+			reminders[occ.ReminderID] = rem
+		}
+		if rem == nil {
+			continue
+		}
+		payload := OccurrenceWithReminder{Occurrence: occ, Reminder: rem}
+
+		if s.escalator != nil && rem.Escalates() && occ.EscalatedAtUtc.IsZero() && !nowUTC.Before(occ.SentAtUtc.Add(rem.EscalateAfter)) {
+			if err := s.sendEscalation(ctx, payload); err != nil {
+				log.Printf("escalate occurrence %d failed: %v", occ.ID, err)
+			} else if err := s.occurrenceStore.MarkEscalated(ctx, occ.ID, time.Now().UTC()); err != nil {
+				log.Printf("mark occurrence %d escalated failed: %v", occ.ID, err)
+			}
+		}
+
+		if s.steps != nil && occ.EscalationStep < len(rem.Escalations) {
+			step := rem.Escalations[occ.EscalationStep]
+			if nowUTC.Before(occ.SentAtUtc.Add(step.After)) {
+				continue
+			}
+			// Answering the occurrence in the meantime makes the claim fail.
+			claimed, err := s.occurrenceStore.AdvanceEscalation(ctx, occ.ID, occ.EscalationStep)
+			if err != nil {
+				log.Printf("advance escalation of occurrence %d failed: %v", occ.ID, err)
+				continue
+			}
+			// A step waiting for its target to accept is passed over.
+			if !claimed || step.Pending {
+				continue
+			}
+			if err := s.sendStep(ctx, payload, step); err != nil {
+				log.Printf("escalation step %d of occurrence %d failed: %v", occ.EscalationStep+1, occ.ID, err)
+			}
+		}
+	}
 	return nil
 }
 
@@ -0,0 +1,323 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"naggingbot/internal/domain"
+	"naggingbot/internal/storage/memory"
+)
+
+// panickingNotifier panics for one occurrence and records the others.
+type panickingNotifier struct {
+	poisoned int64
+	sent     []int64
+}
+
+func (n *panickingNotifier) Send(ctx context.Context, occ OccurrenceWithReminder) error {
+	if occ.Occurrence.ID == n.poisoned {
+		var rem *domain.Reminder
+		_ = rem.Name // nil dereference
+	}
+	n.sent = append(n.sent, occ.Occurrence.ID)
+	return nil
+}
+
+func TestTickRecoversFromNotifierPanic(t *testing.T) {
+	ctx := context.Background()
+	occurrences := memory.NewInMemoryOccurrenceStore()
+	reminders := memory.NewInMemoryReminderStore()
+
+	past := time.Now().UTC().Add(-time.Minute)
+	bad := &domain.Occurrence{FireAtUtc: past, Status: domain.OccurrenceCreated}
+	good := &domain.Occurrence{FireAtUtc: past.Add(time.Second), Status: domain.OccurrenceCreated}
+	if err := occurrences.CreateBatch(ctx, []*domain.Occurrence{bad, good}); err != nil {
+		t.Fatal(err)
+	}
+
+	notifier := &panickingNotifier{poisoned: bad.ID}
+	s := New(occurrences, reminders, notifier, time.Second)
+	if err := s.safeTick(ctx); err != nil {
+		t.Fatalf("tick: %v", err)
+	}
+
+	if len(notifier.sent) != 1 || notifier.sent[0] != good.ID {
+		t.Fatalf("sent = %v, want [%d]", notifier.sent, good.ID)
+	}
+	got, err := occurrences.GetByID(ctx, bad.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Status != domain.OccurrenceCreated || got.Attempts != 1 {
+		t.Fatalf("poisoned occurrence = status %v attempts %d, want created with 1 attempt", got.Status, got.Attempts)
+	}
+
+	// A panic is a failed attempt: it backs off, and the occurrence is given
+	// up once the attempts run out instead of panicking on every tick.
+	if err := s.safeTick(ctx); err != nil {
+		t.Fatalf("tick: %v", err)
+	}
+	if got, _ := occurrences.GetByID(ctx, bad.ID); got.Attempts != 1 {
+		t.Fatalf("attempts = %d during the backoff, want 1", got.Attempts)
+	}
+	s.retryBackoff = 0
+	s.retries[bad.ID].next = time.Time{}
+	for i := 1; i < MaxSendAttempts+2; i++ {
+		if err := s.safeTick(ctx); err != nil {
+			t.Fatalf("tick: %v", err)
+		}
+	}
+	if got, _ := occurrences.GetByID(ctx, bad.ID); got.Status != domain.OccurrenceFailed || got.Attempts != MaxSendAttempts {
+		t.Fatalf("poisoned occurrence = status %v attempts %d, want failed after %d attempts", got.Status, got.Attempts, MaxSendAttempts)
+	}
+	if len(notifier.sent) != 1 {
+		t.Fatalf("sent = %v, want the good occurrence once", notifier.sent)
+	}
+}
+
+// recordingEscalator records the occurrences it escalates.
+type recordingEscalator struct {
+	escalated []int64
+}
+
+func (e *recordingEscalator) Escalate(ctx context.Context, occ OccurrenceWithReminder) error {
+	e.escalated = append(e.escalated, occ.Occurrence.ID)
+	return nil
+}
+
+func TestTickEscalatesOverdueOccurrences(t *testing.T) {
+	ctx := context.Background()
+	occurrences := memory.NewInMemoryOccurrenceStore()
+	reminders := memory.NewInMemoryReminderStore()
+
+	assigned := &domain.Reminder{UserID: 1, RecipientID: 2, RecipientAccepted: true, EscalateAfter: 30 * time.Minute}
+	pending := &domain.Reminder{UserID: 1, RecipientID: 2, EscalateAfter: 30 * time.Minute}
+	own := &domain.Reminder{UserID: 1, EscalateAfter: 30 * time.Minute}
+	for _, rem := range []*domain.Reminder{assigned, pending, own} {
+		if err := reminders.Create(ctx, rem); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	now := time.Now().UTC()
+	sent := func(rem *domain.Reminder, ago time.Duration) *domain.Occurrence {
+		t.Helper()
+		occ := &domain.Occurrence{ReminderID: rem.ID, FireAtUtc: now.Add(-ago), Status: domain.OccurrenceCreated}
+		if err := occurrences.Create(ctx, occ); err != nil {
+			t.Fatal(err)
+		}
+		if err := occurrences.MarkSent(ctx, occ.ID, now.Add(-ago)); err != nil {
+			t.Fatal(err)
+		}
+		return occ
+	}
+	overdue := sent(assigned, 31*time.Minute)
+	sent(assigned, 10*time.Minute)
+	sent(pending, time.Hour)
+	sent(own, time.Hour)
+
+	escalator := &recordingEscalator{}
+	s := New(occurrences, reminders, &panickingNotifier{}, time.Second)
+	s.SetEscalator(escalator)
+	for i := 0; i < 2; i++ {
+		s.lastEscalationCheck = time.Time{}
+		if err := s.safeTick(ctx); err != nil {
+			t.Fatalf("tick: %v", err)
+		}
+	}
+
+	if len(escalator.escalated) != 1 || escalator.escalated[0] != overdue.ID {
+		t.Fatalf("escalated = %v, want [%d]", escalator.escalated, overdue.ID)
+	}
+	got, err := occurrences.GetByID(ctx, overdue.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.EscalatedAtUtc.IsZero() {
+		t.Fatal("escalation was not recorded")
+	}
+}
+
+// stepRecord is one escalation step fired for an occurrence.
+type stepRecord struct {
+	occurrenceID int64
+	step         domain.EscalationStep
+}
+
+// recordingStepSender records the escalation steps it fires.
+type recordingStepSender struct {
+	fired []stepRecord
+}
+
+func (r *recordingStepSender) SendStep(ctx context.Context, occ OccurrenceWithReminder, step domain.EscalationStep) error {
+	r.fired = append(r.fired, stepRecord{occ.Occurrence.ID, step})
+	return nil
+}
+
+func TestTickFiresEscalationSteps(t *testing.T) {
+	ctx := context.Background()
+	occurrences := memory.NewInMemoryOccurrenceStore()
+	reminders := memory.NewInMemoryReminderStore()
+
+	chatStep := domain.EscalationStep{After: 10 * time.Minute, ChatID: 5}
+	hookStep := domain.EscalationStep{After: 20 * time.Minute, URL: "https://example.com/hook"}
+	rem := &domain.Reminder{UserID: 1, Escalations: []domain.EscalationStep{chatStep, hookStep}}
+	if err := reminders.Create(ctx, rem); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now().UTC()
+	sent := func(ago time.Duration) *domain.Occurrence {
+		t.Helper()
+		occ := &domain.Occurrence{ReminderID: rem.ID, FireAtUtc: now.Add(-ago), Status: domain.OccurrenceCreated}
+		if err := occurrences.Create(ctx, occ); err != nil {
+			t.Fatal(err)
+		}
+		if err := occurrences.MarkSent(ctx, occ.ID, now.Add(-ago)); err != nil {
+			t.Fatal(err)
+		}
+		return occ
+	}
+	first := sent(15 * time.Minute)
+	both := sent(25 * time.Minute)
+	answered := sent(25 * time.Minute)
+	if err := occurrences.MarkAcked(ctx, answered.ID, domain.OccurrenceDone, now); err != nil {
+		t.Fatal(err)
+	}
+
+	chat, hooks := &recordingStepSender{}, &recordingStepSender{}
+	s := New(occurrences, reminders, &panickingNotifier{}, time.Second)
+	s.SetStepSender(StepRouter{Chat: chat, Webhook: hooks})
+	for i := 0; i < 3; i++ {
+		s.lastEscalationCheck = time.Time{}
+		if err := s.safeTick(ctx); err != nil {
+			t.Fatalf("tick: %v", err)
+		}
+	}
+
+	// Occurrences are escalated in fire time order.
+	if len(chat.fired) != 2 || chat.fired[0] != (stepRecord{both.ID, chatStep}) || chat.fired[1] != (stepRecord{first.ID, chatStep}) {
+		t.Fatalf("chat steps = %+v, want the first step of occurrences %d and %d", chat.fired, both.ID, first.ID)
+	}
+	if len(hooks.fired) != 1 || hooks.fired[0] != (stepRecord{both.ID, hookStep}) {
+		t.Fatalf("webhook steps = %+v, want the second step of occurrence %d", hooks.fired, both.ID)
+	}
+	got, err := occurrences.GetByID(ctx, both.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.EscalationStep != 2 {
+		t.Fatalf("escalation step = %d, want 2", got.EscalationStep)
+	}
+
+	// Checks run at most once per escalationCheckInterval.
+	late := sent(15 * time.Minute)
+	if err := s.safeTick(ctx); err != nil {
+		t.Fatalf("tick: %v", err)
+	}
+	if len(chat.fired) != 2 {
+		t.Fatalf("chat steps = %+v, want occurrence %d to wait for the next check", chat.fired, late.ID)
+	}
+}
+
+func TestTickSkipsPendingEscalationSteps(t *testing.T) {
+	ctx := context.Background()
+	occurrences := memory.NewInMemoryOccurrenceStore()
+	reminders := memory.NewInMemoryReminderStore()
+
+	pendingStep := domain.EscalationStep{After: 10 * time.Minute, ChatID: 5, Pending: true}
+	hookStep := domain.EscalationStep{After: 20 * time.Minute, URL: "https://example.com/hook"}
+	rem := &domain.Reminder{UserID: 1, Escalations: []domain.EscalationStep{pendingStep, hookStep}}
+	if err := reminders.Create(ctx, rem); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now().UTC()
+	occ := &domain.Occurrence{ReminderID: rem.ID, FireAtUtc: now.Add(-25 * time.Minute), Status: domain.OccurrenceCreated}
+	if err := occurrences.Create(ctx, occ); err != nil {
+		t.Fatal(err)
+	}
+	if err := occurrences.MarkSent(ctx, occ.ID, occ.FireAtUtc); err != nil {
+		t.Fatal(err)
+	}
+
+	chat, hooks := &recordingStepSender{}, &recordingStepSender{}
+	s := New(occurrences, reminders, &panickingNotifier{}, time.Second)
+	s.SetStepSender(StepRouter{Chat: chat, Webhook: hooks})
+	for i := 0; i < 3; i++ {
+		s.lastEscalationCheck = time.Time{}
+		if err := s.safeTick(ctx); err != nil {
+			t.Fatalf("tick: %v", err)
+		}
+	}
+
+	// The pending step is passed over without holding up the next one.
+	if len(chat.fired) != 0 {
+		t.Fatalf("chat steps = %+v, want none for a pending step", chat.fired)
+	}
+	if len(hooks.fired) != 1 || hooks.fired[0] != (stepRecord{occ.ID, hookStep}) {
+		t.Fatalf("webhook steps = %+v, want the second step of occurrence %d", hooks.fired, occ.ID)
+	}
+}
+
+// failingNotifier fails every send.
+type failingNotifier struct {
+	sent int
+}
+
+func (n *failingNotifier) Send(ctx context.Context, occ OccurrenceWithReminder) error {
+	n.sent++
+	return errors.New("unreachable")
+}
+
+func TestTickGivesUpFailingOccurrences(t *testing.T) {
+	ctx := context.Background()
+	occurrences := memory.NewInMemoryOccurrenceStore()
+	occ := &domain.Occurrence{FireAtUtc: time.Now().UTC().Add(-time.Minute), Status: domain.OccurrenceCreated}
+	if err := occurrences.Create(ctx, occ); err != nil {
+		t.Fatal(err)
+	}
+
+	notifier := &failingNotifier{}
+	s := New(occurrences, memory.NewInMemoryReminderStore(), notifier, time.Second)
+	if err := s.tick(ctx); err != nil {
+		t.Fatalf("tick: %v", err)
+	}
+	if err := s.tick(ctx); err != nil {
+		t.Fatalf("tick: %v", err)
+	}
+	if notifier.sent != 1 {
+		t.Fatalf("sent %d times, want 1 before the backoff expires", notifier.sent)
+	}
+
+	// Without backoff the remaining attempts run on the next ticks.
+	s.retryBackoff = 0
+	s.retries[occ.ID].next = time.Time{}
+	for i := 1; i < MaxSendAttempts; i++ {
+		if err := s.tick(ctx); err != nil {
+			t.Fatalf("tick: %v", err)
+		}
+	}
+	if notifier.sent != MaxSendAttempts {
+		t.Fatalf("sent %d times, want %d", notifier.sent, MaxSendAttempts)
+	}
+	got, err := occurrences.GetByID(ctx, occ.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Status != domain.OccurrenceFailed || got.Attempts != MaxSendAttempts {
+		t.Fatalf("occurrence = status %v attempts %d, want failed after %d attempts", got.Status, got.Attempts, MaxSendAttempts)
+	}
+
+	// A failed occurrence can be requeued and is tried again.
+	if err := occurrences.UpdateStatus(ctx, occ.ID, domain.OccurrenceCreated); err != nil {
+		t.Fatalf("requeue: %v", err)
+	}
+	if err := s.tick(ctx); err != nil {
+		t.Fatalf("tick: %v", err)
+	}
+	if notifier.sent != MaxSendAttempts+1 {
+		t.Fatalf("sent %d times after the requeue, want %d", notifier.sent, MaxSendAttempts+1)
+	}
+}
@@ -0,0 +1,117 @@
+// Package stats computes adherence statistics from occurrence history.
+package stats
+
+import (
+	"time"
+
+	"naggingbot/internal/domain"
+)
+
+// WeekdayCount holds per-weekday outcome totals.
+type WeekdayCount struct {
+	Done  int
+	Total int
+}
+
+// Summary aggregates the outcomes of past occurrences of one reminder.
+type Summary struct {
+	Done    int
+	Ignored int
+	// Missed counts past occurrences the user never acknowledged.
+	Missed        int
+	CurrentStreak int
+	LongestStreak int
+	// AvgResponse is the mean delay between delivery and Done; zero if unknown.
+	AvgResponse time.Duration
+	// ByWeekday is indexed by time.Weekday in the reminder's time zone.
+	ByWeekday [7]WeekdayCount
+}
+
+// Total returns the number of occurrences that counted towards the summary.
+func (s Summary) Total() int {
+	return s.Done + s.Ignored + s.Missed
+}
+
+// CompletionRate returns the share of Done occurrences in [0, 1].
+func (s Summary) CompletionRate() float64 {
+	if s.Total() == 0 {
+		return 0
+	}
+	return float64(s.Done) / float64(s.Total())
+}
+
+// responseWindow is how long an unanswered occurrence stays open after
+// delivery before it counts as missed.
+const responseWindow = 24 * time.Hour
+
+// Compute summarizes occurrences that fired in [since, now]. A zero since means
+// no lower bound. Occurrences are expected in chronological order, as returned
+// by domain.OccurrenceStore.ListByReminder; loc selects the weekday buckets.
+// Occurrences the bot failed to deliver are left out, and so are unanswered
+// ones still within responseWindow.
+func Compute(occs []*domain.Occurrence, loc *time.Location, since, now time.Time) Summary {
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	var s Summary
+	var streak int
+	var responseTotal time.Duration
+	var responses int
+
+	for _, occ := range occs {
+		if occ.FireAtUtc.After(now) || (!since.IsZero() && occ.FireAtUtc.Before(since)) {
+			continue
+		}
+		if occ.Status == domain.OccurrenceFailed || pending(occ, now) {
+			continue
+		}
+
+		day := occ.FireAtUtc.In(loc).Weekday()
+		s.ByWeekday[day].Total++
+
+		switch occ.Status {
+		case domain.OccurrenceDone:
+			s.Done++
+			s.ByWeekday[day].Done++
+			streak++
+			if streak > s.LongestStreak {
+				s.LongestStreak = streak
+			}
+			if sent := deliveredAt(occ); !sent.IsZero() && !occ.AckedAtUtc.IsZero() && !occ.AckedAtUtc.Before(sent) {
+				responseTotal += occ.AckedAtUtc.Sub(sent)
+				responses++
+			}
+		case domain.OccurrenceIgnored:
+			s.Ignored++
+			streak = 0
+		default:
+			s.Missed++
+			streak = 0
+		}
+	}
+
+	s.CurrentStreak = streak
+	if responses > 0 {
+		s.AvgResponse = responseTotal / time.Duration(responses)
+	}
+	return s
+}
+
+// pending reports whether occ is not answered yet but may still be: it waits
+// for delivery or was delivered less than responseWindow before now.
+func pending(occ *domain.Occurrence, now time.Time) bool {
+	if occ.Status != domain.OccurrenceCreated && occ.Status != domain.OccurrenceSent {
+		return false
+	}
+	return now.Before(deliveredAt(occ).Add(responseWindow))
+}
+
+// deliveredAt prefers the actual send time and falls back to the scheduled one
+// for occurrences recorded before send times were tracked.
+func deliveredAt(occ *domain.Occurrence) time.Time {
+	if !occ.SentAtUtc.IsZero() {
+		return occ.SentAtUtc
+	}
+	return occ.FireAtUtc
+}
@@ -0,0 +1,66 @@
+package stats
+
+import (
+	"testing"
+	"time"
+
+	"naggingbot/internal/domain"
+)
+
+func TestCompute(t *testing.T) {
+	// 2026-01-19 is a Monday.
+	day := time.Date(2026, 1, 19, 8, 0, 0, 0, time.UTC)
+	at := func(days int) time.Time { return day.AddDate(0, 0, days) }
+	occ := func(days int, status domain.OccurrenceStatus, delay time.Duration) *domain.Occurrence {
+		o := &domain.Occurrence{FireAtUtc: at(days), Status: status}
+		if status == domain.OccurrenceDone {
+			o.SentAtUtc = at(days)
+			o.AckedAtUtc = at(days).Add(delay)
+		}
+		return o
+	}
+
+	occs := []*domain.Occurrence{
+		occ(-10, domain.OccurrenceDone, time.Minute), // before the window
+		occ(0, domain.OccurrenceDone, 10*time.Minute),
+		occ(1, domain.OccurrenceDone, 20*time.Minute),
+		occ(2, domain.OccurrenceDone, 30*time.Minute),
+		occ(3, domain.OccurrenceIgnored, 0),
+		occ(4, domain.OccurrenceSent, 0),
+		occ(5, domain.OccurrenceDone, 0),
+		occ(6, domain.OccurrenceFailed, 0), // not delivered, left out
+		occ(7, domain.OccurrenceDone, 0),
+		// Still open, so neither missed nor breaking the streak.
+		{FireAtUtc: at(8).Add(-2 * time.Hour), SentAtUtc: at(8).Add(-time.Hour), Status: domain.OccurrenceSent},
+		{FireAtUtc: at(8).Add(-time.Minute), Status: domain.OccurrenceCreated},
+		occ(30, domain.OccurrenceCreated, 0), // in the future
+	}
+
+	s := Compute(occs, time.UTC, at(-1), at(8))
+
+	if s.Done != 5 || s.Ignored != 1 || s.Missed != 1 {
+		t.Fatalf("counts = done %d ignored %d missed %d, want 5/1/1", s.Done, s.Ignored, s.Missed)
+	}
+	if got, want := s.CompletionRate(), 5.0/7.0; got != want {
+		t.Fatalf("completion rate = %v, want %v", got, want)
+	}
+	if s.CurrentStreak != 2 || s.LongestStreak != 3 {
+		t.Fatalf("streaks = current %d longest %d, want 2/3", s.CurrentStreak, s.LongestStreak)
+	}
+	if want := 12 * time.Minute; s.AvgResponse != want {
+		t.Fatalf("avg response = %v, want %v", s.AvgResponse, want)
+	}
+	if got := s.ByWeekday[time.Monday]; got != (WeekdayCount{Done: 2, Total: 2}) {
+		t.Fatalf("monday = %+v, want 2/2", got)
+	}
+	if got := s.ByWeekday[time.Thursday]; got != (WeekdayCount{Done: 0, Total: 1}) {
+		t.Fatalf("thursday = %+v, want 0/1", got)
+	}
+}
+
+func TestComputeEmpty(t *testing.T) {
+	s := Compute(nil, nil, time.Time{}, time.Now())
+	if s.Total() != 0 || s.CompletionRate() != 0 || s.AvgResponse != 0 {
+		t.Fatalf("empty summary = %+v", s)
+	}
+}
@@ -0,0 +1,89 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"naggingbot/internal/domain"
+)
+
+// InMemoryDigestStore is an in-memory implementation of domain.DigestStore.
+type InMemoryDigestStore struct {
+	mu     sync.Mutex
+	byUser map[int64]*domain.DigestSettings
+}
+
+// NewInMemoryDigestStore constructs an empty digest store.
+func NewInMemoryDigestStore() *InMemoryDigestStore {
+	return &InMemoryDigestStore{byUser: make(map[int64]*domain.DigestSettings)}
+}
+
+func (s *InMemoryDigestStore) Get(ctx context.Context, userID int64) (*domain.DigestSettings, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	d, ok := s.byUser[userID]
+	if !ok {
+		return nil, nil
+	}
+	return cloneDigest(d), nil
+}
+
+func (s *InMemoryDigestStore) ListEnabled(ctx context.Context) ([]*domain.DigestSettings, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var out []*domain.DigestSettings
+	for _, d := range s.byUser {
+		if d.Enabled() {
+			out = append(out, cloneDigest(d))
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].UserID < out[j].UserID })
+	return out, nil
+}
+
+func (s *InMemoryDigestStore) Save(ctx context.Context, d *domain.DigestSettings) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.byUser[d.UserID] = cloneDigest(d)
+	return nil
+}
+
+func (s *InMemoryDigestStore) MarkSent(ctx context.Context, userID int64, kind domain.DigestKind, sentAtUTC time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	d, ok := s.byUser[userID]
+	if !ok {
+		return nil
+	}
+	switch kind {
+	case domain.DigestMorning:
+		d.LastMorningUtc = sentAtUTC
+	case domain.DigestEvening:
+		d.LastEveningUtc = sentAtUTC
+	case domain.DigestWeekly:
+		d.LastWeeklyUtc = sentAtUTC
+	default:
+		return fmt.Errorf("unknown digest kind %q", kind)
+	}
+	return nil
+}
+
+func cloneDigest(d *domain.DigestSettings) *domain.DigestSettings {
+	c := *d
+	if d.Morning != nil {
+		m := *d.Morning
+		c.Morning = &m
+	}
+	if d.Evening != nil {
+		e := *d.Evening
+		c.Evening = &e
+	}
+	return &c
+}
@@ -0,0 +1,48 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"naggingbot/internal/domain"
+)
+
+// InMemoryEmailStore is an in-memory implementation of domain.EmailStore.
+type InMemoryEmailStore struct {
+	mu     sync.Mutex
+	byUser map[int64]*domain.EmailAddress
+}
+
+// NewInMemoryEmailStore constructs an empty email store.
+func NewInMemoryEmailStore() *InMemoryEmailStore {
+	return &InMemoryEmailStore{byUser: make(map[int64]*domain.EmailAddress)}
+}
+
+func (s *InMemoryEmailStore) Get(ctx context.Context, userID int64) (*domain.EmailAddress, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.byUser[userID]
+	if !ok {
+		return nil, nil
+	}
+	c := *e
+	return &c, nil
+}
+
+func (s *InMemoryEmailStore) Save(ctx context.Context, e *domain.EmailAddress) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c := *e
+	s.byUser[e.UserID] = &c
+	return nil
+}
+
+func (s *InMemoryEmailStore) Delete(ctx context.Context, userID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.byUser, userID)
+	return nil
+}
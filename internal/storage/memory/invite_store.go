@@ -0,0 +1,107 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"naggingbot/internal/domain"
+)
+
+// InMemoryInviteStore is an in-memory implementation of domain.InviteStore.
+type InMemoryInviteStore struct {
+	mu     sync.Mutex
+	byCode map[string]*domain.Invite
+}
+
+// NewInMemoryInviteStore constructs an empty invite store.
+func NewInMemoryInviteStore() *InMemoryInviteStore {
+	return &InMemoryInviteStore{byCode: make(map[string]*domain.Invite)}
+}
+
+func (s *InMemoryInviteStore) Create(ctx context.Context, inv *domain.Invite) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.byCode[inv.Code]; ok {
+		return fmt.Errorf("invite %q already exists", inv.Code)
+	}
+	c := *inv
+	s.byCode[inv.Code] = &c
+	return nil
+}
+
+func (s *InMemoryInviteStore) Get(ctx context.Context, code string) (*domain.Invite, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	inv, ok := s.byCode[code]
+	if !ok {
+		return nil, nil
+	}
+	c := *inv
+	return &c, nil
+}
+
+func (s *InMemoryInviteStore) ListUsable(ctx context.Context, at time.Time) ([]*domain.Invite, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var out []*domain.Invite
+	for _, inv := range s.byCode {
+		if inv.Usable(at) {
+			c := *inv
+			out = append(out, &c)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].CreatedAtUtc.Equal(out[j].CreatedAtUtc) {
+			return out[i].CreatedAtUtc.Before(out[j].CreatedAtUtc)
+		}
+		return out[i].Code < out[j].Code
+	})
+	return out, nil
+}
+
+func (s *InMemoryInviteStore) Redeem(ctx context.Context, code string, at time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	inv, ok := s.byCode[code]
+	if !ok || !inv.Usable(at) {
+		return false, nil
+	}
+	inv.Uses++
+	return true, nil
+}
+
+func (s *InMemoryInviteStore) Revoke(ctx context.Context, code string, revokedAtUTC time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if inv, ok := s.byCode[code]; ok && inv.RevokedAtUtc.IsZero() {
+		inv.RevokedAtUtc = revokedAtUTC
+	}
+	return nil
+}
+
+func (s *InMemoryInviteStore) snapshot() map[string]*domain.Invite {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snap := make(map[string]*domain.Invite, len(s.byCode))
+	for code, inv := range s.byCode {
+		c := *inv
+		snap[code] = &c
+	}
+	return snap
+}
+
+func (s *InMemoryInviteStore) restore(snap map[string]*domain.Invite) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.byCode = snap
+}
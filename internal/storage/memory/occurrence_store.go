@@ -2,6 +2,7 @@ package memory
 
 import (
 	"context"
+	"sort"
 	"sync"
 	"time"
 
@@ -10,16 +11,22 @@ import (
 
 // InMemoryOccurrenceStore is an in-memory implementation of domain.OccurrenceStore.
 type InMemoryOccurrenceStore struct {
-	mu     sync.Mutex
-	nextID int64
-	byID   map[int64]*domain.Occurrence
+	mu            sync.Mutex
+	nextID        int64
+	byID          map[int64]*domain.Occurrence
+	confirmations map[int64][]*domain.Confirmation
+	deliveries    map[int64][]*domain.ChannelDelivery
+	nextDelivery  int64
 }
 
 // NewInMemoryOccurrenceStore constructs an empty in-memory store.
 func NewInMemoryOccurrenceStore() *InMemoryOccurrenceStore {
 	return &InMemoryOccurrenceStore{
-		nextID: 1,
-		byID:   make(map[int64]*domain.Occurrence),
+		nextID:        1,
+		byID:          make(map[int64]*domain.Occurrence),
+		confirmations: make(map[int64][]*domain.Confirmation),
+		deliveries:    make(map[int64][]*domain.ChannelDelivery),
+		nextDelivery:  1,
 	}
 }
 
@@ -46,6 +53,23 @@ func (s *InMemoryOccurrenceStore) ListByReminder(ctx context.Context, reminderID
 		}
 	}
 
+	sortOccurrences(out)
+	return out, nil
+}
+
+func (s *InMemoryOccurrenceStore) ListByReminderInRange(ctx context.Context, reminderID int64, startUTC, endUTC time.Time) ([]*domain.Occurrence, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var out []*domain.Occurrence
+	for _, occ := range s.byID {
+		if occ.ReminderID != reminderID || occ.FireAtUtc.Before(startUTC) || occ.FireAtUtc.After(endUTC) {
+			continue
+		}
+		out = append(out, cloneOccurrence(occ))
+	}
+
+	sortOccurrences(out)
 	return out, nil
 }
 
@@ -70,6 +94,26 @@ func (s *InMemoryOccurrenceStore) ListPendingInRange(ctx context.Context, startU
 		out = append(out, cloneOccurrence(occ))
 	}
 
+	sortOccurrences(out)
+	return out, nil
+}
+
+func (s *InMemoryOccurrenceStore) ListSentInRange(ctx context.Context, startUTC, endUTC time.Time) ([]*domain.Occurrence, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var out []*domain.Occurrence
+	for _, occ := range s.byID {
+		if occ.Status != domain.OccurrenceSent {
+			continue
+		}
+		if occ.SentAtUtc.Before(startUTC) || occ.SentAtUtc.After(endUTC) {
+			continue
+		}
+		out = append(out, cloneOccurrence(occ))
+	}
+
+	sortOccurrences(out)
 	return out, nil
 }
 
@@ -86,6 +130,20 @@ func (s *InMemoryOccurrenceStore) Create(ctx context.Context, occ *domain.Occurr
 	return nil
 }
 
+func (s *InMemoryOccurrenceStore) CreateBatch(ctx context.Context, occs []*domain.Occurrence) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, occ := range occs {
+		if occ.ID == 0 {
+			occ.ID = s.nextID
+			s.nextID++
+		}
+		s.byID[occ.ID] = cloneOccurrence(occ)
+	}
+	return nil
+}
+
 func (s *InMemoryOccurrenceStore) UpdateStatus(ctx context.Context, id int64, status domain.OccurrenceStatus) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -94,11 +152,144 @@ func (s *InMemoryOccurrenceStore) UpdateStatus(ctx context.Context, id int64, st
 	if !ok {
 		return nil
 	}
+	if !occ.Status.CanTransition(status) {
+		return &domain.TransitionError{ID: id, From: occ.Status, To: status}
+	}
 
 	occ.Status = status
 	return nil
 }
 
+func (s *InMemoryOccurrenceStore) MarkSent(ctx context.Context, id int64, sentAtUTC time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	occ, ok := s.byID[id]
+	if !ok {
+		return nil
+	}
+	if !occ.Status.CanTransition(domain.OccurrenceSent) {
+		return &domain.TransitionError{ID: id, From: occ.Status, To: domain.OccurrenceSent}
+	}
+
+	occ.Status = domain.OccurrenceSent
+	occ.SentAtUtc = sentAtUTC
+	occ.Attempts++
+	return nil
+}
+
+func (s *InMemoryOccurrenceStore) IncrementAttempts(ctx context.Context, id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if occ, ok := s.byID[id]; ok {
+		occ.Attempts++
+	}
+	return nil
+}
+
+func (s *InMemoryOccurrenceStore) SetMessage(ctx context.Context, id int64, chatID, messageID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if occ, ok := s.byID[id]; ok {
+		occ.ChatID = chatID
+		occ.MessageID = messageID
+	}
+	return nil
+}
+
+func (s *InMemoryOccurrenceStore) MarkEscalated(ctx context.Context, id int64, escalatedAtUTC time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if occ, ok := s.byID[id]; ok {
+		occ.EscalatedAtUtc = escalatedAtUTC
+	}
+	return nil
+}
+
+func (s *InMemoryOccurrenceStore) AdvanceEscalation(ctx context.Context, id int64, step int) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	occ, ok := s.byID[id]
+	if !ok || occ.Status != domain.OccurrenceSent || occ.EscalationStep != step {
+		return false, nil
+	}
+	occ.EscalationStep++
+	return true, nil
+}
+
+func (s *InMemoryOccurrenceStore) MarkAcked(ctx context.Context, id int64, status domain.OccurrenceStatus, ackedAtUTC time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	occ, ok := s.byID[id]
+	if !ok {
+		return nil
+	}
+	if !occ.Status.CanTransition(status) {
+		return &domain.TransitionError{ID: id, From: occ.Status, To: status}
+	}
+
+	occ.Status = status
+	occ.AckedAtUtc = ackedAtUTC
+	return nil
+}
+
+func (s *InMemoryOccurrenceStore) AddConfirmation(ctx context.Context, c *domain.Confirmation) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, existing := range s.confirmations[c.OccurrenceID] {
+		if existing.TelegramID == c.TelegramID {
+			return false, nil
+		}
+	}
+	copied := *c
+	s.confirmations[c.OccurrenceID] = append(s.confirmations[c.OccurrenceID], &copied)
+	return true, nil
+}
+
+func (s *InMemoryOccurrenceStore) ListConfirmations(ctx context.Context, occurrenceID int64) ([]*domain.Confirmation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	list := s.confirmations[occurrenceID]
+	out := make([]*domain.Confirmation, 0, len(list))
+	for _, c := range list {
+		copied := *c
+		out = append(out, &copied)
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].AtUtc.Before(out[j].AtUtc) })
+	return out, nil
+}
+
+func (s *InMemoryOccurrenceStore) RecordDelivery(ctx context.Context, d *domain.ChannelDelivery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	d.ID = s.nextDelivery
+	s.nextDelivery++
+	copied := *d
+	s.deliveries[d.OccurrenceID] = append(s.deliveries[d.OccurrenceID], &copied)
+	return nil
+}
+
+func (s *InMemoryOccurrenceStore) ListDeliveries(ctx context.Context, occurrenceID int64) ([]*domain.ChannelDelivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	list := s.deliveries[occurrenceID]
+	out := make([]*domain.ChannelDelivery, 0, len(list))
+	for _, d := range list {
+		copied := *d
+		out = append(out, &copied)
+	}
+	return out, nil
+}
+
 func (s *InMemoryOccurrenceStore) DeleteByReminder(ctx context.Context, reminderID int64) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -106,11 +297,65 @@ func (s *InMemoryOccurrenceStore) DeleteByReminder(ctx context.Context, reminder
 	for id, occ := range s.byID {
 		if occ.ReminderID == reminderID {
 			delete(s.byID, id)
+			delete(s.confirmations, id)
+			delete(s.deliveries, id)
 		}
 	}
 	return nil
 }
 
+type occurrenceSnapshot struct {
+	nextID        int64
+	byID          map[int64]*domain.Occurrence
+	confirmations map[int64][]*domain.Confirmation
+	deliveries    map[int64][]*domain.ChannelDelivery
+	nextDelivery  int64
+}
+
+func (s *InMemoryOccurrenceStore) snapshot() occurrenceSnapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snap := occurrenceSnapshot{
+		nextID:        s.nextID,
+		byID:          make(map[int64]*domain.Occurrence, len(s.byID)),
+		confirmations: make(map[int64][]*domain.Confirmation, len(s.confirmations)),
+		deliveries:    make(map[int64][]*domain.ChannelDelivery, len(s.deliveries)),
+		nextDelivery:  s.nextDelivery,
+	}
+	for id, occ := range s.byID {
+		snap.byID[id] = cloneOccurrence(occ)
+	}
+	for id, list := range s.confirmations {
+		snap.confirmations[id] = append([]*domain.Confirmation(nil), list...)
+	}
+	for id, list := range s.deliveries {
+		snap.deliveries[id] = append([]*domain.ChannelDelivery(nil), list...)
+	}
+	return snap
+}
+
+func (s *InMemoryOccurrenceStore) restore(snap occurrenceSnapshot) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextID = snap.nextID
+	s.byID = snap.byID
+	s.confirmations = snap.confirmations
+	s.deliveries = snap.deliveries
+	s.nextDelivery = snap.nextDelivery
+}
+
+// sortOccurrences orders occurrences by fire time, then ID, matching the SQL stores.
+func sortOccurrences(occs []*domain.Occurrence) {
+	sort.Slice(occs, func(i, j int) bool {
+		if !occs[i].FireAtUtc.Equal(occs[j].FireAtUtc) {
+			return occs[i].FireAtUtc.Before(occs[j].FireAtUtc)
+		}
+		return occs[i].ID < occs[j].ID
+	})
+}
+
 func cloneOccurrence(occ *domain.Occurrence) *domain.Occurrence {
 	c := *occ
 	return &c
@@ -105,10 +105,48 @@ func (s *InMemoryReminderStore) DeleteByID(ctx context.Context, id int64) error
 	return nil
 }
 
+type reminderSnapshot struct {
+	nextID int64
+	byID   map[int64]*domain.Reminder
+	byUser map[int64][]*domain.Reminder
+}
+
+func (s *InMemoryReminderStore) snapshot() reminderSnapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snap := reminderSnapshot{
+		nextID: s.nextID,
+		byID:   make(map[int64]*domain.Reminder, len(s.byID)),
+		byUser: make(map[int64][]*domain.Reminder, len(s.byUser)),
+	}
+	for id, r := range s.byID {
+		snap.byID[id] = cloneReminder(r)
+	}
+	for userID, rs := range s.byUser {
+		for _, r := range rs {
+			snap.byUser[userID] = append(snap.byUser[userID], cloneReminder(r))
+		}
+	}
+	return snap
+}
+
+func (s *InMemoryReminderStore) restore(snap reminderSnapshot) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextID = snap.nextID
+	s.byID = snap.byID
+	s.byUser = snap.byUser
+}
+
 func cloneReminder(r *domain.Reminder) *domain.Reminder {
 	c := *r
 	if r.TimesOfDay != nil {
 		c.TimesOfDay = append([]domain.TimeOfDay{}, r.TimesOfDay...)
 	}
+	if r.Escalations != nil {
+		c.Escalations = append([]domain.EscalationStep{}, r.Escalations...)
+	}
 	return &c
 }
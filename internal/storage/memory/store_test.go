@@ -0,0 +1,26 @@
+package memory
+
+import (
+	"testing"
+
+	"naggingbot/internal/storage/storetest"
+)
+
+func TestConformance(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) storetest.Stores {
+		users := NewInMemoryUserStore()
+		reminders := NewInMemoryReminderStore()
+		occurrences := NewInMemoryOccurrenceStore()
+		invites := NewInMemoryInviteStore()
+		return storetest.Stores{
+			Users:       users,
+			Reminders:   reminders,
+			Occurrences: occurrences,
+			UnitOfWork:  NewUnitOfWork(users, reminders, occurrences, invites),
+			Digests:     NewInMemoryDigestStore(),
+			Invites:     invites,
+			Webhooks:    NewInMemoryWebhookStore(),
+			Emails:      NewInMemoryEmailStore(),
+		}
+	})
+}
@@ -0,0 +1,53 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"naggingbot/internal/domain"
+)
+
+// UnitOfWork implements domain.UnitOfWork for the in-memory stores.
+// Units of work are serialized; each one snapshots the stores up front and
+// restores the snapshot if fn fails. Writes made outside a unit of work while
+// one is running are not isolated and may be lost on rollback.
+type UnitOfWork struct {
+	mu          sync.Mutex
+	users       *InMemoryUserStore
+	reminders   *InMemoryReminderStore
+	occurrences *InMemoryOccurrenceStore
+	invites     *InMemoryInviteStore
+}
+
+// NewUnitOfWork constructs a unit of work over the given stores.
+func NewUnitOfWork(users *InMemoryUserStore, reminders *InMemoryReminderStore, occurrences *InMemoryOccurrenceStore, invites *InMemoryInviteStore) *UnitOfWork {
+	return &UnitOfWork{users: users, reminders: reminders, occurrences: occurrences, invites: invites}
+}
+
+// Do runs fn against the wrapped stores and rolls every store back to its
+// previous state when fn returns an error or panics.
+func (u *UnitOfWork) Do(ctx context.Context, fn func(ctx context.Context, stores domain.Stores) error) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	users := u.users.snapshot()
+	reminders := u.reminders.snapshot()
+	occurrences := u.occurrences.snapshot()
+	invites := u.invites.snapshot()
+
+	committed := false
+	defer func() {
+		if !committed {
+			u.users.restore(users)
+			u.reminders.restore(reminders)
+			u.occurrences.restore(occurrences)
+			u.invites.restore(invites)
+		}
+	}()
+
+	if err := fn(ctx, domain.Stores{Users: u.users, Reminders: u.reminders, Occurrences: u.occurrences, Invites: u.invites}); err != nil {
+		return err
+	}
+	committed = true
+	return nil
+}
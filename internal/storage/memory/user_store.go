@@ -2,6 +2,8 @@ package memory
 
 import (
 	"context"
+	"sort"
+	"strings"
 	"sync"
 
 	"naggingbot/internal/domain"
@@ -46,23 +48,169 @@ func (s *InMemoryUserStore) GetByTelegramID(ctx context.Context, telegramID int6
 	return cloneUser(u), nil
 }
 
+func (s *InMemoryUserStore) GetByUsername(ctx context.Context, username string) (*domain.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	username = strings.TrimPrefix(username, "@")
+	if username == "" {
+		return nil, nil
+	}
+	var found *domain.User
+	for _, u := range s.byID {
+		if strings.EqualFold(u.Username, username) && (found == nil || u.ID < found.ID) {
+			found = u
+		}
+	}
+	if found == nil {
+		return nil, nil
+	}
+	return cloneUser(found), nil
+}
+
+func (s *InMemoryUserStore) List(ctx context.Context) ([]*domain.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := make([]*domain.User, 0, len(s.byID))
+	for _, u := range s.byID {
+		out = append(out, cloneUser(u))
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out, nil
+}
+
 func (s *InMemoryUserStore) Upsert(ctx context.Context, user *domain.User) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	// Assign new ID if needed.
-	if user.ID == 0 {
+	// Refresh profile fields of an existing Telegram user, keeping its ID and
+	// settings; otherwise assign a new ID.
+	if existing, ok := s.byTGID[user.TelegramID]; ok && user.TelegramID != 0 {
+		updated := cloneUser(existing)
+		updated.Username = user.Username
+		updated.FirstName = user.FirstName
+		updated.LastName = user.LastName
+		updated.Language = user.Language
+		*user = *updated
+	} else if user.ID == 0 {
 		user.ID = s.nextID
 		s.nextID++
 	}
 
-	// Overwrite existing entries.
+	s.put(user)
+	return nil
+}
+
+func (s *InMemoryUserStore) SetTimeZone(ctx context.Context, id int64, timeZone string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, ok := s.byID[id]
+	if !ok {
+		return nil
+	}
+	updated := cloneUser(u)
+	updated.TimeZone = timeZone
+	s.put(updated)
+	return nil
+}
+
+func (s *InMemoryUserStore) SetLocale(ctx context.Context, id int64, locale string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, ok := s.byID[id]
+	if !ok {
+		return nil
+	}
+	updated := cloneUser(u)
+	updated.Locale = locale
+	s.put(updated)
+	return nil
+}
+
+func (s *InMemoryUserStore) SetRegistered(ctx context.Context, id int64, registered bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, ok := s.byID[id]
+	if !ok {
+		return nil
+	}
+	updated := cloneUser(u)
+	updated.Registered = registered
+	s.put(updated)
+	return nil
+}
+
+func (s *InMemoryUserStore) SetBanned(ctx context.Context, id int64, banned bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, ok := s.byID[id]
+	if !ok {
+		return nil
+	}
+	updated := cloneUser(u)
+	updated.Banned = banned
+	s.put(updated)
+	return nil
+}
+
+func (s *InMemoryUserStore) SetChannels(ctx context.Context, id int64, channels domain.Channels) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, ok := s.byID[id]
+	if !ok {
+		return nil
+	}
+	updated := cloneUser(u)
+	updated.Channels = channels
+	s.put(updated)
+	return nil
+}
+
+// put stores a copy of the user in both indexes. Callers must hold s.mu.
+func (s *InMemoryUserStore) put(user *domain.User) {
 	s.byID[user.ID] = cloneUser(user)
 	if user.TelegramID != 0 {
 		s.byTGID[user.TelegramID] = cloneUser(user)
 	}
+}
 
-	return nil
+type userSnapshot struct {
+	nextID int64
+	byID   map[int64]*domain.User
+	byTGID map[int64]*domain.User
+}
+
+func (s *InMemoryUserStore) snapshot() userSnapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snap := userSnapshot{
+		nextID: s.nextID,
+		byID:   make(map[int64]*domain.User, len(s.byID)),
+		byTGID: make(map[int64]*domain.User, len(s.byTGID)),
+	}
+	for id, u := range s.byID {
+		snap.byID[id] = cloneUser(u)
+	}
+	for id, u := range s.byTGID {
+		snap.byTGID[id] = cloneUser(u)
+	}
+	return snap
+}
+
+func (s *InMemoryUserStore) restore(snap userSnapshot) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextID = snap.nextID
+	s.byID = snap.byID
+	s.byTGID = snap.byTGID
 }
 
 func cloneUser(u *domain.User) *domain.User {
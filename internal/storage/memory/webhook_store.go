@@ -0,0 +1,141 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"naggingbot/internal/domain"
+)
+
+// InMemoryWebhookStore is an in-memory implementation of domain.WebhookStore.
+type InMemoryWebhookStore struct {
+	mu         sync.Mutex
+	byUser     map[int64]*domain.WebhookSettings
+	deliveries map[int64][]*domain.WebhookDelivery
+	jobs       map[int64]*domain.WebhookJob
+	nextID     int64
+}
+
+// NewInMemoryWebhookStore constructs an empty webhook store.
+func NewInMemoryWebhookStore() *InMemoryWebhookStore {
+	return &InMemoryWebhookStore{
+		byUser:     make(map[int64]*domain.WebhookSettings),
+		deliveries: make(map[int64][]*domain.WebhookDelivery),
+		jobs:       make(map[int64]*domain.WebhookJob),
+		nextID:     1,
+	}
+}
+
+func (s *InMemoryWebhookStore) Get(ctx context.Context, userID int64) (*domain.WebhookSettings, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	w, ok := s.byUser[userID]
+	if !ok {
+		return nil, nil
+	}
+	c := *w
+	return &c, nil
+}
+
+func (s *InMemoryWebhookStore) Save(ctx context.Context, w *domain.WebhookSettings) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c := *w
+	s.byUser[w.UserID] = &c
+	return nil
+}
+
+func (s *InMemoryWebhookStore) Delete(ctx context.Context, userID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.byUser, userID)
+	return nil
+}
+
+func (s *InMemoryWebhookStore) RecordDelivery(ctx context.Context, d *domain.WebhookDelivery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	d.ID = s.nextID
+	s.nextID++
+	c := *d
+	list := append(s.deliveries[d.UserID], &c)
+	if len(list) > domain.MaxWebhookDeliveries {
+		list = append([]*domain.WebhookDelivery(nil), list[len(list)-domain.MaxWebhookDeliveries:]...)
+	}
+	s.deliveries[d.UserID] = list
+	return nil
+}
+
+func (s *InMemoryWebhookStore) ListDeliveries(ctx context.Context, userID int64, limit int) ([]*domain.WebhookDelivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	list := s.deliveries[userID]
+	var out []*domain.WebhookDelivery
+	for i := len(list) - 1; i >= 0 && len(out) < limit; i-- {
+		c := *list[i]
+		out = append(out, &c)
+	}
+	return out, nil
+}
+
+func (s *InMemoryWebhookStore) Enqueue(ctx context.Context, job *domain.WebhookJob) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job.ID = s.nextID
+	s.nextID++
+	c := *job
+	c.Body = append([]byte(nil), job.Body...)
+	s.jobs[job.ID] = &c
+	return nil
+}
+
+func (s *InMemoryWebhookStore) ListDueJobs(ctx context.Context, at time.Time, limit int) ([]*domain.WebhookJob, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var out []*domain.WebhookJob
+	for _, j := range s.jobs {
+		if !j.NextAtUtc.After(at) {
+			c := *j
+			c.Body = append([]byte(nil), j.Body...)
+			out = append(out, &c)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].NextAtUtc.Equal(out[j].NextAtUtc) {
+			return out[i].NextAtUtc.Before(out[j].NextAtUtc)
+		}
+		return out[i].ID < out[j].ID
+	})
+	if len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+func (s *InMemoryWebhookStore) RetryJob(ctx context.Context, id int64, attempts int, nextAtUTC time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if j, ok := s.jobs[id]; ok {
+		j.Attempts = attempts
+		j.NextAtUtc = nextAtUTC
+	}
+	return nil
+}
+
+func (s *InMemoryWebhookStore) DeleteJob(ctx context.Context, id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.jobs, id)
+	return nil
+}
@@ -0,0 +1,24 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// busyTimeoutMS makes a connection wait for a concurrent transaction (e.g. the
+// scheduler updating statuses while a handler commits) instead of failing
+// immediately with SQLITE_BUSY.
+const busyTimeoutMS = 5000
+
+// Open opens the SQLite database at path with the connection pragmas the stores rely on.
+func Open(path string) (*sql.DB, error) {
+	dsn := fmt.Sprintf("%s?_pragma=busy_timeout(%d)", path, busyTimeoutMS)
+	return sql.Open("sqlite", dsn)
+}
+
+// Vacuum rebuilds the database file, reclaiming space left by deleted rows.
+func Vacuum(ctx context.Context, db *sql.DB) error {
+	_, err := db.ExecContext(ctx, "VACUUM")
+	return err
+}
@@ -0,0 +1,131 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"naggingbot/internal/domain"
+)
+
+const digestColumns = `user_id, morning, evening, weekly, last_morning_utc, last_evening_utc, last_weekly_utc`
+
+// DigestStore implements domain.DigestStore backed by SQLite.
+type DigestStore struct {
+	db dbtx
+}
+
+func NewDigestStore(db *sql.DB) *DigestStore {
+	return &DigestStore{db: db}
+}
+
+func (s *DigestStore) Get(ctx context.Context, userID int64) (*domain.DigestSettings, error) {
+	row := s.db.QueryRowContext(ctx, `
+		SELECT `+digestColumns+`
+		FROM digests WHERE user_id = ?`, userID)
+
+	return scanDigest(row)
+}
+
+func (s *DigestStore) ListEnabled(ctx context.Context) ([]*domain.DigestSettings, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+digestColumns+`
+		FROM digests
+		WHERE morning IS NOT NULL OR evening IS NOT NULL OR weekly = 1
+		ORDER BY user_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []*domain.DigestSettings
+	for rows.Next() {
+		d, err := scanDigest(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (s *DigestStore) Save(ctx context.Context, d *domain.DigestSettings) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO digests (`+digestColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(user_id) DO UPDATE SET
+			morning = excluded.morning,
+			evening = excluded.evening,
+			weekly = excluded.weekly,
+			last_morning_utc = excluded.last_morning_utc,
+			last_evening_utc = excluded.last_evening_utc,
+			last_weekly_utc = excluded.last_weekly_utc`,
+		d.UserID, formatTimeOfDay(d.Morning), formatTimeOfDay(d.Evening), boolToInt(d.Weekly),
+		nullTime(d.LastMorningUtc), nullTime(d.LastEveningUtc), nullTime(d.LastWeeklyUtc))
+	return err
+}
+
+func (s *DigestStore) MarkSent(ctx context.Context, userID int64, kind domain.DigestKind, sentAtUTC time.Time) error {
+	var column string
+	switch kind {
+	case domain.DigestMorning:
+		column = "last_morning_utc"
+	case domain.DigestEvening:
+		column = "last_evening_utc"
+	case domain.DigestWeekly:
+		column = "last_weekly_utc"
+	default:
+		return fmt.Errorf("unknown digest kind %q", kind)
+	}
+	_, err := s.db.ExecContext(ctx, `UPDATE digests SET `+column+` = ? WHERE user_id = ?`, sentAtUTC, userID)
+	return err
+}
+
+func scanDigest(scanner interface {
+	Scan(dest ...any) error
+}) (*domain.DigestSettings, error) {
+	var d domain.DigestSettings
+	var morning, evening sql.NullString
+	var lastMorning, lastEvening, lastWeekly sql.NullTime
+	if err := scanner.Scan(&d.UserID, &morning, &evening, &d.Weekly, &lastMorning, &lastEvening, &lastWeekly); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var err error
+	if d.Morning, err = parseTimeOfDay(morning); err != nil {
+		return nil, err
+	}
+	if d.Evening, err = parseTimeOfDay(evening); err != nil {
+		return nil, err
+	}
+	d.LastMorningUtc = lastMorning.Time
+	d.LastEveningUtc = lastEvening.Time
+	d.LastWeeklyUtc = lastWeekly.Time
+	return &d, nil
+}
+
+// formatTimeOfDay stores a time of day as "HH:MM", or NULL when unset.
+func formatTimeOfDay(t *domain.TimeOfDay) sql.NullString {
+	if t == nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: fmt.Sprintf("%02d:%02d", t.Hour, t.Minute), Valid: true}
+}
+
+func parseTimeOfDay(s sql.NullString) (*domain.TimeOfDay, error) {
+	if !s.Valid || s.String == "" {
+		return nil, nil
+	}
+	var t domain.TimeOfDay
+	if _, err := fmt.Sscanf(s.String, "%d:%d", &t.Hour, &t.Minute); err != nil {
+		return nil, fmt.Errorf("parse time of day %q: %w", s.String, err)
+	}
+	return &t, nil
+}
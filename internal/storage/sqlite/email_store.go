@@ -0,0 +1,54 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+
+	"naggingbot/internal/domain"
+)
+
+// EmailStore implements domain.EmailStore backed by SQLite.
+type EmailStore struct {
+	db dbtx
+}
+
+func NewEmailStore(db *sql.DB) *EmailStore {
+	return &EmailStore{db: db}
+}
+
+func (s *EmailStore) Get(ctx context.Context, userID int64) (*domain.EmailAddress, error) {
+	var e domain.EmailAddress
+	var expiresAt, verifiedAt sql.NullTime
+	err := s.db.QueryRowContext(ctx, `
+		SELECT user_id, address, code, code_expires_at_utc, code_attempts, verified_at_utc
+		FROM emails WHERE user_id = ?`, userID).
+		Scan(&e.UserID, &e.Address, &e.Code, &expiresAt, &e.CodeAttempts, &verifiedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	e.CodeExpiresAtUtc = expiresAt.Time
+	e.VerifiedAtUtc = verifiedAt.Time
+	return &e, nil
+}
+
+func (s *EmailStore) Save(ctx context.Context, e *domain.EmailAddress) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO emails (user_id, address, code, code_expires_at_utc, code_attempts, verified_at_utc)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(user_id) DO UPDATE SET
+			address = excluded.address,
+			code = excluded.code,
+			code_expires_at_utc = excluded.code_expires_at_utc,
+			code_attempts = excluded.code_attempts,
+			verified_at_utc = excluded.verified_at_utc`,
+		e.UserID, e.Address, e.Code, nullTime(e.CodeExpiresAtUtc), e.CodeAttempts, nullTime(e.VerifiedAtUtc))
+	return err
+}
+
+func (s *EmailStore) Delete(ctx context.Context, userID int64) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM emails WHERE user_id = ?`, userID)
+	return err
+}
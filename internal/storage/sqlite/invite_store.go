@@ -0,0 +1,103 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"naggingbot/internal/domain"
+)
+
+const inviteColumns = `code, created_by, created_at_utc, expires_at_utc, max_uses, uses, revoked_at_utc`
+
+// usableInvite is the SQL form of domain.Invite.Usable; it binds the time once.
+const usableInvite = `revoked_at_utc IS NULL
+	AND (expires_at_utc IS NULL OR expires_at_utc > ?)
+	AND (max_uses = 0 OR uses < max_uses)`
+
+// InviteStore implements domain.InviteStore backed by SQLite.
+type InviteStore struct {
+	db dbtx
+}
+
+func NewInviteStore(db *sql.DB) *InviteStore {
+	return &InviteStore{db: db}
+}
+
+func (s *InviteStore) Create(ctx context.Context, inv *domain.Invite) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO invites (`+inviteColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		inv.Code, inv.CreatedBy, inv.CreatedAtUtc, nullTime(inv.ExpiresAtUtc), inv.MaxUses, inv.Uses, nullTime(inv.RevokedAtUtc))
+	return err
+}
+
+func (s *InviteStore) Get(ctx context.Context, code string) (*domain.Invite, error) {
+	row := s.db.QueryRowContext(ctx, `
+		SELECT `+inviteColumns+`
+		FROM invites WHERE code = ?`, code)
+
+	return scanInvite(row)
+}
+
+func (s *InviteStore) ListUsable(ctx context.Context, at time.Time) ([]*domain.Invite, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+inviteColumns+`
+		FROM invites
+		WHERE `+usableInvite+`
+		ORDER BY created_at_utc, code`, at)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []*domain.Invite
+	for rows.Next() {
+		inv, err := scanInvite(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, inv)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (s *InviteStore) Redeem(ctx context.Context, code string, at time.Time) (bool, error) {
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE invites SET uses = uses + 1
+		WHERE code = ? AND `+usableInvite, code, at)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+func (s *InviteStore) Revoke(ctx context.Context, code string, revokedAtUTC time.Time) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE invites SET revoked_at_utc = ?
+		WHERE code = ? AND revoked_at_utc IS NULL`, revokedAtUTC, code)
+	return err
+}
+
+func scanInvite(scanner interface {
+	Scan(dest ...any) error
+}) (*domain.Invite, error) {
+	var inv domain.Invite
+	var expires, revoked sql.NullTime
+	if err := scanner.Scan(&inv.Code, &inv.CreatedBy, &inv.CreatedAtUtc, &expires, &inv.MaxUses, &inv.Uses, &revoked); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	inv.ExpiresAtUtc = expires.Time
+	inv.RevokedAtUtc = revoked.Time
+	return &inv, nil
+}
@@ -45,20 +45,146 @@ CREATE INDEX idx_occurrence_reminder ON occurrences(reminder_id);
 CREATE INDEX idx_occurrence_fire_at ON occurrences(fire_at_utc);
 `
 
-// EnsureDB creates the SQLite database and applies initial schema if the file does not exist.
-// If the file already exists, nothing is applied.
+// migrations upgrade the schema step by step; migrations[i] moves the
+// database from user_version i to i+1. Append new steps, never edit old ones.
+var migrations = []string{
+	schema,
+	`
+ALTER TABLE occurrences ADD COLUMN sent_at_utc DATETIME;
+ALTER TABLE occurrences ADD COLUMN acked_at_utc DATETIME;
+ALTER TABLE occurrences ADD COLUMN attempts INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE occurrences ADD COLUMN chat_id INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE occurrences ADD COLUMN message_id INTEGER NOT NULL DEFAULT 0;
+`,
+	`
+ALTER TABLE users ADD COLUMN time_zone TEXT NOT NULL DEFAULT '';
+`,
+	`
+CREATE TABLE digests (
+    user_id INTEGER PRIMARY KEY,
+    morning TEXT,
+    evening TEXT,
+    weekly INTEGER NOT NULL DEFAULT 0,
+    last_morning_utc DATETIME,
+    last_evening_utc DATETIME,
+    last_weekly_utc DATETIME,
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+`,
+	`
+ALTER TABLE users ADD COLUMN banned INTEGER NOT NULL DEFAULT 0;
+`,
+	`
+ALTER TABLE users ADD COLUMN registered INTEGER NOT NULL DEFAULT 0;
+UPDATE users SET registered = 1;
+CREATE TABLE invites (
+    code TEXT PRIMARY KEY,
+    created_by INTEGER NOT NULL,
+    created_at_utc DATETIME NOT NULL,
+    expires_at_utc DATETIME,
+    max_uses INTEGER NOT NULL DEFAULT 0,
+    uses INTEGER NOT NULL DEFAULT 0,
+    revoked_at_utc DATETIME
+);
+`,
+	`
+ALTER TABLE reminders ADD COLUMN priority INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE reminders ADD COLUMN template TEXT NOT NULL DEFAULT '';
+`,
+	`
+ALTER TABLE users ADD COLUMN locale TEXT NOT NULL DEFAULT '';
+`,
+	`
+ALTER TABLE reminders ADD COLUMN chat_id INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE reminders ADD COLUMN confirm_mode INTEGER NOT NULL DEFAULT 0;
+CREATE TABLE confirmations (
+    occurrence_id INTEGER NOT NULL,
+    telegram_id INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    at_utc DATETIME NOT NULL,
+    PRIMARY KEY (occurrence_id, telegram_id),
+    FOREIGN KEY (occurrence_id) REFERENCES occurrences(id)
+);
+`,
+	`
+ALTER TABLE reminders ADD COLUMN recipient_id INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE reminders ADD COLUMN recipient_accepted INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE reminders ADD COLUMN escalate_after_min INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE occurrences ADD COLUMN escalated_at_utc DATETIME;
+`,
+	`
+ALTER TABLE reminders ADD COLUMN escalations TEXT;
+ALTER TABLE occurrences ADD COLUMN escalation_step INTEGER NOT NULL DEFAULT 0;
+`,
+	`
+ALTER TABLE reminders ADD COLUMN webhook_url TEXT NOT NULL DEFAULT '';
+CREATE TABLE webhooks (
+    user_id INTEGER PRIMARY KEY,
+    url TEXT NOT NULL DEFAULT '',
+    secret TEXT NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+CREATE TABLE webhook_deliveries (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    occurrence_id INTEGER NOT NULL,
+    event TEXT NOT NULL,
+    url TEXT NOT NULL,
+    attempt INTEGER NOT NULL,
+    status_code INTEGER NOT NULL DEFAULT 0,
+    error TEXT NOT NULL DEFAULT '',
+    duration_ms INTEGER NOT NULL DEFAULT 0,
+    at_utc DATETIME NOT NULL
+);
+CREATE INDEX idx_webhook_deliveries_user ON webhook_deliveries(user_id, id);
+`,
+	`
+CREATE TABLE emails (
+    user_id INTEGER PRIMARY KEY,
+    address TEXT NOT NULL,
+    code TEXT NOT NULL DEFAULT '',
+    code_expires_at_utc DATETIME,
+    code_attempts INTEGER NOT NULL DEFAULT 0,
+    verified_at_utc DATETIME,
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+`,
+	`
+ALTER TABLE reminders ADD COLUMN channels INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE users ADD COLUMN channels INTEGER NOT NULL DEFAULT 0;
+CREATE TABLE channel_deliveries (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    occurrence_id INTEGER NOT NULL,
+    channel INTEGER NOT NULL,
+    required INTEGER NOT NULL,
+    error TEXT NOT NULL DEFAULT '',
+    at_utc DATETIME NOT NULL,
+    FOREIGN KEY (occurrence_id) REFERENCES occurrences(id)
+);
+CREATE INDEX idx_channel_deliveries_occurrence ON channel_deliveries(occurrence_id, id);
+`,
+	`
+CREATE TABLE webhook_jobs (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    occurrence_id INTEGER NOT NULL,
+    event TEXT NOT NULL,
+    url TEXT NOT NULL,
+    body BLOB NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_at_utc DATETIME NOT NULL
+);
+CREATE INDEX idx_webhook_jobs_next ON webhook_jobs(next_at_utc);
+`,
+}
+
+// EnsureDB creates the SQLite database if the file does not exist and applies
+// any migrations newer than the database's user_version.
 func EnsureDB(ctx context.Context, path string) error {
 	if path == "" {
 		return fmt.Errorf("db path is empty")
 	}
 
-	// If DB file exists, skip migrations.
-	if _, err := os.Stat(path); err == nil {
-		return nil
-	} else if !os.IsNotExist(err) {
-		return err
-	}
-
 	// Ensure directory exists.
 	if dir := filepath.Dir(path); dir != "" && dir != "." {
 		if err := os.MkdirAll(dir, 0o755); err != nil {
@@ -76,9 +202,64 @@ func EnsureDB(ctx context.Context, path string) error {
 		return fmt.Errorf("ping sqlite db: %w", err)
 	}
 
-	if _, err := db.ExecContext(ctx, schema); err != nil {
-		return fmt.Errorf("apply schema: %w", err)
+	return migrate(ctx, db)
+}
+
+func migrate(ctx context.Context, db *sql.DB) error {
+	version, err := schemaVersion(ctx, db)
+	if err != nil {
+		return err
 	}
 
+	for i := version; i < len(migrations); i++ {
+		tx, err := db.BeginTx(ctx, nil)
+		if err != nil {
+			return fmt.Errorf("migration %d: begin: %w", i+1, err)
+		}
+		if _, err := tx.ExecContext(ctx, migrations[i]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %d: apply: %w", i+1, err)
+		}
+		// PRAGMA does not accept bound parameters.
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf("PRAGMA user_version = %d", i+1)); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %d: set version: %w", i+1, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("migration %d: commit: %w", i+1, err)
+		}
+	}
 	return nil
 }
+
+// LatestSchemaVersion is the version EnsureDB migrates to.
+func LatestSchemaVersion() int {
+	return len(migrations)
+}
+
+// SchemaVersion reports the schema version of an open database.
+func SchemaVersion(ctx context.Context, db *sql.DB) (int, error) {
+	return schemaVersion(ctx, db)
+}
+
+// schemaVersion reads PRAGMA user_version. Databases created before versioning
+// have version 0 but already contain the initial schema, so they report 1.
+func schemaVersion(ctx context.Context, db *sql.DB) (int, error) {
+	var version int
+	if err := db.QueryRowContext(ctx, "PRAGMA user_version").Scan(&version); err != nil {
+		return 0, fmt.Errorf("read schema version: %w", err)
+	}
+	if version > 0 {
+		return version, nil
+	}
+
+	var tables int
+	if err := db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'users'`).Scan(&tables); err != nil {
+		return 0, fmt.Errorf("inspect schema: %w", err)
+	}
+	if tables > 0 {
+		return 1, nil
+	}
+	return 0, nil
+}
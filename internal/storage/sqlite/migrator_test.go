@@ -0,0 +1,61 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"naggingbot/internal/domain"
+)
+
+func TestEnsureDBUpgradesUnversionedDatabase(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "legacy.db")
+
+	// Simulate a database created before migrations were versioned.
+	legacy, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatalf("open legacy db: %v", err)
+	}
+	if _, err := legacy.ExecContext(ctx, schema); err != nil {
+		t.Fatalf("apply legacy schema: %v", err)
+	}
+	if _, err := legacy.ExecContext(ctx, `
+		INSERT INTO occurrences (reminder_id, fire_at_utc, status) VALUES (1, ?, 0)`,
+		time.Date(2026, 1, 19, 8, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatalf("insert legacy occurrence: %v", err)
+	}
+	legacy.Close()
+
+	if err := EnsureDB(ctx, path); err != nil {
+		t.Fatalf("ensure db: %v", err)
+	}
+	// Running again must be a no-op.
+	if err := EnsureDB(ctx, path); err != nil {
+		t.Fatalf("ensure db twice: %v", err)
+	}
+
+	db, err := Open(path)
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer db.Close()
+
+	var version int
+	if err := db.QueryRowContext(ctx, "PRAGMA user_version").Scan(&version); err != nil {
+		t.Fatalf("read version: %v", err)
+	}
+	if version != len(migrations) {
+		t.Fatalf("user_version = %d, want %d", version, len(migrations))
+	}
+
+	occ, err := NewOccurrenceStore(db).GetByID(ctx, 1)
+	if err != nil || occ == nil {
+		t.Fatalf("get legacy occurrence = (%v, %v)", occ, err)
+	}
+	if occ.Status != domain.OccurrenceCreated || occ.Attempts != 0 || !occ.SentAtUtc.IsZero() {
+		t.Fatalf("legacy occurrence after upgrade: %+v", occ)
+	}
+}
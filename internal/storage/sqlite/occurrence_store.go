@@ -3,14 +3,18 @@ package sqlite
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"strings"
 	"time"
 
 	"naggingbot/internal/domain"
 )
 
+const occurrenceColumns = `id, reminder_id, fire_at_utc, status, sent_at_utc, acked_at_utc, attempts, chat_id, message_id, escalated_at_utc, escalation_step`
+
 // OccurrenceStore implements domain.OccurrenceStore backed by SQLite.
 type OccurrenceStore struct {
-	db *sql.DB
+	db dbtx
 }
 
 func NewOccurrenceStore(db *sql.DB) *OccurrenceStore {
@@ -19,74 +23,74 @@ func NewOccurrenceStore(db *sql.DB) *OccurrenceStore {
 
 func (s *OccurrenceStore) GetByID(ctx context.Context, id int64) (*domain.Occurrence, error) {
 	row := s.db.QueryRowContext(ctx, `
-		SELECT id, reminder_id, fire_at_utc, status
+		SELECT `+occurrenceColumns+`
 		FROM occurrences WHERE id = ?`, id)
 
-	var occ domain.Occurrence
-	if err := row.Scan(&occ.ID, &occ.ReminderID, &occ.FireAtUtc, &occ.Status); err != nil {
-		if err == sql.ErrNoRows {
-			return nil, nil
-		}
-		return nil, err
-	}
-	return &occ, nil
+	return scanOccurrence(row)
 }
 
 func (s *OccurrenceStore) ListByReminder(ctx context.Context, reminderID int64) ([]*domain.Occurrence, error) {
 	rows, err := s.db.QueryContext(ctx, `
-		SELECT id, reminder_id, fire_at_utc, status
-		FROM occurrences WHERE reminder_id = ?`, reminderID)
+		SELECT `+occurrenceColumns+`
+		FROM occurrences WHERE reminder_id = ?
+		ORDER BY fire_at_utc, id`, reminderID)
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
+	return scanOccurrences(rows)
+}
 
-	var out []*domain.Occurrence
-	for rows.Next() {
-		var occ domain.Occurrence
-		if err := rows.Scan(&occ.ID, &occ.ReminderID, &occ.FireAtUtc, &occ.Status); err != nil {
-			return nil, err
-		}
-		out = append(out, &occ)
-	}
-	if err := rows.Err(); err != nil {
+func (s *OccurrenceStore) ListByReminderInRange(ctx context.Context, reminderID int64, startUTC, endUTC time.Time) ([]*domain.Occurrence, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+occurrenceColumns+`
+		FROM occurrences
+		WHERE reminder_id = ?
+		  AND fire_at_utc >= ?
+		  AND fire_at_utc <= ?
+		ORDER BY fire_at_utc, id`,
+		reminderID, startUTC, endUTC)
+	if err != nil {
 		return nil, err
 	}
-	return out, nil
+	return scanOccurrences(rows)
 }
 
 func (s *OccurrenceStore) ListPendingInRange(ctx context.Context, startUTC, endUTC time.Time) ([]*domain.Occurrence, error) {
 	rows, err := s.db.QueryContext(ctx, `
-		SELECT id, reminder_id, fire_at_utc, status
+		SELECT `+occurrenceColumns+`
 		FROM occurrences
 		WHERE status = ?
 		  AND fire_at_utc >= ?
-		  AND fire_at_utc <= ?`,
+		  AND fire_at_utc <= ?
+		ORDER BY fire_at_utc, id`,
 		domain.OccurrenceCreated, startUTC, endUTC)
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
+	return scanOccurrences(rows)
+}
 
-	var out []*domain.Occurrence
-	for rows.Next() {
-		var occ domain.Occurrence
-		if err := rows.Scan(&occ.ID, &occ.ReminderID, &occ.FireAtUtc, &occ.Status); err != nil {
-			return nil, err
-		}
-		out = append(out, &occ)
-	}
-	if err := rows.Err(); err != nil {
+func (s *OccurrenceStore) ListSentInRange(ctx context.Context, startUTC, endUTC time.Time) ([]*domain.Occurrence, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+occurrenceColumns+`
+		FROM occurrences
+		WHERE status = ?
+		  AND sent_at_utc >= ?
+		  AND sent_at_utc <= ?
+		ORDER BY fire_at_utc, id`,
+		domain.OccurrenceSent, startUTC, endUTC)
+	if err != nil {
 		return nil, err
 	}
-	return out, nil
+	return scanOccurrences(rows)
 }
 
+const insertOccurrence = `
+		INSERT INTO occurrences (reminder_id, fire_at_utc, status, sent_at_utc, acked_at_utc, attempts, chat_id, message_id, escalated_at_utc, escalation_step)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
 func (s *OccurrenceStore) Create(ctx context.Context, occ *domain.Occurrence) error {
-	res, err := s.db.ExecContext(ctx, `
-		INSERT INTO occurrences (reminder_id, fire_at_utc, status)
-		VALUES (?, ?, ?)`,
-		occ.ReminderID, occ.FireAtUtc, occ.Status)
+	res, err := s.db.ExecContext(ctx, insertOccurrence, occurrenceArgs(occ)...)
 	if err != nil {
 		return err
 	}
@@ -96,13 +100,227 @@ func (s *OccurrenceStore) Create(ctx context.Context, occ *domain.Occurrence) er
 	return nil
 }
 
+func (s *OccurrenceStore) CreateBatch(ctx context.Context, occs []*domain.Occurrence) error {
+	if len(occs) == 0 {
+		return nil
+	}
+
+	stmt, err := s.db.PrepareContext(ctx, insertOccurrence)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, occ := range occs {
+		res, err := stmt.ExecContext(ctx, occurrenceArgs(occ)...)
+		if err != nil {
+			return err
+		}
+		if id, err := res.LastInsertId(); err == nil {
+			occ.ID = id
+		}
+	}
+	return nil
+}
+
 func (s *OccurrenceStore) UpdateStatus(ctx context.Context, id int64, status domain.OccurrenceStatus) error {
+	return s.transition(ctx, id, status, `status = ?`, status)
+}
+
+func (s *OccurrenceStore) MarkSent(ctx context.Context, id int64, sentAtUTC time.Time) error {
+	return s.transition(ctx, id, domain.OccurrenceSent,
+		`status = ?, sent_at_utc = ?, attempts = attempts + 1`, domain.OccurrenceSent, sentAtUTC)
+}
+
+func (s *OccurrenceStore) IncrementAttempts(ctx context.Context, id int64) error {
 	_, err := s.db.ExecContext(ctx, `
-		UPDATE occurrences SET status = ? WHERE id = ?`, status, id)
+		UPDATE occurrences SET attempts = attempts + 1 WHERE id = ?`, id)
 	return err
 }
 
+func (s *OccurrenceStore) SetMessage(ctx context.Context, id int64, chatID, messageID int64) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE occurrences SET chat_id = ?, message_id = ? WHERE id = ?`, chatID, messageID, id)
+	return err
+}
+
+func (s *OccurrenceStore) MarkEscalated(ctx context.Context, id int64, escalatedAtUTC time.Time) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE occurrences SET escalated_at_utc = ? WHERE id = ?`, escalatedAtUTC, id)
+	return err
+}
+
+func (s *OccurrenceStore) AdvanceEscalation(ctx context.Context, id int64, step int) (bool, error) {
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE occurrences SET escalation_step = escalation_step + 1
+		WHERE id = ? AND escalation_step = ? AND status = ?`, id, step, domain.OccurrenceSent)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
+
+func (s *OccurrenceStore) MarkAcked(ctx context.Context, id int64, status domain.OccurrenceStatus, ackedAtUTC time.Time) error {
+	return s.transition(ctx, id, status, `status = ?, acked_at_utc = ?`, status, ackedAtUTC)
+}
+
+// transition applies the SET clause only if the occurrence may move to the
+// status, so concurrent updates cannot skip the state machine.
+func (s *OccurrenceStore) transition(ctx context.Context, id int64, to domain.OccurrenceStatus, set string, args ...any) error {
+	from := domain.TransitionSources(to)
+	if len(from) == 0 {
+		return s.rejectTransition(ctx, id, to)
+	}
+	query := `UPDATE occurrences SET ` + set + ` WHERE id = ? AND status IN (?` + strings.Repeat(`, ?`, len(from)-1) + `)`
+	args = append(args, id)
+	for _, st := range from {
+		args = append(args, st)
+	}
+	res, err := s.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil || n > 0 {
+		return err
+	}
+	return s.rejectTransition(ctx, id, to)
+}
+
+// rejectTransition returns a *domain.TransitionError, or nil if the
+// occurrence does not exist.
+func (s *OccurrenceStore) rejectTransition(ctx context.Context, id int64, to domain.OccurrenceStatus) error {
+	var current domain.OccurrenceStatus
+	err := s.db.QueryRowContext(ctx, `SELECT status FROM occurrences WHERE id = ?`, id).Scan(&current)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return &domain.TransitionError{ID: id, From: current, To: to}
+}
+
+func (s *OccurrenceStore) AddConfirmation(ctx context.Context, c *domain.Confirmation) (bool, error) {
+	res, err := s.db.ExecContext(ctx, `
+		INSERT OR IGNORE INTO confirmations (occurrence_id, telegram_id, name, at_utc)
+		VALUES (?, ?, ?, ?)`, c.OccurrenceID, c.TelegramID, c.Name, c.AtUtc)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+func (s *OccurrenceStore) ListConfirmations(ctx context.Context, occurrenceID int64) ([]*domain.Confirmation, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT occurrence_id, telegram_id, name, at_utc
+		FROM confirmations WHERE occurrence_id = ?
+		ORDER BY at_utc, rowid`, occurrenceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []*domain.Confirmation
+	for rows.Next() {
+		var c domain.Confirmation
+		if err := rows.Scan(&c.OccurrenceID, &c.TelegramID, &c.Name, &c.AtUtc); err != nil {
+			return nil, err
+		}
+		out = append(out, &c)
+	}
+	return out, rows.Err()
+}
+
+func (s *OccurrenceStore) RecordDelivery(ctx context.Context, d *domain.ChannelDelivery) error {
+	res, err := s.db.ExecContext(ctx, `
+		INSERT INTO channel_deliveries (occurrence_id, channel, required, error, at_utc)
+		VALUES (?, ?, ?, ?, ?)`, d.OccurrenceID, int(d.Channel), boolToInt(d.Required), d.Error, d.AtUtc)
+	if err != nil {
+		return err
+	}
+	if id, err := res.LastInsertId(); err == nil {
+		d.ID = id
+	}
+	return nil
+}
+
+func (s *OccurrenceStore) ListDeliveries(ctx context.Context, occurrenceID int64) ([]*domain.ChannelDelivery, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, occurrence_id, channel, required, error, at_utc
+		FROM channel_deliveries WHERE occurrence_id = ?
+		ORDER BY id`, occurrenceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []*domain.ChannelDelivery
+	for rows.Next() {
+		var d domain.ChannelDelivery
+		if err := rows.Scan(&d.ID, &d.OccurrenceID, &d.Channel, &d.Required, &d.Error, &d.AtUtc); err != nil {
+			return nil, err
+		}
+		out = append(out, &d)
+	}
+	return out, rows.Err()
+}
+
 func (s *OccurrenceStore) DeleteByReminder(ctx context.Context, reminderID int64) error {
+	for _, table := range []string{"confirmations", "channel_deliveries"} {
+		if _, err := s.db.ExecContext(ctx, `
+			DELETE FROM `+table+`
+			WHERE occurrence_id IN (SELECT id FROM occurrences WHERE reminder_id = ?)`, reminderID); err != nil {
+			return err
+		}
+	}
 	_, err := s.db.ExecContext(ctx, `DELETE FROM occurrences WHERE reminder_id = ?`, reminderID)
 	return err
 }
+
+func occurrenceArgs(occ *domain.Occurrence) []any {
+	return []any{occ.ReminderID, occ.FireAtUtc, occ.Status, nullTime(occ.SentAtUtc), nullTime(occ.AckedAtUtc), occ.Attempts, occ.ChatID, occ.MessageID, nullTime(occ.EscalatedAtUtc), occ.EscalationStep}
+}
+
+func scanOccurrence(scanner interface {
+	Scan(dest ...any) error
+}) (*domain.Occurrence, error) {
+	var occ domain.Occurrence
+	var sentAt, ackedAt, escalatedAt sql.NullTime
+	if err := scanner.Scan(&occ.ID, &occ.ReminderID, &occ.FireAtUtc, &occ.Status, &sentAt, &ackedAt, &occ.Attempts, &occ.ChatID, &occ.MessageID, &escalatedAt, &occ.EscalationStep); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	occ.SentAtUtc = sentAt.Time
+	occ.AckedAtUtc = ackedAt.Time
+	occ.EscalatedAtUtc = escalatedAt.Time
+	return &occ, nil
+}
+
+func scanOccurrences(rows *sql.Rows) ([]*domain.Occurrence, error) {
+	defer rows.Close()
+
+	var out []*domain.Occurrence
+	for rows.Next() {
+		occ, err := scanOccurrence(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, occ)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// nullTime stores zero times as NULL.
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
@@ -4,13 +4,14 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"time"
 
 	"naggingbot/internal/domain"
 )
 
 // ReminderStore implements domain.ReminderStore backed by SQLite.
 type ReminderStore struct {
-	db *sql.DB
+	db dbtx
 }
 
 func NewReminderStore(db *sql.DB) *ReminderStore {
@@ -19,7 +20,7 @@ func NewReminderStore(db *sql.DB) *ReminderStore {
 
 func (s *ReminderStore) GetByID(ctx context.Context, id int64) (*domain.Reminder, error) {
 	row := s.db.QueryRowContext(ctx, `
-		SELECT id, user_id, name, description, start_date_utc, end_date_utc, times_of_day, time_zone, is_active
+		SELECT id, user_id, name, description, start_date_utc, end_date_utc, times_of_day, time_zone, is_active, priority, template, chat_id, confirm_mode, recipient_id, recipient_accepted, escalate_after_min, escalations, webhook_url, channels
 		FROM reminders WHERE id = ?`, id)
 
 	return scanReminder(row)
@@ -27,8 +28,9 @@ func (s *ReminderStore) GetByID(ctx context.Context, id int64) (*domain.Reminder
 
 func (s *ReminderStore) ListByUser(ctx context.Context, userID int64) ([]*domain.Reminder, error) {
 	rows, err := s.db.QueryContext(ctx, `
-		SELECT id, user_id, name, description, start_date_utc, end_date_utc, times_of_day, time_zone, is_active
-		FROM reminders WHERE user_id = ?`, userID)
+		SELECT id, user_id, name, description, start_date_utc, end_date_utc, times_of_day, time_zone, is_active, priority, template, chat_id, confirm_mode, recipient_id, recipient_accepted, escalate_after_min, escalations, webhook_url, channels
+		FROM reminders WHERE user_id = ?
+		ORDER BY id`, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -54,11 +56,18 @@ func (s *ReminderStore) Create(ctx context.Context, reminder *domain.Reminder) e
 	if err != nil {
 		return err
 	}
+	escalationsJSON, err := marshalEscalations(reminder.Escalations)
+	if err != nil {
+		return err
+	}
 
 	res, err := s.db.ExecContext(ctx, `
-		INSERT INTO reminders (user_id, name, description, start_date_utc, end_date_utc, times_of_day, time_zone, is_active)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
-		reminder.UserID, reminder.Name, reminder.Description, reminder.StartDate, reminder.EndDate, timesJSON, reminder.TimeZone, boolToInt(reminder.IsActive))
+		INSERT INTO reminders (user_id, name, description, start_date_utc, end_date_utc, times_of_day, time_zone, is_active, priority, template, chat_id, confirm_mode,
+		                       recipient_id, recipient_accepted, escalate_after_min, escalations, webhook_url, channels)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		reminder.UserID, reminder.Name, reminder.Description, reminder.StartDate, reminder.EndDate, timesJSON, reminder.TimeZone, boolToInt(reminder.IsActive),
+		int(reminder.Priority), reminder.Template, reminder.ChatID, int(reminder.Confirm),
+		reminder.RecipientID, boolToInt(reminder.RecipientAccepted), int(reminder.EscalateAfter/time.Minute), escalationsJSON, reminder.WebhookURL, int(reminder.Channels))
 	if err != nil {
 		return err
 	}
@@ -74,12 +83,19 @@ func (s *ReminderStore) Update(ctx context.Context, reminder *domain.Reminder) e
 	if err != nil {
 		return err
 	}
+	escalationsJSON, err := marshalEscalations(reminder.Escalations)
+	if err != nil {
+		return err
+	}
 
 	_, err = s.db.ExecContext(ctx, `
 		UPDATE reminders
-		SET user_id = ?, name = ?, description = ?, start_date_utc = ?, end_date_utc = ?, times_of_day = ?, time_zone = ?, is_active = ?
+		SET user_id = ?, name = ?, description = ?, start_date_utc = ?, end_date_utc = ?, times_of_day = ?, time_zone = ?, is_active = ?, priority = ?, template = ?,
+		    chat_id = ?, confirm_mode = ?, recipient_id = ?, recipient_accepted = ?, escalate_after_min = ?, escalations = ?, webhook_url = ?, channels = ?
 		WHERE id = ?`,
-		reminder.UserID, reminder.Name, reminder.Description, reminder.StartDate, reminder.EndDate, timesJSON, reminder.TimeZone, boolToInt(reminder.IsActive), reminder.ID)
+		reminder.UserID, reminder.Name, reminder.Description, reminder.StartDate, reminder.EndDate, timesJSON, reminder.TimeZone, boolToInt(reminder.IsActive),
+		int(reminder.Priority), reminder.Template, reminder.ChatID, int(reminder.Confirm),
+		reminder.RecipientID, boolToInt(reminder.RecipientAccepted), int(reminder.EscalateAfter/time.Minute), escalationsJSON, reminder.WebhookURL, int(reminder.Channels), reminder.ID)
 	return err
 }
 
@@ -92,13 +108,16 @@ func scanReminder(scanner interface {
 	Scan(dest ...any) error
 }) (*domain.Reminder, error) {
 	var r domain.Reminder
-	var timesJSON sql.NullString
-	if err := scanner.Scan(&r.ID, &r.UserID, &r.Name, &r.Description, &r.StartDate, &r.EndDate, &timesJSON, &r.TimeZone, &r.IsActive); err != nil {
+	var timesJSON, escalationsJSON sql.NullString
+	var escalateMin int
+	if err := scanner.Scan(&r.ID, &r.UserID, &r.Name, &r.Description, &r.StartDate, &r.EndDate, &timesJSON, &r.TimeZone, &r.IsActive, &r.Priority, &r.Template, &r.ChatID, &r.Confirm,
+		&r.RecipientID, &r.RecipientAccepted, &escalateMin, &escalationsJSON, &r.WebhookURL, &r.Channels); err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
 		}
 		return nil, err
 	}
+	r.EscalateAfter = time.Duration(escalateMin) * time.Minute
 
 	if timesJSON.Valid && timesJSON.String != "" {
 		var tod []domain.TimeOfDay
@@ -107,6 +126,13 @@ func scanReminder(scanner interface {
 		}
 		r.TimesOfDay = tod
 	}
+	if escalationsJSON.Valid && escalationsJSON.String != "" {
+		steps, err := unmarshalEscalations(escalationsJSON.String)
+		if err != nil {
+			return nil, err
+		}
+		r.Escalations = steps
+	}
 
 	return &r, nil
 }
@@ -122,6 +148,42 @@ func marshalTimes(times []domain.TimeOfDay) (sql.NullString, error) {
 	return sql.NullString{String: string(b), Valid: true}, nil
 }
 
+// escalationStep is how an escalation step is stored, with the delay in
+// minutes like escalate_after_min.
+type escalationStep struct {
+	AfterMin int    `json:"after_min"`
+	ChatID   int64  `json:"chat_id,omitempty"`
+	URL      string `json:"url,omitempty"`
+	Pending  bool   `json:"pending,omitempty"`
+}
+
+func marshalEscalations(steps []domain.EscalationStep) (sql.NullString, error) {
+	if len(steps) == 0 {
+		return sql.NullString{}, nil
+	}
+	stored := make([]escalationStep, 0, len(steps))
+	for _, st := range steps {
+		stored = append(stored, escalationStep{AfterMin: int(st.After / time.Minute), ChatID: st.ChatID, URL: st.URL, Pending: st.Pending})
+	}
+	b, err := json.Marshal(stored)
+	if err != nil {
+		return sql.NullString{}, err
+	}
+	return sql.NullString{String: string(b), Valid: true}, nil
+}
+
+func unmarshalEscalations(s string) ([]domain.EscalationStep, error) {
+	var stored []escalationStep
+	if err := json.Unmarshal([]byte(s), &stored); err != nil {
+		return nil, err
+	}
+	steps := make([]domain.EscalationStep, 0, len(stored))
+	for _, st := range stored {
+		steps = append(steps, domain.EscalationStep{After: time.Duration(st.AfterMin) * time.Minute, ChatID: st.ChatID, URL: st.URL, Pending: st.Pending})
+	}
+	return steps, nil
+}
+
 func boolToInt(b bool) int {
 	if b {
 		return 1
@@ -0,0 +1,40 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"naggingbot/internal/storage/storetest"
+)
+
+func TestConformance(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) storetest.Stores {
+		db := openTestDB(t)
+		return storetest.Stores{
+			Users:       NewUserStore(db),
+			Reminders:   NewReminderStore(db),
+			Occurrences: NewOccurrenceStore(db),
+			UnitOfWork:  NewUnitOfWork(db),
+			Digests:     NewDigestStore(db),
+			Invites:     NewInviteStore(db),
+			Webhooks:    NewWebhookStore(db),
+			Emails:      NewEmailStore(db),
+		}
+	})
+}
+
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "test.db")
+	if err := EnsureDB(context.Background(), path); err != nil {
+		t.Fatalf("ensure db: %v", err)
+	}
+	db, err := Open(path)
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
@@ -0,0 +1,53 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"naggingbot/internal/domain"
+)
+
+// dbtx is the subset of *sql.DB and *sql.Tx used by the stores, so the same
+// store code runs both standalone and inside a transaction.
+type dbtx interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// UnitOfWork implements domain.UnitOfWork with SQLite transactions.
+type UnitOfWork struct {
+	db *sql.DB
+}
+
+func NewUnitOfWork(db *sql.DB) *UnitOfWork {
+	return &UnitOfWork{db: db}
+}
+
+// Do runs fn inside a transaction. The transaction is committed when fn returns
+// nil and rolled back when it returns an error or panics.
+func (u *UnitOfWork) Do(ctx context.Context, fn func(ctx context.Context, stores domain.Stores) error) error {
+	tx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	// Rollback after a successful commit is a no-op returning sql.ErrTxDone.
+	defer tx.Rollback()
+
+	stores := domain.Stores{
+		Users:       &UserStore{db: tx},
+		Reminders:   &ReminderStore{db: tx},
+		Occurrences: &OccurrenceStore{db: tx},
+		Invites:     &InviteStore{db: tx},
+	}
+	if err := fn(ctx, stores); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+	return nil
+}
@@ -3,13 +3,16 @@ package sqlite
 import (
 	"context"
 	"database/sql"
+	"strings"
 
 	"naggingbot/internal/domain"
 )
 
+const userColumns = `id, telegram_id, username, first_name, last_name, language, locale, time_zone, registered, banned, channels`
+
 // UserStore implements domain.UserStore backed by SQLite.
 type UserStore struct {
-	db *sql.DB
+	db dbtx
 }
 
 func NewUserStore(db *sql.DB) *UserStore {
@@ -18,55 +21,116 @@ func NewUserStore(db *sql.DB) *UserStore {
 
 func (s *UserStore) GetByID(ctx context.Context, id int64) (*domain.User, error) {
 	row := s.db.QueryRowContext(ctx, `
-		SELECT id, telegram_id, username, first_name, last_name, language
+		SELECT `+userColumns+`
 		FROM users WHERE id = ?`, id)
 
-	var u domain.User
-	if err := row.Scan(&u.ID, &u.TelegramID, &u.Username, &u.FirstName, &u.LastName, &u.Language); err != nil {
-		if err == sql.ErrNoRows {
-			return nil, nil
-		}
-		return nil, err
-	}
-	return &u, nil
+	return scanUser(row)
 }
 
 func (s *UserStore) GetByTelegramID(ctx context.Context, telegramID int64) (*domain.User, error) {
 	row := s.db.QueryRowContext(ctx, `
-		SELECT id, telegram_id, username, first_name, last_name, language
+		SELECT `+userColumns+`
 		FROM users WHERE telegram_id = ?`, telegramID)
 
-	var u domain.User
-	if err := row.Scan(&u.ID, &u.TelegramID, &u.Username, &u.FirstName, &u.LastName, &u.Language); err != nil {
-		if err == sql.ErrNoRows {
-			return nil, nil
+	return scanUser(row)
+}
+
+func (s *UserStore) GetByUsername(ctx context.Context, username string) (*domain.User, error) {
+	username = strings.TrimPrefix(username, "@")
+	if username == "" {
+		return nil, nil
+	}
+	row := s.db.QueryRowContext(ctx, `
+		SELECT `+userColumns+`
+		FROM users WHERE username = ? COLLATE NOCASE
+		ORDER BY id LIMIT 1`, username)
+
+	return scanUser(row)
+}
+
+func (s *UserStore) List(ctx context.Context) ([]*domain.User, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+userColumns+`
+		FROM users ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []*domain.User
+	for rows.Next() {
+		u, err := scanUser(rows)
+		if err != nil {
+			return nil, err
 		}
+		out = append(out, u)
+	}
+	if err := rows.Err(); err != nil {
 		return nil, err
 	}
-	return &u, nil
+
+	return out, nil
 }
 
 func (s *UserStore) Upsert(ctx context.Context, user *domain.User) error {
 	_, err := s.db.ExecContext(ctx, `
-		INSERT INTO users (telegram_id, username, first_name, last_name, language)
-		VALUES (?, ?, ?, ?, ?)
+		INSERT INTO users (telegram_id, username, first_name, last_name, language, time_zone)
+		VALUES (?, ?, ?, ?, ?, ?)
 		ON CONFLICT(telegram_id) DO UPDATE SET
 			username = excluded.username,
 			first_name = excluded.first_name,
 			last_name = excluded.last_name,
 			language = excluded.language
-	`, user.TelegramID, user.Username, user.FirstName, user.LastName, user.Language)
+	`, user.TelegramID, user.Username, user.FirstName, user.LastName, user.Language, user.TimeZone)
 	if err != nil {
 		return err
 	}
 
-	// Reload to populate ID.
+	// Reload to populate ID and stored settings.
 	reloaded, err := s.GetByTelegramID(ctx, user.TelegramID)
 	if err != nil {
 		return err
 	}
 	if reloaded != nil {
-		user.ID = reloaded.ID
+		*user = *reloaded
 	}
 	return nil
 }
+
+func (s *UserStore) SetTimeZone(ctx context.Context, id int64, timeZone string) error {
+	_, err := s.db.ExecContext(ctx, `UPDATE users SET time_zone = ? WHERE id = ?`, timeZone, id)
+	return err
+}
+
+func (s *UserStore) SetLocale(ctx context.Context, id int64, locale string) error {
+	_, err := s.db.ExecContext(ctx, `UPDATE users SET locale = ? WHERE id = ?`, locale, id)
+	return err
+}
+
+func (s *UserStore) SetRegistered(ctx context.Context, id int64, registered bool) error {
+	_, err := s.db.ExecContext(ctx, `UPDATE users SET registered = ? WHERE id = ?`, registered, id)
+	return err
+}
+
+func (s *UserStore) SetBanned(ctx context.Context, id int64, banned bool) error {
+	_, err := s.db.ExecContext(ctx, `UPDATE users SET banned = ? WHERE id = ?`, banned, id)
+	return err
+}
+
+func (s *UserStore) SetChannels(ctx context.Context, id int64, channels domain.Channels) error {
+	_, err := s.db.ExecContext(ctx, `UPDATE users SET channels = ? WHERE id = ?`, int(channels), id)
+	return err
+}
+
+func scanUser(scanner interface {
+	Scan(dest ...any) error
+}) (*domain.User, error) {
+	var u domain.User
+	if err := scanner.Scan(&u.ID, &u.TelegramID, &u.Username, &u.FirstName, &u.LastName, &u.Language, &u.Locale, &u.TimeZone, &u.Registered, &u.Banned, &u.Channels); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &u, nil
+}
@@ -0,0 +1,150 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"naggingbot/internal/domain"
+)
+
+const webhookDeliveryColumns = `id, user_id, occurrence_id, event, url, attempt, status_code, error, duration_ms, at_utc`
+
+const webhookJobColumns = `id, user_id, occurrence_id, event, url, body, attempts, next_at_utc`
+
+// WebhookStore implements domain.WebhookStore backed by SQLite.
+type WebhookStore struct {
+	db dbtx
+}
+
+func NewWebhookStore(db *sql.DB) *WebhookStore {
+	return &WebhookStore{db: db}
+}
+
+func (s *WebhookStore) Get(ctx context.Context, userID int64) (*domain.WebhookSettings, error) {
+	var w domain.WebhookSettings
+	err := s.db.QueryRowContext(ctx, `
+		SELECT user_id, url, secret
+		FROM webhooks WHERE user_id = ?`, userID).Scan(&w.UserID, &w.URL, &w.Secret)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
+func (s *WebhookStore) Save(ctx context.Context, w *domain.WebhookSettings) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO webhooks (user_id, url, secret)
+		VALUES (?, ?, ?)
+		ON CONFLICT(user_id) DO UPDATE SET
+			url = excluded.url,
+			secret = excluded.secret`,
+		w.UserID, w.URL, w.Secret)
+	return err
+}
+
+func (s *WebhookStore) Delete(ctx context.Context, userID int64) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM webhooks WHERE user_id = ?`, userID)
+	return err
+}
+
+func (s *WebhookStore) RecordDelivery(ctx context.Context, d *domain.WebhookDelivery) error {
+	res, err := s.db.ExecContext(ctx, `
+		INSERT INTO webhook_deliveries (user_id, occurrence_id, event, url, attempt, status_code, error, duration_ms, at_utc)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		d.UserID, d.OccurrenceID, d.Event, d.URL, d.Attempt, d.StatusCode, d.Error, d.Duration.Milliseconds(), d.AtUtc)
+	if err != nil {
+		return err
+	}
+	if id, err := res.LastInsertId(); err == nil {
+		d.ID = id
+	}
+
+	_, err = s.db.ExecContext(ctx, `
+		DELETE FROM webhook_deliveries
+		WHERE user_id = ? AND id NOT IN (
+			SELECT id FROM webhook_deliveries WHERE user_id = ? ORDER BY id DESC LIMIT ?)`,
+		d.UserID, d.UserID, domain.MaxWebhookDeliveries)
+	return err
+}
+
+func (s *WebhookStore) ListDeliveries(ctx context.Context, userID int64, limit int) ([]*domain.WebhookDelivery, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+webhookDeliveryColumns+`
+		FROM webhook_deliveries WHERE user_id = ?
+		ORDER BY id DESC
+		LIMIT ?`, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []*domain.WebhookDelivery
+	for rows.Next() {
+		var d domain.WebhookDelivery
+		var durationMs int64
+		if err := rows.Scan(&d.ID, &d.UserID, &d.OccurrenceID, &d.Event, &d.URL, &d.Attempt, &d.StatusCode, &d.Error, &durationMs, &d.AtUtc); err != nil {
+			return nil, err
+		}
+		d.Duration = time.Duration(durationMs) * time.Millisecond
+		out = append(out, &d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (s *WebhookStore) Enqueue(ctx context.Context, job *domain.WebhookJob) error {
+	res, err := s.db.ExecContext(ctx, `
+		INSERT INTO webhook_jobs (user_id, occurrence_id, event, url, body, attempts, next_at_utc)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		job.UserID, job.OccurrenceID, job.Event, job.URL, job.Body, job.Attempts, job.NextAtUtc.UTC())
+	if err != nil {
+		return err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	job.ID = id
+	return nil
+}
+
+func (s *WebhookStore) ListDueJobs(ctx context.Context, at time.Time, limit int) ([]*domain.WebhookJob, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+webhookJobColumns+`
+		FROM webhook_jobs WHERE next_at_utc <= ?
+		ORDER BY next_at_utc, id
+		LIMIT ?`, at.UTC(), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []*domain.WebhookJob
+	for rows.Next() {
+		var j domain.WebhookJob
+		if err := rows.Scan(&j.ID, &j.UserID, &j.OccurrenceID, &j.Event, &j.URL, &j.Body, &j.Attempts, &j.NextAtUtc); err != nil {
+			return nil, err
+		}
+		out = append(out, &j)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (s *WebhookStore) RetryJob(ctx context.Context, id int64, attempts int, nextAtUTC time.Time) error {
+	_, err := s.db.ExecContext(ctx, `UPDATE webhook_jobs SET attempts = ?, next_at_utc = ? WHERE id = ?`, attempts, nextAtUTC.UTC(), id)
+	return err
+}
+
+func (s *WebhookStore) DeleteJob(ctx context.Context, id int64) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM webhook_jobs WHERE id = ?`, id)
+	return err
+}
@@ -0,0 +1,1331 @@
+// Package storetest provides a conformance suite that every storage backend
+// implementing the domain store interfaces is expected to pass.
+package storetest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"naggingbot/internal/domain"
+)
+
+// Stores bundles the store implementations under test.
+type Stores struct {
+	Users       domain.UserStore
+	Reminders   domain.ReminderStore
+	Occurrences domain.OccurrenceStore
+	UnitOfWork  domain.UnitOfWork
+	Digests     domain.DigestStore
+	Invites     domain.InviteStore
+	Webhooks    domain.WebhookStore
+	Emails      domain.EmailStore
+}
+
+// Factory returns a fresh, empty set of stores. It is called once per subtest.
+type Factory func(t *testing.T) Stores
+
+// Run executes the whole conformance suite against stores built by newStores.
+func Run(t *testing.T, newStores Factory) {
+	t.Run("UserUpsertAndGet", func(t *testing.T) { testUserUpsertAndGet(t, newStores(t)) })
+	t.Run("UserSettingsSurviveUpsert", func(t *testing.T) { testUserSettingsSurviveUpsert(t, newStores(t)) })
+	t.Run("UserNotFound", func(t *testing.T) { testUserNotFound(t, newStores(t)) })
+	t.Run("UserList", func(t *testing.T) { testUserList(t, newStores(t)) })
+	t.Run("ReminderCRUD", func(t *testing.T) { testReminderCRUD(t, newStores(t)) })
+	t.Run("ReminderNotFound", func(t *testing.T) { testReminderNotFound(t, newStores(t)) })
+	t.Run("ReminderListByUser", func(t *testing.T) { testReminderListByUser(t, newStores(t)) })
+	t.Run("OccurrenceCreateAndGet", func(t *testing.T) { testOccurrenceCreateAndGet(t, newStores(t)) })
+	t.Run("OccurrenceNotFound", func(t *testing.T) { testOccurrenceNotFound(t, newStores(t)) })
+	t.Run("OccurrenceListByReminder", func(t *testing.T) { testOccurrenceListByReminder(t, newStores(t)) })
+	t.Run("OccurrenceListByReminderInRange", func(t *testing.T) { testOccurrenceListByReminderInRange(t, newStores(t)) })
+	t.Run("OccurrencePendingRange", func(t *testing.T) { testOccurrencePendingRange(t, newStores(t)) })
+	t.Run("OccurrenceStatusTransitions", func(t *testing.T) { testOccurrenceStatusTransitions(t, newStores(t)) })
+	t.Run("CascadingDelete", func(t *testing.T) { testCascadingDelete(t, newStores(t)) })
+	t.Run("OccurrenceCreateBatch", func(t *testing.T) { testOccurrenceCreateBatch(t, newStores(t)) })
+	t.Run("OccurrenceDeliveryTracking", func(t *testing.T) { testOccurrenceDeliveryTracking(t, newStores(t)) })
+	t.Run("OccurrenceConfirmations", func(t *testing.T) { testOccurrenceConfirmations(t, newStores(t)) })
+	t.Run("OccurrenceChannelDeliveries", func(t *testing.T) { testOccurrenceChannelDeliveries(t, newStores(t)) })
+	t.Run("OccurrenceEscalation", func(t *testing.T) { testOccurrenceEscalation(t, newStores(t)) })
+	t.Run("DigestSettings", func(t *testing.T) { testDigestSettings(t, newStores(t)) })
+	t.Run("Invites", func(t *testing.T) { testInvites(t, newStores(t)) })
+	t.Run("Webhooks", func(t *testing.T) { testWebhooks(t, newStores(t)) })
+	t.Run("WebhookJobs", func(t *testing.T) { testWebhookJobs(t, newStores(t)) })
+	t.Run("Emails", func(t *testing.T) { testEmails(t, newStores(t)) })
+	t.Run("UnitOfWorkCommit", func(t *testing.T) { testUnitOfWorkCommit(t, newStores(t)) })
+	t.Run("UnitOfWorkRollback", func(t *testing.T) { testUnitOfWorkRollback(t, newStores(t)) })
+}
+
+// base is a fixed instant used to build deterministic fire times.
+var base = time.Date(2026, 1, 19, 8, 0, 0, 0, time.UTC)
+
+func testUserUpsertAndGet(t *testing.T, s Stores) {
+	ctx := context.Background()
+
+	u := &domain.User{TelegramID: 1001, Username: "alice", FirstName: "Alice", LastName: "A", Language: "en"}
+	if err := s.Users.Upsert(ctx, u); err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+	if u.ID == 0 {
+		t.Fatal("upsert did not assign an ID")
+	}
+
+	got, err := s.Users.GetByID(ctx, u.ID)
+	if err != nil {
+		t.Fatalf("get by id: %v", err)
+	}
+	assertUser(t, got, u)
+
+	got, err = s.Users.GetByTelegramID(ctx, u.TelegramID)
+	if err != nil {
+		t.Fatalf("get by telegram id: %v", err)
+	}
+	assertUser(t, got, u)
+
+	// A second upsert for the same Telegram user keeps the ID and updates fields.
+	again := &domain.User{TelegramID: 1001, Username: "alice2", FirstName: "Alice", LastName: "B", Language: "ru"}
+	if err := s.Users.Upsert(ctx, again); err != nil {
+		t.Fatalf("second upsert: %v", err)
+	}
+	if again.ID != u.ID {
+		t.Fatalf("second upsert changed ID: got %d, want %d", again.ID, u.ID)
+	}
+	got, err = s.Users.GetByTelegramID(ctx, u.TelegramID)
+	if err != nil {
+		t.Fatalf("get after second upsert: %v", err)
+	}
+	assertUser(t, got, again)
+
+	other := &domain.User{TelegramID: 1002, Username: "bob"}
+	if err := s.Users.Upsert(ctx, other); err != nil {
+		t.Fatalf("upsert other: %v", err)
+	}
+	if other.ID == u.ID {
+		t.Fatalf("distinct users share ID %d", u.ID)
+	}
+
+	for _, name := range []string{"alice2", "@Alice2", "ALICE2"} {
+		got, err = s.Users.GetByUsername(ctx, name)
+		if err != nil {
+			t.Fatalf("get by username %q: %v", name, err)
+		}
+		assertUser(t, got, again)
+	}
+	for _, name := range []string{"alice", "", "@"} {
+		if got, err := s.Users.GetByUsername(ctx, name); err != nil || got != nil {
+			t.Fatalf("GetByUsername(%q) = (%v, %v), want (nil, nil)", name, got, err)
+		}
+	}
+}
+
+func testUserSettingsSurviveUpsert(t *testing.T, s Stores) {
+	ctx := context.Background()
+	u := mustUser(t, s, 1101)
+
+	if err := s.Users.SetTimeZone(ctx, u.ID, "Europe/Warsaw"); err != nil {
+		t.Fatalf("set time zone: %v", err)
+	}
+	if err := s.Users.SetLocale(ctx, u.ID, "ru"); err != nil {
+		t.Fatalf("set locale: %v", err)
+	}
+	if err := s.Users.SetRegistered(ctx, u.ID, true); err != nil {
+		t.Fatalf("set registered: %v", err)
+	}
+	if err := s.Users.SetBanned(ctx, u.ID, true); err != nil {
+		t.Fatalf("set banned: %v", err)
+	}
+	channels := domain.ChannelTelegram | domain.ChannelEmail
+	if err := s.Users.SetChannels(ctx, u.ID, channels); err != nil {
+		t.Fatalf("set channels: %v", err)
+	}
+
+	// Handlers upsert with fresh Telegram profile data that carries no settings.
+	fresh := &domain.User{TelegramID: u.TelegramID, Username: "renamed", Language: "en"}
+	if err := s.Users.Upsert(ctx, fresh); err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+	if fresh.ID != u.ID || fresh.TimeZone != "Europe/Warsaw" || fresh.Locale != "ru" || !fresh.Registered || !fresh.Banned || fresh.Channels != channels {
+		t.Fatalf("upsert result = %+v, want ID %d and stored time zone", fresh, u.ID)
+	}
+
+	got, err := s.Users.GetByID(ctx, u.ID)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if got == nil || got.TimeZone != "Europe/Warsaw" || got.Locale != "ru" || !got.Registered || !got.Banned || got.Channels != channels ||
+		got.Username != "renamed" || got.Language != "en" {
+		t.Fatalf("stored user = %+v", got)
+	}
+
+	if err := s.Users.SetTimeZone(ctx, 4242, "UTC"); err != nil {
+		t.Fatalf("set time zone on missing user: %v", err)
+	}
+	if err := s.Users.SetBanned(ctx, 4242, true); err != nil {
+		t.Fatalf("set banned on missing user: %v", err)
+	}
+}
+
+func testUserNotFound(t *testing.T, s Stores) {
+	ctx := context.Background()
+
+	got, err := s.Users.GetByID(ctx, 42)
+	if err != nil || got != nil {
+		t.Fatalf("GetByID on missing user = (%v, %v), want (nil, nil)", got, err)
+	}
+	got, err = s.Users.GetByTelegramID(ctx, 42)
+	if err != nil || got != nil {
+		t.Fatalf("GetByTelegramID on missing user = (%v, %v), want (nil, nil)", got, err)
+	}
+}
+
+func testUserList(t *testing.T, s Stores) {
+	ctx := context.Background()
+
+	list, err := s.Users.List(ctx)
+	if err != nil || len(list) != 0 {
+		t.Fatalf("List on empty store = (%v, %v), want empty", list, err)
+	}
+
+	var want []int64
+	for _, tgID := range []int64{5003, 5001, 5002} {
+		want = append(want, mustUser(t, s, tgID).ID)
+	}
+	mustUser(t, s, 5001) // re-upsert must not duplicate
+
+	list, err = s.Users.List(ctx)
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	ids := make([]int64, 0, len(list))
+	for _, u := range list {
+		ids = append(ids, u.ID)
+	}
+	assertIDs(t, "users", ids, want)
+}
+
+func testReminderCRUD(t *testing.T, s Stores) {
+	ctx := context.Background()
+	user := mustUser(t, s, 2001)
+
+	rem := newReminder(user.ID, "Pill")
+	if err := s.Reminders.Create(ctx, rem); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if rem.ID == 0 {
+		t.Fatal("create did not assign an ID")
+	}
+
+	got, err := s.Reminders.GetByID(ctx, rem.ID)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	assertReminder(t, got, rem)
+
+	rem.Name = "Pill (updated)"
+	rem.Description = "VitD"
+	rem.TimesOfDay = []domain.TimeOfDay{{Hour: 9, Minute: 30}}
+	rem.EndDate = rem.EndDate.Add(48 * time.Hour)
+	rem.IsActive = false
+	rem.Priority = domain.PriorityHigh
+	rem.Template = "<b>{{.Name}}</b> at {{.Time}}"
+	rem.ChatID = -100123
+	rem.Confirm = domain.ConfirmEveryone
+	rem.RecipientID = user.ID + 1
+	rem.RecipientAccepted = true
+	rem.EscalateAfter = 45 * time.Minute
+	rem.WebhookURL = "https://example.com/reminder"
+	rem.Channels = domain.ChannelWebhook
+	rem.Escalations = []domain.EscalationStep{
+		{After: 10 * time.Minute, ChatID: 4242, Pending: true},
+		{After: time.Hour, URL: "https://example.com/hook?token=x"},
+	}
+	if err := s.Reminders.Update(ctx, rem); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	got, err = s.Reminders.GetByID(ctx, rem.ID)
+	if err != nil {
+		t.Fatalf("get after update: %v", err)
+	}
+	assertReminder(t, got, rem)
+
+	list, err := s.Reminders.ListByUser(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("list after update: %v", err)
+	}
+	if len(list) != 1 {
+		t.Fatalf("list after update: got %d reminders, want 1", len(list))
+	}
+	assertReminder(t, list[0], rem)
+
+	if err := s.Reminders.DeleteByID(ctx, rem.ID); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	got, err = s.Reminders.GetByID(ctx, rem.ID)
+	if err != nil || got != nil {
+		t.Fatalf("get after delete = (%v, %v), want (nil, nil)", got, err)
+	}
+	list, err = s.Reminders.ListByUser(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("list after delete: %v", err)
+	}
+	if len(list) != 0 {
+		t.Fatalf("list after delete: got %d reminders, want 0", len(list))
+	}
+}
+
+func testReminderNotFound(t *testing.T, s Stores) {
+	ctx := context.Background()
+
+	got, err := s.Reminders.GetByID(ctx, 42)
+	if err != nil || got != nil {
+		t.Fatalf("GetByID on missing reminder = (%v, %v), want (nil, nil)", got, err)
+	}
+	list, err := s.Reminders.ListByUser(ctx, 42)
+	if err != nil {
+		t.Fatalf("ListByUser on missing user: %v", err)
+	}
+	if len(list) != 0 {
+		t.Fatalf("ListByUser on missing user: got %d reminders, want 0", len(list))
+	}
+	if err := s.Reminders.DeleteByID(ctx, 42); err != nil {
+		t.Fatalf("DeleteByID on missing reminder: %v", err)
+	}
+}
+
+func testReminderListByUser(t *testing.T, s Stores) {
+	ctx := context.Background()
+	alice := mustUser(t, s, 3001)
+	bob := mustUser(t, s, 3002)
+
+	var want []int64
+	for _, name := range []string{"a", "b", "c"} {
+		rem := newReminder(alice.ID, name)
+		if err := s.Reminders.Create(ctx, rem); err != nil {
+			t.Fatalf("create %s: %v", name, err)
+		}
+		want = append(want, rem.ID)
+	}
+	if err := s.Reminders.Create(ctx, newReminder(bob.ID, "other")); err != nil {
+		t.Fatalf("create other: %v", err)
+	}
+
+	list, err := s.Reminders.ListByUser(ctx, alice.ID)
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	assertReminderIDs(t, list, want)
+}
+
+func testOccurrenceCreateAndGet(t *testing.T, s Stores) {
+	ctx := context.Background()
+	rem := mustReminder(t, s, 4001)
+
+	occ := &domain.Occurrence{ReminderID: rem.ID, FireAtUtc: base, Status: domain.OccurrenceCreated}
+	if err := s.Occurrences.Create(ctx, occ); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if occ.ID == 0 {
+		t.Fatal("create did not assign an ID")
+	}
+
+	got, err := s.Occurrences.GetByID(ctx, occ.ID)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	assertOccurrence(t, got, occ)
+}
+
+func testOccurrenceNotFound(t *testing.T, s Stores) {
+	ctx := context.Background()
+
+	got, err := s.Occurrences.GetByID(ctx, 42)
+	if err != nil || got != nil {
+		t.Fatalf("GetByID on missing occurrence = (%v, %v), want (nil, nil)", got, err)
+	}
+	list, err := s.Occurrences.ListByReminder(ctx, 42)
+	if err != nil {
+		t.Fatalf("ListByReminder on missing reminder: %v", err)
+	}
+	if len(list) != 0 {
+		t.Fatalf("ListByReminder on missing reminder: got %d, want 0", len(list))
+	}
+	if err := s.Occurrences.UpdateStatus(ctx, 42, domain.OccurrenceDone); err != nil {
+		t.Fatalf("UpdateStatus on missing occurrence: %v", err)
+	}
+	if err := s.Occurrences.DeleteByReminder(ctx, 42); err != nil {
+		t.Fatalf("DeleteByReminder on missing reminder: %v", err)
+	}
+}
+
+func testOccurrenceListByReminder(t *testing.T, s Stores) {
+	ctx := context.Background()
+	rem := mustReminder(t, s, 5001)
+	other := mustReminder(t, s, 5002)
+
+	// Insert out of chronological order; results must come back sorted by fire time.
+	late := mustOccurrence(t, s, rem.ID, base.Add(2*time.Hour))
+	early := mustOccurrence(t, s, rem.ID, base)
+	mid := mustOccurrence(t, s, rem.ID, base.Add(time.Hour))
+	mustOccurrence(t, s, other.ID, base.Add(30*time.Minute))
+
+	list, err := s.Occurrences.ListByReminder(ctx, rem.ID)
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	assertOccurrenceIDs(t, list, []int64{early.ID, mid.ID, late.ID})
+}
+
+func testOccurrenceListByReminderInRange(t *testing.T, s Stores) {
+	ctx := context.Background()
+	rem := mustReminder(t, s, 5501)
+	other := mustReminder(t, s, 5502)
+
+	mustOccurrence(t, s, rem.ID, base.Add(-time.Second))
+	atStart := mustOccurrence(t, s, rem.ID, base)
+	done := mustOccurrence(t, s, rem.ID, base.Add(time.Minute))
+	atEnd := mustOccurrence(t, s, rem.ID, base.Add(time.Hour))
+	mustOccurrence(t, s, rem.ID, base.Add(time.Hour+time.Second))
+	mustOccurrence(t, s, other.ID, base.Add(time.Minute))
+	if err := s.Occurrences.MarkAcked(ctx, done.ID, domain.OccurrenceDone, base); err != nil {
+		t.Fatalf("mark done: %v", err)
+	}
+
+	// Inclusive bounds, any status, only the requested reminder.
+	list, err := s.Occurrences.ListByReminderInRange(ctx, rem.ID, base, base.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("list in range: %v", err)
+	}
+	assertOccurrenceIDs(t, list, []int64{atStart.ID, done.ID, atEnd.ID})
+}
+
+func testOccurrencePendingRange(t *testing.T, s Stores) {
+	ctx := context.Background()
+	rem := mustReminder(t, s, 6001)
+
+	before := mustOccurrence(t, s, rem.ID, base.Add(-time.Minute))
+	atStart := mustOccurrence(t, s, rem.ID, base)
+	inside := mustOccurrence(t, s, rem.ID, base.Add(30*time.Minute))
+	atEnd := mustOccurrence(t, s, rem.ID, base.Add(time.Hour))
+	mustOccurrence(t, s, rem.ID, base.Add(time.Hour+time.Second))
+	sent := mustOccurrence(t, s, rem.ID, base.Add(10*time.Minute))
+	if err := s.Occurrences.UpdateStatus(ctx, sent.ID, domain.OccurrenceSent); err != nil {
+		t.Fatalf("mark sent: %v", err)
+	}
+
+	// Both bounds are inclusive and only OccurrenceCreated rows are returned.
+	list, err := s.Occurrences.ListPendingInRange(ctx, base, base.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("list pending: %v", err)
+	}
+	assertOccurrenceIDs(t, list, []int64{atStart.ID, inside.ID, atEnd.ID})
+
+	// A zero start means "everything due up to end".
+	list, err = s.Occurrences.ListPendingInRange(ctx, time.Time{}, base.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("list pending from zero: %v", err)
+	}
+	assertOccurrenceIDs(t, list, []int64{before.ID, atStart.ID, inside.ID, atEnd.ID})
+
+	list, err = s.Occurrences.ListPendingInRange(ctx, time.Time{}, base.Add(-2*time.Minute))
+	if err != nil {
+		t.Fatalf("list pending before all: %v", err)
+	}
+	assertOccurrenceIDs(t, list, nil)
+}
+
+func testOccurrenceStatusTransitions(t *testing.T, s Stores) {
+	ctx := context.Background()
+	rem := mustReminder(t, s, 7001)
+	occ := mustOccurrence(t, s, rem.ID, base)
+
+	for _, status := range []domain.OccurrenceStatus{
+		domain.OccurrenceSent,
+		domain.OccurrenceDone,
+	} {
+		if err := s.Occurrences.UpdateStatus(ctx, occ.ID, status); err != nil {
+			t.Fatalf("update to %d: %v", status, err)
+		}
+		got, err := s.Occurrences.GetByID(ctx, occ.ID)
+		if err != nil {
+			t.Fatalf("get after update to %d: %v", status, err)
+		}
+		if got == nil || got.Status != status {
+			t.Fatalf("status after update = %+v, want %d", got, status)
+		}
+		if !got.FireAtUtc.Equal(occ.FireAtUtc) || got.ReminderID != occ.ReminderID {
+			t.Fatalf("update to %d changed other fields: %+v", status, got)
+		}
+	}
+
+	// Done and Ignored are final; illegal changes are rejected and leave the
+	// occurrence untouched.
+	for _, status := range []domain.OccurrenceStatus{domain.OccurrenceIgnored, domain.OccurrenceCreated} {
+		err := s.Occurrences.UpdateStatus(ctx, occ.ID, status)
+		if !errors.Is(err, domain.ErrInvalidTransition) {
+			t.Fatalf("update done to %s: err = %v, want ErrInvalidTransition", status, err)
+		}
+	}
+	if err := s.Occurrences.MarkAcked(ctx, occ.ID, domain.OccurrenceIgnored, base); !errors.Is(err, domain.ErrInvalidTransition) {
+		t.Fatalf("mark done as ignored: err = %v, want ErrInvalidTransition", err)
+	}
+	if err := s.Occurrences.MarkSent(ctx, occ.ID, base); !errors.Is(err, domain.ErrInvalidTransition) {
+		t.Fatalf("mark done as sent: err = %v, want ErrInvalidTransition", err)
+	}
+	if got, _ := s.Occurrences.GetByID(ctx, occ.ID); got.Status != domain.OccurrenceDone || !got.AckedAtUtc.IsZero() {
+		t.Fatalf("after rejected updates: %+v", got)
+	}
+
+	// Sent occurrences can be queued again.
+	requeued := mustOccurrence(t, s, rem.ID, base.Add(time.Minute))
+	if err := s.Occurrences.MarkSent(ctx, requeued.ID, base); err != nil {
+		t.Fatalf("mark sent: %v", err)
+	}
+	if err := s.Occurrences.UpdateStatus(ctx, requeued.ID, domain.OccurrenceCreated); err != nil {
+		t.Fatalf("requeue: %v", err)
+	}
+	if err := s.Occurrences.MarkSent(ctx, requeued.ID, base); err != nil {
+		t.Fatalf("mark requeued sent: %v", err)
+	}
+	if err := s.Occurrences.MarkSent(ctx, requeued.ID, base); !errors.Is(err, domain.ErrInvalidTransition) {
+		t.Fatalf("mark sent twice: err = %v, want ErrInvalidTransition", err)
+	}
+
+	// Occurrences given up as failed can be queued again, but not sent as is.
+	failed := mustOccurrence(t, s, rem.ID, base.Add(2*time.Minute))
+	if err := s.Occurrences.UpdateStatus(ctx, failed.ID, domain.OccurrenceFailed); err != nil {
+		t.Fatalf("give up: %v", err)
+	}
+	if err := s.Occurrences.MarkSent(ctx, failed.ID, base); !errors.Is(err, domain.ErrInvalidTransition) {
+		t.Fatalf("mark failed as sent: err = %v, want ErrInvalidTransition", err)
+	}
+	if err := s.Occurrences.UpdateStatus(ctx, failed.ID, domain.OccurrenceCreated); err != nil {
+		t.Fatalf("requeue failed: %v", err)
+	}
+	if err := s.Occurrences.UpdateStatus(ctx, failed.ID, domain.OccurrenceFailed); err != nil {
+		t.Fatalf("give up again: %v", err)
+	}
+
+	// Non-created occurrences are never reported as pending.
+	list, err := s.Occurrences.ListPendingInRange(ctx, time.Time{}, base.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("list pending: %v", err)
+	}
+	assertOccurrenceIDs(t, list, nil)
+}
+
+func testCascadingDelete(t *testing.T, s Stores) {
+	ctx := context.Background()
+	user := mustUser(t, s, 8001)
+
+	doomed := newReminder(user.ID, "doomed")
+	if err := s.Reminders.Create(ctx, doomed); err != nil {
+		t.Fatalf("create doomed: %v", err)
+	}
+	kept := newReminder(user.ID, "kept")
+	if err := s.Reminders.Create(ctx, kept); err != nil {
+		t.Fatalf("create kept: %v", err)
+	}
+
+	doomedOcc := mustOccurrence(t, s, doomed.ID, base)
+	mustOccurrence(t, s, doomed.ID, base.Add(time.Hour))
+	keptOcc := mustOccurrence(t, s, kept.ID, base)
+
+	if err := s.Occurrences.DeleteByReminder(ctx, doomed.ID); err != nil {
+		t.Fatalf("delete occurrences: %v", err)
+	}
+	if err := s.Reminders.DeleteByID(ctx, doomed.ID); err != nil {
+		t.Fatalf("delete reminder: %v", err)
+	}
+
+	if got, err := s.Occurrences.GetByID(ctx, doomedOcc.ID); err != nil || got != nil {
+		t.Fatalf("deleted occurrence still readable: (%v, %v)", got, err)
+	}
+	list, err := s.Occurrences.ListByReminder(ctx, doomed.ID)
+	if err != nil {
+		t.Fatalf("list deleted reminder occurrences: %v", err)
+	}
+	assertOccurrenceIDs(t, list, nil)
+
+	list, err = s.Occurrences.ListByReminder(ctx, kept.ID)
+	if err != nil {
+		t.Fatalf("list kept reminder occurrences: %v", err)
+	}
+	assertOccurrenceIDs(t, list, []int64{keptOcc.ID})
+
+	pending, err := s.Occurrences.ListPendingInRange(ctx, time.Time{}, base.Add(24*time.Hour))
+	if err != nil {
+		t.Fatalf("list pending: %v", err)
+	}
+	assertOccurrenceIDs(t, pending, []int64{keptOcc.ID})
+
+	rems, err := s.Reminders.ListByUser(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("list reminders: %v", err)
+	}
+	assertReminderIDs(t, rems, []int64{kept.ID})
+}
+
+func testOccurrenceCreateBatch(t *testing.T, s Stores) {
+	ctx := context.Background()
+	rem := mustReminder(t, s, 9001)
+
+	batch := []*domain.Occurrence{
+		{ReminderID: rem.ID, FireAtUtc: base, Status: domain.OccurrenceCreated},
+		{ReminderID: rem.ID, FireAtUtc: base.Add(time.Hour), Status: domain.OccurrenceCreated},
+		{ReminderID: rem.ID, FireAtUtc: base.Add(2 * time.Hour), Status: domain.OccurrenceCreated},
+	}
+	if err := s.Occurrences.CreateBatch(ctx, batch); err != nil {
+		t.Fatalf("create batch: %v", err)
+	}
+
+	var want []int64
+	seen := make(map[int64]bool)
+	for _, occ := range batch {
+		if occ.ID == 0 || seen[occ.ID] {
+			t.Fatalf("create batch assigned invalid ID %d", occ.ID)
+		}
+		seen[occ.ID] = true
+		want = append(want, occ.ID)
+	}
+
+	list, err := s.Occurrences.ListByReminder(ctx, rem.ID)
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	assertOccurrenceIDs(t, list, want)
+
+	if err := s.Occurrences.CreateBatch(ctx, nil); err != nil {
+		t.Fatalf("create empty batch: %v", err)
+	}
+}
+
+func testOccurrenceDeliveryTracking(t *testing.T, s Stores) {
+	ctx := context.Background()
+	rem := mustReminder(t, s, 12001)
+	occ := mustOccurrence(t, s, rem.ID, base)
+
+	get := func() *domain.Occurrence {
+		t.Helper()
+		got, err := s.Occurrences.GetByID(ctx, occ.ID)
+		if err != nil || got == nil {
+			t.Fatalf("get occurrence = (%v, %v)", got, err)
+		}
+		return got
+	}
+
+	got := get()
+	if !got.SentAtUtc.IsZero() || !got.AckedAtUtc.IsZero() || got.Attempts != 0 || got.ChatID != 0 || got.MessageID != 0 {
+		t.Fatalf("new occurrence has delivery data: %+v", got)
+	}
+
+	if err := s.Occurrences.IncrementAttempts(ctx, occ.ID); err != nil {
+		t.Fatalf("increment attempts: %v", err)
+	}
+	if got := get(); got.Attempts != 1 || got.Status != domain.OccurrenceCreated {
+		t.Fatalf("after failed attempt: %+v", got)
+	}
+
+	if err := s.Occurrences.SetMessage(ctx, occ.ID, -100123, 777); err != nil {
+		t.Fatalf("set message: %v", err)
+	}
+	sentAt := base.Add(3 * time.Second)
+	if err := s.Occurrences.MarkSent(ctx, occ.ID, sentAt); err != nil {
+		t.Fatalf("mark sent: %v", err)
+	}
+	got = get()
+	if got.Status != domain.OccurrenceSent || !got.SentAtUtc.Equal(sentAt) || got.Attempts != 2 ||
+		got.ChatID != -100123 || got.MessageID != 777 {
+		t.Fatalf("after mark sent: %+v", got)
+	}
+
+	ackedAt := base.Add(5 * time.Minute)
+	if err := s.Occurrences.MarkAcked(ctx, occ.ID, domain.OccurrenceDone, ackedAt); err != nil {
+		t.Fatalf("mark acked: %v", err)
+	}
+	got = get()
+	if got.Status != domain.OccurrenceDone || !got.AckedAtUtc.Equal(ackedAt) || !got.SentAtUtc.Equal(sentAt) {
+		t.Fatalf("after mark acked: %+v", got)
+	}
+
+	// Delivery data round-trips through Create as well.
+	copied := &domain.Occurrence{
+		ReminderID: rem.ID, FireAtUtc: base.Add(time.Hour), Status: got.Status,
+		SentAtUtc: got.SentAtUtc, AckedAtUtc: got.AckedAtUtc, Attempts: got.Attempts,
+		ChatID: got.ChatID, MessageID: got.MessageID,
+	}
+	if err := s.Occurrences.Create(ctx, copied); err != nil {
+		t.Fatalf("create with delivery data: %v", err)
+	}
+	reloaded, err := s.Occurrences.GetByID(ctx, copied.ID)
+	if err != nil {
+		t.Fatalf("get copied: %v", err)
+	}
+	assertOccurrence(t, reloaded, copied)
+}
+
+func testOccurrenceConfirmations(t *testing.T, s Stores) {
+	ctx := context.Background()
+	rem := mustReminder(t, s, 12501)
+	occ := mustOccurrence(t, s, rem.ID, base)
+	other := mustOccurrence(t, s, rem.ID, base.Add(time.Hour))
+
+	confirm := func(occID, telegramID int64, name string, at time.Time) bool {
+		t.Helper()
+		added, err := s.Occurrences.AddConfirmation(ctx, &domain.Confirmation{OccurrenceID: occID, TelegramID: telegramID, Name: name, AtUtc: at})
+		if err != nil {
+			t.Fatalf("add confirmation: %v", err)
+		}
+		return added
+	}
+	if !confirm(occ.ID, 2, "@bob", base.Add(2*time.Minute)) || !confirm(occ.ID, 1, "@alice", base.Add(time.Minute)) {
+		t.Fatal("first confirmations were not added")
+	}
+	if confirm(occ.ID, 2, "@bob", base.Add(3*time.Minute)) {
+		t.Fatal("a member confirmed the same occurrence twice")
+	}
+	confirm(other.ID, 2, "@bob", base.Add(time.Hour))
+
+	list, err := s.Occurrences.ListConfirmations(ctx, occ.ID)
+	if err != nil {
+		t.Fatalf("list confirmations: %v", err)
+	}
+	if len(list) != 2 || list[0].Name != "@alice" || list[1].TelegramID != 2 || !list[1].AtUtc.Equal(base.Add(2*time.Minute)) {
+		t.Fatalf("confirmations = %+v, want alice then bob", list)
+	}
+
+	if err := s.Occurrences.DeleteByReminder(ctx, rem.ID); err != nil {
+		t.Fatalf("delete occurrences: %v", err)
+	}
+	for _, id := range []int64{occ.ID, other.ID} {
+		list, err := s.Occurrences.ListConfirmations(ctx, id)
+		if err != nil || len(list) != 0 {
+			t.Fatalf("confirmations of deleted occurrence %d = (%v, %v)", id, list, err)
+		}
+	}
+}
+
+func testOccurrenceChannelDeliveries(t *testing.T, s Stores) {
+	ctx := context.Background()
+	rem := mustReminder(t, s, 12551)
+	occ := mustOccurrence(t, s, rem.ID, base)
+	other := mustOccurrence(t, s, rem.ID, base.Add(time.Hour))
+
+	record := func(d *domain.ChannelDelivery) {
+		t.Helper()
+		if err := s.Occurrences.RecordDelivery(ctx, d); err != nil {
+			t.Fatalf("record delivery: %v", err)
+		}
+		if d.ID == 0 {
+			t.Fatal("delivery got no ID")
+		}
+	}
+	record(&domain.ChannelDelivery{OccurrenceID: occ.ID, Channel: domain.ChannelTelegram, Required: true, Error: "blocked", AtUtc: base})
+	record(&domain.ChannelDelivery{OccurrenceID: occ.ID, Channel: domain.ChannelEmail, AtUtc: base.Add(time.Second)})
+	record(&domain.ChannelDelivery{OccurrenceID: other.ID, Channel: domain.ChannelWebhook, Required: true, AtUtc: base.Add(time.Hour)})
+
+	list, err := s.Occurrences.ListDeliveries(ctx, occ.ID)
+	if err != nil {
+		t.Fatalf("list deliveries: %v", err)
+	}
+	if len(list) != 2 || list[0].Channel != domain.ChannelTelegram || !list[0].Required || list[0].OK() || list[0].Error != "blocked" ||
+		list[1].Channel != domain.ChannelEmail || list[1].Required || !list[1].OK() || !list[1].AtUtc.Equal(base.Add(time.Second)) {
+		t.Fatalf("deliveries = %+v, want failed telegram then email", list)
+	}
+
+	if err := s.Occurrences.DeleteByReminder(ctx, rem.ID); err != nil {
+		t.Fatalf("delete occurrences: %v", err)
+	}
+	for _, id := range []int64{occ.ID, other.ID} {
+		list, err := s.Occurrences.ListDeliveries(ctx, id)
+		if err != nil || len(list) != 0 {
+			t.Fatalf("deliveries of deleted occurrence %d = (%v, %v)", id, list, err)
+		}
+	}
+}
+
+func testOccurrenceEscalation(t *testing.T, s Stores) {
+	ctx := context.Background()
+	rem := mustReminder(t, s, 12601)
+
+	sent := func(fireAt, sentAt time.Time) *domain.Occurrence {
+		t.Helper()
+		occ := mustOccurrence(t, s, rem.ID, fireAt)
+		if err := s.Occurrences.MarkSent(ctx, occ.ID, sentAt); err != nil {
+			t.Fatalf("mark sent: %v", err)
+		}
+		return occ
+	}
+	early := sent(base, base.Add(time.Second))
+	late := sent(base.Add(time.Hour), base.Add(time.Hour+time.Second))
+	answered := sent(base.Add(2*time.Hour), base.Add(2*time.Hour))
+	if err := s.Occurrences.MarkAcked(ctx, answered.ID, domain.OccurrenceDone, base.Add(2*time.Hour+time.Minute)); err != nil {
+		t.Fatalf("mark acked: %v", err)
+	}
+	mustOccurrence(t, s, rem.ID, base.Add(-time.Hour)) // never sent
+
+	list, err := s.Occurrences.ListSentInRange(ctx, base, base.Add(3*time.Hour))
+	if err != nil {
+		t.Fatalf("list sent: %v", err)
+	}
+	assertOccurrenceIDs(t, list, []int64{early.ID, late.ID})
+
+	list, err = s.Occurrences.ListSentInRange(ctx, base.Add(time.Minute), base.Add(3*time.Hour))
+	if err != nil {
+		t.Fatalf("list sent from a later start: %v", err)
+	}
+	assertOccurrenceIDs(t, list, []int64{late.ID})
+
+	escalatedAt := base.Add(30 * time.Minute)
+	if err := s.Occurrences.MarkEscalated(ctx, early.ID, escalatedAt); err != nil {
+		t.Fatalf("mark escalated: %v", err)
+	}
+	got, err := s.Occurrences.GetByID(ctx, early.ID)
+	if err != nil || got == nil || !got.EscalatedAtUtc.Equal(escalatedAt) {
+		t.Fatalf("escalated occurrence = (%+v, %v)", got, err)
+	}
+
+	// Steps of the escalation chain are claimed one at a time.
+	if ok, err := s.Occurrences.AdvanceEscalation(ctx, early.ID, 0); err != nil || !ok {
+		t.Fatalf("advance escalation = (%v, %v), want true", ok, err)
+	}
+	if ok, err := s.Occurrences.AdvanceEscalation(ctx, early.ID, 0); err != nil || ok {
+		t.Fatalf("advance claimed step = (%v, %v), want false", ok, err)
+	}
+	if ok, err := s.Occurrences.AdvanceEscalation(ctx, early.ID, 1); err != nil || !ok {
+		t.Fatalf("advance second step = (%v, %v), want true", ok, err)
+	}
+	if got, err := s.Occurrences.GetByID(ctx, early.ID); err != nil || got == nil || got.EscalationStep != 2 {
+		t.Fatalf("escalation step = (%+v, %v), want 2", got, err)
+	}
+	// Answering stops the chain.
+	if ok, err := s.Occurrences.AdvanceEscalation(ctx, answered.ID, 0); err != nil || ok {
+		t.Fatalf("advance answered = (%v, %v), want false", ok, err)
+	}
+}
+
+func testDigestSettings(t *testing.T, s Stores) {
+	ctx := context.Background()
+	alice := mustUser(t, s, 13001)
+	bob := mustUser(t, s, 13002)
+	carol := mustUser(t, s, 13003)
+
+	if got, err := s.Digests.Get(ctx, alice.ID); err != nil || got != nil {
+		t.Fatalf("Get on missing settings = (%v, %v), want (nil, nil)", got, err)
+	}
+
+	morning := domain.TimeOfDay{Hour: 7, Minute: 30}
+	want := &domain.DigestSettings{UserID: alice.ID, Morning: &morning, Weekly: true}
+	if err := s.Digests.Save(ctx, want); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	if err := s.Digests.Save(ctx, &domain.DigestSettings{UserID: carol.ID, Weekly: true}); err != nil {
+		t.Fatalf("save carol: %v", err)
+	}
+	// Disabled settings are stored but not listed.
+	if err := s.Digests.Save(ctx, &domain.DigestSettings{UserID: bob.ID}); err != nil {
+		t.Fatalf("save bob: %v", err)
+	}
+
+	got, err := s.Digests.Get(ctx, alice.ID)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if got == nil || got.Morning == nil || *got.Morning != morning || got.Evening != nil || !got.Weekly {
+		t.Fatalf("stored settings = %+v", got)
+	}
+
+	sentAt := base.Add(-time.Hour)
+	if err := s.Digests.MarkSent(ctx, alice.ID, domain.DigestMorning, sentAt); err != nil {
+		t.Fatalf("mark sent: %v", err)
+	}
+	got, err = s.Digests.Get(ctx, alice.ID)
+	if err != nil {
+		t.Fatalf("get after mark sent: %v", err)
+	}
+	if !got.LastMorningUtc.Equal(sentAt) || !got.LastEveningUtc.IsZero() || !got.LastWeeklyUtc.IsZero() {
+		t.Fatalf("after mark sent: %+v", got)
+	}
+
+	list, err := s.Digests.ListEnabled(ctx)
+	if err != nil {
+		t.Fatalf("list enabled: %v", err)
+	}
+	if len(list) != 2 || list[0].UserID != alice.ID || list[1].UserID != carol.ID {
+		t.Fatalf("list enabled = %+v, want alice and carol", list)
+	}
+
+	// Save replaces the settings wholesale.
+	evening := domain.TimeOfDay{Hour: 21, Minute: 15}
+	if err := s.Digests.Save(ctx, &domain.DigestSettings{UserID: alice.ID, Evening: &evening, LastMorningUtc: sentAt}); err != nil {
+		t.Fatalf("resave: %v", err)
+	}
+	got, err = s.Digests.Get(ctx, alice.ID)
+	if err != nil {
+		t.Fatalf("get after resave: %v", err)
+	}
+	if got.Morning != nil || got.Evening == nil || *got.Evening != evening || got.Weekly || !got.LastMorningUtc.Equal(sentAt) {
+		t.Fatalf("after resave: %+v", got)
+	}
+}
+
+func testWebhooks(t *testing.T, s Stores) {
+	ctx := context.Background()
+	alice := mustUser(t, s, 14001)
+	bob := mustUser(t, s, 14002)
+
+	if got, err := s.Webhooks.Get(ctx, alice.ID); err != nil || got != nil {
+		t.Fatalf("Get on missing settings = (%v, %v), want (nil, nil)", got, err)
+	}
+	settings := &domain.WebhookSettings{UserID: alice.ID, URL: "https://example.com/a", Secret: "s1"}
+	if err := s.Webhooks.Save(ctx, settings); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	settings.URL, settings.Secret = "", "s2"
+	if err := s.Webhooks.Save(ctx, settings); err != nil {
+		t.Fatalf("save again: %v", err)
+	}
+	if got, err := s.Webhooks.Get(ctx, alice.ID); err != nil || got == nil || *got != *settings {
+		t.Fatalf("get = (%+v, %v), want %+v", got, err, settings)
+	}
+
+	for i := 1; i <= domain.MaxWebhookDeliveries+2; i++ {
+		d := &domain.WebhookDelivery{UserID: alice.ID, OccurrenceID: int64(i), Event: "reminder", URL: "https://example.com/a",
+			Attempt: 1, StatusCode: 200, Duration: 15 * time.Millisecond, AtUtc: base.Add(time.Duration(i) * time.Second)}
+		if i%2 == 0 {
+			d.StatusCode, d.Error = 0, "timeout"
+		}
+		if err := s.Webhooks.RecordDelivery(ctx, d); err != nil {
+			t.Fatalf("record delivery %d: %v", i, err)
+		}
+		if d.ID == 0 {
+			t.Fatalf("delivery %d has no ID", i)
+		}
+	}
+	if err := s.Webhooks.RecordDelivery(ctx, &domain.WebhookDelivery{UserID: bob.ID, OccurrenceID: 1, Event: "reminder", URL: "https://example.com/b", Attempt: 1, AtUtc: base}); err != nil {
+		t.Fatalf("record delivery of bob: %v", err)
+	}
+
+	list, err := s.Webhooks.ListDeliveries(ctx, alice.ID, 3)
+	if err != nil {
+		t.Fatalf("list deliveries: %v", err)
+	}
+	last := int64(domain.MaxWebhookDeliveries + 2)
+	if len(list) != 3 || list[0].OccurrenceID != last || list[2].OccurrenceID != last-2 {
+		t.Fatalf("latest deliveries = %+v, want occurrences %d down to %d", list, last, last-2)
+	}
+	if d := list[0]; d.OK() || d.Error != "timeout" || d.Event != "reminder" || d.URL != "https://example.com/a" ||
+		d.Attempt != 1 || d.Duration != 15*time.Millisecond || !d.AtUtc.Equal(base.Add(time.Duration(last)*time.Second)) {
+		t.Fatalf("delivery = %+v", d)
+	}
+	if !list[1].OK() || list[1].StatusCode != 200 {
+		t.Fatalf("delivery = %+v, want a delivered one", list[1])
+	}
+
+	// Only the latest MaxWebhookDeliveries entries are kept.
+	list, err = s.Webhooks.ListDeliveries(ctx, alice.ID, 1000)
+	if err != nil || len(list) != domain.MaxWebhookDeliveries || list[len(list)-1].OccurrenceID != 3 {
+		t.Fatalf("all deliveries: %d entries, err %v", len(list), err)
+	}
+	if list, err := s.Webhooks.ListDeliveries(ctx, bob.ID, 10); err != nil || len(list) != 1 {
+		t.Fatalf("deliveries of bob = (%v, %v)", list, err)
+	}
+
+	if err := s.Webhooks.Delete(ctx, alice.ID); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if got, err := s.Webhooks.Get(ctx, alice.ID); err != nil || got != nil {
+		t.Fatalf("get after delete = (%v, %v), want (nil, nil)", got, err)
+	}
+}
+
+func testWebhookJobs(t *testing.T, s Stores) {
+	ctx := context.Background()
+	alice := mustUser(t, s, 14101)
+
+	var jobs []*domain.WebhookJob
+	for i, next := range []time.Duration{time.Minute, 0, time.Hour} {
+		j := &domain.WebhookJob{UserID: alice.ID, OccurrenceID: int64(i + 1), Event: "reminder", URL: "https://example.com/a",
+			Body: []byte(`{"event":"reminder"}`), NextAtUtc: base.Add(next)}
+		if err := s.Webhooks.Enqueue(ctx, j); err != nil {
+			t.Fatalf("enqueue %d: %v", i, err)
+		}
+		if j.ID == 0 {
+			t.Fatalf("job %d has no ID", i)
+		}
+		jobs = append(jobs, j)
+	}
+
+	due, err := s.Webhooks.ListDueJobs(ctx, base.Add(time.Minute), 10)
+	if err != nil {
+		t.Fatalf("list due: %v", err)
+	}
+	if len(due) != 2 || due[0].ID != jobs[1].ID || due[1].ID != jobs[0].ID {
+		t.Fatalf("due jobs = %+v, want jobs %d and %d", due, jobs[1].ID, jobs[0].ID)
+	}
+	if j := due[0]; j.UserID != alice.ID || j.OccurrenceID != 2 || j.Event != "reminder" || j.URL != "https://example.com/a" ||
+		string(j.Body) != `{"event":"reminder"}` || j.Attempts != 0 || !j.NextAtUtc.Equal(base) {
+		t.Fatalf("job = %+v", j)
+	}
+	if due, err := s.Webhooks.ListDueJobs(ctx, base.Add(time.Hour), 1); err != nil || len(due) != 1 || due[0].ID != jobs[1].ID {
+		t.Fatalf("limited due jobs = (%+v, %v)", due, err)
+	}
+
+	// A failed attempt postpones the job; a delivered one leaves the queue.
+	if err := s.Webhooks.RetryJob(ctx, jobs[1].ID, 1, base.Add(2*time.Hour)); err != nil {
+		t.Fatalf("retry: %v", err)
+	}
+	if err := s.Webhooks.DeleteJob(ctx, jobs[0].ID); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	due, err = s.Webhooks.ListDueJobs(ctx, base.Add(2*time.Hour), 10)
+	if err != nil {
+		t.Fatalf("list due: %v", err)
+	}
+	if len(due) != 2 || due[0].ID != jobs[2].ID || due[1].ID != jobs[1].ID || due[1].Attempts != 1 {
+		t.Fatalf("due jobs after retry = %+v", due)
+	}
+}
+
+func testEmails(t *testing.T, s Stores) {
+	ctx := context.Background()
+	alice := mustUser(t, s, 15001)
+
+	if got, err := s.Emails.Get(ctx, alice.ID); err != nil || got != nil {
+		t.Fatalf("Get on missing address = (%v, %v), want (nil, nil)", got, err)
+	}
+	pending := &domain.EmailAddress{UserID: alice.ID, Address: "alice@example.com", Code: "123456", CodeExpiresAtUtc: base.Add(domain.EmailCodeTTL), CodeAttempts: 2}
+	if err := s.Emails.Save(ctx, pending); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	got, err := s.Emails.Get(ctx, alice.ID)
+	if err != nil || got == nil || got.Address != pending.Address || got.Code != "123456" || got.CodeAttempts != 2 ||
+		!got.CodeExpiresAtUtc.Equal(pending.CodeExpiresAtUtc) || got.Verified() {
+		t.Fatalf("get = (%+v, %v), want %+v", got, err, pending)
+	}
+
+	verified := &domain.EmailAddress{UserID: alice.ID, Address: "alice@example.com", VerifiedAtUtc: base.Add(time.Minute)}
+	if err := s.Emails.Save(ctx, verified); err != nil {
+		t.Fatalf("save verified: %v", err)
+	}
+	got, err = s.Emails.Get(ctx, alice.ID)
+	if err != nil || got == nil || got.Code != "" || !got.CodeExpiresAtUtc.IsZero() || got.CodeAttempts != 0 ||
+		!got.Verified() || !got.VerifiedAtUtc.Equal(verified.VerifiedAtUtc) {
+		t.Fatalf("get verified = (%+v, %v), want %+v", got, err, verified)
+	}
+
+	if err := s.Emails.Delete(ctx, alice.ID); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if got, err := s.Emails.Get(ctx, alice.ID); err != nil || got != nil {
+		t.Fatalf("get after delete = (%v, %v), want (nil, nil)", got, err)
+	}
+}
+
+func testInvites(t *testing.T, s Stores) {
+	ctx := context.Background()
+
+	got, err := s.Invites.Get(ctx, "missing")
+	if err != nil || got != nil {
+		t.Fatalf("Get on missing invite = (%v, %v), want (nil, nil)", got, err)
+	}
+	if ok, err := s.Invites.Redeem(ctx, "missing", base); err != nil || ok {
+		t.Fatalf("Redeem on missing invite = (%v, %v), want (false, nil)", ok, err)
+	}
+
+	once := &domain.Invite{Code: "ONCE", CreatedBy: 7, CreatedAtUtc: base, MaxUses: 1}
+	expiring := &domain.Invite{Code: "SOON", CreatedBy: 7, CreatedAtUtc: base.Add(time.Minute), ExpiresAtUtc: base.Add(time.Hour)}
+	unlimited := &domain.Invite{Code: "OPEN", CreatedBy: 7, CreatedAtUtc: base.Add(2 * time.Minute)}
+	for _, inv := range []*domain.Invite{once, expiring, unlimited} {
+		if err := s.Invites.Create(ctx, inv); err != nil {
+			t.Fatalf("create %s: %v", inv.Code, err)
+		}
+	}
+	if err := s.Invites.Create(ctx, &domain.Invite{Code: "ONCE", CreatedAtUtc: base}); err == nil {
+		t.Fatalf("creating a duplicate code succeeded")
+	}
+
+	got, err = s.Invites.Get(ctx, "SOON")
+	if err != nil || got == nil || got.CreatedBy != 7 || !got.CreatedAtUtc.Equal(expiring.CreatedAtUtc) ||
+		!got.ExpiresAtUtc.Equal(expiring.ExpiresAtUtc) || got.MaxUses != 0 || got.Uses != 0 || !got.RevokedAtUtc.IsZero() {
+		t.Fatalf("get = (%+v, %v), want %+v", got, err, expiring)
+	}
+
+	assertCodes := func(at time.Time, want ...string) {
+		t.Helper()
+		list, err := s.Invites.ListUsable(ctx, at)
+		if err != nil {
+			t.Fatalf("list usable: %v", err)
+		}
+		var codes []string
+		for _, inv := range list {
+			codes = append(codes, inv.Code)
+		}
+		if strings.Join(codes, ",") != strings.Join(want, ",") {
+			t.Fatalf("usable at %s = %v, want %v", at, codes, want)
+		}
+	}
+	assertCodes(base, "ONCE", "SOON", "OPEN")
+
+	if ok, err := s.Invites.Redeem(ctx, "ONCE", base); err != nil || !ok {
+		t.Fatalf("first redeem = (%v, %v), want (true, nil)", ok, err)
+	}
+	if ok, err := s.Invites.Redeem(ctx, "ONCE", base); err != nil || ok {
+		t.Fatalf("redeem of used-up invite = (%v, %v), want (false, nil)", ok, err)
+	}
+	if ok, err := s.Invites.Redeem(ctx, "SOON", base.Add(time.Hour)); err != nil || ok {
+		t.Fatalf("redeem of expired invite = (%v, %v), want (false, nil)", ok, err)
+	}
+	for i := 0; i < 3; i++ {
+		if ok, err := s.Invites.Redeem(ctx, "OPEN", base); err != nil || !ok {
+			t.Fatalf("redeem unlimited #%d = (%v, %v), want (true, nil)", i+1, ok, err)
+		}
+	}
+	if got, _ := s.Invites.Get(ctx, "OPEN"); got == nil || got.Uses != 3 {
+		t.Fatalf("unlimited invite after 3 redeems = %+v", got)
+	}
+	assertCodes(base, "SOON", "OPEN")
+	assertCodes(base.Add(time.Hour), "OPEN")
+
+	if err := s.Invites.Revoke(ctx, "OPEN", base.Add(5*time.Minute)); err != nil {
+		t.Fatalf("revoke: %v", err)
+	}
+	if ok, err := s.Invites.Redeem(ctx, "OPEN", base.Add(10*time.Minute)); err != nil || ok {
+		t.Fatalf("redeem of revoked invite = (%v, %v), want (false, nil)", ok, err)
+	}
+	if got, _ := s.Invites.Get(ctx, "OPEN"); got == nil || !got.RevokedAtUtc.Equal(base.Add(5*time.Minute)) {
+		t.Fatalf("revoked invite = %+v", got)
+	}
+	assertCodes(base, "SOON")
+	if err := s.Invites.Revoke(ctx, "missing", base); err != nil {
+		t.Fatalf("revoke missing invite: %v", err)
+	}
+}
+
+func testUnitOfWorkCommit(t *testing.T, s Stores) {
+	ctx := context.Background()
+	user := mustUser(t, s, 10001)
+
+	var rem *domain.Reminder
+	var occs []*domain.Occurrence
+	err := s.UnitOfWork.Do(ctx, func(ctx context.Context, tx domain.Stores) error {
+		rem = newReminder(user.ID, "tx")
+		if err := tx.Reminders.Create(ctx, rem); err != nil {
+			return err
+		}
+		occs = []*domain.Occurrence{
+			{ReminderID: rem.ID, FireAtUtc: base, Status: domain.OccurrenceCreated},
+			{ReminderID: rem.ID, FireAtUtc: base.Add(time.Hour), Status: domain.OccurrenceCreated},
+		}
+		return tx.Occurrences.CreateBatch(ctx, occs)
+	})
+	if err != nil {
+		t.Fatalf("unit of work: %v", err)
+	}
+
+	got, err := s.Reminders.GetByID(ctx, rem.ID)
+	if err != nil {
+		t.Fatalf("get committed reminder: %v", err)
+	}
+	assertReminder(t, got, rem)
+
+	list, err := s.Occurrences.ListByReminder(ctx, rem.ID)
+	if err != nil {
+		t.Fatalf("list committed occurrences: %v", err)
+	}
+	assertOccurrenceIDs(t, list, []int64{occs[0].ID, occs[1].ID})
+}
+
+func testUnitOfWorkRollback(t *testing.T, s Stores) {
+	ctx := context.Background()
+	user := mustUser(t, s, 11001)
+	kept := newReminder(user.ID, "kept")
+	if err := s.Reminders.Create(ctx, kept); err != nil {
+		t.Fatalf("create kept: %v", err)
+	}
+	keptOcc := mustOccurrence(t, s, kept.ID, base)
+	if err := s.Invites.Create(ctx, &domain.Invite{Code: "ROLLBACK", CreatedBy: 7, CreatedAtUtc: base, MaxUses: 1}); err != nil {
+		t.Fatalf("create invite: %v", err)
+	}
+
+	boom := errors.New("boom")
+	var created *domain.Reminder
+	err := s.UnitOfWork.Do(ctx, func(ctx context.Context, tx domain.Stores) error {
+		if ok, err := tx.Invites.Redeem(ctx, "ROLLBACK", base); err != nil || !ok {
+			return fmt.Errorf("redeem = (%v, %v)", ok, err)
+		}
+		if err := tx.Users.SetRegistered(ctx, user.ID, true); err != nil {
+			return err
+		}
+		created = newReminder(user.ID, "rolled back")
+		if err := tx.Reminders.Create(ctx, created); err != nil {
+			return err
+		}
+		if err := tx.Occurrences.Create(ctx, &domain.Occurrence{ReminderID: created.ID, FireAtUtc: base, Status: domain.OccurrenceCreated}); err != nil {
+			return err
+		}
+		if err := tx.Occurrences.DeleteByReminder(ctx, kept.ID); err != nil {
+			return err
+		}
+		if err := tx.Reminders.DeleteByID(ctx, kept.ID); err != nil {
+			return err
+		}
+		return boom
+	})
+	if !errors.Is(err, boom) {
+		t.Fatalf("unit of work error = %v, want %v", err, boom)
+	}
+
+	if got, err := s.Reminders.GetByID(ctx, created.ID); err != nil || got != nil {
+		t.Fatalf("rolled back reminder still readable: (%v, %v)", got, err)
+	}
+	if inv, err := s.Invites.Get(ctx, "ROLLBACK"); err != nil || inv == nil || inv.Uses != 0 {
+		t.Fatalf("rolled back invite = (%+v, %v), want it unused", inv, err)
+	}
+	if got, err := s.Users.GetByID(ctx, user.ID); err != nil || got == nil || got.Registered {
+		t.Fatalf("rolled back user = (%+v, %v), want unregistered", got, err)
+	}
+	list, err := s.Occurrences.ListByReminder(ctx, created.ID)
+	if err != nil {
+		t.Fatalf("list rolled back occurrences: %v", err)
+	}
+	assertOccurrenceIDs(t, list, nil)
+
+	rems, err := s.Reminders.ListByUser(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("list reminders: %v", err)
+	}
+	assertReminderIDs(t, rems, []int64{kept.ID})
+	list, err = s.Occurrences.ListByReminder(ctx, kept.ID)
+	if err != nil {
+		t.Fatalf("list kept occurrences: %v", err)
+	}
+	assertOccurrenceIDs(t, list, []int64{keptOcc.ID})
+}
+
+func newReminder(userID int64, name string) *domain.Reminder {
+	return &domain.Reminder{
+		UserID:      userID,
+		Name:        name,
+		Description: name + " description",
+		StartDate:   base,
+		EndDate:     base.Add(72 * time.Hour),
+		TimesOfDay:  []domain.TimeOfDay{{Hour: 8, Minute: 0}, {Hour: 19, Minute: 45}},
+		TimeZone:    "Europe/Warsaw",
+		IsActive:    true,
+	}
+}
+
+func mustUser(t *testing.T, s Stores, telegramID int64) *domain.User {
+	t.Helper()
+	u := &domain.User{TelegramID: telegramID, Username: "user", FirstName: "Test"}
+	if err := s.Users.Upsert(context.Background(), u); err != nil {
+		t.Fatalf("upsert user %d: %v", telegramID, err)
+	}
+	return u
+}
+
+func mustReminder(t *testing.T, s Stores, telegramID int64) *domain.Reminder {
+	t.Helper()
+	u := mustUser(t, s, telegramID)
+	rem := newReminder(u.ID, "reminder")
+	if err := s.Reminders.Create(context.Background(), rem); err != nil {
+		t.Fatalf("create reminder: %v", err)
+	}
+	return rem
+}
+
+func mustOccurrence(t *testing.T, s Stores, reminderID int64, fireAt time.Time) *domain.Occurrence {
+	t.Helper()
+	occ := &domain.Occurrence{ReminderID: reminderID, FireAtUtc: fireAt, Status: domain.OccurrenceCreated}
+	if err := s.Occurrences.Create(context.Background(), occ); err != nil {
+		t.Fatalf("create occurrence: %v", err)
+	}
+	return occ
+}
+
+func assertUser(t *testing.T, got, want *domain.User) {
+	t.Helper()
+	if got == nil {
+		t.Fatalf("user %d not found", want.ID)
+	}
+	if *got != *want {
+		t.Fatalf("user mismatch:\n got %+v\nwant %+v", *got, *want)
+	}
+}
+
+func assertReminder(t *testing.T, got, want *domain.Reminder) {
+	t.Helper()
+	if got == nil {
+		t.Fatalf("reminder %d not found", want.ID)
+	}
+	if got.ID != want.ID || got.UserID != want.UserID || got.Name != want.Name ||
+		got.Description != want.Description || got.TimeZone != want.TimeZone || got.IsActive != want.IsActive ||
+		got.Priority != want.Priority || got.Template != want.Template ||
+		got.ChatID != want.ChatID || got.Confirm != want.Confirm ||
+		got.RecipientID != want.RecipientID || got.RecipientAccepted != want.RecipientAccepted || got.EscalateAfter != want.EscalateAfter ||
+		got.WebhookURL != want.WebhookURL || got.Channels != want.Channels ||
+		!got.StartDate.Equal(want.StartDate) || !got.EndDate.Equal(want.EndDate) {
+		t.Fatalf("reminder mismatch:\n got %+v\nwant %+v", *got, *want)
+	}
+	if len(got.TimesOfDay) != len(want.TimesOfDay) {
+		t.Fatalf("times of day mismatch: got %v, want %v", got.TimesOfDay, want.TimesOfDay)
+	}
+	for i := range got.TimesOfDay {
+		if got.TimesOfDay[i] != want.TimesOfDay[i] {
+			t.Fatalf("times of day mismatch: got %v, want %v", got.TimesOfDay, want.TimesOfDay)
+		}
+	}
+	if len(got.Escalations) != len(want.Escalations) {
+		t.Fatalf("escalations mismatch: got %v, want %v", got.Escalations, want.Escalations)
+	}
+	for i := range got.Escalations {
+		if got.Escalations[i] != want.Escalations[i] {
+			t.Fatalf("escalations mismatch: got %v, want %v", got.Escalations, want.Escalations)
+		}
+	}
+}
+
+func assertOccurrence(t *testing.T, got, want *domain.Occurrence) {
+	t.Helper()
+	if got == nil {
+		t.Fatalf("occurrence %d not found", want.ID)
+	}
+	if got.ID != want.ID || got.ReminderID != want.ReminderID || got.Status != want.Status ||
+		!got.FireAtUtc.Equal(want.FireAtUtc) || !got.SentAtUtc.Equal(want.SentAtUtc) || !got.AckedAtUtc.Equal(want.AckedAtUtc) ||
+		got.Attempts != want.Attempts || got.ChatID != want.ChatID || got.MessageID != want.MessageID ||
+		!got.EscalatedAtUtc.Equal(want.EscalatedAtUtc) || got.EscalationStep != want.EscalationStep {
+		t.Fatalf("occurrence mismatch:\n got %+v\nwant %+v", *got, *want)
+	}
+}
+
+func assertReminderIDs(t *testing.T, got []*domain.Reminder, want []int64) {
+	t.Helper()
+	ids := make([]int64, 0, len(got))
+	for _, r := range got {
+		ids = append(ids, r.ID)
+	}
+	assertIDs(t, "reminders", ids, want)
+}
+
+func assertOccurrenceIDs(t *testing.T, got []*domain.Occurrence, want []int64) {
+	t.Helper()
+	ids := make([]int64, 0, len(got))
+	for _, o := range got {
+		ids = append(ids, o.ID)
+	}
+	assertIDs(t, "occurrences", ids, want)
+}
+
+func assertIDs(t *testing.T, what string, got, want []int64) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("%s: got IDs %v, want %v", what, got, want)
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Fatalf("%s: got IDs %v, want %v", what, got, want)
+		}
+	}
+}
@@ -0,0 +1,407 @@
+package telegram
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"naggingbot/internal/domain"
+)
+
+// broadcastDelay keeps broadcasts under Telegram's limit of ~30 messages per second.
+const broadcastDelay = 50 * time.Millisecond
+
+const adminUsage = "Usage:\n" +
+	"/admin stats - users, reminders, delivery backlog and handler metrics\n" +
+	"/admin broadcast <text> - message every user\n" +
+	"/admin user <telegram_id> - show a user\n" +
+	"/admin ban <telegram_id> - ignore a user\n" +
+	"/admin unban <telegram_id> - lift a ban\n" +
+	"/admin invite [uses] [days] - issue an invite code (default 1 use, 7 days; 0 = unlimited)\n" +
+	"/admin invites - list usable invites\n" +
+	"/admin revoke <code> - revoke an invite"
+
+// Invite defaults for /admin invite.
+const (
+	defaultInviteUses = 1
+	defaultInviteDays = 7
+)
+
+// inviteAlphabet avoids characters that are easily confused (0/O, 1/I).
+const inviteAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// AdminHandler handles /admin subcommands. It must be registered with
+// Dispatcher.RegisterAdminCommand.
+type AdminHandler struct {
+	users       domain.UserStore
+	reminders   domain.ReminderStore
+	occurrences domain.OccurrenceStore
+	invites     domain.InviteStore
+	auth        *Authorizer
+	metrics     *Metrics
+	responder   Responder
+	// botUsername is used for invite deep links; empty disables them.
+	botUsername string
+}
+
+func NewAdminHandler(users domain.UserStore, reminders domain.ReminderStore, occurrences domain.OccurrenceStore, invites domain.InviteStore, auth *Authorizer, metrics *Metrics, responder Responder, botUsername string) *AdminHandler {
+	return &AdminHandler{
+		users:       users,
+		reminders:   reminders,
+		occurrences: occurrences,
+		invites:     invites,
+		auth:        auth,
+		metrics:     metrics,
+		responder:   responder,
+		botUsername: botUsername,
+	}
+}
+
+func (h *AdminHandler) HandleCommand(ctx context.Context, msg *Message) error {
+	user := msg.From
+	if user == nil {
+		return nil
+	}
+
+	parts := strings.Fields(msg.Text)
+	if len(parts) < 2 {
+		reply(ctx, h.responder, user.ID, adminUsage)
+		return nil
+	}
+
+	switch parts[1] {
+	case "stats":
+		h.stats(ctx, user.ID)
+	case "broadcast":
+		text := textAfterTokens(msg.Text, 2)
+		if text == "" {
+			reply(ctx, h.responder, user.ID, "Usage: /admin broadcast <text>")
+			return nil
+		}
+		h.broadcast(ctx, user.ID, text)
+	case "user", "ban", "unban":
+		if len(parts) != 3 {
+			reply(ctx, h.responder, user.ID, fmt.Sprintf("Usage: /admin %s <telegram_id>", parts[1]))
+			return nil
+		}
+		id, err := strconv.ParseInt(parts[2], 10, 64)
+		if err != nil {
+			reply(ctx, h.responder, user.ID, "Invalid id")
+			return nil
+		}
+		target, err := h.findUser(ctx, id)
+		if err != nil {
+			log.Printf("telegram: admin find user %d failed: %v", id, err)
+			reply(ctx, h.responder, user.ID, "Failed to load user")
+			return nil
+		}
+		if target == nil {
+			reply(ctx, h.responder, user.ID, "User not found")
+			return nil
+		}
+		if parts[1] == "user" {
+			h.showUser(ctx, user.ID, target)
+		} else {
+			h.setBanned(ctx, user.ID, target, parts[1] == "ban")
+		}
+	case "invite":
+		h.issueInvite(ctx, user.ID, parts[2:])
+	case "invites":
+		h.listInvites(ctx, user.ID)
+	case "revoke":
+		if len(parts) != 3 {
+			reply(ctx, h.responder, user.ID, "Usage: /admin revoke <code>")
+			return nil
+		}
+		h.revokeInvite(ctx, user.ID, strings.ToUpper(parts[2]))
+	default:
+		reply(ctx, h.responder, user.ID, adminUsage)
+	}
+	return nil
+}
+
+func (h *AdminHandler) stats(ctx context.Context, chatID int64) {
+	users, err := h.users.List(ctx)
+	if err != nil {
+		log.Printf("telegram: admin list users failed: %v", err)
+		reply(ctx, h.responder, chatID, "Failed to load stats")
+		return
+	}
+
+	banned, reminders, active := 0, 0, 0
+	for _, u := range users {
+		if u.Banned {
+			banned++
+		}
+		rems, err := h.reminders.ListByUser(ctx, u.ID)
+		if err != nil {
+			log.Printf("telegram: admin list reminders of user %d failed: %v", u.ID, err)
+			reply(ctx, h.responder, chatID, "Failed to load stats")
+			return
+		}
+		reminders += len(rems)
+		for _, rem := range rems {
+			if rem.IsActive {
+				active++
+			}
+		}
+	}
+
+	now := time.Now().UTC()
+	overdue, err := h.occurrences.ListPendingInRange(ctx, time.Time{}, now)
+	if err != nil {
+		log.Printf("telegram: admin list pending occurrences failed: %v", err)
+		reply(ctx, h.responder, chatID, "Failed to load stats")
+		return
+	}
+	upcoming, err := h.occurrences.ListPendingInRange(ctx, now.Add(time.Nanosecond), now.Add(24*time.Hour))
+	if err != nil {
+		log.Printf("telegram: admin list pending occurrences failed: %v", err)
+		reply(ctx, h.responder, chatID, "Failed to load stats")
+		return
+	}
+
+	text := fmt.Sprintf(
+		"Users: %d (%d banned)\nReminders: %d (%d active)\nBacklog: %d due but not yet delivered\nNext 24h: %d scheduled",
+		len(users), banned, reminders, active, len(overdue), len(upcoming))
+	if h.metrics != nil {
+		if m := h.metrics.String(); m != "" {
+			text += "\n\nUpdates since start:\n" + m
+		}
+	}
+	reply(ctx, h.responder, chatID, text)
+}
+
+// broadcast sends text to every user in the background, so a long broadcast
+// does not hold up other updates, and reports the result to the admin.
+func (h *AdminHandler) broadcast(ctx context.Context, chatID int64, text string) {
+	users, err := h.users.List(ctx)
+	if err != nil {
+		log.Printf("telegram: admin list users failed: %v", err)
+		reply(ctx, h.responder, chatID, "Failed to load users")
+		return
+	}
+	reply(ctx, h.responder, chatID, fmt.Sprintf("Broadcasting to %d users...", len(users)))
+
+	go func() {
+		// Outside the update's recovery boundary, so recover here.
+		defer func() {
+			if r := recover(); r != nil {
+				_ = panicError(r, "broadcasting")
+			}
+		}()
+		sent, failed, err := Broadcast(ctx, h.responder, users, text)
+		result := fmt.Sprintf("Broadcast finished: sent %d, failed %d.", sent, failed)
+		if err != nil {
+			result = fmt.Sprintf("Broadcast interrupted: sent %d, failed %d.", sent, failed)
+		}
+		reply(ctx, h.responder, chatID, result)
+	}()
+}
+
+// findUser looks the user up by Telegram ID, falling back to the internal ID.
+func (h *AdminHandler) findUser(ctx context.Context, id int64) (*domain.User, error) {
+	u, err := h.users.GetByTelegramID(ctx, id)
+	if err != nil || u != nil {
+		return u, err
+	}
+	return h.users.GetByID(ctx, id)
+}
+
+func (h *AdminHandler) showUser(ctx context.Context, chatID int64, u *domain.User) {
+	rems, err := h.reminders.ListByUser(ctx, u.ID)
+	if err != nil {
+		log.Printf("telegram: admin list reminders of user %d failed: %v", u.ID, err)
+		reply(ctx, h.responder, chatID, "Failed to load user")
+		return
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "User #%d (Telegram %d)\n", u.ID, u.TelegramID)
+	if u.Username != "" {
+		fmt.Fprintf(&b, "Username: @%s\n", u.Username)
+	}
+	fmt.Fprintf(&b, "Name: %s\n", strings.TrimSpace(u.FirstName+" "+u.LastName))
+	if u.Language != "" {
+		fmt.Fprintf(&b, "Language: %s\n", u.Language)
+	}
+	if u.TimeZone != "" {
+		fmt.Fprintf(&b, "Time zone: %s\n", u.TimeZone)
+	}
+	if h.auth != nil && h.auth.IsAdmin(u.TelegramID) {
+		b.WriteString("Role: admin\n")
+	}
+	if u.Banned {
+		b.WriteString("Banned: yes\n")
+	}
+	fmt.Fprintf(&b, "Reminders: %d", len(rems))
+	for _, rem := range rems {
+		state := "active"
+		if !rem.IsActive {
+			state = "inactive"
+		}
+		fmt.Fprintf(&b, "\n#%d %s (%s)", rem.ID, rem.Name, state)
+	}
+	reply(ctx, h.responder, chatID, b.String())
+}
+
+func (h *AdminHandler) setBanned(ctx context.Context, chatID int64, u *domain.User, banned bool) {
+	if banned && h.auth != nil && h.auth.IsAdmin(u.TelegramID) {
+		reply(ctx, h.responder, chatID, "Admins cannot be banned")
+		return
+	}
+	if err := h.users.SetBanned(ctx, u.ID, banned); err != nil {
+		log.Printf("telegram: admin set banned for user %d failed: %v", u.ID, err)
+		reply(ctx, h.responder, chatID, "Failed to update user")
+		return
+	}
+	if banned {
+		reply(ctx, h.responder, chatID, fmt.Sprintf("User %d banned", u.TelegramID))
+	} else {
+		reply(ctx, h.responder, chatID, fmt.Sprintf("User %d unbanned", u.TelegramID))
+	}
+}
+
+// issueInvite handles /admin invite [uses] [days].
+func (h *AdminHandler) issueInvite(ctx context.Context, adminID int64, args []string) {
+	limits := []int{defaultInviteUses, defaultInviteDays}
+	if len(args) > len(limits) {
+		reply(ctx, h.responder, adminID, "Usage: /admin invite [uses] [days]")
+		return
+	}
+	for i, arg := range args {
+		n, err := strconv.Atoi(arg)
+		if err != nil || n < 0 {
+			reply(ctx, h.responder, adminID, "Usage: /admin invite [uses] [days]")
+			return
+		}
+		limits[i] = n
+	}
+
+	code, err := newInviteCode()
+	if err != nil {
+		log.Printf("telegram: generate invite code failed: %v", err)
+		reply(ctx, h.responder, adminID, "Failed to create invite")
+		return
+	}
+	now := time.Now().UTC()
+	inv := &domain.Invite{Code: code, CreatedBy: adminID, CreatedAtUtc: now, MaxUses: limits[0]}
+	if limits[1] > 0 {
+		inv.ExpiresAtUtc = now.AddDate(0, 0, limits[1])
+	}
+	if err := h.invites.Create(ctx, inv); err != nil {
+		log.Printf("telegram: create invite failed: %v", err)
+		reply(ctx, h.responder, adminID, "Failed to create invite")
+		return
+	}
+
+	text := fmt.Sprintf("Invite %s (%s)\nRedeem with /start %s", inv.Code, describeInvite(inv), inv.Code)
+	if h.botUsername != "" {
+		text += fmt.Sprintf("\nor open https://t.me/%s?start=%s", h.botUsername, inv.Code)
+	}
+	reply(ctx, h.responder, adminID, text)
+}
+
+func (h *AdminHandler) listInvites(ctx context.Context, adminID int64) {
+	invites, err := h.invites.ListUsable(ctx, time.Now().UTC())
+	if err != nil {
+		log.Printf("telegram: list invites failed: %v", err)
+		reply(ctx, h.responder, adminID, "Failed to load invites")
+		return
+	}
+	if len(invites) == 0 {
+		reply(ctx, h.responder, adminID, "No usable invites.")
+		return
+	}
+	var b strings.Builder
+	b.WriteString("Usable invites:")
+	for _, inv := range invites {
+		fmt.Fprintf(&b, "\n%s - %s, issued by %d", inv.Code, describeInvite(inv), inv.CreatedBy)
+	}
+	reply(ctx, h.responder, adminID, b.String())
+}
+
+func (h *AdminHandler) revokeInvite(ctx context.Context, adminID int64, code string) {
+	inv, err := h.invites.Get(ctx, code)
+	if err != nil {
+		log.Printf("telegram: get invite failed: %v", err)
+		reply(ctx, h.responder, adminID, "Failed to revoke invite")
+		return
+	}
+	if inv == nil {
+		reply(ctx, h.responder, adminID, "Invite not found")
+		return
+	}
+	if err := h.invites.Revoke(ctx, code, time.Now().UTC()); err != nil {
+		log.Printf("telegram: revoke invite failed: %v", err)
+		reply(ctx, h.responder, adminID, "Failed to revoke invite")
+		return
+	}
+	reply(ctx, h.responder, adminID, fmt.Sprintf("Invite %s revoked (%d uses so far)", code, inv.Uses))
+}
+
+// describeInvite summarizes the remaining uses and the expiry of an invite.
+func describeInvite(inv *domain.Invite) string {
+	uses := "unlimited uses"
+	if inv.MaxUses > 0 {
+		uses = fmt.Sprintf("%d of %d uses left", inv.MaxUses-inv.Uses, inv.MaxUses)
+	}
+	if inv.ExpiresAtUtc.IsZero() {
+		return uses + ", never expires"
+	}
+	return uses + ", expires " + inv.ExpiresAtUtc.Format("02.01.2006 15:04 UTC")
+}
+
+func newInviteCode() (string, error) {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	for i, b := range buf {
+		buf[i] = inviteAlphabet[int(b)%len(inviteAlphabet)]
+	}
+	return string(buf), nil
+}
+
+// textAfterTokens returns s without its first n whitespace-separated tokens,
+// keeping the line breaks of the remainder.
+func textAfterTokens(s string, n int) string {
+	s = strings.TrimSpace(s)
+	for i := 0; i < n; i++ {
+		idx := strings.IndexAny(s, " \t\r\n")
+		if idx < 0 {
+			return ""
+		}
+		s = strings.TrimSpace(s[idx:])
+	}
+	return s
+}
+
+// Broadcast sends text to every user that is not banned, pacing messages to
+// stay within Telegram's rate limits. Failed sends are logged and counted; an
+// error is returned only if ctx is cancelled.
+func Broadcast(ctx context.Context, responder Responder, users []*domain.User, text string) (sent, failed int, err error) {
+	for _, u := range users {
+		if u.Banned {
+			continue
+		}
+		if sent+failed > 0 {
+			select {
+			case <-ctx.Done():
+				return sent, failed, ctx.Err()
+			case <-time.After(broadcastDelay):
+			}
+		}
+		if err := responder.SendMessage(ctx, u.TelegramID, text); err != nil {
+			log.Printf("telegram: broadcast to %d failed: %v", u.TelegramID, err)
+			failed++
+			continue
+		}
+		sent++
+	}
+	return sent, failed, nil
+}
@@ -0,0 +1,275 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"naggingbot/internal/domain"
+)
+
+const (
+	defaultUpcomingCount = 10
+	maxUpcomingCount     = 20
+	maxUpcomingHours     = 7 * 24
+	// maxAgendaButtons caps the complete/skip rows attached to one agenda message.
+	maxAgendaButtons = 20
+)
+
+// agendaView identifies what an agenda message shows so it can be re-rendered
+// after a button press. It is encoded into callback data.
+type agendaView struct {
+	// kind is 't' for today, 'n' for the next n occurrences and 'h' for the next n hours.
+	kind byte
+	n    int
+}
+
+func (v agendaView) String() string {
+	if v.kind == 't' {
+		return "t"
+	}
+	return fmt.Sprintf("%c%d", v.kind, v.n)
+}
+
+func parseAgendaView(s string) (agendaView, error) {
+	if s == "t" {
+		return agendaView{kind: 't'}, nil
+	}
+	if len(s) < 2 || (s[0] != 'n' && s[0] != 'h') {
+		return agendaView{}, fmt.Errorf("invalid view %q", s)
+	}
+	n, err := strconv.Atoi(s[1:])
+	if err != nil || n <= 0 {
+		return agendaView{}, fmt.Errorf("invalid view %q", s)
+	}
+	return agendaView{kind: s[0], n: n}, nil
+}
+
+// parseUpcomingArg parses the /upcoming argument: a count (e.g. 5) or hours (e.g. 12h).
+func parseUpcomingArg(arg string) (agendaView, error) {
+	arg = strings.ToLower(strings.TrimSpace(arg))
+	if arg == "" {
+		return agendaView{kind: 'n', n: defaultUpcomingCount}, nil
+	}
+	if strings.HasSuffix(arg, "h") {
+		n, err := strconv.Atoi(strings.TrimSuffix(arg, "h"))
+		if err != nil || n <= 0 {
+			return agendaView{}, fmt.Errorf("invalid hours %q", arg)
+		}
+		return agendaView{kind: 'h', n: min(n, maxUpcomingHours)}, nil
+	}
+	n, err := strconv.Atoi(arg)
+	if err != nil || n <= 0 {
+		return agendaView{}, fmt.Errorf("invalid count %q", arg)
+	}
+	return agendaView{kind: 'n', n: min(n, maxUpcomingCount)}, nil
+}
+
+// agendaEntry pairs an occurrence with its reminder for rendering.
+type agendaEntry struct {
+	occ *domain.Occurrence
+	rem *domain.Reminder
+}
+
+// AgendaHandler handles /today and /upcoming [n|Nh], showing occurrences across
+// all reminders in the user's zone with buttons to complete or skip them early.
+type AgendaHandler struct {
+	reminders   domain.ReminderStore
+	occurrences domain.OccurrenceStore
+	responder   Responder
+}
+
+func NewAgendaHandler(reminders domain.ReminderStore, occurrences domain.OccurrenceStore, responder Responder) *AgendaHandler {
+	return &AgendaHandler{
+		reminders:   reminders,
+		occurrences: occurrences,
+		responder:   responder,
+	}
+}
+
+func (h *AgendaHandler) HandleCommand(ctx context.Context, msg *Message) error {
+	user := UserFrom(ctx)
+	if user == nil {
+		return nil
+	}
+
+	p := userPrinter(user)
+	view := agendaView{kind: 't'}
+	if commandName(msg.Text) == "/upcoming" {
+		var arg string
+		if parts := strings.Fields(msg.Text); len(parts) > 1 {
+			arg = parts[1]
+		}
+		v, err := parseUpcomingArg(arg)
+		if err != nil {
+			reply(ctx, h.responder, user.TelegramID, p.T("Usage: /upcoming [count|hours], e.g. /upcoming 5 or /upcoming 12h"))
+			return nil
+		}
+		view = v
+	}
+
+	text, markup, err := h.render(ctx, user, view)
+	if err != nil {
+		log.Printf("telegram: agenda render failed: %v", err)
+		reply(ctx, h.responder, user.TelegramID, p.T("Failed to load agenda"))
+		return nil
+	}
+	if h.responder != nil {
+		if err := h.responder.SendMessageWithMarkup(ctx, user.TelegramID, text, markup); err != nil {
+			log.Printf("telegram: failed to send agenda: %v", err)
+		}
+	}
+	return nil
+}
+
+func (h *AgendaHandler) HandleCallback(ctx context.Context, cb *CallbackQuery) error {
+	if cb == nil || cb.From == nil || cb.Message == nil {
+		return nil
+	}
+
+	action, occID, rawView, err := ParseAgendaCallback(cb.Data)
+	if err != nil {
+		log.Printf("telegram: bad agenda callback %q: %v", cb.Data, err)
+		rejectCallback(ctx, h.responder, cb, printerFrom(ctx).T("This button is not valid."))
+		return nil
+	}
+	view, err := parseAgendaView(rawView)
+	if err != nil {
+		log.Printf("telegram: bad agenda view %q: %v", rawView, err)
+		return nil
+	}
+
+	var status domain.OccurrenceStatus
+	switch action {
+	case OccurrenceActionDone:
+		status = domain.OccurrenceDone
+	case OccurrenceActionIgnore:
+		status = domain.OccurrenceIgnored
+	default:
+		return nil
+	}
+
+	user := UserFrom(ctx)
+	if user == nil {
+		return nil
+	}
+	p := userPrinter(user)
+	_, _, refusal, err := ownedOccurrence(ctx, h.reminders, h.occurrences, user, occID)
+	if err != nil {
+		failCallback(ctx, h.responder, cb, p.T("Failed to load the reminder, please try again."))
+		return nil
+	}
+	if refusal != "" {
+		rejectCallback(ctx, h.responder, cb, refusal)
+		return nil
+	}
+
+	err = h.occurrences.MarkAcked(ctx, occID, status, time.Now().UTC())
+	var transErr *domain.TransitionError
+	switch {
+	case errors.As(err, &transErr):
+		// Stale view: re-render it so it shows the recorded status.
+		answerCallback(ctx, h.responder, cb, alreadyAnswered(p, transErr.From))
+	case err != nil:
+		log.Printf("telegram: agenda mark occurrence %d failed: %v", occID, err)
+		failCallback(ctx, h.responder, cb, p.T("Failed to save, please try again."))
+		return nil
+	default:
+		answerCallback(ctx, h.responder, cb, AckToast(p, status))
+	}
+
+	text, markup, err := h.render(ctx, user, view)
+	if err != nil {
+		log.Printf("telegram: agenda render failed: %v", err)
+		return nil
+	}
+	if h.responder != nil {
+		if err := h.responder.EditMessageText(ctx, cb.Message.Chat.ID, cb.Message.MessageID, text, markup); err != nil {
+			log.Printf("telegram: failed to edit agenda: %v", err)
+		}
+	}
+	return nil
+}
+
+func (h *AgendaHandler) render(ctx context.Context, user *domain.User, view agendaView) (string, map[string]any, error) {
+	rems, err := h.reminders.ListByUser(ctx, user.ID)
+	if err != nil {
+		return "", nil, err
+	}
+	loc := user.Location(rems)
+	now := time.Now().UTC()
+
+	var start, end time.Time
+	switch view.kind {
+	case 't':
+		local := now.In(loc)
+		dayStart := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+		start, end = dayStart.UTC(), dayStart.AddDate(0, 0, 1).Add(-time.Nanosecond).UTC()
+	case 'h':
+		start, end = now, now.Add(time.Duration(view.n)*time.Hour)
+	default:
+		start, end = now, time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC)
+	}
+
+	var entries []agendaEntry
+	for _, rem := range rems {
+		if !rem.IsActive {
+			continue
+		}
+		occs, err := h.occurrences.ListByReminderInRange(ctx, rem.ID, start, end)
+		if err != nil {
+			return "", nil, err
+		}
+		for _, occ := range occs {
+			// Upcoming views only list what is still scheduled.
+			if view.kind != 't' && occ.Status != domain.OccurrenceCreated {
+				continue
+			}
+			entries = append(entries, agendaEntry{occ: occ, rem: rem})
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		a, b := entries[i].occ, entries[j].occ
+		if !a.FireAtUtc.Equal(b.FireAtUtc) {
+			return a.FireAtUtc.Before(b.FireAtUtc)
+		}
+		return a.ID < b.ID
+	})
+	if view.kind == 'n' && len(entries) > view.n {
+		entries = entries[:view.n]
+	}
+
+	p := userPrinter(user)
+	var b strings.Builder
+	when := p.ShortDateTime
+	switch view.kind {
+	case 't':
+		b.WriteString(p.T("Today, %s (%s):", p.ShortDate(now.In(loc)), loc) + "\n")
+		when = p.Time
+	case 'h':
+		b.WriteString(p.T("Upcoming in the next %dh (%s):", view.n, loc) + "\n")
+	default:
+		b.WriteString(p.N("Next %d occurrences (%s):", view.n, view.n, loc) + "\n")
+	}
+	if len(entries) == 0 {
+		b.WriteString("\n" + p.T("Nothing scheduled."))
+	}
+
+	rows := [][]map[string]any{}
+	for _, e := range entries {
+		fmt.Fprintf(&b, "\n%s %s (#%d) %s", when(e.occ.FireAtUtc.In(loc)), e.rem.Name, e.occ.ID, StatusLabel(p, e.occ, now))
+		if !isAnswered(e.occ) && len(rows) < maxAgendaButtons {
+			rows = append(rows, []map[string]any{
+				{"text": fmt.Sprintf("✅ #%d", e.occ.ID), "callback_data": BuildAgendaCallback(OccurrenceActionDone, e.occ.ID, view.String())},
+				{"text": p.T("⏭ Skip #%d", e.occ.ID), "callback_data": BuildAgendaCallback(OccurrenceActionIgnore, e.occ.ID, view.String())},
+			})
+		}
+	}
+
+	return b.String(), map[string]any{"inline_keyboard": rows}, nil
+}
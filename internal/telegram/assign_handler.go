@@ -0,0 +1,267 @@
+package telegram
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"naggingbot/internal/domain"
+	"naggingbot/internal/i18n"
+)
+
+const assignUsage = "Usage:\n" +
+	"/assign <id> @username [minutes]: let another user receive a reminder; you are alerted if they leave it unanswered for that long (default 30, 0 turns alerts off)\n" +
+	"/assign <id> off: stop the assignment (the recipient may do that too)\n" +
+	"/assign <id>: show who receives a reminder"
+
+// AssignHandler handles /assign, which delivers a reminder to another user
+// (caregiver mode), and the recipient's Accept/Decline buttons. The owner
+// keeps managing the reminder and is alerted by the scheduler when the
+// recipient leaves an occurrence unanswered.
+type AssignHandler struct {
+	users     domain.UserStore
+	reminders domain.ReminderStore
+	responder Responder
+}
+
+func NewAssignHandler(users domain.UserStore, reminders domain.ReminderStore, responder Responder) *AssignHandler {
+	return &AssignHandler{users: users, reminders: reminders, responder: responder}
+}
+
+func (h *AssignHandler) HandleCommand(ctx context.Context, msg *Message) error {
+	user := UserFrom(ctx)
+	if user == nil {
+		return nil
+	}
+
+	p := userPrinter(user)
+	parts := strings.Fields(msg.Text)
+	if len(parts) < 2 || len(parts) > 4 {
+		reply(ctx, h.responder, user.TelegramID, p.T(assignUsage))
+		return nil
+	}
+	id, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		reply(ctx, h.responder, user.TelegramID, p.T("Invalid id"))
+		return nil
+	}
+	rem, err := h.reminders.GetByID(ctx, id)
+	if err != nil {
+		log.Printf("telegram: assign get reminder failed: %v", err)
+		reply(ctx, h.responder, user.TelegramID, p.T("Failed to load reminder"))
+		return nil
+	}
+	// The recipient may look at or stop an assignment, nothing else.
+	off := len(parts) == 3 && strings.EqualFold(parts[2], "off")
+	if rem == nil || (rem.UserID != user.ID && (rem.RecipientID != user.ID || len(parts) > 2 && !off)) {
+		reply(ctx, h.responder, user.TelegramID, p.T("Reminder not found"))
+		return nil
+	}
+
+	switch {
+	case len(parts) == 2:
+		h.show(ctx, p, user, rem)
+	case off:
+		h.unassign(ctx, p, user, rem)
+	default:
+		escalateAfter := domain.DefaultEscalateAfter
+		if len(parts) == 4 {
+			minutes, err := strconv.Atoi(parts[3])
+			if err != nil || minutes < 0 || time.Duration(minutes)*time.Minute > domain.MaxEscalateAfter {
+				reply(ctx, h.responder, user.TelegramID, p.T("The alert delay must be 0 to %d minutes.", int(domain.MaxEscalateAfter/time.Minute)))
+				return nil
+			}
+			escalateAfter = time.Duration(minutes) * time.Minute
+		}
+		h.assign(ctx, p, user, rem, parts[2], escalateAfter)
+	}
+	return nil
+}
+
+func (h *AssignHandler) show(ctx context.Context, p *i18n.Printer, user *domain.User, rem *domain.Reminder) {
+	if rem.RecipientID == 0 {
+		reply(ctx, h.responder, user.TelegramID, p.T("Reminder #%d is not assigned to anyone.", rem.ID))
+		return
+	}
+	name := h.name(ctx, p, rem.RecipientID)
+	switch {
+	case !rem.RecipientAccepted:
+		reply(ctx, h.responder, user.TelegramID, p.T("Reminder #%d waits for %s to accept it.", rem.ID, name))
+	case rem.Escalates():
+		reply(ctx, h.responder, user.TelegramID, p.T("Reminder #%d is delivered to %s; the owner is alerted after %d min without an answer.", rem.ID, name, int(rem.EscalateAfter/time.Minute)))
+	default:
+		reply(ctx, h.responder, user.TelegramID, p.T("Reminder #%d is delivered to %s.", rem.ID, name))
+	}
+}
+
+// assign asks the user named by username to receive the reminder. It is
+// delivered to them only once they accept.
+func (h *AssignHandler) assign(ctx context.Context, p *i18n.Printer, user *domain.User, rem *domain.Reminder, username string, escalateAfter time.Duration) {
+	if rem.Shared() {
+		reply(ctx, h.responder, user.TelegramID, p.T("Reminder #%d is posted to a group; stop that with /share %d off first.", rem.ID, rem.ID))
+		return
+	}
+	recipient, err := h.users.GetByUsername(ctx, username)
+	if err != nil {
+		log.Printf("telegram: assign get user %q failed: %v", username, err)
+		reply(ctx, h.responder, user.TelegramID, p.T("Failed to look up %s, please try again.", username))
+		return
+	}
+	if recipient == nil || !recipient.Registered || recipient.Banned {
+		reply(ctx, h.responder, user.TelegramID, p.T("%s has to open the bot and send /start first.", username))
+		return
+	}
+	if recipient.ID == user.ID {
+		reply(ctx, h.responder, user.TelegramID, p.T("You already receive your own reminders."))
+		return
+	}
+
+	rem.RecipientID, rem.RecipientAccepted, rem.EscalateAfter = recipient.ID, false, escalateAfter
+	if err := h.reminders.Update(ctx, rem); err != nil {
+		log.Printf("telegram: assign update reminder failed: %v", err)
+		reply(ctx, h.responder, user.TelegramID, p.T("Failed to save the assignment"))
+		return
+	}
+
+	rp := userPrinter(recipient)
+	text := rp.T("%s asks you to receive the reminder “%s” and answer it with ✅ Done.", userName(user), rem.Name)
+	if escalateAfter > 0 {
+		text += "\n" + rp.T("They are alerted if you do not answer within %d min.", int(escalateAfter/time.Minute))
+	}
+	markup := map[string]any{
+		"inline_keyboard": [][]map[string]any{{
+			{"text": rp.T("✅ Accept"), "callback_data": BuildAssignCallback(rem.ID, AssignAccept)},
+			{"text": rp.T("❌ Decline"), "callback_data": BuildAssignCallback(rem.ID, AssignDecline)},
+		}},
+	}
+	if h.responder != nil {
+		if err := h.responder.SendMessageWithMarkup(ctx, recipient.TelegramID, text, markup); err != nil {
+			log.Printf("telegram: failed to send assignment request: %v", err)
+			reply(ctx, h.responder, user.TelegramID, p.T("Could not send the request to %s, please try again.", userName(recipient)))
+			return
+		}
+	}
+	reply(ctx, h.responder, user.TelegramID, p.T("Asked %s to accept reminder #%d. Until they do, you keep receiving it.", userName(recipient), rem.ID))
+}
+
+// unassign delivers the reminder to its owner again and tells the other side.
+func (h *AssignHandler) unassign(ctx context.Context, p *i18n.Printer, user *domain.User, rem *domain.Reminder) {
+	if rem.RecipientID == 0 {
+		reply(ctx, h.responder, user.TelegramID, p.T("Reminder #%d is not assigned to anyone.", rem.ID))
+		return
+	}
+	recipientID, accepted := rem.RecipientID, rem.RecipientAccepted
+	rem.RecipientID, rem.RecipientAccepted, rem.EscalateAfter = 0, false, 0
+	if err := h.reminders.Update(ctx, rem); err != nil {
+		log.Printf("telegram: unassign update reminder failed: %v", err)
+		reply(ctx, h.responder, user.TelegramID, p.T("Failed to save the assignment"))
+		return
+	}
+	reply(ctx, h.responder, user.TelegramID, p.T("Reminder #%d is no longer assigned.", rem.ID))
+
+	if user.ID == recipientID {
+		h.notify(ctx, rem.UserID, func(op *i18n.Printer) string {
+			return op.T("%s stopped receiving reminder #%d “%s”.", userName(user), rem.ID, rem.Name)
+		})
+	} else if accepted {
+		h.notify(ctx, recipientID, func(rp *i18n.Printer) string {
+			return rp.T("%s no longer sends you the reminder “%s”.", userName(user), rem.Name)
+		})
+	}
+}
+
+// HandleCallback handles the recipient's Accept/Decline buttons.
+func (h *AssignHandler) HandleCallback(ctx context.Context, cb *CallbackQuery) error {
+	user := UserFrom(ctx)
+	if cb == nil || user == nil {
+		return nil
+	}
+
+	p := userPrinter(user)
+	answer, id, err := ParseAssignCallback(cb.Data)
+	if err != nil || (answer != AssignAccept && answer != AssignDecline) {
+		log.Printf("telegram: bad callback data %q: %v", cb.Data, err)
+		rejectCallback(ctx, h.responder, cb, p.T("This button is not valid."))
+		return nil
+	}
+	rem, err := h.reminders.GetByID(ctx, id)
+	if err != nil {
+		log.Printf("telegram: get reminder %d failed: %v", id, err)
+		failCallback(ctx, h.responder, cb, p.T("Failed to load the reminder, please try again."))
+		return nil
+	}
+	// The owner may have cancelled or reassigned the reminder since.
+	if rem == nil || rem.RecipientID != user.ID || rem.RecipientAccepted {
+		rejectCallback(ctx, h.responder, cb, p.T("This request is no longer valid."))
+		h.edit(ctx, cb, p.T("This request is no longer valid."))
+		return nil
+	}
+
+	if answer == AssignAccept {
+		rem.RecipientAccepted = true
+	} else {
+		rem.RecipientID, rem.EscalateAfter = 0, 0
+	}
+	if err := h.reminders.Update(ctx, rem); err != nil {
+		log.Printf("telegram: assign answer update reminder failed: %v", err)
+		failCallback(ctx, h.responder, cb, p.T("Failed to save, please try again."))
+		return nil
+	}
+
+	answerCallback(ctx, h.responder, cb, "")
+	if answer == AssignAccept {
+		h.edit(ctx, cb, p.T("You now receive reminder #%d “%s”. Send /assign %d off to stop.", rem.ID, rem.Name, rem.ID))
+		h.notify(ctx, rem.UserID, func(op *i18n.Printer) string {
+			return op.T("%s accepted reminder #%d “%s”.", userName(user), rem.ID, rem.Name)
+		})
+	} else {
+		h.edit(ctx, cb, p.T("You declined the reminder “%s”.", rem.Name))
+		h.notify(ctx, rem.UserID, func(op *i18n.Printer) string {
+			return op.T("%s declined reminder #%d “%s”.", userName(user), rem.ID, rem.Name)
+		})
+	}
+	return nil
+}
+
+// edit replaces the assignment request with text and removes its buttons.
+func (h *AssignHandler) edit(ctx context.Context, cb *CallbackQuery, text string) {
+	if h.responder == nil || cb.Message == nil {
+		return
+	}
+	if err := h.responder.EditMessageText(ctx, cb.Message.Chat.ID, cb.Message.MessageID, text, BuildFinalMarkup()); err != nil {
+		log.Printf("telegram: failed to edit assignment request: %v", err)
+	}
+}
+
+// notify sends another user a message built in their language.
+func (h *AssignHandler) notify(ctx context.Context, userID int64, text func(*i18n.Printer) string) {
+	other, err := h.users.GetByID(ctx, userID)
+	if err != nil || other == nil {
+		log.Printf("telegram: assign notify user %d failed: %v", userID, err)
+		return
+	}
+	reply(ctx, h.responder, other.TelegramID, text(userPrinter(other)))
+}
+
+// name returns how to show the user with the given ID.
+func (h *AssignHandler) name(ctx context.Context, p *i18n.Printer, userID int64) string {
+	u, err := h.users.GetByID(ctx, userID)
+	if err != nil || u == nil {
+		log.Printf("telegram: assign get user %d failed: %v", userID, err)
+		return p.T("The recipient")
+	}
+	return userName(u)
+}
+
+// userName shows a user as @username, else by name.
+func userName(u *domain.User) string {
+	if u.Username != "" {
+		return "@" + u.Username
+	}
+	if name := strings.TrimSpace(u.FirstName + " " + u.LastName); name != "" {
+		return name
+	}
+	return strconv.FormatInt(u.TelegramID, 10)
+}
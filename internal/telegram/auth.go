@@ -0,0 +1,186 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"naggingbot/internal/domain"
+)
+
+// Access is the level a command or callback requires from its sender.
+type Access int
+
+const (
+	// AccessMember requires a user admitted by the registration policy.
+	AccessMember Access = iota
+	// AccessPublic is open to everyone who is not banned, e.g. /start.
+	AccessPublic
+	// AccessAdmin requires the admin role.
+	AccessAdmin
+)
+
+// RegistrationMode selects who may start using the bot.
+type RegistrationMode string
+
+const (
+	// RegistrationOpen admits everyone.
+	RegistrationOpen RegistrationMode = "open"
+	// RegistrationAllowlist admits configured Telegram IDs and usernames.
+	RegistrationAllowlist RegistrationMode = "allowlist"
+	// RegistrationInvite admits users who redeemed an invite code via /start.
+	RegistrationInvite RegistrationMode = "invite"
+)
+
+// RegistrationPolicy decides which users are admitted.
+type RegistrationPolicy struct {
+	Mode      RegistrationMode
+	ids       map[int64]bool
+	usernames map[string]bool
+}
+
+// NewRegistrationPolicy builds a policy. Allowlist entries are numeric
+// Telegram user IDs or usernames, with or without a leading '@'.
+func NewRegistrationPolicy(mode RegistrationMode, allowlist []string) (*RegistrationPolicy, error) {
+	switch mode {
+	case RegistrationOpen, RegistrationAllowlist, RegistrationInvite:
+	default:
+		return nil, fmt.Errorf("unknown registration mode %q", mode)
+	}
+	p := &RegistrationPolicy{
+		Mode:      mode,
+		ids:       make(map[int64]bool),
+		usernames: make(map[string]bool),
+	}
+	for _, entry := range allowlist {
+		entry = strings.TrimSpace(entry)
+		if id, err := strconv.ParseInt(entry, 10, 64); err == nil {
+			p.ids[id] = true
+			continue
+		}
+		name := strings.ToLower(strings.TrimPrefix(entry, "@"))
+		if name == "" {
+			return nil, fmt.Errorf("invalid allowlist entry %q", entry)
+		}
+		p.usernames[name] = true
+	}
+	return p, nil
+}
+
+// Allowlisted reports whether the Telegram user is on the allowlist.
+func (p *RegistrationPolicy) Allowlisted(from *User) bool {
+	if p.ids[from.ID] {
+		return true
+	}
+	return from.Username != "" && p.usernames[strings.ToLower(from.Username)]
+}
+
+// admits reports whether the sender may use member commands; user is the
+// stored user, nil if unknown.
+func (p *RegistrationPolicy) admits(from *User, user *domain.User) bool {
+	switch p.Mode {
+	case RegistrationAllowlist:
+		return p.Allowlisted(from)
+	case RegistrationInvite:
+		return user != nil && user.Registered
+	default:
+		return true
+	}
+}
+
+// Authorizer decides whether the sender of an update may use the bot, and
+// whether they may run admin commands. Admins come from configuration; bans
+// and registrations are stored on the user.
+type Authorizer struct {
+	admins    map[int64]bool
+	users     domain.UserStore
+	policy    *RegistrationPolicy
+	responder Responder
+}
+
+// NewAuthorizer builds an Authorizer. A nil policy admits everyone; the
+// responder, if set, tells users who are not admitted how to register.
+func NewAuthorizer(adminIDs []int64, users domain.UserStore, policy *RegistrationPolicy, responder Responder) *Authorizer {
+	admins := make(map[int64]bool, len(adminIDs))
+	for _, id := range adminIDs {
+		admins[id] = true
+	}
+	if policy == nil {
+		policy = &RegistrationPolicy{Mode: RegistrationOpen}
+	}
+	return &Authorizer{admins: admins, users: users, policy: policy, responder: responder}
+}
+
+// IsAdmin reports whether the Telegram user has the admin role.
+func (a *Authorizer) IsAdmin(telegramID int64) bool {
+	return a.admins[telegramID]
+}
+
+// Policy returns the registration policy.
+func (a *Authorizer) Policy() *RegistrationPolicy {
+	return a.policy
+}
+
+// Allow reports whether an update from the sender may be handled. Admins are
+// never banned and always admitted. The stored user is taken from the context
+// when LoadUser ran, otherwise it is looked up. Store errors are logged and
+// reject the update, since bans and registrations cannot be checked; admins
+// are let through without a lookup.
+func (a *Authorizer) Allow(ctx context.Context, from *User, access Access) bool {
+	allowed, _, _ := a.check(ctx, from, access)
+	return allowed
+}
+
+// check is Allow that also reports whether the sender is a member, i.e. an
+// admin or admitted by the policy, and returns the stored user it looked up,
+// nil if unknown.
+func (a *Authorizer) check(ctx context.Context, from *User, access Access) (allowed, member bool, user *domain.User) {
+	if from == nil {
+		return access != AccessAdmin, false, nil
+	}
+	if a.IsAdmin(from.ID) {
+		return true, true, UserFrom(ctx)
+	}
+	if access == AccessAdmin {
+		return false, false, nil
+	}
+
+	user = UserFrom(ctx)
+	if user == nil && a.users != nil {
+		var err error
+		user, err = a.users.GetByTelegramID(ctx, from.ID)
+		if err != nil {
+			log.Printf("telegram: authorize user %d failed: %v", from.ID, err)
+			return false, false, nil
+		}
+	}
+	if user != nil && user.Banned {
+		return false, false, user
+	}
+	member = a.policy.admits(from, user)
+	if access == AccessPublic || member {
+		return true, member, user
+	}
+	a.tellNotRegistered(ctx, from, user)
+	return false, false, user
+}
+
+// tellNotRegistered explains how to get access; user may be nil.
+func (a *Authorizer) tellNotRegistered(ctx context.Context, from *User, user *domain.User) {
+	if a.responder == nil {
+		return
+	}
+	p := senderPrinter(from)
+	if user != nil {
+		p = userPrinter(user)
+	}
+	text := p.T("This bot is private. Send /start to check whether you have access.")
+	if a.policy.Mode == RegistrationInvite {
+		text = p.T("This bot is invite-only. Open your invite link or send /start <code>.")
+	}
+	if err := a.responder.SendMessage(ctx, from.ID, text); err != nil {
+		log.Printf("telegram: failed to send registration hint: %v", err)
+	}
+}
@@ -0,0 +1,326 @@
+package telegram
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"naggingbot/internal/backup"
+	"naggingbot/internal/domain"
+	"naggingbot/internal/i18n"
+	"naggingbot/internal/render"
+)
+
+// restoreTTL bounds how long an uploaded backup waits for confirmation.
+const restoreTTL = 15 * time.Minute
+
+// pendingRestore is a validated backup awaiting the user's merge/replace choice.
+type pendingRestore struct {
+	doc     *backup.Document
+	token   string
+	expires time.Time
+}
+
+// exportJSON sends the user's full backup document.
+func (h *ExportHandler) exportJSON(ctx context.Context, p *i18n.Printer, user *domain.User, rems []*domain.Reminder) {
+	chatID := user.TelegramID
+	occs := make(map[int64][]*domain.Occurrence, len(rems))
+	for _, rem := range rems {
+		list, err := h.occurrences.ListByReminder(ctx, rem.ID)
+		if err != nil {
+			log.Printf("telegram: export list occurrences for reminder %d failed: %v", rem.ID, err)
+			reply(ctx, h.responder, chatID, p.T("Failed to export"))
+			return
+		}
+		occs[rem.ID] = list
+	}
+	digest, err := h.digests.Get(ctx, user.ID)
+	if err != nil {
+		log.Printf("telegram: export load digest settings failed: %v", err)
+		reply(ctx, h.responder, chatID, p.T("Failed to export"))
+		return
+	}
+
+	doc := backup.Build(user, digest, rems, occs, time.Now())
+	data, err := backup.Encode(doc)
+	if err != nil {
+		log.Printf("telegram: export encode backup failed: %v", err)
+		reply(ctx, h.responder, chatID, p.T("Failed to export"))
+		return
+	}
+
+	caption := p.T("Backup of %s and %s. Send it back with /import json to restore.", p.N("%d reminders", len(doc.Reminders)), p.N("%d occurrences", doc.OccurrenceCount()))
+	if err := h.responder.SendDocument(ctx, chatID, "naggingbot-backup.json", data, caption); err != nil {
+		log.Printf("telegram: export send document failed: %v", err)
+		reply(ctx, h.responder, chatID, p.T("Failed to send export file"))
+	}
+}
+
+// prepareRestore validates an uploaded backup and offers merge or replace
+// with a dry-run summary. Nothing is written until the user confirms.
+func (h *ImportHandler) prepareRestore(ctx context.Context, p *i18n.Printer, user *domain.User, data []byte) {
+	doc, err := backup.Decode(data)
+	if err != nil {
+		reply(ctx, h.responder, user.TelegramID, p.T("Could not read backup: %v", err))
+		return
+	}
+
+	existing, err := h.reminders.ListByUser(ctx, user.ID)
+	if err != nil {
+		log.Printf("telegram: restore load current reminders failed: %v", err)
+		reply(ctx, h.responder, user.TelegramID, p.T("Failed to read your current reminders"))
+		return
+	}
+
+	token, err := newRestoreToken()
+	if err != nil {
+		log.Printf("telegram: restore token: %v", err)
+		reply(ctx, h.responder, user.TelegramID, p.T("Failed to prepare restore"))
+		return
+	}
+	h.mu.Lock()
+	h.pending[user.TelegramID] = &pendingRestore{doc: doc, token: token, expires: time.Now().Add(restoreTTL)}
+	h.mu.Unlock()
+
+	markup := map[string]any{"inline_keyboard": [][]map[string]any{
+		{
+			{"text": p.T("Merge"), "callback_data": BuildRestoreCallback(RestoreMerge, token)},
+			{"text": p.T("Replace"), "callback_data": BuildRestoreCallback(RestoreReplace, token)},
+		},
+		{
+			{"text": p.T("Cancel"), "callback_data": BuildRestoreCallback(RestoreCancel, token)},
+		},
+	}}
+	if h.responder != nil {
+		if err := h.responder.SendMessageWithMarkup(ctx, user.TelegramID, restoreSummary(p, doc, existing), markup); err != nil {
+			log.Printf("telegram: failed to send restore summary: %v", err)
+		}
+	}
+}
+
+func (h *ImportHandler) HandleCallback(ctx context.Context, cb *CallbackQuery) error {
+	user := UserFrom(ctx)
+	if cb == nil || cb.Message == nil || user == nil {
+		return nil
+	}
+	p := userPrinter(user)
+	mode, token, err := ParseRestoreCallback(cb.Data)
+	if err != nil {
+		log.Printf("telegram: bad restore callback %q: %v", cb.Data, err)
+		rejectCallback(ctx, h.responder, cb, p.T("This button is not valid."))
+		return nil
+	}
+
+	h.mu.Lock()
+	pending := h.pending[user.TelegramID]
+	if pending != nil && pending.token == token {
+		delete(h.pending, user.TelegramID)
+	}
+	h.mu.Unlock()
+
+	var text string
+	switch {
+	case pending == nil || pending.token != token || time.Now().After(pending.expires):
+		rejectCallback(ctx, h.responder, cb, p.T("Restore expired"))
+		text = p.T("This restore is no longer available. Upload the backup again.")
+	case mode == RestoreCancel:
+		answerCallback(ctx, h.responder, cb, p.T("Restore cancelled"))
+		text = p.T("Restore cancelled. Nothing was changed.")
+	case mode == RestoreMerge || mode == RestoreReplace:
+		// Answer first: restoring a large backup can take a while.
+		answerCallback(ctx, h.responder, cb, p.T("Restoring backup…"))
+		text = h.applyRestore(ctx, p, user, pending.doc, mode)
+	default:
+		log.Printf("telegram: unknown restore mode %q", mode)
+		return nil
+	}
+
+	if h.responder != nil {
+		if err := h.responder.EditMessageText(ctx, cb.Message.Chat.ID, cb.Message.MessageID, text, nil); err != nil {
+			log.Printf("telegram: failed to edit restore message: %v", err)
+		}
+	}
+	return nil
+}
+
+// applyRestore writes the backup and returns the text to show the user.
+// Reminders and occurrences are restored atomically; settings are applied
+// afterwards. Merge skips reminders with an identical schedule and only fills
+// in settings the user has not set; replace deletes all current reminders and
+// takes the backup's settings as-is. A reminder over the limits or with an
+// invalid template rejects the whole backup.
+func (h *ImportHandler) applyRestore(ctx context.Context, p *i18n.Printer, user *domain.User, doc *backup.Document, mode RestoreMode) string {
+	now := time.Now().UTC()
+	var created, skipped, removed int
+	var rejected string
+	var templateErr error
+	err := h.uow.Do(ctx, func(ctx context.Context, tx domain.Stores) error {
+		created, skipped, removed = 0, 0, 0
+		current, err := tx.Reminders.ListByUser(ctx, user.ID)
+		if err != nil {
+			return fmt.Errorf("list reminders: %w", err)
+		}
+
+		seen := make(map[string]bool, len(current))
+		for _, rem := range current {
+			if mode == RestoreReplace {
+				if err := tx.Occurrences.DeleteByReminder(ctx, rem.ID); err != nil {
+					return fmt.Errorf("delete occurrences: %w", err)
+				}
+				if err := tx.Reminders.DeleteByID(ctx, rem.ID); err != nil {
+					return fmt.Errorf("delete reminder: %w", err)
+				}
+				removed++
+				continue
+			}
+			seen[backup.Key(rem)] = true
+		}
+
+		if mode == RestoreReplace {
+			current = nil
+		}
+		for _, r := range doc.Reminders {
+			rem, occs := r.ToDomain(user.ID, now)
+			if mode == RestoreMerge && seen[backup.Key(rem)] {
+				skipped++
+				continue
+			}
+			if err := h.limits.CheckReminder(rem, current, now); err != nil {
+				rejected = rem.Name
+				return fmt.Errorf("%q: %w", rem.Name, err)
+			}
+			if rem.Template != "" {
+				if err := render.ValidateTemplate(rem.Template); err != nil {
+					rejected, templateErr = rem.Name, err
+					return fmt.Errorf("%q: %w", rem.Name, err)
+				}
+			}
+			if len(occs) > maxOccurrencesPerReminder {
+				return fmt.Errorf("%q: backup has %d occurrences, more than %d", rem.Name, len(occs), maxOccurrencesPerReminder)
+			}
+			if err := tx.Reminders.Create(ctx, rem); err != nil {
+				return fmt.Errorf("create reminder: %w", err)
+			}
+			if len(occs) == 0 {
+				// A hand-written backup without history: schedule what is still ahead.
+				planned, err := buildOccurrences(rem, rem.Location())
+				if err != nil {
+					return err
+				}
+				for _, occ := range planned {
+					if occ.FireAtUtc.After(now) {
+						occs = append(occs, occ)
+					}
+				}
+			}
+			for _, occ := range occs {
+				occ.ReminderID = rem.ID
+			}
+			if err := tx.Occurrences.CreateBatch(ctx, occs); err != nil {
+				return fmt.Errorf("create occurrences: %w", err)
+			}
+			seen[backup.Key(rem)] = true
+			current = append(current, rem)
+			created++
+		}
+		return nil
+	})
+	var limitErr *domain.LimitError
+	if errors.As(err, &limitErr) {
+		return p.T("Backup not restored, nothing was changed. %q: %s", rejected, limitMessage(p, limitErr))
+	}
+	if templateErr != nil {
+		return p.T("Backup not restored, nothing was changed. %q: invalid template: %v", rejected, templateErr)
+	}
+	if err != nil {
+		log.Printf("telegram: restore %v", err)
+		return p.T("Failed to restore backup. Nothing was changed.")
+	}
+
+	var b strings.Builder
+	if mode == RestoreReplace {
+		b.WriteString(p.T("Backup restored: removed %s, restored %d.", p.N("%d reminders", removed), created))
+	} else {
+		b.WriteString(p.T("Backup merged: added %s, skipped %d already present.", p.N("%d reminders", created), skipped))
+	}
+	if err := h.restoreSettings(ctx, user, doc.Settings, mode); err != nil {
+		log.Printf("telegram: restore settings failed: %v", err)
+		b.WriteString("\n" + p.T("Settings could not be restored; set them again with /timezone and /digest."))
+	}
+	return b.String()
+}
+
+func (h *ImportHandler) restoreSettings(ctx context.Context, user *domain.User, s backup.Settings, mode RestoreMode) error {
+	if mode == RestoreReplace || (user.TimeZone == "" && s.TimeZone != "") {
+		if err := h.users.SetTimeZone(ctx, user.ID, s.TimeZone); err != nil {
+			return fmt.Errorf("set time zone: %w", err)
+		}
+	}
+
+	current, err := h.digests.Get(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("load digest settings: %w", err)
+	}
+	restored := s.DigestSettings(user.ID)
+	if mode == RestoreMerge && (restored == nil || (current != nil && current.Enabled())) {
+		return nil
+	}
+	if restored == nil {
+		if current == nil {
+			return nil
+		}
+		restored = &domain.DigestSettings{UserID: user.ID}
+	}
+	if current != nil {
+		// Keep delivery bookkeeping so today's digests are not sent twice.
+		restored.LastMorningUtc = current.LastMorningUtc
+		restored.LastEveningUtc = current.LastEveningUtc
+		restored.LastWeeklyUtc = current.LastWeeklyUtc
+	}
+	if err := h.digests.Save(ctx, restored); err != nil {
+		return fmt.Errorf("save digest settings: %w", err)
+	}
+	return nil
+}
+
+// restoreSummary describes what each restore mode would do.
+func restoreSummary(p *i18n.Printer, doc *backup.Document, existing []*domain.Reminder) string {
+	seen := make(map[string]bool, len(existing))
+	for _, rem := range existing {
+		seen[backup.Key(rem)] = true
+	}
+	dup := 0
+	for _, r := range doc.Reminders {
+		rem, _ := r.ToDomain(0, time.Time{})
+		if seen[backup.Key(rem)] {
+			dup++
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString(p.T("Backup from %s: %s, %s.", doc.ExportedAt.UTC().Format("02.01.2006 15:04 UTC"),
+		p.N("%d reminders", len(doc.Reminders)), p.N("%d occurrences", doc.OccurrenceCount())))
+	if doc.Settings.TimeZone != "" {
+		b.WriteString("\n" + p.T("Time zone: %s", doc.Settings.TimeZone))
+	}
+	if doc.Settings.Digest != nil {
+		b.WriteString("\n" + p.T("Digests: enabled"))
+	}
+	b.WriteString("\n\n" + p.T("Merge: add %s, skip %d already present, keep your current settings.", p.N("%d reminders", len(doc.Reminders)-dup), dup))
+	b.WriteString("\n" + p.T("Replace: delete your %s and their history, then restore everything from the backup.", p.N("%d reminders", len(existing))))
+	b.WriteString("\n\n" + p.T("Nothing has been changed yet. Choose within %s.", p.N("%d minutes", int(restoreTTL.Minutes()))))
+	return b.String()
+}
+
+func newRestoreToken() (string, error) {
+	buf := make([]byte, 6)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,41 @@
+package telegram
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"naggingbot/internal/backup"
+	"naggingbot/internal/domain"
+	"naggingbot/internal/storage/memory"
+)
+
+func TestRestoreRejectsInvalidTemplates(t *testing.T) {
+	ctx := context.Background()
+	users := memory.NewInMemoryUserStore()
+	reminders := memory.NewInMemoryReminderStore()
+	uow := memory.NewUnitOfWork(users, reminders, memory.NewInMemoryOccurrenceStore(), memory.NewInMemoryInviteStore())
+	h := NewImportHandler(users, reminders, memory.NewInMemoryDigestStore(), uow, domain.Limits{}, nil)
+
+	user := &domain.User{TelegramID: 100}
+	if err := users.Upsert(ctx, user); err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now().UTC().Add(-time.Hour)
+	reminder := func(name, tmpl string) backup.Reminder {
+		return backup.Reminder{Name: name, TimeZone: "UTC", StartDate: start, EndDate: start.Add(24 * time.Hour), Template: tmpl}
+	}
+	doc := &backup.Document{Version: backup.Version, Reminders: []backup.Reminder{
+		reminder("Fine", "<b>{{.Name}}</b>"),
+		reminder("Loop", "{{range 9223372036854775807}}{{end}}x"),
+	}}
+
+	text := h.applyRestore(ctx, userPrinter(user), user, doc, RestoreMerge)
+	if !strings.Contains(text, `"Loop": invalid template`) {
+		t.Fatalf("restore replied %q, want the template refusal", text)
+	}
+	if rems, err := reminders.ListByUser(ctx, user.ID); err != nil || len(rems) != 0 {
+		t.Fatalf("reminders after a refused restore = %d (%v), want none", len(rems), err)
+	}
+}
@@ -2,19 +2,24 @@ package telegram
 
 import (
 	"context"
+	"errors"
 	"log"
+	"time"
 
 	"naggingbot/internal/domain"
+	"naggingbot/internal/i18n"
+	"naggingbot/internal/render"
 )
 
 // OccurrenceCallbackHandler handles Done/Ignore callbacks for occurrences.
 type OccurrenceCallbackHandler struct {
+	reminders   domain.ReminderStore
 	occurrences domain.OccurrenceStore
 	responder   Responder
 }
 
-func NewOccurrenceCallbackHandler(occurrences domain.OccurrenceStore, responder Responder) *OccurrenceCallbackHandler {
-	return &OccurrenceCallbackHandler{occurrences: occurrences, responder: responder}
+func NewOccurrenceCallbackHandler(reminders domain.ReminderStore, occurrences domain.OccurrenceStore, responder Responder) *OccurrenceCallbackHandler {
+	return &OccurrenceCallbackHandler{reminders: reminders, occurrences: occurrences, responder: responder}
 }
 
 func (h *OccurrenceCallbackHandler) HandleCallback(ctx context.Context, cb *CallbackQuery) error {
@@ -30,6 +35,7 @@ func (h *OccurrenceCallbackHandler) HandleCallback(ctx context.Context, cb *Call
 	action, occID, err := ParseOccurrenceCallback(cb.Data)
 	if err != nil {
 		log.Printf("telegram: bad callback data %q: %v", cb.Data, err)
+		rejectCallback(ctx, h.responder, cb, printerFrom(ctx).T("This button is not valid."))
 		return nil
 	}
 
@@ -43,17 +49,123 @@ func (h *OccurrenceCallbackHandler) HandleCallback(ctx context.Context, cb *Call
 		return nil
 	}
 
-	if err := h.occurrences.UpdateStatus(ctx, occID, status); err != nil {
+	user := UserFrom(ctx)
+	if user == nil {
+		return nil
+	}
+	p := userPrinter(user)
+	occ, rem, refusal, err := ownedOccurrence(ctx, h.reminders, h.occurrences, user, occID)
+	if err != nil {
+		failCallback(ctx, h.responder, cb, p.T("Failed to load the reminder, please try again."))
+		return nil
+	}
+	if refusal != "" {
+		rejectCallback(ctx, h.responder, cb, refusal)
+		return nil
+	}
+
+	// Answering also stops the escalation chain: the scheduler only advances
+	// it for occurrences that are still sent.
+	err = h.occurrences.MarkAcked(ctx, occID, status, time.Now().UTC())
+	var transErr *domain.TransitionError
+	switch {
+	case errors.As(err, &transErr):
+		// A second press, or a press on a stale message: show what was recorded.
+		answerCallback(ctx, h.responder, cb, alreadyAnswered(p, transErr.From))
+		status = transErr.From
+	case err != nil:
 		log.Printf("telegram: failed to update occurrence %d status: %v", occID, err)
+		failCallback(ctx, h.responder, cb, p.T("Failed to save, please try again."))
 		return nil
+	default:
+		answerCallback(ctx, h.responder, cb, AckToast(p, status))
 	}
 
-	// Update message text and remove buttons.
+	// Re-render the notification with its status and remove the buttons.
 	if cb.Message != nil && h.responder != nil {
-		newText := BuildFinalText(cb.Message.Text, status)
-		if err := h.responder.EditMessageText(ctx, cb.Message.Chat.ID, cb.Message.MessageID, newText, BuildFinalMarkup()); err != nil {
+		occ.Status = status
+		if err := h.responder.EditMessageHTML(ctx, cb.Message.Chat.ID, cb.Message.MessageID, render.StatusHTML(p, rem, occ), BuildFinalMarkup()); err != nil {
 			log.Printf("telegram: failed to edit message text/markup: %v", err)
 		}
 	}
 	return nil
 }
+
+// ownedOccurrence loads the occurrence and its reminder if user may answer
+// them: the owner, or the recipient the reminder is assigned to. Otherwise it
+// returns the reason to show, in the user's language: the occurrence does not
+// exist or belongs to another user's reminder. A store error is logged and
+// returned instead, since the button may work when pressed again.
+func ownedOccurrence(ctx context.Context, reminders domain.ReminderStore, occurrences domain.OccurrenceStore, user *domain.User, occID int64) (*domain.Occurrence, *domain.Reminder, string, error) {
+	p := userPrinter(user)
+	occ, err := occurrences.GetByID(ctx, occID)
+	if err != nil {
+		log.Printf("telegram: get occurrence %d failed: %v", occID, err)
+		return nil, nil, "", err
+	}
+	if occ == nil {
+		return nil, nil, p.T("This reminder no longer exists."), nil
+	}
+	rem, err := reminders.GetByID(ctx, occ.ReminderID)
+	if err != nil {
+		log.Printf("telegram: get reminder %d failed: %v", occ.ReminderID, err)
+		return nil, nil, "", err
+	}
+	if rem == nil {
+		return nil, nil, p.T("This reminder no longer exists."), nil
+	}
+	if !rem.CanAnswer(user.ID) {
+		log.Printf("telegram: occurrence %d rejected for user %d", occID, user.TelegramID)
+		return nil, nil, p.T("This button belongs to someone else."), nil
+	}
+	return occ, rem, "", nil
+}
+
+// alreadyAnswered explains why an answered occurrence cannot be changed.
+func alreadyAnswered(p *i18n.Printer, status domain.OccurrenceStatus) string {
+	switch status {
+	case domain.OccurrenceDone:
+		return p.T("Already marked done.")
+	case domain.OccurrenceIgnored:
+		return p.T("Already marked ignored.")
+	default:
+		return p.T("Already handled.")
+	}
+}
+
+// rejectedCallbackCacheTime lets clients cache answers to buttons that will
+// never work, so repeated presses do not reach the bot.
+const rejectedCallbackCacheTime = 60
+
+// answerCallback answers the callback query, showing text as a toast if set.
+func answerCallback(ctx context.Context, responder Responder, cb *CallbackQuery, text string) {
+	sendCallbackAnswer(ctx, responder, cb, text, false, 0)
+}
+
+// rejectCallback answers a press on a button that cannot work, e.g. a forged
+// or stale one.
+func rejectCallback(ctx context.Context, responder Responder, cb *CallbackQuery, text string) {
+	sendCallbackAnswer(ctx, responder, cb, text, false, rejectedCallbackCacheTime)
+}
+
+// failCallback answers with an alert the user has to dismiss, for errors.
+func failCallback(ctx context.Context, responder Responder, cb *CallbackQuery, text string) {
+	sendCallbackAnswer(ctx, responder, cb, text, true, 0)
+}
+
+// sendCallbackAnswer answers the query and records that in the context for
+// AnswerCallbacks. A query can only be answered once.
+func sendCallbackAnswer(ctx context.Context, responder Responder, cb *CallbackQuery, text string, showAlert bool, cacheTime int) {
+	if responder == nil || cb == nil || cb.ID == "" {
+		return
+	}
+	if answered, ok := ctx.Value(answeredKey).(*bool); ok {
+		if *answered {
+			return
+		}
+		*answered = true
+	}
+	if err := responder.AnswerCallbackQuery(ctx, cb.ID, text, showAlert, cacheTime); err != nil {
+		log.Printf("telegram: failed to answer callback query: %v", err)
+	}
+}
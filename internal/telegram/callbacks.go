@@ -1,11 +1,100 @@
 package telegram
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
 	"fmt"
 	"strconv"
 	"strings"
 )
 
+// callbackSignatureSep separates callback data from its signature. It must not
+// occur in unsigned data.
+const callbackSignatureSep = "|"
+
+// callbackSignatureBytes is the truncated HMAC length; Telegram limits
+// callback data to 64 bytes.
+const callbackSignatureBytes = 8
+
+// CallbackSigner signs callback data so users cannot forge button presses,
+// e.g. for another user's occurrence. A nil signer leaves data unsigned.
+type CallbackSigner struct {
+	key []byte
+}
+
+// NewCallbackSigner derives the signing key from secret, e.g. the bot token.
+// Changing the secret invalidates all buttons already sent.
+func NewCallbackSigner(secret string) *CallbackSigner {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("naggingbot callback data"))
+	return &CallbackSigner{key: mac.Sum(nil)}
+}
+
+// Sign appends the signature to data.
+func (s *CallbackSigner) Sign(data string) string {
+	if s == nil {
+		return data
+	}
+	return data + callbackSignatureSep + s.signature(data)
+}
+
+// Verify checks signed data and returns it without the signature. A nil
+// signer accepts data as-is.
+func (s *CallbackSigner) Verify(signed string) (string, bool) {
+	if s == nil {
+		return signed, true
+	}
+	i := strings.LastIndex(signed, callbackSignatureSep)
+	if i < 0 {
+		return "", false
+	}
+	data, sig := signed[:i], signed[i+len(callbackSignatureSep):]
+	if !hmac.Equal([]byte(sig), []byte(s.signature(data))) {
+		return "", false
+	}
+	return data, true
+}
+
+// SignMarkup returns a copy of an inline keyboard markup with every
+// callback_data signed. Other markups are returned unchanged.
+func (s *CallbackSigner) SignMarkup(markup any) any {
+	m, ok := markup.(map[string]any)
+	if s == nil || !ok {
+		return markup
+	}
+	rows, ok := m["inline_keyboard"].([][]map[string]any)
+	if !ok {
+		return markup
+	}
+	signedRows := make([][]map[string]any, len(rows))
+	for i, row := range rows {
+		signedRows[i] = make([]map[string]any, len(row))
+		for j, button := range row {
+			signed := make(map[string]any, len(button))
+			for k, v := range button {
+				signed[k] = v
+			}
+			if data, ok := button["callback_data"].(string); ok {
+				signed["callback_data"] = s.Sign(data)
+			}
+			signedRows[i][j] = signed
+		}
+	}
+	out := make(map[string]any, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	out["inline_keyboard"] = signedRows
+	return out
+}
+
+func (s *CallbackSigner) signature(data string) string {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(data))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)[:callbackSignatureBytes])
+}
+
 type OccurrenceAction string
 
 const (
@@ -13,17 +102,26 @@ const (
 	OccurrenceActionIgnore OccurrenceAction = "ignore"
 )
 
-const occurrencePrefix = "occ"
+// Callback data namespaces routed by the Dispatcher.
+const (
+	OccurrenceCallbackPrefix = "occ"
+	HistoryCallbackPrefix    = "hist"
+	AgendaCallbackPrefix     = "agenda"
+	RestoreCallbackPrefix    = "restore"
+	SharedCallbackPrefix     = "grp"
+	AssignCallbackPrefix     = "assign"
+	EscalateCallbackPrefix   = "escalate"
+)
 
 // BuildOccurrenceCallback creates callback data for an occurrence action.
 func BuildOccurrenceCallback(id int64, action OccurrenceAction) string {
-	return fmt.Sprintf("%s:%d:%s", occurrencePrefix, id, action)
+	return fmt.Sprintf("%s:%d:%s", OccurrenceCallbackPrefix, id, action)
 }
 
 // ParseOccurrenceCallback parses callback data into action and occurrence ID.
 func ParseOccurrenceCallback(data string) (action OccurrenceAction, occID int64, err error) {
 	parts := strings.Split(data, ":")
-	if len(parts) != 3 || parts[0] != occurrencePrefix {
+	if len(parts) != 3 || parts[0] != OccurrenceCallbackPrefix {
 		return "", 0, fmt.Errorf("unexpected format")
 	}
 	id, err := strconv.ParseInt(parts[1], 10, 64)
@@ -32,3 +130,144 @@ func ParseOccurrenceCallback(data string) (action OccurrenceAction, occID int64,
 	}
 	return OccurrenceAction(parts[2]), id, nil
 }
+
+// BuildSharedCallback creates callback data for an occurrence action on a
+// reminder posted to a group or channel. Unlike occurrence callbacks, anyone
+// in that chat may press these buttons.
+func BuildSharedCallback(id int64, action OccurrenceAction) string {
+	return fmt.Sprintf("%s:%d:%s", SharedCallbackPrefix, id, action)
+}
+
+// ParseSharedCallback parses callback data built by BuildSharedCallback.
+func ParseSharedCallback(data string) (action OccurrenceAction, occID int64, err error) {
+	parts := strings.Split(data, ":")
+	if len(parts) != 3 || parts[0] != SharedCallbackPrefix {
+		return "", 0, fmt.Errorf("unexpected format")
+	}
+	id, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return "", 0, err
+	}
+	return OccurrenceAction(parts[2]), id, nil
+}
+
+// HistoryAction is an action triggered from the /history view.
+type HistoryAction string
+
+const (
+	HistoryActionPage   HistoryAction = "page"
+	HistoryActionDone   HistoryAction = "done"
+	HistoryActionIgnore HistoryAction = "ignore"
+)
+
+// BuildHistoryCallback creates callback data for the /history view. For
+// HistoryActionPage id is the reminder ID; otherwise it is the occurrence ID.
+// page is the page to render after the action.
+func BuildHistoryCallback(action HistoryAction, id int64, page int) string {
+	return fmt.Sprintf("%s:%s:%d:%d", HistoryCallbackPrefix, action, id, page)
+}
+
+// ParseHistoryCallback parses callback data built by BuildHistoryCallback.
+func ParseHistoryCallback(data string) (action HistoryAction, id int64, page int, err error) {
+	parts := strings.Split(data, ":")
+	if len(parts) != 4 || parts[0] != HistoryCallbackPrefix {
+		return "", 0, 0, fmt.Errorf("unexpected format")
+	}
+	id, err = strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return "", 0, 0, err
+	}
+	page, err = strconv.Atoi(parts[3])
+	if err != nil {
+		return "", 0, 0, err
+	}
+	return HistoryAction(parts[1]), id, page, nil
+}
+
+// BuildAgendaCallback creates callback data to complete or skip an occurrence
+// from an agenda view; view identifies the view to re-render afterwards.
+func BuildAgendaCallback(action OccurrenceAction, occID int64, view string) string {
+	return fmt.Sprintf("%s:%s:%d:%s", AgendaCallbackPrefix, action, occID, view)
+}
+
+// ParseAgendaCallback parses callback data built by BuildAgendaCallback.
+func ParseAgendaCallback(data string) (action OccurrenceAction, occID int64, view string, err error) {
+	parts := strings.Split(data, ":")
+	if len(parts) != 4 || parts[0] != AgendaCallbackPrefix {
+		return "", 0, "", fmt.Errorf("unexpected format")
+	}
+	occID, err = strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return "", 0, "", err
+	}
+	return OccurrenceAction(parts[1]), occID, parts[3], nil
+}
+
+// RestoreMode is the choice offered after a JSON backup upload.
+type RestoreMode string
+
+const (
+	RestoreMerge   RestoreMode = "merge"
+	RestoreReplace RestoreMode = "replace"
+	RestoreCancel  RestoreMode = "cancel"
+)
+
+// BuildRestoreCallback creates callback data confirming a pending restore;
+// token ties the button to the upload it was offered for.
+func BuildRestoreCallback(mode RestoreMode, token string) string {
+	return fmt.Sprintf("%s:%s:%s", RestoreCallbackPrefix, mode, token)
+}
+
+// ParseRestoreCallback parses callback data built by BuildRestoreCallback.
+func ParseRestoreCallback(data string) (mode RestoreMode, token string, err error) {
+	parts := strings.Split(data, ":")
+	if len(parts) != 3 || parts[0] != RestoreCallbackPrefix {
+		return "", "", fmt.Errorf("unexpected format")
+	}
+	return RestoreMode(parts[1]), parts[2], nil
+}
+
+// AssignAnswer is a recipient's answer to a reminder assignment.
+type AssignAnswer string
+
+const (
+	AssignAccept  AssignAnswer = "accept"
+	AssignDecline AssignAnswer = "decline"
+)
+
+// BuildAssignCallback creates callback data answering the assignment of a reminder.
+func BuildAssignCallback(reminderID int64, answer AssignAnswer) string {
+	return fmt.Sprintf("%s:%d:%s", AssignCallbackPrefix, reminderID, answer)
+}
+
+// ParseAssignCallback parses callback data built by BuildAssignCallback.
+func ParseAssignCallback(data string) (answer AssignAnswer, reminderID int64, err error) {
+	parts := strings.Split(data, ":")
+	if len(parts) != 3 || parts[0] != AssignCallbackPrefix {
+		return "", 0, fmt.Errorf("unexpected format")
+	}
+	id, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return "", 0, err
+	}
+	return AssignAnswer(parts[2]), id, nil
+}
+
+// BuildEscalateCallback creates callback data answering a request to be an
+// escalation step of a reminder. It reuses the assignment answers.
+func BuildEscalateCallback(reminderID int64, answer AssignAnswer) string {
+	return fmt.Sprintf("%s:%d:%s", EscalateCallbackPrefix, reminderID, answer)
+}
+
+// ParseEscalateCallback parses callback data built by BuildEscalateCallback.
+func ParseEscalateCallback(data string) (answer AssignAnswer, reminderID int64, err error) {
+	parts := strings.Split(data, ":")
+	if len(parts) != 3 || parts[0] != EscalateCallbackPrefix {
+		return "", 0, fmt.Errorf("unexpected format")
+	}
+	id, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return "", 0, err
+	}
+	return AssignAnswer(parts[2]), id, nil
+}
@@ -0,0 +1,189 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"naggingbot/internal/domain"
+	"naggingbot/internal/storage/memory"
+)
+
+// callbackAnswer is one AnswerCallbackQuery call.
+type callbackAnswer struct {
+	text      string
+	alert     bool
+	cacheTime int
+}
+
+// answerRecorder records callback answers. Methods the tests do not use panic.
+type answerRecorder struct {
+	Responder
+	answers []callbackAnswer
+}
+
+func (r *answerRecorder) AnswerCallbackQuery(ctx context.Context, callbackQueryID, text string, showAlert bool, cacheTime int) error {
+	r.answers = append(r.answers, callbackAnswer{text, showAlert, cacheTime})
+	return nil
+}
+
+// callbackRecorder records the callback data it was called with.
+type callbackRecorder struct {
+	data []string
+}
+
+func (h *callbackRecorder) HandleCallback(ctx context.Context, cb *CallbackQuery) error {
+	h.data = append(h.data, cb.Data)
+	return nil
+}
+
+func TestCallbackSignerRoundTrip(t *testing.T) {
+	signer := NewCallbackSigner("token")
+	data := BuildOccurrenceCallback(42, OccurrenceActionDone)
+	signed := signer.Sign(data)
+	if len(signed) > 64 {
+		t.Fatalf("signed data %q exceeds Telegram's 64 bytes", signed)
+	}
+	if got, ok := signer.Verify(signed); !ok || got != data {
+		t.Fatalf("Verify(%q) = %q, %v, want %q", signed, got, ok, data)
+	}
+
+	sig := signed[strings.LastIndex(signed, callbackSignatureSep)+1:]
+	flipped := []byte(sig)
+	flipped[0] ^= 1
+	cases := map[string]string{
+		"tampered data":      BuildOccurrenceCallback(43, OccurrenceActionDone) + callbackSignatureSep + sig,
+		"tampered signature": data + callbackSignatureSep + string(flipped),
+		"no separator":       data,
+		"empty signature":    data + callbackSignatureSep,
+		"other key":          NewCallbackSigner("other token").Sign(data),
+	}
+	for name, signed := range cases {
+		if got, ok := signer.Verify(signed); ok || got != "" {
+			t.Errorf("%s: Verify(%q) = %q, %v, want a rejection", name, signed, got, ok)
+		}
+	}
+
+	var unsigned *CallbackSigner
+	if got := unsigned.Sign(data); got != data {
+		t.Fatalf("a nil signer signed %q as %q", data, got)
+	}
+}
+
+func TestSignMarkupCopiesTheMarkup(t *testing.T) {
+	signer := NewCallbackSigner("token")
+	markup := map[string]any{
+		"inline_keyboard": [][]map[string]any{{
+			{"text": "Done", "callback_data": "occ:1:done"},
+			{"text": "Site", "url": "https://example.com"},
+		}},
+	}
+	original := map[string]any{
+		"inline_keyboard": [][]map[string]any{{
+			{"text": "Done", "callback_data": "occ:1:done"},
+			{"text": "Site", "url": "https://example.com"},
+		}},
+	}
+
+	signed := signer.SignMarkup(markup).(map[string]any)
+	if !reflect.DeepEqual(markup, original) {
+		t.Fatalf("SignMarkup changed its input to %v", markup)
+	}
+	rows := signed["inline_keyboard"].([][]map[string]any)
+	if got := rows[0][0]["callback_data"]; got != signer.Sign("occ:1:done") {
+		t.Fatalf("callback_data = %v, want it signed", got)
+	}
+	if _, ok := rows[0][1]["callback_data"]; ok || rows[0][1]["url"] != "https://example.com" {
+		t.Fatalf("url button = %v, want it unchanged", rows[0][1])
+	}
+}
+
+func TestVerifyCallbacks(t *testing.T) {
+	signer := NewCallbackSigner("token")
+	responder, handler := &answerRecorder{}, &callbackRecorder{}
+	d := NewDispatcher()
+	d.Use(VerifyCallbacks(signer, responder))
+	d.RegisterCallback(OccurrenceCallbackPrefix, handler)
+	press := func(data string) {
+		t.Helper()
+		cb := &CallbackQuery{ID: "q", From: &User{ID: 100}, Data: data}
+		if err := d.Dispatch(context.Background(), Update{CallbackQuery: cb}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	press("occ:1:done")
+	press(NewCallbackSigner("other").Sign("occ:1:done"))
+	if len(handler.data) != 0 {
+		t.Fatalf("handler saw %q, want unsigned data dropped", handler.data)
+	}
+	if len(responder.answers) != 2 || responder.answers[0].cacheTime != rejectedCallbackCacheTime {
+		t.Fatalf("answers = %+v, want two cached rejections", responder.answers)
+	}
+
+	press(signer.Sign("occ:1:done"))
+	if len(handler.data) != 1 || handler.data[0] != "occ:1:done" {
+		t.Fatalf("handler saw %q, want the data without its signature", handler.data)
+	}
+}
+
+func TestOccurrenceCallbackRejectsOtherUsers(t *testing.T) {
+	ctx := context.Background()
+	reminders := memory.NewInMemoryReminderStore()
+	occurrences := memory.NewInMemoryOccurrenceStore()
+	rem := &domain.Reminder{UserID: 1, Name: "Pill", TimeZone: "UTC"}
+	if err := reminders.Create(ctx, rem); err != nil {
+		t.Fatal(err)
+	}
+	occ := &domain.Occurrence{ReminderID: rem.ID, FireAtUtc: time.Now().UTC(), Status: domain.OccurrenceSent}
+	if err := occurrences.Create(ctx, occ); err != nil {
+		t.Fatal(err)
+	}
+
+	responder := &answerRecorder{}
+	h := NewOccurrenceCallbackHandler(reminders, occurrences, responder)
+	ctx = withUser(ctx, &domain.User{ID: 2, TelegramID: 200})
+	cb := &CallbackQuery{ID: "q", From: &User{ID: 200}, Data: BuildOccurrenceCallback(occ.ID, OccurrenceActionDone)}
+	if err := h.HandleCallback(ctx, cb); err != nil {
+		t.Fatal(err)
+	}
+
+	want := callbackAnswer{text: "This button belongs to someone else.", cacheTime: rejectedCallbackCacheTime}
+	if len(responder.answers) != 1 || responder.answers[0] != want {
+		t.Fatalf("answers = %+v, want %+v", responder.answers, want)
+	}
+	got, err := occurrences.GetByID(ctx, occ.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Status != domain.OccurrenceSent {
+		t.Fatalf("status = %s, want it unchanged", got.Status)
+	}
+}
+
+// brokenOccurrences fails every lookup, like a database that is down.
+type brokenOccurrences struct {
+	domain.OccurrenceStore
+}
+
+func (brokenOccurrences) GetByID(ctx context.Context, id int64) (*domain.Occurrence, error) {
+	return nil, errors.New("database is locked")
+}
+
+func TestOccurrenceCallbackLoadFailureIsNotCached(t *testing.T) {
+	responder := &answerRecorder{}
+	h := NewOccurrenceCallbackHandler(memory.NewInMemoryReminderStore(), brokenOccurrences{}, responder)
+	ctx := withUser(context.Background(), &domain.User{ID: 1, TelegramID: 100})
+	cb := &CallbackQuery{ID: "q", From: &User{ID: 100}, Data: BuildOccurrenceCallback(1, OccurrenceActionDone)}
+	if err := h.HandleCallback(ctx, cb); err != nil {
+		t.Fatal(err)
+	}
+
+	want := callbackAnswer{text: "Failed to load the reminder, please try again.", alert: true}
+	if len(responder.answers) != 1 || responder.answers[0] != want {
+		t.Fatalf("answers = %+v, want an uncached alert %+v", responder.answers, want)
+	}
+}
@@ -0,0 +1,249 @@
+package telegram
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"naggingbot/internal/domain"
+	"naggingbot/internal/i18n"
+)
+
+const channelsUsage = "Usage:\n" +
+	"/channels telegram|telegram+email|webhook|...: choose where your reminders are sent\n" +
+	"/channels <id> telegram|telegram+email|webhook|...: choose it for one reminder\n" +
+	"/channels [<id>] default: go back to the default\n" +
+	"/channels <id>: show the channels and latest deliveries of a reminder\n" +
+	"By default reminders go to Telegram, and also to your email and webhook if you set them up. " +
+	"A reminder counts as delivered when any chosen channel succeeds."
+
+// channelsLookback is how many past occurrences /channels <id> searches for
+// the latest deliveries.
+const channelsLookback = 5
+
+// ChannelsHandler handles /channels, which chooses the notification channels
+// of a user or a reminder and shows how they delivered.
+type ChannelsHandler struct {
+	users       domain.UserStore
+	reminders   domain.ReminderStore
+	occurrences domain.OccurrenceStore
+	emails      domain.EmailStore
+	webhooks    domain.WebhookStore
+	// emailEnabled is false when no SMTP relay is configured.
+	emailEnabled bool
+	responder    Responder
+}
+
+func NewChannelsHandler(users domain.UserStore, reminders domain.ReminderStore, occurrences domain.OccurrenceStore,
+	emails domain.EmailStore, webhooks domain.WebhookStore, emailEnabled bool, responder Responder) *ChannelsHandler {
+	return &ChannelsHandler{
+		users:        users,
+		reminders:    reminders,
+		occurrences:  occurrences,
+		emails:       emails,
+		webhooks:     webhooks,
+		emailEnabled: emailEnabled,
+		responder:    responder,
+	}
+}
+
+func (h *ChannelsHandler) HandleCommand(ctx context.Context, msg *Message) error {
+	user := UserFrom(ctx)
+	if user == nil {
+		return nil
+	}
+
+	p := userPrinter(user)
+	parts := strings.Fields(msg.Text)
+	switch len(parts) {
+	case 1:
+		h.show(ctx, p, user)
+	case 2:
+		if id, err := strconv.ParseInt(parts[1], 10, 64); err == nil {
+			if rem := h.ownReminder(ctx, p, user, id); rem != nil {
+				h.showReminder(ctx, p, user, rem)
+			}
+			return nil
+		}
+		channels, ok := h.parse(ctx, p, user, parts[1])
+		if !ok {
+			return nil
+		}
+		if err := h.users.SetChannels(ctx, user.ID, channels); err != nil {
+			log.Printf("telegram: channels set user channels failed: %v", err)
+			reply(ctx, h.responder, user.TelegramID, p.T("Failed to save channels"))
+			return nil
+		}
+		reply(ctx, h.responder, user.TelegramID, p.T("Your reminders are sent over: %s.", channelList(p, channels))+h.warnings(ctx, p, user, channels))
+	case 3:
+		id, err := strconv.ParseInt(parts[1], 10, 64)
+		if err != nil {
+			reply(ctx, h.responder, user.TelegramID, p.T("Invalid id"))
+			return nil
+		}
+		rem := h.ownReminder(ctx, p, user, id)
+		if rem == nil {
+			return nil
+		}
+		channels, ok := h.parse(ctx, p, user, parts[2])
+		if !ok {
+			return nil
+		}
+		rem.Channels = channels
+		if err := h.reminders.Update(ctx, rem); err != nil {
+			log.Printf("telegram: channels update reminder failed: %v", err)
+			reply(ctx, h.responder, user.TelegramID, p.T("Failed to save channels"))
+			return nil
+		}
+		if channels == 0 {
+			reply(ctx, h.responder, user.TelegramID, p.T("Reminder #%d follows your channels again.", rem.ID))
+			return nil
+		}
+		reply(ctx, h.responder, user.TelegramID, p.T("Reminder #%d is sent over: %s.", rem.ID, channelList(p, channels))+h.warnings(ctx, p, user, channels))
+	default:
+		reply(ctx, h.responder, user.TelegramID, p.T(channelsUsage))
+	}
+	return nil
+}
+
+func (h *ChannelsHandler) show(ctx context.Context, p *i18n.Printer, user *domain.User) {
+	lines := []string{p.T("Your reminders are sent over: %s.", channelList(p, user.Channels))}
+	rems, err := h.reminders.ListByUser(ctx, user.ID)
+	if err != nil {
+		log.Printf("telegram: channels list reminders failed: %v", err)
+	}
+	for _, rem := range rems {
+		if rem.Channels != 0 {
+			lines = append(lines, p.T("Reminder #%d “%s”: %s", rem.ID, rem.Name, channelList(p, rem.Channels)))
+		}
+	}
+	reply(ctx, h.responder, user.TelegramID, strings.Join(lines, "\n")+"\n\n"+p.T(channelsUsage))
+}
+
+// showReminder lists the reminder's channels and the deliveries of its
+// latest occurrence that was sent or tried.
+func (h *ChannelsHandler) showReminder(ctx context.Context, p *i18n.Printer, user *domain.User, rem *domain.Reminder) {
+	channels := rem.Channels
+	if channels == 0 {
+		channels = user.Channels
+	}
+	lines := []string{p.T("Reminder #%d “%s” is sent over: %s.", rem.ID, rem.Name, channelList(p, channels))}
+
+	occs, err := h.occurrences.ListByReminderInRange(ctx, rem.ID, time.Time{}, time.Now().UTC())
+	if err != nil {
+		log.Printf("telegram: channels list occurrences failed: %v", err)
+		reply(ctx, h.responder, user.TelegramID, p.T("Failed to load the delivery log"))
+		return
+	}
+	var deliveries []*domain.ChannelDelivery
+	for i := len(occs) - 1; i >= 0 && i >= len(occs)-channelsLookback && len(deliveries) == 0; i-- {
+		if deliveries, err = h.occurrences.ListDeliveries(ctx, occs[i].ID); err != nil {
+			log.Printf("telegram: channels list deliveries failed: %v", err)
+			reply(ctx, h.responder, user.TelegramID, p.T("Failed to load the delivery log"))
+			return
+		}
+	}
+
+	if len(deliveries) == 0 {
+		lines = append(lines, p.T("No deliveries yet."))
+	} else {
+		lines = append(lines, p.T("Latest deliveries:"))
+		loc := rem.Location()
+		for _, d := range deliveries {
+			at := d.AtUtc.In(loc).Format("02.01 15:04:05")
+			name := channelName(p, d.Channel)
+			if !d.Required {
+				name = p.T("%s (extra)", name)
+			}
+			if d.OK() {
+				lines = append(lines, p.T("✅ %s %s", at, name))
+			} else {
+				lines = append(lines, p.T("❌ %s %s: %s", at, name, d.Error))
+			}
+		}
+	}
+	reply(ctx, h.responder, user.TelegramID, strings.Join(lines, "\n"))
+}
+
+// parse reads a channel choice, rejecting email when the bot cannot send it.
+func (h *ChannelsHandler) parse(ctx context.Context, p *i18n.Printer, user *domain.User, arg string) (domain.Channels, bool) {
+	channels, err := domain.ParseChannels(arg)
+	if err != nil {
+		reply(ctx, h.responder, user.TelegramID, p.T(channelsUsage))
+		return 0, false
+	}
+	if channels.Has(domain.ChannelEmail) && !h.emailEnabled {
+		reply(ctx, h.responder, user.TelegramID, p.T("Email notifications are not available on this bot."))
+		return 0, false
+	}
+	return channels, true
+}
+
+// warnings reminds the user to set up chosen channels that are not set up
+// yet; until then they count as failed.
+func (h *ChannelsHandler) warnings(ctx context.Context, p *i18n.Printer, user *domain.User, channels domain.Channels) string {
+	var out []string
+	if channels.Has(domain.ChannelEmail) {
+		addr, err := h.emails.Get(ctx, user.ID)
+		if err != nil {
+			log.Printf("telegram: channels get email failed: %v", err)
+		} else if addr == nil || !addr.Verified() {
+			out = append(out, p.T("Email is not set up yet, see /email."))
+		}
+	}
+	if channels.Has(domain.ChannelWebhook) {
+		settings, err := h.webhooks.Get(ctx, user.ID)
+		if err != nil {
+			log.Printf("telegram: channels get webhook failed: %v", err)
+		} else if settings == nil {
+			out = append(out, p.T("No webhook is set up yet, see /webhook."))
+		}
+	}
+	if len(out) == 0 {
+		return ""
+	}
+	return "\n" + strings.Join(out, "\n")
+}
+
+func (h *ChannelsHandler) ownReminder(ctx context.Context, p *i18n.Printer, user *domain.User, id int64) *domain.Reminder {
+	rem, err := h.reminders.GetByID(ctx, id)
+	if err != nil {
+		log.Printf("telegram: channels get reminder failed: %v", err)
+		reply(ctx, h.responder, user.TelegramID, p.T("Failed to load reminder"))
+		return nil
+	}
+	if rem == nil || rem.UserID != user.ID {
+		reply(ctx, h.responder, user.TelegramID, p.T("Reminder not found"))
+		return nil
+	}
+	return rem
+}
+
+// channelList names a channel set in the user's language.
+func channelList(p *i18n.Printer, channels domain.Channels) string {
+	if channels == 0 {
+		return p.T("Telegram, plus email and webhook if set up (default)")
+	}
+	var names []string
+	for _, ch := range domain.AllChannels {
+		if channels.Has(ch) {
+			names = append(names, channelName(p, ch))
+		}
+	}
+	return strings.Join(names, " + ")
+}
+
+func channelName(p *i18n.Printer, ch domain.Channels) string {
+	switch ch {
+	case domain.ChannelTelegram:
+		return "Telegram"
+	case domain.ChannelEmail:
+		return p.T("email")
+	case domain.ChannelWebhook:
+		return p.T("webhook")
+	default:
+		return ch.String()
+	}
+}
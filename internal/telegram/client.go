@@ -3,6 +3,7 @@ package telegram
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
@@ -54,10 +55,19 @@ func (c *Client) Poll(ctx context.Context, handler Handler) error {
 			continue
 		}
 
+		poisoned := false
 		for _, u := range updates {
 			offset = u.UpdateID + 1
-			// Handler handles its own errors internally; keep loop running.
-			_ = handler.HandleUpdate(ctx, u)
+			if err := c.handle(ctx, handler, u); errors.Is(err, errPanic) {
+				poisoned = true
+				break
+			}
+		}
+		if poisoned {
+			// Poll again right away: getUpdates with the new offset confirms
+			// the update that panicked, so Telegram does not redeliver it after
+			// a restart, and returns the rest of the batch.
+			continue
 		}
 
 		// Cooldown between polling attempts.
@@ -69,6 +79,18 @@ func (c *Client) Poll(ctx context.Context, handler Handler) error {
 	}
 }
 
+// handle passes one update to the handler. A panic is recovered and returned
+// as an error wrapping errPanic instead of crashing the poller.
+func (c *Client) handle(ctx context.Context, handler Handler, u Update) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = panicError(r, "handling update %d", u.UpdateID)
+		}
+	}()
+	// Handler handles its own errors internally; keep loop running.
+	return handler.HandleUpdate(ctx, u)
+}
+
 func (c *Client) getUpdates(ctx context.Context, offset int64) ([]Update, error) {
 	reqCtx, cancel := context.WithTimeout(ctx, c.httpClient.Timeout)
 	defer cancel()
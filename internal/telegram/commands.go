@@ -7,19 +7,58 @@ import (
 	"fmt"
 	"net/http"
 	"time"
+
+	"naggingbot/internal/i18n"
 )
 
-// SetBotCommands registers bot commands for Telegram clients.
+// botCommands lists the commands shown in Telegram's menu; descriptions are
+// catalog keys.
+var botCommands = []struct{ command, description string }{
+	{"start", "Register"},
+	{"reminder", "Create reminder"},
+	{"list", "List reminders"},
+	{"delete", "Delete reminder"},
+	{"template", "Customize notification template"},
+	{"priority", "Set reminder priority"},
+	{"share", "Post a reminder to a group"},
+	{"assign", "Let someone else receive a reminder"},
+	{"escalate", "Escalate unanswered reminders"},
+	{"stats", "Adherence statistics"},
+	{"history", "Occurrence history"},
+	{"today", "Today's agenda"},
+	{"upcoming", "Upcoming occurrences"},
+	{"timezone", "Show or set time zone"},
+	{"language", "Show or set language"},
+	{"digest", "Daily and weekly digests"},
+	{"webhook", "Post notifications to a URL"},
+	{"email", "Receive reminders by email"},
+	{"channels", "Choose notification channels"},
+	{"export", "Export reminders (ics, json)"},
+	{"import", "Import reminders from a file"},
+}
+
+// SetBotCommands registers bot commands for Telegram clients: the default
+// language for everyone, plus translated descriptions for clients in each
+// other supported language.
 func SetBotCommands(ctx context.Context, token string) error {
-	payload := map[string]any{
-		"commands": []map[string]string{
-			{"command": "start", "description": "Register"},
-			{"command": "reminder", "description": "Create reminder"},
-			{"command": "list", "description": "List reminders"},
-			{"command": "delete", "description": "Delete reminder"},
-			{"command": "test", "description": "Demo reminder (restricted)"},
-		},
+	for _, l := range i18n.Locales() {
+		p := i18n.For(l)
+		commands := make([]map[string]string, 0, len(botCommands))
+		for _, c := range botCommands {
+			commands = append(commands, map[string]string{"command": c.command, "description": p.T(c.description)})
+		}
+		payload := map[string]any{"commands": commands}
+		if l != i18n.Default {
+			payload["language_code"] = string(l)
+		}
+		if err := setMyCommands(ctx, token, payload); err != nil {
+			return fmt.Errorf("%s: %w", l, err)
+		}
 	}
+	return nil
+}
+
+func setMyCommands(ctx context.Context, token string, payload map[string]any) error {
 	body, err := json.Marshal(payload)
 	if err != nil {
 		return err
@@ -45,3 +84,36 @@ func SetBotCommands(ctx context.Context, token string) error {
 
 	return nil
 }
+
+// GetMe returns the bot's own user, e.g. to build t.me deep links.
+func GetMe(ctx context.Context, token string) (*User, error) {
+	url := fmt.Sprintf("https://api.telegram.org/bot%s/getMe", token)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("getMe status %s", resp.Status)
+	}
+
+	var envelope struct {
+		OK          bool   `json:"ok"`
+		Result      User   `json:"result"`
+		Description string `json:"description,omitempty"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+		return nil, err
+	}
+	if !envelope.OK {
+		return nil, fmt.Errorf("telegram API error: %s", envelope.Description)
+	}
+	return &envelope.Result, nil
+}
@@ -2,6 +2,7 @@ package telegram
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"strconv"
 	"strings"
@@ -11,85 +12,68 @@ import (
 
 // DeleteHandler handles /delete <id> to remove reminder and occurrences.
 type DeleteHandler struct {
-	users       domain.UserStore
-	reminders   domain.ReminderStore
-	occurrences domain.OccurrenceStore
-	responder   Responder
+	reminders domain.ReminderStore
+	uow       domain.UnitOfWork
+	responder Responder
 }
 
-func NewDeleteHandler(users domain.UserStore, reminders domain.ReminderStore, occurrences domain.OccurrenceStore, responder Responder) *DeleteHandler {
+func NewDeleteHandler(reminders domain.ReminderStore, uow domain.UnitOfWork, responder Responder) *DeleteHandler {
 	return &DeleteHandler{
-		users:       users,
-		reminders:   reminders,
-		occurrences: occurrences,
-		responder:   responder,
+		reminders: reminders,
+		uow:       uow,
+		responder: responder,
 	}
 }
 
 func (h *DeleteHandler) HandleCommand(ctx context.Context, msg *Message) error {
-	user := msg.From
+	user := UserFrom(ctx)
 	if user == nil {
 		return nil
 	}
 
+	p := userPrinter(user)
 	parts := strings.Split(strings.TrimSpace(msg.Text), " ")
 	if len(parts) != 2 {
-		h.reply(ctx, user.ID, "Usage: /delete <reminder_id>")
+		reply(ctx, h.responder, user.TelegramID, p.T("Usage: /delete <reminder_id>"))
 		return nil
 	}
 
 	id, err := strconv.ParseInt(parts[1], 10, 64)
 	if err != nil {
-		h.reply(ctx, user.ID, "Invalid id")
-		return nil
-	}
-
-	domainUser, err := h.users.GetByTelegramID(ctx, user.ID)
-	if err != nil {
-		log.Printf("telegram: delete fetch user failed: %v", err)
-		h.reply(ctx, user.ID, "Failed to delete")
-		return nil
-	}
-	if domainUser == nil {
-		h.reply(ctx, user.ID, "Reminder not found")
+		reply(ctx, h.responder, user.TelegramID, p.T("Invalid id"))
 		return nil
 	}
 
 	rem, err := h.reminders.GetByID(ctx, id)
 	if err != nil {
 		log.Printf("telegram: delete get reminder failed: %v", err)
-		h.reply(ctx, user.ID, "Failed to delete")
+		reply(ctx, h.responder, user.TelegramID, p.T("Failed to delete"))
 		return nil
 	}
 	if rem == nil {
-		h.reply(ctx, user.ID, "Reminder not found")
+		reply(ctx, h.responder, user.TelegramID, p.T("Reminder not found"))
 		return nil
 	}
-	if rem.UserID != domainUser.ID {
-		h.reply(ctx, user.ID, "Cannot delete reminder of another user")
+	if rem.UserID != user.ID {
+		reply(ctx, h.responder, user.TelegramID, p.T("Cannot delete reminder of another user"))
 		return nil
 	}
 
-	if err := h.occurrences.DeleteByReminder(ctx, id); err != nil {
-		log.Printf("telegram: delete occurrences failed: %v", err)
-		h.reply(ctx, user.ID, "Failed to delete occurrences")
+	err = h.uow.Do(ctx, func(ctx context.Context, tx domain.Stores) error {
+		if err := tx.Occurrences.DeleteByReminder(ctx, id); err != nil {
+			return fmt.Errorf("delete occurrences: %w", err)
+		}
+		if err := tx.Reminders.DeleteByID(ctx, id); err != nil {
+			return fmt.Errorf("delete reminder: %w", err)
+		}
 		return nil
-	}
-	if err := h.reminders.DeleteByID(ctx, id); err != nil {
-		log.Printf("telegram: delete reminder failed: %v", err)
-		h.reply(ctx, user.ID, "Failed to delete reminder")
+	})
+	if err != nil {
+		log.Printf("telegram: %v", err)
+		reply(ctx, h.responder, user.TelegramID, p.T("Failed to delete reminder"))
 		return nil
 	}
 
-	h.reply(ctx, user.ID, "Reminder deleted")
+	reply(ctx, h.responder, user.TelegramID, p.T("Reminder deleted"))
 	return nil
 }
-
-func (h *DeleteHandler) reply(ctx context.Context, chatID int64, text string) {
-	if h.responder == nil {
-		return
-	}
-	if err := h.responder.SendMessage(ctx, chatID, text); err != nil {
-		log.Printf("telegram: failed to send delete reply: %v", err)
-	}
-}
@@ -0,0 +1,136 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"naggingbot/internal/domain"
+	"naggingbot/internal/i18n"
+)
+
+const digestUsage = "Usage:\n" +
+	"/digest - show settings\n" +
+	"/digest morning <HH:MM|off> - list of the day's reminders\n" +
+	"/digest evening <HH:MM|off> - recap of done, ignored and missed\n" +
+	"/digest weekly <on|off> - weekly recap on Sundays"
+
+// DigestHandler handles /digest to configure the opt-in digest messages.
+type DigestHandler struct {
+	reminders domain.ReminderStore
+	digests   domain.DigestStore
+	responder Responder
+}
+
+func NewDigestHandler(reminders domain.ReminderStore, digests domain.DigestStore, responder Responder) *DigestHandler {
+	return &DigestHandler{reminders: reminders, digests: digests, responder: responder}
+}
+
+func (h *DigestHandler) HandleCommand(ctx context.Context, msg *Message) error {
+	user := UserFrom(ctx)
+	if user == nil {
+		return nil
+	}
+
+	p := userPrinter(user)
+	settings, err := h.digests.Get(ctx, user.ID)
+	if err != nil {
+		log.Printf("telegram: digest get settings failed: %v", err)
+		reply(ctx, h.responder, user.TelegramID, p.T("Failed to load digest settings"))
+		return nil
+	}
+	if settings == nil {
+		settings = &domain.DigestSettings{UserID: user.ID}
+	}
+
+	parts := strings.Fields(msg.Text)
+	if len(parts) == 1 {
+		reply(ctx, h.responder, user.TelegramID, h.describe(ctx, p, user, settings)+"\n\n"+p.T(digestUsage))
+		return nil
+	}
+	if len(parts) != 3 {
+		reply(ctx, h.responder, user.TelegramID, p.T(digestUsage))
+		return nil
+	}
+
+	kind, value := domain.DigestKind(strings.ToLower(parts[1])), strings.ToLower(parts[2])
+	switch kind {
+	case domain.DigestMorning, domain.DigestEvening:
+		var at *domain.TimeOfDay
+		if value != "off" {
+			t, err := time.Parse("15:04", value)
+			if err != nil {
+				reply(ctx, h.responder, user.TelegramID, p.T("Invalid time. Use HH:MM or off"))
+				return nil
+			}
+			at = &domain.TimeOfDay{Hour: t.Hour(), Minute: t.Minute()}
+		}
+		if kind == domain.DigestMorning {
+			settings.Morning = at
+		} else {
+			settings.Evening = at
+		}
+	case domain.DigestWeekly:
+		switch value {
+		case "on":
+			settings.Weekly = true
+		case "off":
+			settings.Weekly = false
+		default:
+			reply(ctx, h.responder, user.TelegramID, p.T("Use /digest weekly on or /digest weekly off"))
+			return nil
+		}
+	default:
+		reply(ctx, h.responder, user.TelegramID, p.T(digestUsage))
+		return nil
+	}
+
+	if err := h.digests.Save(ctx, settings); err != nil {
+		log.Printf("telegram: digest save settings failed: %v", err)
+		reply(ctx, h.responder, user.TelegramID, p.T("Failed to save digest settings"))
+		return nil
+	}
+	reply(ctx, h.responder, user.TelegramID, p.T("Saved.")+"\n"+h.describe(ctx, p, user, settings))
+	return nil
+}
+
+func (h *DigestHandler) describe(ctx context.Context, p *i18n.Printer, user *domain.User, s *domain.DigestSettings) string {
+	rems, err := h.reminders.ListByUser(ctx, user.ID)
+	if err != nil {
+		log.Printf("telegram: digest list reminders failed: %v", err)
+	}
+
+	weekly := p.T("off")
+	if s.Weekly {
+		at := s.WeeklyTime()
+		weekly = p.T("Sundays at %02d:%02d", at.Hour, at.Minute)
+	}
+	return p.T("Digests (%s):\nmorning: %s\nevening: %s\nweekly: %s",
+		user.Location(rems), formatDigestTime(p, s.Morning), formatDigestTime(p, s.Evening), weekly)
+}
+
+func formatDigestTime(p *i18n.Printer, t *domain.TimeOfDay) string {
+	if t == nil {
+		return p.T("off")
+	}
+	return fmt.Sprintf("%02d:%02d", t.Hour, t.Minute)
+}
+
+// DigestSender delivers digests as plain Telegram messages.
+type DigestSender struct {
+	responder Responder
+}
+
+// NewDigestSender constructs a scheduler.DigestSender backed by the responder.
+func NewDigestSender(responder Responder) *DigestSender {
+	return &DigestSender{responder: responder}
+}
+
+func (s *DigestSender) SendDigest(ctx context.Context, user *domain.User, text string) error {
+	if user.TelegramID == 0 {
+		return fmt.Errorf("telegram digest: no telegram id for user %d", user.ID)
+	}
+	return s.responder.SendMessage(ctx, user.TelegramID, text)
+}
@@ -16,57 +16,174 @@ type CallbackHandler interface {
 	HandleCallback(ctx context.Context, cb *CallbackQuery) error
 }
 
-// Dispatcher routes updates to command or callback handlers.
+// Dispatcher routes updates to command or callback handlers, running them
+// through the middlewares installed with Use.
 type Dispatcher struct {
-	commands map[string]CommandHandler
-	callback CallbackHandler
+	commands       map[string]CommandHandler
+	access         map[string]Access
+	callbacks      map[string]CallbackHandler
+	callbackAccess map[string]Access
+	document       CommandHandler
+	middleware     []Middleware
+	// username is the bot's own username; commands addressed to another bot
+	// ("/list@OtherBot") are dropped.
+	username string
 }
 
 // NewDispatcher constructs a dispatcher with optional handlers.
 func NewDispatcher() *Dispatcher {
 	return &Dispatcher{
-		commands: make(map[string]CommandHandler),
+		commands:       make(map[string]CommandHandler),
+		access:         make(map[string]Access),
+		callbacks:      make(map[string]CallbackHandler),
+		callbackAccess: make(map[string]Access),
 	}
 }
 
+// SetUsername sets the bot's username. In groups commands may carry a
+// mention ("/list@NaggingBot"); once the username is set, commands mentioning
+// another bot are ignored.
+func (d *Dispatcher) SetUsername(name string) {
+	d.username = name
+}
+
+// Use appends middlewares. They wrap every routed update, the first one
+// outermost; updates no handler is registered for are dropped before them.
+// Admin commands only run behind the Authorize middleware.
+func (d *Dispatcher) Use(mw ...Middleware) {
+	d.middleware = append(d.middleware, mw...)
+}
+
 // HandleUpdate allows Dispatcher to satisfy the Client Handler interface.
 func (d *Dispatcher) HandleUpdate(ctx context.Context, update Update) error {
-	d.Dispatch(ctx, update)
-	return nil
+	return d.Dispatch(ctx, update)
 }
 
-// RegisterCommand registers a handler for a given command (e.g., "/start").
+// RegisterCommand registers a handler for a given command (e.g., "/list")
+// that requires a registered user.
 func (d *Dispatcher) RegisterCommand(cmd string, h CommandHandler) {
 	d.commands[cmd] = h
+	d.access[cmd] = AccessMember
 }
 
-// RegisterCallback sets the handler for callback queries.
-func (d *Dispatcher) RegisterCallback(h CallbackHandler) {
-	d.callback = h
+// RegisterPublicCommand registers a command that unregistered users may run,
+// such as /start, which performs the registration.
+func (d *Dispatcher) RegisterPublicCommand(cmd string, h CommandHandler) {
+	d.commands[cmd] = h
+	d.access[cmd] = AccessPublic
+}
+
+// RegisterAdminCommand registers a command that only admins may run. Other
+// users get no reply, as if the command did not exist.
+func (d *Dispatcher) RegisterAdminCommand(cmd string, h CommandHandler) {
+	d.commands[cmd] = h
+	d.access[cmd] = AccessAdmin
+}
+
+// RegisterCallback registers a handler for callback data in the given
+// namespace, i.e. the part before the first ':' (e.g. "occ").
+func (d *Dispatcher) RegisterCallback(prefix string, h CallbackHandler) {
+	d.callbacks[prefix] = h
+	d.callbackAccess[prefix] = AccessMember
+}
+
+// RegisterPublicCallback registers a callback handler that unregistered
+// users may trigger, such as the buttons of reminders posted to a group.
+func (d *Dispatcher) RegisterPublicCallback(prefix string, h CallbackHandler) {
+	d.callbacks[prefix] = h
+	d.callbackAccess[prefix] = AccessPublic
+}
+
+// RegisterDocument sets the handler for uploaded files sent without a command caption.
+func (d *Dispatcher) RegisterDocument(h CommandHandler) {
+	d.document = h
 }
 
 // Dispatch routes the update to the appropriate handler.
-func (d *Dispatcher) Dispatch(ctx context.Context, update Update) {
+func (d *Dispatcher) Dispatch(ctx context.Context, update Update) error {
+	route, h := d.route(update)
+	if h == nil {
+		return nil
+	}
+	for i := len(d.middleware) - 1; i >= 0; i-- {
+		h = d.middleware[i](h)
+	}
+	return h.HandleUpdate(context.WithValue(ctx, routeKey, route), update)
+}
+
+// route finds the handler for the update, or returns a nil Handler.
+func (d *Dispatcher) route(update Update) (Route, Handler) {
 	// Callback query has priority.
-	if update.CallbackQuery != nil && d.callback != nil {
-		if err := d.callback.HandleCallback(ctx, update.CallbackQuery); err != nil {
-			log.Printf("telegram callback handler error: %v", err)
+	if cb := update.CallbackQuery; cb != nil {
+		prefix := callbackPrefix(cb.Data)
+		h, ok := d.callbacks[prefix]
+		if !ok {
+			return Route{}, nil
 		}
-		return
+		return Route{Name: prefix, Access: d.callbackAccess[prefix]}, HandlerFunc(func(ctx context.Context, _ Update) error {
+			return h.HandleCallback(ctx, cb)
+		})
 	}
 
-	// Commands in messages.
-	if update.Message != nil {
-		text := strings.TrimSpace(update.Message.Text)
-		if strings.HasPrefix(text, "/") {
-			cmd := firstToken(text)
-			if h, ok := d.commands[cmd]; ok {
-				if err := h.HandleCommand(ctx, update.Message); err != nil {
-					log.Printf("telegram command handler error (%s): %v", cmd, err)
-				}
-			}
+	msg := update.Message
+	if msg == nil {
+		return Route{}, nil
+	}
+	// Commands in messages. Uploaded files carry the command in their caption.
+	if text := commandText(msg); strings.HasPrefix(text, "/") {
+		cmd, bot := splitCommand(firstToken(text))
+		if bot != "" && d.username != "" && !strings.EqualFold(bot, d.username) {
+			return Route{}, nil
 		}
+		h, ok := d.commands[cmd]
+		if !ok {
+			return Route{}, nil
+		}
+		route := Route{Name: cmd, Access: d.access[cmd]}
+		return route, HandlerFunc(func(ctx context.Context, _ Update) error {
+			if route.Access == AccessAdmin && !authorized(ctx) {
+				log.Printf("telegram: %s dropped, admin commands need the Authorize middleware", cmd)
+				return nil
+			}
+			return h.HandleCommand(ctx, msg)
+		})
+	}
+	if msg.Document != nil && d.document != nil {
+		return Route{Name: "document", Access: AccessMember}, HandlerFunc(func(ctx context.Context, _ Update) error {
+			return d.document.HandleCommand(ctx, msg)
+		})
+	}
+	return Route{}, nil
+}
+
+// commandText returns the message text, or the caption of an uploaded file.
+func commandText(msg *Message) string {
+	if msg.Text == "" && msg.Document != nil {
+		return strings.TrimSpace(msg.Caption)
+	}
+	return strings.TrimSpace(msg.Text)
+}
+
+func callbackPrefix(data string) string {
+	if idx := strings.IndexByte(data, ':'); idx >= 0 {
+		return data[:idx]
+	}
+	return data
+}
+
+// commandName returns the command a message starts with, without the bot
+// mention.
+func commandName(text string) string {
+	cmd, _ := splitCommand(firstToken(strings.TrimSpace(text)))
+	return cmd
+}
+
+// splitCommand splits "/cmd@bot" into the command and the bot's username.
+func splitCommand(token string) (cmd, bot string) {
+	if idx := strings.IndexByte(token, '@'); idx >= 0 {
+		return token[:idx], token[idx+1:]
 	}
+	return token, ""
 }
 
 func firstToken(s string) string {
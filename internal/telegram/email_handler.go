@@ -0,0 +1,182 @@
+package telegram
+
+import (
+	"context"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"naggingbot/internal/domain"
+	"naggingbot/internal/email"
+	"naggingbot/internal/i18n"
+)
+
+const emailUsage = "Usage:\n" +
+	"/email <address>: receive reminders by email too\n" +
+	"/email verify <code>: confirm the address with the code mailed to it\n" +
+	"/email off: stop emailing reminders\n" +
+	"/email: show the address"
+
+// EmailHandler handles /email, which sets up and verifies the address email
+// notifications are sent to.
+type EmailHandler struct {
+	emails domain.EmailStore
+	// mailer is nil when no SMTP relay is configured.
+	mailer    email.Mailer
+	responder Responder
+	now       func() time.Time
+
+	// lastSent is when a code was last mailed per user, so /email cannot be
+	// used to flood an inbox.
+	mu       sync.Mutex
+	lastSent map[int64]time.Time
+}
+
+func NewEmailHandler(emails domain.EmailStore, mailer email.Mailer, responder Responder) *EmailHandler {
+	return &EmailHandler{emails: emails, mailer: mailer, responder: responder, now: time.Now, lastSent: make(map[int64]time.Time)}
+}
+
+func (h *EmailHandler) HandleCommand(ctx context.Context, msg *Message) error {
+	user := UserFrom(ctx)
+	if user == nil {
+		return nil
+	}
+
+	p := userPrinter(user)
+	if h.mailer == nil {
+		reply(ctx, h.responder, user.TelegramID, p.T("Email notifications are not available on this bot."))
+		return nil
+	}
+	addr, err := h.emails.Get(ctx, user.ID)
+	if err != nil {
+		log.Printf("telegram: email get address failed: %v", err)
+		reply(ctx, h.responder, user.TelegramID, p.T("Failed to load your email address"))
+		return nil
+	}
+
+	parts := strings.Fields(msg.Text)
+	switch {
+	case len(parts) == 1:
+		h.show(ctx, p, user, addr)
+	case len(parts) == 2 && strings.EqualFold(parts[1], "off"):
+		if addr == nil {
+			reply(ctx, h.responder, user.TelegramID, p.T("No email address is set up."))
+			return nil
+		}
+		if err := h.emails.Delete(ctx, user.ID); err != nil {
+			log.Printf("telegram: email delete address failed: %v", err)
+			reply(ctx, h.responder, user.TelegramID, p.T("Failed to save your email address"))
+			return nil
+		}
+		reply(ctx, h.responder, user.TelegramID, p.T("Reminders are no longer emailed to %s.", addr.Address))
+	case len(parts) == 3 && strings.EqualFold(parts[1], "verify"):
+		h.verify(ctx, p, user, addr, parts[2])
+	case len(parts) == 2:
+		h.setAddress(ctx, p, user, parts[1])
+	default:
+		reply(ctx, h.responder, user.TelegramID, p.T(emailUsage))
+	}
+	return nil
+}
+
+func (h *EmailHandler) show(ctx context.Context, p *i18n.Printer, user *domain.User, addr *domain.EmailAddress) {
+	var status string
+	switch {
+	case addr == nil:
+		status = p.T("No email address is set up.")
+	case addr.Verified():
+		status = p.T("Reminders are emailed to %s.", addr.Address)
+	default:
+		status = p.T("%s is not verified yet. Send the code from the email with /email verify <code>.", addr.Address)
+	}
+	reply(ctx, h.responder, user.TelegramID, status+"\n\n"+p.T(emailUsage))
+}
+
+// setAddress replaces the user's address with an unverified one and mails
+// it a verification code. Notifications stop until the new address is
+// verified. Codes are mailed at most once per EmailCodeResendInterval.
+func (h *EmailHandler) setAddress(ctx context.Context, p *i18n.Printer, user *domain.User, address string) {
+	if err := domain.ValidateEmail(address); err != nil {
+		reply(ctx, h.responder, user.TelegramID, p.T("Invalid email address"))
+		return
+	}
+	if wait := h.reserveSend(user.ID); wait > 0 {
+		reply(ctx, h.responder, user.TelegramID, p.T("A code was mailed just now. Try again in %d seconds.", int((wait+time.Second-1)/time.Second)))
+		return
+	}
+	code, err := email.NewCode()
+	if err != nil {
+		log.Printf("telegram: email code failed: %v", err)
+		reply(ctx, h.responder, user.TelegramID, p.T("Failed to save your email address"))
+		return
+	}
+	addr := &domain.EmailAddress{
+		UserID:           user.ID,
+		Address:          address,
+		Code:             code,
+		CodeExpiresAtUtc: h.now().UTC().Add(domain.EmailCodeTTL),
+	}
+	if err := h.emails.Save(ctx, addr); err != nil {
+		log.Printf("telegram: email save address failed: %v", err)
+		reply(ctx, h.responder, user.TelegramID, p.T("Failed to save your email address"))
+		return
+	}
+
+	msg, err := email.VerificationMessage(p, address, code)
+	if err == nil {
+		err = h.mailer.Send(ctx, msg)
+	}
+	if err != nil {
+		log.Printf("telegram: email send verification failed: %v", err)
+		reply(ctx, h.responder, user.TelegramID, p.T("Failed to send the verification email. Check the address and try again."))
+		return
+	}
+	reply(ctx, h.responder, user.TelegramID, p.T("A verification code was sent to %s. Send it with /email verify <code> within %d minutes.", address, int(domain.EmailCodeTTL.Minutes())))
+}
+
+// reserveSend records a code mailed to the user now, or returns how long
+// they have to wait for the next one.
+func (h *EmailHandler) reserveSend(userID int64) time.Duration {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	now := h.now()
+	if wait := h.lastSent[userID].Add(domain.EmailCodeResendInterval).Sub(now); wait > 0 {
+		return wait
+	}
+	h.lastSent[userID] = now
+	return 0
+}
+
+func (h *EmailHandler) verify(ctx context.Context, p *i18n.Printer, user *domain.User, addr *domain.EmailAddress, code string) {
+	switch {
+	case addr == nil:
+		reply(ctx, h.responder, user.TelegramID, p.T("No email address is set up."))
+		return
+	case addr.Verified():
+		reply(ctx, h.responder, user.TelegramID, p.T("%s is already verified.", addr.Address))
+		return
+	case addr.Code == "" || addr.CodeAttempts >= domain.MaxEmailCodeAttempts || !h.now().Before(addr.CodeExpiresAtUtc):
+		reply(ctx, h.responder, user.TelegramID, p.T("The code has expired. Send /email %s to get a new one.", addr.Address))
+		return
+	}
+
+	if code != addr.Code {
+		addr.CodeAttempts++
+		if err := h.emails.Save(ctx, addr); err != nil {
+			log.Printf("telegram: email save address failed: %v", err)
+		}
+		reply(ctx, h.responder, user.TelegramID, p.T("Wrong code."))
+		return
+	}
+	addr.Code = ""
+	addr.CodeExpiresAtUtc = time.Time{}
+	addr.CodeAttempts = 0
+	addr.VerifiedAtUtc = h.now().UTC()
+	if err := h.emails.Save(ctx, addr); err != nil {
+		log.Printf("telegram: email save address failed: %v", err)
+		reply(ctx, h.responder, user.TelegramID, p.T("Failed to save your email address"))
+		return
+	}
+	reply(ctx, h.responder, user.TelegramID, p.T("%s is verified. Reminders will be emailed there too.", addr.Address))
+}
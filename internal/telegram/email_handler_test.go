@@ -0,0 +1,53 @@
+package telegram
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"naggingbot/internal/domain"
+	"naggingbot/internal/email"
+	"naggingbot/internal/storage/memory"
+)
+
+// countingMailer counts the messages it is asked to send.
+type countingMailer struct {
+	sent int
+}
+
+func (m *countingMailer) Send(ctx context.Context, msg email.Message) error {
+	m.sent++
+	return nil
+}
+
+func TestEmailHandlerThrottlesCodes(t *testing.T) {
+	now := time.Date(2026, 1, 19, 8, 0, 0, 0, time.UTC)
+	mailer, responder := &countingMailer{}, &fakeResponder{}
+	h := NewEmailHandler(memory.NewInMemoryEmailStore(), mailer, responder)
+	h.now = func() time.Time { return now }
+	ctx := withUser(context.Background(), &domain.User{ID: 1, TelegramID: 100})
+	send := func(text string) {
+		t.Helper()
+		if err := h.HandleCommand(ctx, &Message{Text: text}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	send("/email a@example.com")
+	send("/email b@example.com")
+	send("/email off")
+	send("/email a@example.com")
+	if mailer.sent != 1 {
+		t.Fatalf("mailed %d codes, want 1 within the resend interval", mailer.sent)
+	}
+	if got := responder.sent[len(responder.sent)-1]; !strings.Contains(got, "Try again in 60 seconds") {
+		t.Fatalf("reply = %q, want the cooldown", got)
+	}
+
+	now = now.Add(domain.EmailCodeResendInterval)
+	send("/email b@example.com")
+	if mailer.sent != 2 {
+		t.Fatalf("mailed %d codes, want a new one after the resend interval", mailer.sent)
+	}
+}
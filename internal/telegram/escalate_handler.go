@@ -0,0 +1,304 @@
+package telegram
+
+import (
+	"context"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"naggingbot/internal/domain"
+	"naggingbot/internal/i18n"
+)
+
+const escalateUsage = "Usage:\n" +
+	"/escalate <id> <minutes> @username: tell another user, once they accept, if an occurrence stays unanswered that long\n" +
+	"/escalate <id> <minutes> <chat id>: tell a group you are a member of\n" +
+	"/escalate <id> <minutes> <https://…>: call a webhook\n" +
+	"/escalate <id> clear: remove all escalation steps\n" +
+	"/escalate <id>: show the escalation steps"
+
+// EscalateHandler handles /escalate, which manages a reminder's escalation
+// chain, and the Accept/Decline buttons of users asked to be a step. The
+// scheduler fires the steps in order while an occurrence stays unanswered;
+// answering it stops the chain.
+type EscalateHandler struct {
+	users     domain.UserStore
+	reminders domain.ReminderStore
+	responder Responder
+}
+
+func NewEscalateHandler(users domain.UserStore, reminders domain.ReminderStore, responder Responder) *EscalateHandler {
+	return &EscalateHandler{users: users, reminders: reminders, responder: responder}
+}
+
+func (h *EscalateHandler) HandleCommand(ctx context.Context, msg *Message) error {
+	user := UserFrom(ctx)
+	if user == nil {
+		return nil
+	}
+
+	p := userPrinter(user)
+	parts := strings.Fields(msg.Text)
+	if len(parts) < 2 || len(parts) > 4 || len(parts) == 3 && !strings.EqualFold(parts[2], "clear") {
+		reply(ctx, h.responder, user.TelegramID, p.T(escalateUsage))
+		return nil
+	}
+	id, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		reply(ctx, h.responder, user.TelegramID, p.T("Invalid id"))
+		return nil
+	}
+	rem, err := h.reminders.GetByID(ctx, id)
+	if err != nil {
+		log.Printf("telegram: escalate get reminder failed: %v", err)
+		reply(ctx, h.responder, user.TelegramID, p.T("Failed to load reminder"))
+		return nil
+	}
+	if rem == nil || rem.UserID != user.ID {
+		reply(ctx, h.responder, user.TelegramID, p.T("Reminder not found"))
+		return nil
+	}
+
+	switch len(parts) {
+	case 2:
+		h.show(ctx, p, user, rem)
+	case 3:
+		rem.Escalations = nil
+		if h.save(ctx, p, user, rem) {
+			reply(ctx, h.responder, user.TelegramID, p.T("Reminder #%d no longer escalates.", rem.ID))
+		}
+	default:
+		minutes, err := strconv.Atoi(parts[2])
+		if err != nil || minutes < 1 || time.Duration(minutes)*time.Minute > domain.MaxEscalateAfter {
+			reply(ctx, h.responder, user.TelegramID, p.T("The escalation delay must be 1 to %d minutes.", int(domain.MaxEscalateAfter/time.Minute)))
+			return nil
+		}
+		h.add(ctx, p, user, rem, time.Duration(minutes)*time.Minute, parts[3])
+	}
+	return nil
+}
+
+func (h *EscalateHandler) show(ctx context.Context, p *i18n.Printer, user *domain.User, rem *domain.Reminder) {
+	if len(rem.Escalations) == 0 {
+		reply(ctx, h.responder, user.TelegramID, p.T("Reminder #%d has no escalation steps.", rem.ID))
+		return
+	}
+	lines := []string{p.T("Escalation steps of reminder #%d:", rem.ID)}
+	for i, step := range rem.Escalations {
+		if step.Pending {
+			lines = append(lines, p.T("%d. after %d min: %s (waiting for acceptance)", i+1, int(step.After/time.Minute), h.target(ctx, p, step)))
+			continue
+		}
+		lines = append(lines, p.T("%d. after %d min: %s", i+1, int(step.After/time.Minute), h.target(ctx, p, step)))
+	}
+	reply(ctx, h.responder, user.TelegramID, strings.Join(lines, "\n"))
+}
+
+// add appends a step for target, which is a @username, a group chat id or a
+// webhook URL, keeping the chain ordered by delay. A step for another user
+// stays pending until they accept it, unless they accepted one before.
+func (h *EscalateHandler) add(ctx context.Context, p *i18n.Printer, user *domain.User, rem *domain.Reminder, after time.Duration, target string) {
+	if len(rem.Escalations) >= domain.MaxEscalationSteps {
+		reply(ctx, h.responder, user.TelegramID, p.T("A reminder can have at most %d escalation steps.", domain.MaxEscalationSteps))
+		return
+	}
+
+	step := domain.EscalationStep{After: after}
+	var other *domain.User
+	switch {
+	case strings.HasPrefix(target, "@"):
+		var err error
+		other, err = h.users.GetByUsername(ctx, target)
+		if err != nil {
+			log.Printf("telegram: escalate get user %q failed: %v", target, err)
+			reply(ctx, h.responder, user.TelegramID, p.T("Failed to look up %s, please try again.", target))
+			return
+		}
+		if other == nil || !other.Registered || other.Banned {
+			reply(ctx, h.responder, user.TelegramID, p.T("%s has to open the bot and send /start first.", target))
+			return
+		}
+		step.ChatID = other.TelegramID
+		step.Pending = other.ID != user.ID && !accepted(rem, other.TelegramID)
+	case strings.HasPrefix(target, "http://") || strings.HasPrefix(target, "https://"):
+		step.URL = target
+	default:
+		chatID, err := strconv.ParseInt(target, 10, 64)
+		if err != nil || chatID >= 0 {
+			reply(ctx, h.responder, user.TelegramID, p.T(escalateUsage))
+			return
+		}
+		// Like /share, only members may have the bot post to a group.
+		status, err := h.responder.GetChatMember(ctx, chatID, user.TelegramID)
+		if err != nil {
+			log.Printf("telegram: escalate get chat member of %d failed: %v", chatID, err)
+			reply(ctx, h.responder, user.TelegramID, p.T("Could not check chat %d. Add the bot to it first.", chatID))
+			return
+		}
+		if status != "creator" && status != "administrator" && status != "member" {
+			reply(ctx, h.responder, user.TelegramID, p.T("Only members of chat %d can send escalations there.", chatID))
+			return
+		}
+		step.ChatID = chatID
+	}
+	if err := step.Validate(); err != nil {
+		reply(ctx, h.responder, user.TelegramID, p.T("Invalid escalation step: %v", err))
+		return
+	}
+
+	rem.Escalations = append(rem.Escalations, step)
+	sort.SliceStable(rem.Escalations, func(i, j int) bool { return rem.Escalations[i].After < rem.Escalations[j].After })
+	if !h.save(ctx, p, user, rem) {
+		return
+	}
+	if !step.Pending {
+		reply(ctx, h.responder, user.TelegramID, p.T("Added an escalation step to reminder #%d after %d min: %s", rem.ID, int(after/time.Minute), h.target(ctx, p, step)))
+		return
+	}
+
+	op := userPrinter(other)
+	text := op.T("%s asks you to be told when their reminder “%s” stays unanswered for %d min.", userName(user), rem.Name, int(after/time.Minute))
+	markup := map[string]any{
+		"inline_keyboard": [][]map[string]any{{
+			{"text": op.T("✅ Accept"), "callback_data": BuildEscalateCallback(rem.ID, AssignAccept)},
+			{"text": op.T("❌ Decline"), "callback_data": BuildEscalateCallback(rem.ID, AssignDecline)},
+		}},
+	}
+	if h.responder != nil {
+		if err := h.responder.SendMessageWithMarkup(ctx, other.TelegramID, text, markup); err != nil {
+			log.Printf("telegram: failed to send escalation request: %v", err)
+			reply(ctx, h.responder, user.TelegramID, p.T("Could not send the request to %s, please try again.", userName(other)))
+			return
+		}
+	}
+	reply(ctx, h.responder, user.TelegramID, p.T("Asked %s to accept the escalation step of reminder #%d. It is skipped until they do.", userName(other), rem.ID))
+}
+
+// HandleCallback handles the Accept/Decline buttons of a user asked to be an
+// escalation step. Accepting enables all of the reminder's steps for them,
+// declining removes them.
+func (h *EscalateHandler) HandleCallback(ctx context.Context, cb *CallbackQuery) error {
+	user := UserFrom(ctx)
+	if cb == nil || user == nil {
+		return nil
+	}
+
+	p := userPrinter(user)
+	answer, id, err := ParseEscalateCallback(cb.Data)
+	if err != nil || (answer != AssignAccept && answer != AssignDecline) {
+		log.Printf("telegram: bad callback data %q: %v", cb.Data, err)
+		rejectCallback(ctx, h.responder, cb, p.T("This button is not valid."))
+		return nil
+	}
+	rem, err := h.reminders.GetByID(ctx, id)
+	if err != nil {
+		log.Printf("telegram: get reminder %d failed: %v", id, err)
+		failCallback(ctx, h.responder, cb, p.T("Failed to load the reminder, please try again."))
+		return nil
+	}
+	// The owner may have cleared the steps since.
+	if rem == nil || !pending(rem, user.TelegramID) {
+		rejectCallback(ctx, h.responder, cb, p.T("This request is no longer valid."))
+		h.edit(ctx, cb, p.T("This request is no longer valid."))
+		return nil
+	}
+
+	steps := make([]domain.EscalationStep, 0, len(rem.Escalations))
+	for _, step := range rem.Escalations {
+		if step.Pending && step.ChatID == user.TelegramID {
+			if answer == AssignDecline {
+				continue
+			}
+			step.Pending = false
+		}
+		steps = append(steps, step)
+	}
+	rem.Escalations = steps
+	if err := h.reminders.Update(ctx, rem); err != nil {
+		log.Printf("telegram: escalate answer update reminder failed: %v", err)
+		failCallback(ctx, h.responder, cb, p.T("Failed to save, please try again."))
+		return nil
+	}
+
+	answerCallback(ctx, h.responder, cb, "")
+	if answer == AssignAccept {
+		h.edit(ctx, cb, p.T("You will be told when the reminder “%s” stays unanswered.", rem.Name))
+		h.notify(ctx, rem.UserID, func(op *i18n.Printer) string {
+			return op.T("%s accepted the escalation step of reminder #%d “%s”.", userName(user), rem.ID, rem.Name)
+		})
+	} else {
+		h.edit(ctx, cb, p.T("You declined the escalation step of the reminder “%s”.", rem.Name))
+		h.notify(ctx, rem.UserID, func(op *i18n.Printer) string {
+			return op.T("%s declined the escalation step of reminder #%d “%s”; it was removed.", userName(user), rem.ID, rem.Name)
+		})
+	}
+	return nil
+}
+
+// edit replaces the escalation request with text and removes its buttons.
+func (h *EscalateHandler) edit(ctx context.Context, cb *CallbackQuery, text string) {
+	if h.responder == nil || cb.Message == nil {
+		return
+	}
+	if err := h.responder.EditMessageText(ctx, cb.Message.Chat.ID, cb.Message.MessageID, text, BuildFinalMarkup()); err != nil {
+		log.Printf("telegram: failed to edit escalation request: %v", err)
+	}
+}
+
+// notify sends another user a message built in their language.
+func (h *EscalateHandler) notify(ctx context.Context, userID int64, text func(*i18n.Printer) string) {
+	other, err := h.users.GetByID(ctx, userID)
+	if err != nil || other == nil {
+		log.Printf("telegram: escalate notify user %d failed: %v", userID, err)
+		return
+	}
+	reply(ctx, h.responder, other.TelegramID, text(userPrinter(other)))
+}
+
+// accepted reports whether the user with the given Telegram ID already
+// agreed to be a step of rem.
+func accepted(rem *domain.Reminder, telegramID int64) bool {
+	for _, step := range rem.Escalations {
+		if step.ChatID == telegramID && !step.Pending {
+			return true
+		}
+	}
+	return false
+}
+
+// pending reports whether rem has steps waiting for the user with the given
+// Telegram ID to accept them.
+func pending(rem *domain.Reminder, telegramID int64) bool {
+	for _, step := range rem.Escalations {
+		if step.ChatID == telegramID && step.Pending {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *EscalateHandler) save(ctx context.Context, p *i18n.Printer, user *domain.User, rem *domain.Reminder) bool {
+	if err := h.reminders.Update(ctx, rem); err != nil {
+		log.Printf("telegram: escalate update reminder failed: %v", err)
+		reply(ctx, h.responder, user.TelegramID, p.T("Failed to save the escalation steps"))
+		return false
+	}
+	return true
+}
+
+// target describes where a step goes.
+func (h *EscalateHandler) target(ctx context.Context, p *i18n.Printer, step domain.EscalationStep) string {
+	if step.URL != "" {
+		return p.T("webhook %s", step.URL)
+	}
+	if step.ChatID < 0 {
+		return p.T("chat %d", step.ChatID)
+	}
+	u, err := h.users.GetByTelegramID(ctx, step.ChatID)
+	if err != nil || u == nil {
+		return p.T("user %d", step.ChatID)
+	}
+	return userName(u)
+}
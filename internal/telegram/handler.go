@@ -2,46 +2,86 @@ package telegram
 
 import (
 	"context"
+	"errors"
 	"log"
+	"strings"
+	"time"
 
 	"naggingbot/internal/domain"
+	"naggingbot/internal/i18n"
 )
 
-// StartHandler handles /start messages: upserts the user, seeds a demo reminder, and demo occurrences.
+const startHelp = "You are registered.\n\nCommands:\n" +
+	"/reminder <name>_<description>_<DD.MM.YYYY>_<DD.MM.YYYY>_<HH:MM;HH:MM>_<IANA timezone> - create reminder\n" +
+	"/list - list latest reminders (up to 20)\n" +
+	"/delete <id> - delete reminder and occurrences\n" +
+	"/stats [id] [7d|4w|all] - adherence statistics\n" +
+	"/history <id> - past occurrences, mark missed ones\n" +
+	"/today - today's occurrences\n" +
+	"/upcoming [n|Nh] - next occurrences\n" +
+	"/timezone [IANA timezone] - show or set your time zone\n" +
+	"/language [en|ru|auto] - show or set your language\n" +
+	"/digest - configure morning, evening and weekly digests\n" +
+	"/webhook [<id>] <url> - post notifications to a URL, e.g. Home Assistant\n" +
+	"/email <address> - receive reminders by email too\n" +
+	"/channels [<id>] telegram+email|webhook|... - choose where reminders are sent\n" +
+	"/template <id> - customize the notifications of a reminder\n" +
+	"/priority <id> low|normal|high - set the priority of a reminder\n" +
+	"/share <id> [anyone|everyone] - post a reminder to a group or channel\n" +
+	"/assign <id> @username [minutes] - let someone else receive a reminder, alert you if they miss it\n" +
+	"/escalate <id> <minutes> @user|<chat id>|<url> - notify someone else or call a webhook if a reminder stays unanswered\n" +
+	"/export ics - download reminders as a calendar file\n" +
+	"/export json - download a full backup with settings and history\n" +
+	"/import - upload an .ics file or a .json backup\n\n" +
+	"Example:\n/reminder Pill_VitC_19.01.2026_20.01.2026_08:00;13:00;19:00_Europe/Warsaw"
+
+// StartHandler handles /start [invite code]: registers the user if the
+// registration policy admits them and replies with the command list.
+// It must be registered with Dispatcher.RegisterPublicCommand.
 type StartHandler struct {
-	users       domain.UserStore
-	responder   Responder
+	uow       domain.UnitOfWork
+	auth      *Authorizer
+	responder Responder
 }
 
-// NewStartHandler constructs a StartHandler with required stores.
-func NewStartHandler(users domain.UserStore, responder Responder) *StartHandler {
+// NewStartHandler constructs a StartHandler. Registrations run in uow, so an
+// invite is only used up by a user who ends up registered. A nil authorizer
+// registers everyone.
+func NewStartHandler(uow domain.UnitOfWork, auth *Authorizer, responder Responder) *StartHandler {
 	return &StartHandler{
-		users:     users,
+		uow:       uow,
+		auth:      auth,
 		responder: responder,
 	}
 }
 
-// HandleCommand processes the /start command.
+// errInviteUnusable aborts a registration whose invite code cannot be redeemed.
+var errInviteUnusable = errors.New("invite unusable")
+
+// HandleCommand processes the /start command. The LoadUser middleware creates
+// the record of members; other senders are stored once register admits them.
 func (h *StartHandler) HandleCommand(ctx context.Context, msg *Message) error {
-	user := msg.From
-	if user == nil {
-		log.Printf("telegram: /start received but user is nil (chat_id=%d)", msg.Chat.ID)
+	user, domainUser := msg.From, UserFrom(ctx)
+	if user == nil || domainUser == nil {
+		log.Printf("telegram: /start received without a user (chat_id=%d)", msg.Chat.ID)
 		return nil
 	}
 
-	if _, err := h.ensureUser(ctx, user); err != nil {
-		log.Printf("telegram: failed to upsert user %d: %v", user.ID, err)
-		return nil
+	p := userPrinter(domainUser)
+	if !domainUser.Registered {
+		// Deep links (t.me/<bot>?start=<code>) arrive as "/start <code>".
+		var code string
+		if parts := strings.Fields(msg.Text); len(parts) > 1 {
+			code = parts[1]
+		}
+		if refusal := h.register(ctx, p, user, domainUser, code); refusal != "" {
+			reply(ctx, h.responder, user.ID, refusal)
+			return nil
+		}
 	}
 
 	if h.responder != nil {
-		msg := "You are registered.\n\nCommands:\n" +
-			"/reminder <name>_<description>_<DD.MM.YYYY>_<DD.MM.YYYY>_<HH:MM;HH:MM>_<IANA timezone> - create reminder\n" +
-			"/list - list latest reminders (up to 20)\n" +
-			"/delete <id> - delete reminder and occurrences\n" +
-			"/test - create demo reminder (restricted)\n\n" +
-			"Example:\n/reminder Pill_VitC_19.01.2026_20.01.2026_08:00;13:00;19:00_Europe/Warsaw"
-		if err := h.responder.SendMessage(ctx, user.ID, msg); err != nil {
+		if err := h.responder.SendMessage(ctx, user.ID, p.T(startHelp)); err != nil {
 			log.Printf("telegram: failed to send start ack: %v", err)
 		}
 	}
@@ -51,16 +91,56 @@ func (h *StartHandler) HandleCommand(ctx context.Context, msg *Message) error {
 	return nil
 }
 
-func (h *StartHandler) ensureUser(ctx context.Context, u *User) (*domain.User, error) {
-	domainUser := &domain.User{
-		TelegramID: u.ID,
-		Username:   u.Username,
-		FirstName:  u.FirstName,
-		LastName:   u.LastName,
-		Language:   u.LanguageCode,
+// register applies the registration policy and marks the user registered. It
+// returns the reason shown to a user who is not admitted, or "" on success.
+func (h *StartHandler) register(ctx context.Context, p *i18n.Printer, from *User, user *domain.User, code string) string {
+	var invite string
+	if h.auth != nil && !h.auth.IsAdmin(from.ID) {
+		switch h.auth.Policy().Mode {
+		case RegistrationAllowlist:
+			if !h.auth.Policy().Allowlisted(from) {
+				return p.T("This bot is private. Ask its owner to add you.")
+			}
+		case RegistrationInvite:
+			if code == "" {
+				return p.T("This bot is invite-only. Open your invite link or send /start <code>.")
+			}
+			invite = strings.ToUpper(code)
+		}
+	}
+
+	var redeemErr error
+	err := h.uow.Do(ctx, func(ctx context.Context, tx domain.Stores) error {
+		if invite != "" {
+			ok, err := tx.Invites.Redeem(ctx, invite, time.Now().UTC())
+			if err != nil {
+				redeemErr = err
+				return err
+			}
+			if !ok {
+				return errInviteUnusable
+			}
+		}
+		if user.ID == 0 {
+			if err := tx.Users.Upsert(ctx, user); err != nil {
+				return err
+			}
+		}
+		return tx.Users.SetRegistered(ctx, user.ID, true)
+	})
+	switch {
+	case errors.Is(err, errInviteUnusable):
+		return p.T("This invite code is invalid, expired or already used.")
+	case redeemErr != nil:
+		log.Printf("telegram: redeem invite for user %d failed: %v", from.ID, redeemErr)
+		return p.T("Failed to check the invite code, please try again.")
+	case err != nil:
+		log.Printf("telegram: failed to register user %d: %v", from.ID, err)
+		return p.T("Failed to register, please try again.")
 	}
-	if err := h.users.Upsert(ctx, domainUser); err != nil {
-		return nil, err
+	if invite != "" {
+		log.Printf("telegram: user %d registered with invite %s", from.ID, invite)
 	}
-	return domainUser, nil
+	user.Registered = true
+	return ""
 }
@@ -0,0 +1,244 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"naggingbot/internal/domain"
+	"naggingbot/internal/i18n"
+)
+
+const historyPageSize = 8
+
+// HistoryHandler handles /history <reminder_id>: a paginated list of past
+// occurrences with buttons to retroactively mark missed ones done or ignored.
+type HistoryHandler struct {
+	reminders   domain.ReminderStore
+	occurrences domain.OccurrenceStore
+	responder   Responder
+}
+
+func NewHistoryHandler(reminders domain.ReminderStore, occurrences domain.OccurrenceStore, responder Responder) *HistoryHandler {
+	return &HistoryHandler{
+		reminders:   reminders,
+		occurrences: occurrences,
+		responder:   responder,
+	}
+}
+
+func (h *HistoryHandler) HandleCommand(ctx context.Context, msg *Message) error {
+	user := UserFrom(ctx)
+	if user == nil {
+		return nil
+	}
+
+	p := userPrinter(user)
+	parts := strings.Fields(msg.Text)
+	if len(parts) != 2 {
+		reply(ctx, h.responder, user.TelegramID, p.T("Usage: /history <reminder_id>"))
+		return nil
+	}
+	id, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		reply(ctx, h.responder, user.TelegramID, p.T("Invalid id"))
+		return nil
+	}
+
+	rem, err := h.ownedReminder(ctx, user, id)
+	if err != nil {
+		log.Printf("telegram: history load reminder failed: %v", err)
+		reply(ctx, h.responder, user.TelegramID, p.T("Failed to load history"))
+		return nil
+	}
+	if rem == nil {
+		reply(ctx, h.responder, user.TelegramID, p.T("Reminder not found"))
+		return nil
+	}
+
+	text, markup, err := h.render(ctx, p, rem, 0)
+	if err != nil {
+		log.Printf("telegram: history render failed: %v", err)
+		reply(ctx, h.responder, user.TelegramID, p.T("Failed to load history"))
+		return nil
+	}
+	if h.responder != nil {
+		if err := h.responder.SendMessageWithMarkup(ctx, user.TelegramID, text, markup); err != nil {
+			log.Printf("telegram: failed to send history: %v", err)
+		}
+	}
+	return nil
+}
+
+func (h *HistoryHandler) HandleCallback(ctx context.Context, cb *CallbackQuery) error {
+	if cb == nil || cb.From == nil || cb.Message == nil {
+		return nil
+	}
+
+	p := printerFrom(ctx)
+	action, id, page, err := ParseHistoryCallback(cb.Data)
+	if err != nil {
+		log.Printf("telegram: bad history callback %q: %v", cb.Data, err)
+		rejectCallback(ctx, h.responder, cb, p.T("This button is not valid."))
+		return nil
+	}
+
+	reminderID := id
+	var occ *domain.Occurrence
+	if action != HistoryActionPage {
+		occ, err = h.occurrences.GetByID(ctx, id)
+		if err != nil {
+			log.Printf("telegram: history get occurrence %d failed: %v", id, err)
+			return nil
+		}
+		if occ == nil {
+			rejectCallback(ctx, h.responder, cb, p.T("This reminder no longer exists."))
+			return nil
+		}
+		reminderID = occ.ReminderID
+	}
+
+	rem, err := h.ownedReminder(ctx, UserFrom(ctx), reminderID)
+	if err != nil || rem == nil {
+		log.Printf("telegram: history reminder %d rejected for user %d: %v", reminderID, cb.From.ID, err)
+		rejectCallback(ctx, h.responder, cb, p.T("This button belongs to someone else."))
+		return nil
+	}
+
+	if occ != nil {
+		status, err := h.mark(ctx, occ, action)
+		var transErr *domain.TransitionError
+		switch {
+		case errors.As(err, &transErr):
+			// Stale view: re-render it so it shows the recorded status.
+			answerCallback(ctx, h.responder, cb, alreadyAnswered(p, transErr.From))
+		case errors.Is(err, errNotDue):
+			rejectCallback(ctx, h.responder, cb, p.T("This occurrence is not due yet."))
+			return nil
+		case err != nil:
+			log.Printf("telegram: history mark occurrence %d failed: %v", occ.ID, err)
+			failCallback(ctx, h.responder, cb, p.T("Failed to save, please try again."))
+			return nil
+		default:
+			answerCallback(ctx, h.responder, cb, AckToast(p, status))
+		}
+	}
+
+	text, markup, err := h.render(ctx, p, rem, page)
+	if err != nil {
+		log.Printf("telegram: history render failed: %v", err)
+		return nil
+	}
+	if h.responder != nil {
+		if err := h.responder.EditMessageText(ctx, cb.Message.Chat.ID, cb.Message.MessageID, text, markup); err != nil {
+			log.Printf("telegram: failed to edit history: %v", err)
+		}
+	}
+	return nil
+}
+
+// errNotDue rejects answering an occurrence from the history before it fired.
+var errNotDue = errors.New("occurrence is not due yet")
+
+// mark retroactively answers a past occurrence and returns the new status.
+// Answered occurrences are rejected with a *domain.TransitionError.
+func (h *HistoryHandler) mark(ctx context.Context, occ *domain.Occurrence, action HistoryAction) (domain.OccurrenceStatus, error) {
+	var status domain.OccurrenceStatus
+	switch action {
+	case HistoryActionDone:
+		status = domain.OccurrenceDone
+	case HistoryActionIgnore:
+		status = domain.OccurrenceIgnored
+	default:
+		return 0, fmt.Errorf("unknown action %q", action)
+	}
+
+	now := time.Now().UTC()
+	if occ.FireAtUtc.After(now) {
+		return 0, errNotDue
+	}
+	return status, h.occurrences.MarkAcked(ctx, occ.ID, status, now)
+}
+
+// ownedReminder returns the reminder if it belongs to the user, nil otherwise.
+func (h *HistoryHandler) ownedReminder(ctx context.Context, user *domain.User, reminderID int64) (*domain.Reminder, error) {
+	if user == nil {
+		return nil, nil
+	}
+	rem, err := h.reminders.GetByID(ctx, reminderID)
+	if err != nil || rem == nil {
+		return nil, err
+	}
+	if rem.UserID != user.ID {
+		return nil, nil
+	}
+	return rem, nil
+}
+
+// render builds one page of past occurrences, newest first.
+func (h *HistoryHandler) render(ctx context.Context, p *i18n.Printer, rem *domain.Reminder, page int) (string, map[string]any, error) {
+	occs, err := h.occurrences.ListByReminder(ctx, rem.ID)
+	if err != nil {
+		return "", nil, err
+	}
+
+	now := time.Now().UTC()
+	var past []*domain.Occurrence
+	for i := len(occs) - 1; i >= 0; i-- {
+		if !occs[i].FireAtUtc.After(now) {
+			past = append(past, occs[i])
+		}
+	}
+
+	pages := (len(past) + historyPageSize - 1) / historyPageSize
+	if pages == 0 {
+		pages = 1
+	}
+	if page < 0 {
+		page = 0
+	}
+	if page >= pages {
+		page = pages - 1
+	}
+
+	loc := rem.Location()
+
+	var b strings.Builder
+	b.WriteString(p.T("History of #%d %s (page %d/%d, %s):", rem.ID, rem.Name, page+1, pages, rem.TimeZone) + "\n")
+	if len(past) == 0 {
+		b.WriteString("\n" + p.T("No past occurrences yet."))
+	}
+
+	var rows [][]map[string]any
+	start := page * historyPageSize
+	end := min(start+historyPageSize, len(past))
+	for _, occ := range past[start:end] {
+		fmt.Fprintf(&b, "\n#%d %s %s", occ.ID, p.ShortDateTime(occ.FireAtUtc.In(loc)), StatusLabel(p, occ, now))
+		if !isAnswered(occ) {
+			rows = append(rows, []map[string]any{
+				{"text": fmt.Sprintf("✅ #%d", occ.ID), "callback_data": BuildHistoryCallback(HistoryActionDone, occ.ID, page)},
+				{"text": fmt.Sprintf("🚫 #%d", occ.ID), "callback_data": BuildHistoryCallback(HistoryActionIgnore, occ.ID, page)},
+			})
+		}
+	}
+
+	var nav []map[string]any
+	if page > 0 {
+		nav = append(nav, map[string]any{"text": p.T("◀ Prev"), "callback_data": BuildHistoryCallback(HistoryActionPage, rem.ID, page-1)})
+	}
+	if page < pages-1 {
+		nav = append(nav, map[string]any{"text": p.T("Next ▶"), "callback_data": BuildHistoryCallback(HistoryActionPage, rem.ID, page+1)})
+	}
+	if len(nav) > 0 {
+		rows = append(rows, nav)
+	}
+	if rows == nil {
+		rows = [][]map[string]any{}
+	}
+
+	return b.String(), map[string]any{"inline_keyboard": rows}, nil
+}
@@ -0,0 +1,69 @@
+package telegram
+
+import (
+	"context"
+	"log"
+	"strings"
+
+	"naggingbot/internal/domain"
+	"naggingbot/internal/i18n"
+)
+
+// LanguageHandler handles /language [code|auto] to show or pick the language
+// of the bot's replies. "auto" follows the language of the Telegram app.
+type LanguageHandler struct {
+	users     domain.UserStore
+	responder Responder
+}
+
+func NewLanguageHandler(users domain.UserStore, responder Responder) *LanguageHandler {
+	return &LanguageHandler{users: users, responder: responder}
+}
+
+func (h *LanguageHandler) HandleCommand(ctx context.Context, msg *Message) error {
+	user := UserFrom(ctx)
+	if user == nil {
+		return nil
+	}
+
+	p := userPrinter(user)
+	parts := strings.Fields(msg.Text)
+	if len(parts) < 2 {
+		source := p.T("from your Telegram app")
+		if user.Locale != "" {
+			source = p.T("set explicitly")
+		}
+		reply(ctx, h.responder, user.TelegramID, p.T("Language: %s (%s)\nUsage: /language <%s|auto>", p.Locale().Name(), source, localeCodes()))
+		return nil
+	}
+
+	var locale string
+	if !strings.EqualFold(parts[1], "auto") {
+		l, ok := i18n.Parse(parts[1])
+		if !ok {
+			reply(ctx, h.responder, user.TelegramID, p.T("Unsupported language. Use /language <%s|auto>", localeCodes()))
+			return nil
+		}
+		locale = string(l)
+	}
+	if err := h.users.SetLocale(ctx, user.ID, locale); err != nil {
+		log.Printf("telegram: set locale failed: %v", err)
+		reply(ctx, h.responder, user.TelegramID, p.T("Failed to save language"))
+		return nil
+	}
+
+	user.Locale = locale
+	p = userPrinter(user)
+	reply(ctx, h.responder, user.TelegramID, p.T("Language set to %s.", p.Locale().Name()))
+	return nil
+}
+
+// localeCodes lists the supported language codes, e.g. "en|ru".
+func localeCodes() string {
+	locales := i18n.Locales()
+	codes := make([]string, len(locales))
+	for i, l := range locales {
+		codes[i] = string(l)
+	}
+	return strings.Join(codes, "|")
+}
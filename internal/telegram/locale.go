@@ -0,0 +1,36 @@
+package telegram
+
+import (
+	"context"
+
+	"naggingbot/internal/domain"
+	"naggingbot/internal/i18n"
+)
+
+// userPrinter returns the printer for the user's language: the one picked
+// with /language, else the one reported by Telegram.
+func userPrinter(user *domain.User) *i18n.Printer {
+	if user == nil {
+		return i18n.For(i18n.Default)
+	}
+	return i18n.For(i18n.Match(user.Locale, user.Language))
+}
+
+// printerFrom returns the printer for the sender of the update being handled.
+func printerFrom(ctx context.Context) *i18n.Printer {
+	return userPrinter(UserFrom(ctx))
+}
+
+// senderPrinter returns the printer for a Telegram user before their record
+// is loaded, going by the language Telegram reports.
+func senderPrinter(from *User) *i18n.Printer {
+	if from == nil {
+		return i18n.For(i18n.Default)
+	}
+	return i18n.For(i18n.Match(from.LanguageCode))
+}
+
+// limitMessage translates a limit error for the user.
+func limitMessage(p *i18n.Printer, e *domain.LimitError) string {
+	return p.T(e.Format, e.Args...)
+}
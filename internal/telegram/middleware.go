@@ -0,0 +1,347 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"naggingbot/internal/domain"
+)
+
+// Middleware wraps the handler of a routed update, e.g. to recover panics or
+// check access. See Dispatcher.Use.
+type Middleware func(next Handler) Handler
+
+// HandlerFunc adapts a function to the Handler interface.
+type HandlerFunc func(ctx context.Context, update Update) error
+
+func (f HandlerFunc) HandleUpdate(ctx context.Context, update Update) error {
+	return f(ctx, update)
+}
+
+// Route describes the handler an update was routed to.
+type Route struct {
+	// Name is the command (e.g. "/list"), the callback namespace (e.g. "occ")
+	// or "document" for uploaded files.
+	Name   string
+	Access Access
+}
+
+type ctxKey int
+
+const (
+	routeKey ctxKey = iota
+	userKey
+	authorizedKey
+	memberKey
+	answeredKey
+)
+
+// RouteFrom returns the route of the update being handled.
+func RouteFrom(ctx context.Context) Route {
+	r, _ := ctx.Value(routeKey).(Route)
+	return r
+}
+
+// UserFrom returns the sender of the update being handled, as loaded by the
+// LoadUser middleware, or nil.
+func UserFrom(ctx context.Context) *domain.User {
+	u, _ := ctx.Value(userKey).(*domain.User)
+	return u
+}
+
+// withUser returns a context carrying the user for UserFrom.
+func withUser(ctx context.Context, user *domain.User) context.Context {
+	return context.WithValue(ctx, userKey, user)
+}
+
+func authorized(ctx context.Context) bool {
+	ok, _ := ctx.Value(authorizedKey).(bool)
+	return ok
+}
+
+// member reports whether Authorize admitted the sender as a member rather
+// than only to a public route.
+func member(ctx context.Context) bool {
+	ok, _ := ctx.Value(memberKey).(bool)
+	return ok
+}
+
+// sender returns the Telegram user who sent the update, or nil.
+func sender(update Update) *User {
+	switch {
+	case update.CallbackQuery != nil:
+		return update.CallbackQuery.From
+	case update.Message != nil:
+		return update.Message.From
+	}
+	return nil
+}
+
+func senderID(update Update) int64 {
+	if from := sender(update); from != nil {
+		return from.ID
+	}
+	return 0
+}
+
+// errPanic marks errors returned for a recovered panic.
+var errPanic = errors.New("panic")
+
+// panicError reports a recovered panic with its stack and returns it as an
+// error wrapping errPanic.
+func panicError(r any, format string, args ...any) error {
+	what := fmt.Sprintf(format, args...)
+	log.Printf("telegram: panic %s: %v\n%s", what, r, debug.Stack())
+	return fmt.Errorf("%w %s: %v", errPanic, what, r)
+}
+
+// Recover turns a panic in a handler into a logged error, so one bad update
+// does not take down the bot.
+func Recover() Middleware {
+	return func(next Handler) Handler {
+		return HandlerFunc(func(ctx context.Context, update Update) (err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					err = panicError(r, "handling update %d (%s)", update.UpdateID, RouteFrom(ctx).Name)
+				}
+			}()
+			return next.HandleUpdate(ctx, update)
+		})
+	}
+}
+
+// Logging logs every handled update with its sender and duration, and the
+// handler's error if any.
+func Logging() Middleware {
+	return func(next Handler) Handler {
+		return HandlerFunc(func(ctx context.Context, update Update) error {
+			start := time.Now()
+			err := next.HandleUpdate(ctx, update)
+			route := RouteFrom(ctx).Name
+			if err != nil {
+				log.Printf("telegram: %s from user %d failed after %s: %v", route, senderID(update), time.Since(start), err)
+			} else {
+				log.Printf("telegram: %s from user %d handled in %s", route, senderID(update), time.Since(start))
+			}
+			return err
+		})
+	}
+}
+
+// Metrics counts handled updates per route. It is safe for concurrent use.
+type Metrics struct {
+	mu     sync.Mutex
+	routes map[string]*RouteStats
+}
+
+// RouteStats aggregates the updates handled for one route.
+type RouteStats struct {
+	Count  int
+	Errors int
+	Total  time.Duration
+}
+
+// Avg returns the mean handling time.
+func (s RouteStats) Avg() time.Duration {
+	if s.Count == 0 {
+		return 0
+	}
+	return s.Total / time.Duration(s.Count)
+}
+
+func NewMetrics() *Metrics {
+	return &Metrics{routes: make(map[string]*RouteStats)}
+}
+
+// Snapshot returns a copy of the stats keyed by route name.
+func (m *Metrics) Snapshot() map[string]RouteStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := make(map[string]RouteStats, len(m.routes))
+	for name, s := range m.routes {
+		out[name] = *s
+	}
+	return out
+}
+
+// String renders the stats one route per line, busiest first.
+func (m *Metrics) String() string {
+	snap := m.Snapshot()
+	names := make([]string, 0, len(snap))
+	for name := range snap {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if snap[names[i]].Count != snap[names[j]].Count {
+			return snap[names[i]].Count > snap[names[j]].Count
+		}
+		return names[i] < names[j]
+	})
+
+	var b strings.Builder
+	for _, name := range names {
+		s := snap[name]
+		fmt.Fprintf(&b, "%s: %d handled, %d failed, avg %s\n", name, s.Count, s.Errors, s.Avg().Round(time.Millisecond))
+	}
+	return b.String()
+}
+
+func (m *Metrics) record(route string, d time.Duration, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.routes[route]
+	if !ok {
+		s = &RouteStats{}
+		m.routes[route] = s
+	}
+	s.Count++
+	s.Total += d
+	if err != nil {
+		s.Errors++
+	}
+}
+
+// Instrument records every handled update in m.
+func Instrument(m *Metrics) Middleware {
+	return func(next Handler) Handler {
+		return HandlerFunc(func(ctx context.Context, update Update) error {
+			start := time.Now()
+			err := next.HandleUpdate(ctx, update)
+			m.record(RouteFrom(ctx).Name, time.Since(start), err)
+			return err
+		})
+	}
+}
+
+// AnswerCallbacks answers every callback query that the rest of the chain did
+// not, so clients stop showing a loading indicator even when an update is
+// dropped, a handler has nothing to say or it panics.
+func AnswerCallbacks(responder Responder) Middleware {
+	return func(next Handler) Handler {
+		return HandlerFunc(func(ctx context.Context, update Update) error {
+			cb := update.CallbackQuery
+			if cb == nil {
+				return next.HandleUpdate(ctx, update)
+			}
+			answered := new(bool)
+			ctx = context.WithValue(ctx, answeredKey, answered)
+			defer answerCallback(ctx, responder, cb, "")
+			return next.HandleUpdate(ctx, update)
+		})
+	}
+}
+
+// VerifyCallbacks drops callback queries whose data was not signed by signer,
+// e.g. forged presses or buttons sent before the key changed, and answers them
+// with a toast. Valid data has its signature stripped before later middlewares
+// and handlers see it.
+func VerifyCallbacks(signer *CallbackSigner, responder Responder) Middleware {
+	return func(next Handler) Handler {
+		return HandlerFunc(func(ctx context.Context, update Update) error {
+			cb := update.CallbackQuery
+			if cb == nil {
+				return next.HandleUpdate(ctx, update)
+			}
+			data, ok := signer.Verify(cb.Data)
+			if !ok {
+				log.Printf("telegram: rejected unsigned callback %q from user %d", cb.Data, senderID(update))
+				rejectCallback(ctx, responder, cb, senderPrinter(cb.From).T("This button has expired. Open the menu again."))
+				return nil
+			}
+			cb.Data = data
+			return next.HandleUpdate(ctx, update)
+		})
+	}
+}
+
+// RateLimit drops updates from users who exceed the limiter. Admins are exempt;
+// auth may be nil.
+func RateLimit(l *RateLimiter, auth *Authorizer) Middleware {
+	return func(next Handler) Handler {
+		return HandlerFunc(func(ctx context.Context, update Update) error {
+			from := sender(update)
+			if from != nil && (auth == nil || !auth.IsAdmin(from.ID)) && !l.Allow(ctx, from) {
+				return nil
+			}
+			return next.HandleUpdate(ctx, update)
+		})
+	}
+}
+
+// LoadUser creates or refreshes the sender's user record and makes it
+// available to later middlewares and handlers via UserFrom. Updates without a
+// sender are passed on without a user.
+//
+// It must run after Authorize: only members get a record. Other senders, who
+// reached a public route such as /start, get their stored user if they have
+// one, else an unsaved user with a zero ID, so that strangers and banned
+// users never create rows.
+func LoadUser(users domain.UserStore, responder Responder) Middleware {
+	return func(next Handler) Handler {
+		return HandlerFunc(func(ctx context.Context, update Update) error {
+			from := sender(update)
+			if from == nil {
+				return next.HandleUpdate(ctx, update)
+			}
+			user := &domain.User{
+				TelegramID: from.ID,
+				Username:   from.Username,
+				FirstName:  from.FirstName,
+				LastName:   from.LastName,
+				Language:   from.LanguageCode,
+			}
+			var err error
+			switch {
+			case member(ctx):
+				err = users.Upsert(ctx, user)
+			case UserFrom(ctx) != nil:
+				user = UserFrom(ctx)
+			default:
+				var stored *domain.User
+				if stored, err = users.GetByTelegramID(ctx, from.ID); stored != nil {
+					user = stored
+				}
+			}
+			if err != nil {
+				if responder != nil {
+					if err := responder.SendMessage(ctx, from.ID, senderPrinter(from).T("Something went wrong, please try again later.")); err != nil {
+						log.Printf("telegram: failed to send error reply: %v", err)
+					}
+				}
+				return fmt.Errorf("load user %d: %w", from.ID, err)
+			}
+			return next.HandleUpdate(withUser(ctx, user), update)
+		})
+	}
+}
+
+// Authorize drops updates the Authorizer rejects for the route's access level.
+// Admin routes are only handled behind this middleware. The stored user it
+// looked up is passed on for LoadUser.
+func Authorize(auth *Authorizer) Middleware {
+	return func(next Handler) Handler {
+		return HandlerFunc(func(ctx context.Context, update Update) error {
+			route := RouteFrom(ctx)
+			from := sender(update)
+			allowed, isMember, user := auth.check(ctx, from, route.Access)
+			if !allowed {
+				if from != nil {
+					log.Printf("telegram: rejected %s from user %d", route.Name, from.ID)
+				}
+				return nil
+			}
+			if user != nil && UserFrom(ctx) == nil {
+				ctx = withUser(ctx, user)
+			}
+			ctx = context.WithValue(ctx, authorizedKey, true)
+			return next.HandleUpdate(context.WithValue(ctx, memberKey, isMember), update)
+		})
+	}
+}
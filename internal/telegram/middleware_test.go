@@ -0,0 +1,201 @@
+package telegram
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"naggingbot/internal/domain"
+	"naggingbot/internal/storage/memory"
+)
+
+// fakeResponder records sent messages. Methods the tests do not use panic.
+type fakeResponder struct {
+	Responder
+	sent []string
+}
+
+func (r *fakeResponder) SendMessage(ctx context.Context, chatID int64, text string) error {
+	r.sent = append(r.sent, text)
+	return nil
+}
+
+// recordingHandler records the users it was called for.
+type recordingHandler struct {
+	users []*domain.User
+}
+
+func (h *recordingHandler) HandleCommand(ctx context.Context, msg *Message) error {
+	h.users = append(h.users, UserFrom(ctx))
+	return nil
+}
+
+type chainFixture struct {
+	dispatcher *Dispatcher
+	users      *memory.InMemoryUserStore
+	invites    *memory.InMemoryInviteStore
+	responder  *fakeResponder
+	list       *recordingHandler
+	admin      *recordingHandler
+}
+
+// newChainFixture wires the middlewares the way cmd/bot does.
+func newChainFixture(t *testing.T, mode RegistrationMode) *chainFixture {
+	t.Helper()
+	f := &chainFixture{
+		dispatcher: NewDispatcher(),
+		users:      memory.NewInMemoryUserStore(),
+		invites:    memory.NewInMemoryInviteStore(),
+		responder:  &fakeResponder{},
+		list:       &recordingHandler{},
+		admin:      &recordingHandler{},
+	}
+	policy, err := NewRegistrationPolicy(mode, []string{"@friend"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth := NewAuthorizer([]int64{1}, f.users, policy, f.responder)
+	uow := memory.NewUnitOfWork(f.users, memory.NewInMemoryReminderStore(), memory.NewInMemoryOccurrenceStore(), f.invites)
+
+	f.dispatcher.Use(Recover(), Authorize(auth), LoadUser(f.users, f.responder))
+	f.dispatcher.RegisterPublicCommand("/start", NewStartHandler(uow, auth, f.responder))
+	f.dispatcher.RegisterCommand("/list", f.list)
+	f.dispatcher.RegisterAdminCommand("/admin", f.admin)
+	return f
+}
+
+func (f *chainFixture) send(t *testing.T, from *User, text string) {
+	t.Helper()
+	msg := &Message{From: from, Chat: Chat{ID: from.ID, Type: "private"}, Text: text}
+	if err := f.dispatcher.Dispatch(context.Background(), Update{Message: msg}); err != nil {
+		t.Fatalf("dispatch %q: %v", text, err)
+	}
+}
+
+func (f *chainFixture) stored(t *testing.T, telegramID int64) *domain.User {
+	t.Helper()
+	user, err := f.users.GetByTelegramID(context.Background(), telegramID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+func TestChainStoresOnlyAdmittedUsers(t *testing.T) {
+	ctx := context.Background()
+	f := newChainFixture(t, RegistrationInvite)
+	stranger := &User{ID: 100, Username: "stranger"}
+
+	f.send(t, stranger, "/list")
+	f.send(t, stranger, "/start")
+	f.send(t, stranger, "/start NOPE")
+	f.send(t, stranger, "/admin")
+	if f.stored(t, stranger.ID) != nil {
+		t.Fatal("a stranger got a user record")
+	}
+	if len(f.list.users) != 0 || len(f.admin.users) != 0 {
+		t.Fatal("a stranger reached a member or admin command")
+	}
+	if got := strings.Join(f.responder.sent, "\n"); !strings.Contains(got, "invite code is invalid") {
+		t.Fatalf("replies = %q, want the invalid invite refusal", got)
+	}
+
+	// Banned users are dropped before their record is refreshed.
+	banned := &domain.User{TelegramID: 200, Username: "old"}
+	if err := f.users.Upsert(ctx, banned); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.users.SetBanned(ctx, banned.ID, true); err != nil {
+		t.Fatal(err)
+	}
+	f.send(t, &User{ID: 200, Username: "new"}, "/start")
+	if got := f.stored(t, 200); got.Username != "old" || got.Registered {
+		t.Fatalf("banned user = %+v, want it untouched", got)
+	}
+
+	// A valid invite creates the user and registers it in one go.
+	if err := f.invites.Create(ctx, &domain.Invite{Code: "WELCOME", CreatedAtUtc: time.Now().UTC()}); err != nil {
+		t.Fatal(err)
+	}
+	f.send(t, stranger, "/start welcome")
+	user := f.stored(t, stranger.ID)
+	if user == nil || !user.Registered || user.Username != "stranger" {
+		t.Fatalf("invited user = %+v, want a registered record", user)
+	}
+	f.send(t, stranger, "/list")
+	if len(f.list.users) != 1 || f.list.users[0].ID != user.ID {
+		t.Fatalf("/list saw %+v, want the stored user", f.list.users)
+	}
+}
+
+func TestChainCreatesMembersOnFirstUpdate(t *testing.T) {
+	f := newChainFixture(t, RegistrationAllowlist)
+
+	f.send(t, &User{ID: 100, Username: "stranger"}, "/start")
+	if f.stored(t, 100) != nil {
+		t.Fatal("a user who is not allowlisted got a record")
+	}
+
+	f.send(t, &User{ID: 101, Username: "friend"}, "/list")
+	if user := f.stored(t, 101); user == nil || len(f.list.users) != 1 || f.list.users[0].ID != user.ID {
+		t.Fatalf("allowlisted user = %+v, handler saw %+v", user, f.list.users)
+	}
+
+	f.send(t, &User{ID: 1}, "/admin")
+	if f.stored(t, 1) == nil || len(f.admin.users) != 1 {
+		t.Fatal("the admin was not loaded for /admin")
+	}
+}
+
+func TestMiddlewareOrder(t *testing.T) {
+	var calls []string
+	trace := func(name string) Middleware {
+		return func(next Handler) Handler {
+			return HandlerFunc(func(ctx context.Context, update Update) error {
+				calls = append(calls, name)
+				return next.HandleUpdate(ctx, update)
+			})
+		}
+	}
+	d := NewDispatcher()
+	d.Use(trace("a"), trace("b"))
+	d.Use(trace("c"))
+	d.RegisterCommand("/list", &recordingHandler{})
+
+	msg := &Message{From: &User{ID: 1}, Text: "/list"}
+	if err := d.Dispatch(context.Background(), Update{Message: msg}); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(calls, ","); got != "a,b,c" {
+		t.Fatalf("middlewares ran as %s, want a,b,c", got)
+	}
+}
+
+func TestLoadUserWithoutAuthorizeCreatesNoRecord(t *testing.T) {
+	users := memory.NewInMemoryUserStore()
+	list := &recordingHandler{}
+	d := NewDispatcher()
+	d.Use(LoadUser(users, nil))
+	d.RegisterCommand("/list", list)
+	d.RegisterAdminCommand("/admin", list)
+
+	msg := &Message{From: &User{ID: 100}, Text: "/list"}
+	if err := d.Dispatch(context.Background(), Update{Message: msg}); err != nil {
+		t.Fatal(err)
+	}
+	if user, _ := users.GetByTelegramID(context.Background(), 100); user != nil {
+		t.Fatal("LoadUser stored a sender Authorize did not admit")
+	}
+	if len(list.users) != 1 || list.users[0].ID != 0 || list.users[0].TelegramID != 100 {
+		t.Fatalf("handler saw %+v, want an unsaved user", list.users)
+	}
+
+	msg.Text = "/admin"
+	if err := d.Dispatch(context.Background(), Update{Message: msg}); err != nil {
+		t.Fatal(err)
+	}
+	if len(list.users) != 1 {
+		t.Fatal("an admin command ran without Authorize")
+	}
+}
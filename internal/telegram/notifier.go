@@ -5,25 +5,34 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"html"
+	"log"
 	"net/http"
 	"time"
 
 	"naggingbot/internal/domain"
+	"naggingbot/internal/render"
 	"naggingbot/internal/scheduler"
 )
 
 // Notifier sends messages to Telegram chats.
 type Notifier struct {
-	token     string
-	users     domain.UserStore
-	httpClient *http.Client
+	token       string
+	signer      *CallbackSigner
+	users       domain.UserStore
+	occurrences domain.OccurrenceStore
+	httpClient  *http.Client
 }
 
-// NewNotifier constructs a Telegram notifier.
-func NewNotifier(token string, users domain.UserStore) *Notifier {
+// NewNotifier constructs a Telegram notifier. Delivered message IDs are
+// recorded on the occurrence so the message can be edited later; button data
+// is signed with signer, if set.
+func NewNotifier(token string, signer *CallbackSigner, users domain.UserStore, occurrences domain.OccurrenceStore) *Notifier {
 	return &Notifier{
-		token: token,
-		users: users,
+		token:       token,
+		signer:      signer,
+		users:       users,
+		occurrences: occurrences,
 		httpClient: &http.Client{
 			Timeout: 10 * time.Second,
 		},
@@ -35,45 +44,162 @@ func (n *Notifier) Send(ctx context.Context, occ scheduler.OccurrenceWithReminde
 		return fmt.Errorf("telegram notifier: missing reminder for occurrence %d", occ.Occurrence.ID)
 	}
 
-	user, err := n.users.GetByID(ctx, occ.Reminder.UserID)
+	// Assigned reminders go to their recipient, in the recipient's language.
+	userID := occ.Reminder.Recipient()
+	user, err := n.users.GetByID(ctx, userID)
 	if err != nil {
-		return fmt.Errorf("telegram notifier: get user %d: %w", occ.Reminder.UserID, err)
+		return fmt.Errorf("telegram notifier: get user %d: %w", userID, err)
 	}
 	if user == nil || user.TelegramID == 0 {
+		return fmt.Errorf("telegram notifier: no telegram id for user %d", userID)
+	}
+
+	p := userPrinter(user)
+	text := render.NotificationHTML(p, occ.Reminder, occ.Occurrence)
+
+	// Inline keyboard with Done / Ignore. Shared reminders go to their group
+	// or channel, in the owner's language, where anyone may press the buttons.
+	chatID, markup := user.TelegramID, BuildInitialMarkup(p, occ.Occurrence.ID)
+	if occ.Reminder.Shared() {
+		chatID = occ.Reminder.ChatID
+		markup = BuildSharedMarkup(p, occ.Occurrence.ID, occ.Reminder.Confirm, 0, 0)
+	}
+
+	msg, err := n.sendMessage(ctx, map[string]any{
+		"chat_id":      chatID,
+		"text":         text,
+		"parse_mode":   "HTML",
+		"reply_markup": n.signer.SignMarkup(markup),
+	})
+	if err != nil {
+		return err
+	}
+	if msg == nil {
+		return nil
+	}
+	if err := n.occurrences.SetMessage(ctx, occ.Occurrence.ID, msg.Chat.ID, msg.MessageID); err != nil {
+		log.Printf("telegram notifier: record message for occurrence %d: %v", occ.Occurrence.ID, err)
+	}
+	return nil
+}
+
+// Escalate alerts the owner of an assigned reminder that the recipient has
+// not answered the occurrence. The alert repeats the notification with its
+// buttons, so the owner can answer it after checking in.
+func (n *Notifier) Escalate(ctx context.Context, occ scheduler.OccurrenceWithReminder) error {
+	if occ.Reminder == nil {
+		return fmt.Errorf("telegram notifier: missing reminder for occurrence %d", occ.Occurrence.ID)
+	}
+	owner, err := n.users.GetByID(ctx, occ.Reminder.UserID)
+	if err != nil {
+		return fmt.Errorf("telegram notifier: get user %d: %w", occ.Reminder.UserID, err)
+	}
+	if owner == nil || owner.TelegramID == 0 {
 		return fmt.Errorf("telegram notifier: no telegram id for user %d", occ.Reminder.UserID)
 	}
+	recipient, err := n.users.GetByID(ctx, occ.Reminder.RecipientID)
+	if err != nil {
+		return fmt.Errorf("telegram notifier: get user %d: %w", occ.Reminder.RecipientID, err)
+	}
 
-	text := fmt.Sprintf("Reminder: %s\n%s\nOccurrence #%d at %s",
-		occ.Reminder.Name, occ.Reminder.Description, occ.Occurrence.ID, occ.Occurrence.FireAtUtc.Format(time.RFC3339))
+	p := userPrinter(owner)
+	name := p.T("The recipient")
+	if recipient != nil {
+		name = userName(recipient)
+	}
+	minutes := int(time.Since(occ.Occurrence.SentAtUtc) / time.Minute)
+	text := html.EscapeString(p.T("⚠️ %s has not answered within %d min:", name, minutes)) +
+		"\n\n" + render.NotificationHTML(p, occ.Reminder, occ.Occurrence)
+
+	_, err = n.sendMessage(ctx, map[string]any{
+		"chat_id":      owner.TelegramID,
+		"text":         text,
+		"parse_mode":   "HTML",
+		"reply_markup": n.signer.SignMarkup(BuildInitialMarkup(p, occ.Occurrence.ID)),
+	})
+	return err
+}
 
-	// Inline keyboard with Done / Ignore.
-	replyMarkup := BuildInitialMarkup(occ.Occurrence.ID)
+// SendStep fires a chat step of an escalation chain: it forwards the
+// notification, without buttons, to the step's user or group. Registered
+// users read it in their language, groups in the owner's.
+func (n *Notifier) SendStep(ctx context.Context, occ scheduler.OccurrenceWithReminder, step domain.EscalationStep) error {
+	if occ.Reminder == nil {
+		return fmt.Errorf("telegram notifier: missing reminder for occurrence %d", occ.Occurrence.ID)
+	}
+	owner, err := n.users.GetByID(ctx, occ.Reminder.UserID)
+	if err != nil {
+		return fmt.Errorf("telegram notifier: get user %d: %w", occ.Reminder.UserID, err)
+	}
+	if owner == nil {
+		return fmt.Errorf("telegram notifier: no owner for reminder %d", occ.Reminder.ID)
+	}
+	p := userPrinter(owner)
+	if target, err := n.users.GetByTelegramID(ctx, step.ChatID); err == nil && target != nil {
+		p = userPrinter(target)
+	}
 
-	payload := map[string]any{
-		"chat_id": user.TelegramID,
-		"text":    text,
-		"reply_markup": replyMarkup,
+	minutes := int(step.After / time.Minute)
+	var alert string
+	switch {
+	case occ.Reminder.Shared():
+		alert = p.T("⚠️ Nobody has confirmed within %d min:", minutes)
+	case occ.Reminder.Assigned():
+		recipient, err := n.users.GetByID(ctx, occ.Reminder.RecipientID)
+		if err != nil {
+			return fmt.Errorf("telegram notifier: get user %d: %w", occ.Reminder.RecipientID, err)
+		}
+		name := p.T("The recipient")
+		if recipient != nil {
+			name = userName(recipient)
+		}
+		alert = p.T("⚠️ %s has not answered within %d min:", name, minutes)
+	default:
+		alert = p.T("⚠️ %s has not answered within %d min:", userName(owner), minutes)
 	}
+	text := html.EscapeString(alert) + "\n\n" + render.NotificationHTML(p, occ.Reminder, occ.Occurrence)
+
+	_, err = n.sendMessage(ctx, map[string]any{
+		"chat_id":    step.ChatID,
+		"text":       text,
+		"parse_mode": "HTML",
+	})
+	return err
+}
+
+// sendMessage posts a sendMessage request and returns the delivered message,
+// or nil if the response could not be decoded.
+func (n *Notifier) sendMessage(ctx context.Context, payload map[string]any) (*Message, error) {
 	body, err := json.Marshal(payload)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	url := fmt.Sprintf("https://api.telegram.org/bot%s/sendMessage", n.token)
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
 	if err != nil {
-		return err
+		return nil, err
 	}
 	req.Header.Set("Content-Type", "application/json")
 
 	resp, err := n.httpClient.Do(req)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode >= 300 {
-		return fmt.Errorf("telegram notifier: sendMessage status %s", resp.Status)
+		return nil, fmt.Errorf("telegram notifier: sendMessage status %s", resp.Status)
 	}
-	return nil
+
+	var envelope struct {
+		OK     bool    `json:"ok"`
+		Result Message `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+		// The message was delivered; only the bookkeeping is lost.
+		log.Printf("telegram notifier: decode sendMessage response: %v", err)
+		return nil, nil
+	}
+	return &envelope.Result, nil
 }
@@ -0,0 +1,86 @@
+package telegram
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// RateLimiter is a per-user token bucket: each user may send up to perMinute
+// updates in a burst, refilled evenly over a minute. A user who hits the limit
+// is told once until they are allowed again.
+type RateLimiter struct {
+	mu        sync.Mutex
+	perMinute float64
+	buckets   map[int64]*bucket
+	lastSweep time.Time
+	responder Responder
+	now       func() time.Time
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+	warned bool
+}
+
+// NewRateLimiter allows perMinute updates per user per minute. The responder,
+// if set, is used to tell users they are being limited.
+func NewRateLimiter(perMinute int, responder Responder) *RateLimiter {
+	return &RateLimiter{
+		perMinute: float64(perMinute),
+		buckets:   make(map[int64]*bucket),
+		responder: responder,
+		now:       time.Now,
+	}
+}
+
+// Allow takes a token for the sender and reports whether the update may be
+// handled. The first rejection is explained to the sender in their language.
+func (l *RateLimiter) Allow(ctx context.Context, from *User) bool {
+	userID := from.ID
+	l.mu.Lock()
+	now := l.now()
+	l.sweep(now)
+
+	b, ok := l.buckets[userID]
+	if !ok {
+		b = &bucket{tokens: l.perMinute, last: now}
+		l.buckets[userID] = b
+	}
+	b.tokens = min(l.perMinute, b.tokens+now.Sub(b.last).Minutes()*l.perMinute)
+	b.last = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		b.warned = false
+		l.mu.Unlock()
+		return true
+	}
+	warn := !b.warned
+	b.warned = true
+	l.mu.Unlock()
+
+	log.Printf("telegram: rate limited user %d", userID)
+	if warn && l.responder != nil {
+		if err := l.responder.SendMessage(ctx, userID, senderPrinter(from).T("You are sending commands too quickly. Please wait a minute.")); err != nil {
+			log.Printf("telegram: failed to send rate limit notice: %v", err)
+		}
+	}
+	return false
+}
+
+// sweep drops buckets that have refilled completely, at most once a minute, so
+// the map does not grow with every user ever seen. Callers must hold l.mu.
+func (l *RateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < time.Minute {
+		return
+	}
+	l.lastSweep = now
+	for id, b := range l.buckets {
+		if now.Sub(b.last) >= time.Minute {
+			delete(l.buckets, id)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package telegram
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"strings"
@@ -13,36 +14,35 @@ import (
 // ReminderHandler handles /reminder command to create a reminder for a user.
 // Format: /reminder Name_Description_StartDate_EndDate_HH:MM;HH:MM_TimeZone
 type ReminderHandler struct {
-	users       domain.UserStore
-	reminders   domain.ReminderStore
-	occurrences domain.OccurrenceStore
-	responder   Responder
+	uow       domain.UnitOfWork
+	limits    domain.Limits
+	responder Responder
 }
 
-func NewReminderHandler(users domain.UserStore, reminders domain.ReminderStore, occurrences domain.OccurrenceStore, responder Responder) *ReminderHandler {
+func NewReminderHandler(uow domain.UnitOfWork, limits domain.Limits, responder Responder) *ReminderHandler {
 	return &ReminderHandler{
-		users:       users,
-		reminders:   reminders,
-		occurrences: occurrences,
-		responder:   responder,
+		uow:       uow,
+		limits:    limits,
+		responder: responder,
 	}
 }
 
 func (h *ReminderHandler) HandleCommand(ctx context.Context, msg *Message) error {
-	user := msg.From
+	user := UserFrom(ctx)
 	if user == nil {
 		return nil
 	}
+	p := userPrinter(user)
 	// Format: /reminder Name_Description_StartDate_EndDate_HH:MM;HH:MM_TimeZone
 	parts := strings.SplitN(strings.TrimSpace(msg.Text), " ", 2)
 	if len(parts) < 2 {
-		h.reply(ctx, user.ID, "Usage: /reminder Name_Description_StartDate_EndDate_HH:MM;HH:MM_TimeZone\nExample: /reminder Pill_VitC_19.01.2026_20.01.2026_08:00;13:00;19:00_Europe/Warsaw")
+		reply(ctx, h.responder, user.TelegramID, p.T("Usage: /reminder Name_Description_StartDate_EndDate_HH:MM;HH:MM_TimeZone\nExample: /reminder Pill_VitC_19.01.2026_20.01.2026_08:00;13:00;19:00_Europe/Warsaw"))
 		return nil
 	}
 	payload := parts[1]
 	fields := strings.SplitN(payload, "_", 6)
 	if len(fields) != 6 {
-		h.reply(ctx, user.ID, "Invalid format. Expected: /reminder Name_Description_StartDate_EndDate_HH:MM;HH:MM_TimeZone")
+		reply(ctx, h.responder, user.TelegramID, p.T("Invalid format. Expected: /reminder Name_Description_StartDate_EndDate_HH:MM;HH:MM_TimeZone"))
 		return nil
 	}
 
@@ -55,38 +55,24 @@ func (h *ReminderHandler) HandleCommand(ctx context.Context, msg *Message) error
 
 	tod, err := parseTimesOfDay(timesStr)
 	if err != nil {
-		h.reply(ctx, user.ID, "Invalid times. Use HH:MM;HH:MM")
+		reply(ctx, h.responder, user.TelegramID, p.T("Invalid times. Use HH:MM;HH:MM"))
 		return nil
 	}
 
 	loc, err := time.LoadLocation(timezone)
 	if err != nil {
-		h.reply(ctx, user.ID, "Invalid timezone. Use IANA, e.g., Europe/Moscow")
-		return nil
-	}
-
-	// Ensure user exists.
-	domainUser := &domain.User{
-		TelegramID: user.ID,
-		Username:   user.Username,
-		FirstName:  user.FirstName,
-		LastName:   user.LastName,
-		Language:   user.LanguageCode,
-	}
-	if err := h.users.Upsert(ctx, domainUser); err != nil {
-		log.Printf("telegram: reminder upsert user failed: %v", err)
-		h.reply(ctx, user.ID, "Failed to save user")
+		reply(ctx, h.responder, user.TelegramID, p.T("Invalid timezone. Use IANA, e.g., Europe/Moscow"))
 		return nil
 	}
 
 	start, end, err := parseDateRange(startDateStr, endDateStr, timezone)
 	if err != nil {
-		h.reply(ctx, user.ID, "Invalid date range. Use DD.MM.YYYY_DD.MM.YYYY (inclusive)")
+		reply(ctx, h.responder, user.TelegramID, p.T("Invalid date range. Use DD.MM.YYYY_DD.MM.YYYY (inclusive)"))
 		return nil
 	}
 
 	rem := &domain.Reminder{
-		UserID:      domainUser.ID,
+		UserID:      user.ID,
 		Name:        name,
 		Description: description,
 		StartDate:   start,
@@ -95,32 +81,44 @@ func (h *ReminderHandler) HandleCommand(ctx context.Context, msg *Message) error
 		TimeZone:    timezone,
 		IsActive:    true,
 	}
-	if err := h.reminders.Create(ctx, rem); err != nil {
-		log.Printf("telegram: create reminder failed: %v", err)
-		h.reply(ctx, user.ID, "Failed to create reminder")
+	// Check the limits and create the reminder and its occurrences atomically,
+	// so concurrent requests cannot exceed a quota and a failure never leaves
+	// a reminder without its schedule.
+	err = h.uow.Do(ctx, func(ctx context.Context, tx domain.Stores) error {
+		existing, err := tx.Reminders.ListByUser(ctx, user.ID)
+		if err != nil {
+			return fmt.Errorf("list reminders: %w", err)
+		}
+		if err := h.limits.CheckReminder(rem, existing, time.Now().UTC()); err != nil {
+			return err
+		}
+		if err := tx.Reminders.Create(ctx, rem); err != nil {
+			return fmt.Errorf("create reminder: %w", err)
+		}
+		occs, err := buildOccurrences(rem, loc)
+		if err != nil {
+			return err
+		}
+		if err := tx.Occurrences.CreateBatch(ctx, occs); err != nil {
+			return fmt.Errorf("create occurrences: %w", err)
+		}
+		return nil
+	})
+	var limitErr *domain.LimitError
+	if errors.As(err, &limitErr) {
+		reply(ctx, h.responder, user.TelegramID, limitMessage(p, limitErr))
 		return nil
 	}
-
-	// Create occurrences for the date range based on times of day and timezone.
-	if err := h.seedOccurrences(ctx, rem, loc); err != nil {
-		log.Printf("telegram: create occurrences failed: %v", err)
-		h.reply(ctx, user.ID, "Reminder created, but failed to schedule occurrences")
+	if err != nil {
+		log.Printf("telegram: %v", err)
+		reply(ctx, h.responder, user.TelegramID, p.T("Failed to create reminder"))
 		return nil
 	}
 
-	h.reply(ctx, user.ID, fmt.Sprintf("Reminder created: %s (%s) in %s", name, description, timezone))
+	reply(ctx, h.responder, user.TelegramID, p.T("Reminder created: %s (%s) in %s", name, description, timezone))
 	return nil
 }
 
-func (h *ReminderHandler) reply(ctx context.Context, chatID int64, text string) {
-	if h.responder == nil {
-		return
-	}
-	if err := h.responder.SendMessage(ctx, chatID, text); err != nil {
-		log.Printf("telegram: failed to send reply: %v", err)
-	}
-}
-
 func parseTimesOfDay(s string) ([]domain.TimeOfDay, error) {
 	if strings.TrimSpace(s) == "" {
 		return nil, fmt.Errorf("empty times")
@@ -138,25 +136,33 @@ func parseTimesOfDay(s string) ([]domain.TimeOfDay, error) {
 	return out, nil
 }
 
-func (h *ReminderHandler) seedOccurrences(ctx context.Context, rem *domain.Reminder, loc *time.Location) error {
+// maxOccurrencesPerReminder is a hard cap on the rows generated for a single
+// reminder, independent of the configurable limits, so a bad date range can
+// never flood the database.
+const maxOccurrencesPerReminder = 20000
+
+// buildOccurrences expands the reminder's date range and times of day into occurrences.
+func buildOccurrences(rem *domain.Reminder, loc *time.Location) ([]*domain.Occurrence, error) {
+	if n := rem.Days() * len(rem.TimesOfDay); n > maxOccurrencesPerReminder {
+		return nil, fmt.Errorf("reminder would create %d occurrences, more than %d", n, maxOccurrencesPerReminder)
+	}
+
 	// iterate each day in local tz from start to end inclusive
 	startLoc := rem.StartDate.In(loc)
 	endLoc := rem.EndDate.In(loc)
 
+	out := make([]*domain.Occurrence, 0, rem.Days()*len(rem.TimesOfDay))
 	for day := startLoc; !day.After(endLoc); day = day.Add(24 * time.Hour) {
 		for _, tod := range rem.TimesOfDay {
 			fire := time.Date(day.Year(), day.Month(), day.Day(), tod.Hour, tod.Minute, 0, 0, loc)
-			occ := &domain.Occurrence{
+			out = append(out, &domain.Occurrence{
 				ReminderID: rem.ID,
 				FireAtUtc:  fire.UTC(),
 				Status:     domain.OccurrenceCreated,
-			}
-			if err := h.occurrences.Create(ctx, occ); err != nil {
-				log.Printf("telegram: failed to create occurrence at %s: %v", fire.UTC(), err)
-			}
+			})
 		}
 	}
-	return nil
+	return out, nil
 }
 
 func parseDateRange(startStr, endStr, tz string) (time.Time, time.Time, error) {
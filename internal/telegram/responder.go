@@ -5,7 +5,11 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
 	"net/http"
+	"net/url"
 	"time"
 )
 
@@ -14,29 +18,61 @@ type Responder interface {
 	EditMessageReplyMarkup(ctx context.Context, chatID int64, messageID int64, markup any) error
 	EditMessageText(ctx context.Context, chatID int64, messageID int64, text string, markup any) error
 	SendMessage(ctx context.Context, chatID int64, text string) error
+	SendMessageWithMarkup(ctx context.Context, chatID int64, text string, markup any) error
+	// SendHTML and EditMessageHTML send text with parse_mode HTML; markup may
+	// be nil. The caller escapes the text, see package render.
+	SendHTML(ctx context.Context, chatID int64, text string, markup any) error
+	EditMessageHTML(ctx context.Context, chatID int64, messageID int64, text string, markup any) error
+	SendDocument(ctx context.Context, chatID int64, fileName string, data []byte, caption string) error
+	// DownloadFile fetches an uploaded file by ID, failing if it exceeds maxBytes.
+	DownloadFile(ctx context.Context, fileID string, maxBytes int64) ([]byte, error)
+	// AnswerCallbackQuery stops the button's loading indicator, optionally
+	// showing text as a toast or, with showAlert, as a dialog. Clients may
+	// cache the answer for cacheTime seconds.
+	AnswerCallbackQuery(ctx context.Context, callbackQueryID, text string, showAlert bool, cacheTime int) error
+	// GetChatMember returns a user's status in a chat: "creator",
+	// "administrator", "member", "restricted", "left" or "kicked".
+	GetChatMember(ctx context.Context, chatID, userID int64) (string, error)
+	// GetChatMemberCount returns the number of members of a chat, bots
+	// included.
+	GetChatMemberCount(ctx context.Context, chatID int64) (int, error)
+}
+
+// reply sends a handler's text reply to the chat. Failures are logged with
+// the route, not returned: the update was handled either way.
+func reply(ctx context.Context, responder Responder, chatID int64, text string) {
+	if responder == nil {
+		return
+	}
+	if err := responder.SendMessage(ctx, chatID, text); err != nil {
+		log.Printf("telegram: failed to send %s reply: %v", RouteFrom(ctx).Name, err)
+	}
 }
 
 type httpResponder struct {
 	token      string
 	httpClient *http.Client
+	signer     *CallbackSigner
 }
 
-// NewHTTPResponder constructs a responder using Telegram Bot API.
-func NewHTTPResponder(token string) Responder {
+// NewHTTPResponder constructs a responder using Telegram Bot API. Callback
+// data in inline keyboards is signed with signer, if set.
+func NewHTTPResponder(token string, signer *CallbackSigner) Responder {
 	return &httpResponder{
 		token: token,
 		httpClient: &http.Client{
 			Timeout: 10 * time.Second,
 		},
+		signer: signer,
 	}
 }
 
 func (r *httpResponder) EditMessageReplyMarkup(ctx context.Context, chatID int64, messageID int64, markup any) error {
 	url := fmt.Sprintf("https://api.telegram.org/bot%s/editMessageReplyMarkup", r.token)
 	payload := map[string]any{
-		"chat_id":    chatID,
-		"message_id": messageID,
-		"reply_markup": markup,
+		"chat_id":      chatID,
+		"message_id":   messageID,
+		"reply_markup": r.signer.SignMarkup(markup),
 	}
 	body, err := json.Marshal(payload)
 	if err != nil {
@@ -62,14 +98,25 @@ func (r *httpResponder) EditMessageReplyMarkup(ctx context.Context, chatID int64
 }
 
 func (r *httpResponder) EditMessageText(ctx context.Context, chatID int64, messageID int64, text string, markup any) error {
+	return r.editMessageText(ctx, chatID, messageID, text, "", markup)
+}
+
+func (r *httpResponder) EditMessageHTML(ctx context.Context, chatID int64, messageID int64, text string, markup any) error {
+	return r.editMessageText(ctx, chatID, messageID, text, "HTML", markup)
+}
+
+func (r *httpResponder) editMessageText(ctx context.Context, chatID int64, messageID int64, text, parseMode string, markup any) error {
 	url := fmt.Sprintf("https://api.telegram.org/bot%s/editMessageText", r.token)
 	payload := map[string]any{
 		"chat_id":    chatID,
 		"message_id": messageID,
 		"text":       text,
 	}
+	if parseMode != "" {
+		payload["parse_mode"] = parseMode
+	}
 	if markup != nil {
-		payload["reply_markup"] = markup
+		payload["reply_markup"] = r.signer.SignMarkup(markup)
 	}
 	body, err := json.Marshal(payload)
 	if err != nil {
@@ -95,11 +142,29 @@ func (r *httpResponder) EditMessageText(ctx context.Context, chatID int64, messa
 }
 
 func (r *httpResponder) SendMessage(ctx context.Context, chatID int64, text string) error {
+	return r.SendMessageWithMarkup(ctx, chatID, text, nil)
+}
+
+func (r *httpResponder) SendMessageWithMarkup(ctx context.Context, chatID int64, text string, markup any) error {
+	return r.sendMessage(ctx, chatID, text, "", markup)
+}
+
+func (r *httpResponder) SendHTML(ctx context.Context, chatID int64, text string, markup any) error {
+	return r.sendMessage(ctx, chatID, text, "HTML", markup)
+}
+
+func (r *httpResponder) sendMessage(ctx context.Context, chatID int64, text, parseMode string, markup any) error {
 	url := fmt.Sprintf("https://api.telegram.org/bot%s/sendMessage", r.token)
 	payload := map[string]any{
 		"chat_id": chatID,
 		"text":    text,
 	}
+	if parseMode != "" {
+		payload["parse_mode"] = parseMode
+	}
+	if markup != nil {
+		payload["reply_markup"] = r.signer.SignMarkup(markup)
+	}
 	body, err := json.Marshal(payload)
 	if err != nil {
 		return err
@@ -122,3 +187,182 @@ func (r *httpResponder) SendMessage(ctx context.Context, chatID int64, text stri
 	}
 	return nil
 }
+
+func (r *httpResponder) AnswerCallbackQuery(ctx context.Context, callbackQueryID, text string, showAlert bool, cacheTime int) error {
+	url := fmt.Sprintf("https://api.telegram.org/bot%s/answerCallbackQuery", r.token)
+	payload := map[string]any{
+		"callback_query_id": callbackQueryID,
+	}
+	if text != "" {
+		payload["text"] = text
+	}
+	if showAlert {
+		payload["show_alert"] = true
+	}
+	if cacheTime > 0 {
+		payload["cache_time"] = cacheTime
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("answerCallbackQuery status %s", resp.Status)
+	}
+	return nil
+}
+
+func (r *httpResponder) SendDocument(ctx context.Context, chatID int64, fileName string, data []byte, caption string) error {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	if err := mw.WriteField("chat_id", fmt.Sprint(chatID)); err != nil {
+		return err
+	}
+	if caption != "" {
+		if err := mw.WriteField("caption", caption); err != nil {
+			return err
+		}
+	}
+	part, err := mw.CreateFormFile("document", fileName)
+	if err != nil {
+		return err
+	}
+	if _, err := part.Write(data); err != nil {
+		return err
+	}
+	if err := mw.Close(); err != nil {
+		return err
+	}
+
+	url := fmt.Sprintf("https://api.telegram.org/bot%s/sendDocument", r.token)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("sendDocument status %s", resp.Status)
+	}
+	return nil
+}
+
+func (r *httpResponder) DownloadFile(ctx context.Context, fileID string, maxBytes int64) ([]byte, error) {
+	getFileURL := fmt.Sprintf("https://api.telegram.org/bot%s/getFile?file_id=%s", r.token, url.QueryEscape(fileID))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, getFileURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var envelope struct {
+		OK     bool `json:"ok"`
+		Result struct {
+			FilePath string `json:"file_path"`
+			FileSize int64  `json:"file_size"`
+		} `json:"result"`
+		Description string `json:"description,omitempty"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+		return nil, err
+	}
+	if !envelope.OK {
+		return nil, fmt.Errorf("getFile: %s", envelope.Description)
+	}
+	if envelope.Result.FileSize > maxBytes {
+		return nil, fmt.Errorf("file too large: %d bytes", envelope.Result.FileSize)
+	}
+
+	fileURL := fmt.Sprintf("https://api.telegram.org/file/bot%s/%s", r.token, envelope.Result.FilePath)
+	req, err = http.NewRequestWithContext(ctx, http.MethodGet, fileURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	fileResp, err := r.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer fileResp.Body.Close()
+
+	if fileResp.StatusCode >= 300 {
+		return nil, fmt.Errorf("download file status %s", fileResp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(fileResp.Body, maxBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > maxBytes {
+		return nil, fmt.Errorf("file too large: more than %d bytes", maxBytes)
+	}
+	return data, nil
+}
+
+func (r *httpResponder) GetChatMember(ctx context.Context, chatID, userID int64) (string, error) {
+	var member struct {
+		Status string `json:"status"`
+	}
+	err := r.query(ctx, "getChatMember", map[string]any{"chat_id": chatID, "user_id": userID}, &member)
+	return member.Status, err
+}
+
+func (r *httpResponder) GetChatMemberCount(ctx context.Context, chatID int64) (int, error) {
+	var count int
+	err := r.query(ctx, "getChatMemberCount", map[string]any{"chat_id": chatID}, &count)
+	return count, err
+}
+
+// query calls a Bot API method that returns data and decodes its result.
+func (r *httpResponder) query(ctx context.Context, method string, payload map[string]any, result any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	url := fmt.Sprintf("https://api.telegram.org/bot%s/%s", r.token, method)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var envelope struct {
+		OK          bool            `json:"ok"`
+		Result      json.RawMessage `json:"result"`
+		Description string          `json:"description,omitempty"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+		return err
+	}
+	if !envelope.OK {
+		return fmt.Errorf("%s: %s", method, envelope.Description)
+	}
+	return json.Unmarshal(envelope.Result, result)
+}
@@ -0,0 +1,338 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"naggingbot/internal/domain"
+	"naggingbot/internal/i18n"
+	"naggingbot/internal/render"
+)
+
+const shareUsage = "Usage:\n" +
+	"/share <id> [anyone|everyone] in a group: post the reminder to that group\n" +
+	"/share <id> <chat id> [anyone|everyone]: post it to a group or channel you administer\n" +
+	"/share <id> off: send it to your private chat again\n" +
+	"anyone: the first Done completes it; everyone: every member has to press Done."
+
+// ShareHandler handles /share, which posts a reminder to a group or channel
+// instead of the owner's private chat.
+type ShareHandler struct {
+	reminders domain.ReminderStore
+	responder Responder
+}
+
+func NewShareHandler(reminders domain.ReminderStore, responder Responder) *ShareHandler {
+	return &ShareHandler{reminders: reminders, responder: responder}
+}
+
+func (h *ShareHandler) HandleCommand(ctx context.Context, msg *Message) error {
+	user := UserFrom(ctx)
+	if user == nil {
+		return nil
+	}
+
+	// Answer where the command was sent, so the group sees the outcome.
+	chatID := msg.Chat.ID
+	p := userPrinter(user)
+	parts := strings.Fields(msg.Text)
+	if len(parts) < 2 {
+		reply(ctx, h.responder, chatID, p.T(shareUsage))
+		return nil
+	}
+	id, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		reply(ctx, h.responder, chatID, p.T("Invalid id"))
+		return nil
+	}
+	rem, err := h.reminders.GetByID(ctx, id)
+	if err != nil {
+		log.Printf("telegram: share get reminder failed: %v", err)
+		reply(ctx, h.responder, chatID, p.T("Failed to load reminder"))
+		return nil
+	}
+	if rem == nil || rem.UserID != user.ID {
+		reply(ctx, h.responder, chatID, p.T("Reminder not found"))
+		return nil
+	}
+
+	args := parts[2:]
+	if len(args) == 0 {
+		if rem.Shared() {
+			reply(ctx, h.responder, chatID, p.T("Reminder #%d is posted to chat %d; %s.", rem.ID, rem.ChatID, confirmModeText(p, rem.Confirm)))
+		} else {
+			reply(ctx, h.responder, chatID, p.T("Reminder #%d is sent to your private chat.", rem.ID))
+		}
+		return nil
+	}
+	if strings.EqualFold(args[0], "off") {
+		rem.ChatID, rem.Confirm = 0, domain.ConfirmAnyone
+		if err := h.reminders.Update(ctx, rem); err != nil {
+			log.Printf("telegram: share update reminder failed: %v", err)
+			reply(ctx, h.responder, chatID, p.T("Failed to save sharing settings"))
+			return nil
+		}
+		reply(ctx, h.responder, chatID, p.T("Reminder #%d will be sent to your private chat again.", rem.ID))
+		return nil
+	}
+
+	if rem.RecipientID != 0 {
+		reply(ctx, h.responder, chatID, p.T("Reminder #%d is assigned to another user; stop that with /assign %d off first.", rem.ID, rem.ID))
+		return nil
+	}
+
+	// A chat id names a group or channel other than this chat; the user has
+	// to administer it, or anyone could have reminders posted anywhere the
+	// bot is.
+	target, explicit := chatID, false
+	if v, err := strconv.ParseInt(args[0], 10, 64); err == nil {
+		target, explicit = v, true
+		args = args[1:]
+	}
+	if target >= 0 || (!explicit && msg.Chat.Type == "private") {
+		reply(ctx, h.responder, chatID, p.T(shareUsage))
+		return nil
+	}
+	mode := domain.ConfirmAnyone
+	if len(args) > 0 {
+		if mode, err = domain.ParseConfirmMode(args[0]); err != nil {
+			reply(ctx, h.responder, chatID, p.T("Unknown mode, use anyone or everyone."))
+			return nil
+		}
+	}
+	if explicit && target != chatID {
+		status, err := h.responder.GetChatMember(ctx, target, user.TelegramID)
+		if err != nil {
+			log.Printf("telegram: share get chat member of %d failed: %v", target, err)
+			reply(ctx, h.responder, chatID, p.T("Could not check chat %d. Add the bot to it first.", target))
+			return nil
+		}
+		if status != "creator" && status != "administrator" {
+			reply(ctx, h.responder, chatID, p.T("Only administrators of chat %d can post reminders there.", target))
+			return nil
+		}
+	}
+
+	rem.ChatID, rem.Confirm = target, mode
+	if err := h.reminders.Update(ctx, rem); err != nil {
+		log.Printf("telegram: share update reminder failed: %v", err)
+		reply(ctx, h.responder, chatID, p.T("Failed to save sharing settings"))
+		return nil
+	}
+	if target == chatID {
+		reply(ctx, h.responder, chatID, p.T("Reminder #%d will be posted to this chat; %s.", rem.ID, confirmModeText(p, mode)))
+	} else {
+		reply(ctx, h.responder, chatID, p.T("Reminder #%d will be posted to chat %d; %s.", rem.ID, target, confirmModeText(p, mode)))
+	}
+	return nil
+}
+
+// confirmModeText describes who has to confirm a shared reminder.
+func confirmModeText(p *i18n.Printer, mode domain.ConfirmMode) string {
+	if mode == domain.ConfirmEveryone {
+		return p.T("everyone has to confirm it")
+	}
+	return p.T("anyone can confirm it")
+}
+
+// SharedCallbackHandler handles Done/Ignore on occurrences posted to a group
+// or channel. Any member of that chat may answer; with ConfirmEveryone the
+// occurrence is done once every member pressed Done.
+type SharedCallbackHandler struct {
+	users       domain.UserStore
+	reminders   domain.ReminderStore
+	occurrences domain.OccurrenceStore
+	responder   Responder
+}
+
+func NewSharedCallbackHandler(users domain.UserStore, reminders domain.ReminderStore, occurrences domain.OccurrenceStore, responder Responder) *SharedCallbackHandler {
+	return &SharedCallbackHandler{users: users, reminders: reminders, occurrences: occurrences, responder: responder}
+}
+
+func (h *SharedCallbackHandler) HandleCallback(ctx context.Context, cb *CallbackQuery) error {
+	if cb == nil || cb.From == nil {
+		return nil
+	}
+
+	// Toasts are for the member who pressed; the message itself stays in
+	// the owner's language.
+	p := printerFrom(ctx)
+	action, occID, err := ParseSharedCallback(cb.Data)
+	if err != nil {
+		log.Printf("telegram: bad callback data %q: %v", cb.Data, err)
+		rejectCallback(ctx, h.responder, cb, p.T("This button is not valid."))
+		return nil
+	}
+	if action != OccurrenceActionDone && action != OccurrenceActionIgnore {
+		return nil
+	}
+
+	occ, rem, refusal, err := h.load(ctx, p, cb, occID)
+	if err != nil {
+		failCallback(ctx, h.responder, cb, p.T("Failed to load the reminder, please try again."))
+		return nil
+	}
+	if refusal != "" {
+		rejectCallback(ctx, h.responder, cb, refusal)
+		return nil
+	}
+	if action == OccurrenceActionIgnore && rem.Confirm != domain.ConfirmAnyone {
+		rejectCallback(ctx, h.responder, cb, p.T("This button is not valid."))
+		return nil
+	}
+	op := p
+	if owner, err := h.users.GetByID(ctx, rem.UserID); err != nil {
+		log.Printf("telegram: get owner %d of reminder %d failed: %v", rem.UserID, rem.ID, err)
+	} else if owner != nil {
+		op = userPrinter(owner)
+	}
+
+	now := time.Now().UTC()
+	required := h.required(ctx, rem)
+	edit := true
+	switch {
+	case isAnswered(occ):
+		answerCallback(ctx, h.responder, cb, alreadyAnswered(p, occ.Status))
+	case action == OccurrenceActionIgnore:
+		if !h.ack(ctx, p, cb, occ, domain.OccurrenceIgnored, now) {
+			return nil
+		}
+	default:
+		added, err := h.occurrences.AddConfirmation(ctx, &domain.Confirmation{
+			OccurrenceID: occ.ID,
+			TelegramID:   cb.From.ID,
+			Name:         memberName(cb.From),
+			AtUtc:        now,
+		})
+		if err != nil {
+			log.Printf("telegram: failed to confirm occurrence %d: %v", occ.ID, err)
+			failCallback(ctx, h.responder, cb, p.T("Failed to save, please try again."))
+			return nil
+		}
+		confirmed, err := h.occurrences.ListConfirmations(ctx, occ.ID)
+		if err != nil {
+			log.Printf("telegram: list confirmations of occurrence %d failed: %v", occ.ID, err)
+			failCallback(ctx, h.responder, cb, p.T("Failed to load the reminder, please try again."))
+			return nil
+		}
+		switch {
+		case len(confirmed) >= required:
+			if !h.ack(ctx, p, cb, occ, domain.OccurrenceDone, now) {
+				return nil
+			}
+		case !added:
+			answerCallback(ctx, h.responder, cb, p.T("You already confirmed."))
+			edit = false
+		default:
+			answerCallback(ctx, h.responder, cb, p.T("Confirmed, waiting for %d more.", required-len(confirmed)))
+		}
+	}
+	if edit {
+		h.edit(ctx, op, cb, rem, occ, required)
+	}
+	return nil
+}
+
+// load returns the occurrence and its reminder if the button was pressed in
+// the chat the reminder is posted to, else the reason to show. Store errors
+// are logged and returned like in ownedOccurrence.
+func (h *SharedCallbackHandler) load(ctx context.Context, p *i18n.Printer, cb *CallbackQuery, occID int64) (*domain.Occurrence, *domain.Reminder, string, error) {
+	occ, err := h.occurrences.GetByID(ctx, occID)
+	if err != nil {
+		log.Printf("telegram: get occurrence %d failed: %v", occID, err)
+		return nil, nil, "", err
+	}
+	if occ == nil {
+		return nil, nil, p.T("This reminder no longer exists."), nil
+	}
+	rem, err := h.reminders.GetByID(ctx, occ.ReminderID)
+	if err != nil {
+		log.Printf("telegram: get reminder %d failed: %v", occ.ReminderID, err)
+		return nil, nil, "", err
+	}
+	if rem == nil {
+		return nil, nil, p.T("This reminder no longer exists."), nil
+	}
+	if cb.Message == nil || !rem.Shared() || cb.Message.Chat.ID != rem.ChatID {
+		log.Printf("telegram: shared occurrence %d rejected for user %d", occID, cb.From.ID)
+		return nil, nil, p.T("This button belongs to someone else."), nil
+	}
+	return occ, rem, "", nil
+}
+
+// ack answers the occurrence and the callback. It reports false if saving
+// failed and the callback was answered with an error.
+func (h *SharedCallbackHandler) ack(ctx context.Context, p *i18n.Printer, cb *CallbackQuery, occ *domain.Occurrence, status domain.OccurrenceStatus, now time.Time) bool {
+	err := h.occurrences.MarkAcked(ctx, occ.ID, status, now)
+	var transErr *domain.TransitionError
+	switch {
+	case errors.As(err, &transErr):
+		// Someone else answered it in the meantime.
+		answerCallback(ctx, h.responder, cb, alreadyAnswered(p, transErr.From))
+		occ.Status = transErr.From
+	case err != nil:
+		log.Printf("telegram: failed to update occurrence %d status: %v", occ.ID, err)
+		failCallback(ctx, h.responder, cb, p.T("Failed to save, please try again."))
+		return false
+	default:
+		answerCallback(ctx, h.responder, cb, AckToast(p, status))
+		occ.Status = status
+	}
+	return true
+}
+
+// required returns how many members have to press Done. With
+// ConfirmEveryone that is every member but the bot; if the chat cannot be
+// counted, the first Done completes the occurrence.
+func (h *SharedCallbackHandler) required(ctx context.Context, rem *domain.Reminder) int {
+	if rem.Confirm != domain.ConfirmEveryone || h.responder == nil {
+		return 1
+	}
+	n, err := h.responder.GetChatMemberCount(ctx, rem.ChatID)
+	if err != nil {
+		log.Printf("telegram: count members of chat %d failed: %v", rem.ChatID, err)
+		return 1
+	}
+	return max(n-1, 1)
+}
+
+// edit re-renders the message with who confirmed it, keeping the buttons
+// until the occurrence is answered.
+func (h *SharedCallbackHandler) edit(ctx context.Context, p *i18n.Printer, cb *CallbackQuery, rem *domain.Reminder, occ *domain.Occurrence, required int) {
+	if h.responder == nil {
+		return
+	}
+	confirmed, err := h.occurrences.ListConfirmations(ctx, occ.ID)
+	if err != nil {
+		log.Printf("telegram: list confirmations of occurrence %d failed: %v", occ.ID, err)
+		return
+	}
+	names := make([]string, 0, len(confirmed))
+	for _, c := range confirmed {
+		names = append(names, c.Name)
+	}
+	markup := BuildFinalMarkup()
+	if !isAnswered(occ) {
+		markup = BuildSharedMarkup(p, occ.ID, rem.Confirm, len(names), required)
+	}
+	text := render.SharedStatusHTML(p, rem, occ, names, required)
+	if err := h.responder.EditMessageHTML(ctx, cb.Message.Chat.ID, cb.Message.MessageID, text, markup); err != nil {
+		log.Printf("telegram: failed to edit message text/markup: %v", err)
+	}
+}
+
+// memberName names a chat member in "Done by" lines: the @username, else the
+// full name.
+func memberName(u *User) string {
+	if u.Username != "" {
+		return "@" + u.Username
+	}
+	if name := strings.TrimSpace(u.FirstName + " " + u.LastName); name != "" {
+		return name
+	}
+	return strconv.FormatInt(u.ID, 10)
+}
@@ -0,0 +1,180 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"naggingbot/internal/domain"
+	"naggingbot/internal/i18n"
+	"naggingbot/internal/stats"
+)
+
+const defaultStatsPeriod = 30 * 24 * time.Hour
+
+// StatsHandler handles /stats [id] [period] to report adherence per reminder.
+// Period is Nd or Nw (e.g. 7d, 4w) or "all"; default is 30d.
+type StatsHandler struct {
+	reminders   domain.ReminderStore
+	occurrences domain.OccurrenceStore
+	responder   Responder
+}
+
+func NewStatsHandler(reminders domain.ReminderStore, occurrences domain.OccurrenceStore, responder Responder) *StatsHandler {
+	return &StatsHandler{
+		reminders:   reminders,
+		occurrences: occurrences,
+		responder:   responder,
+	}
+}
+
+func (h *StatsHandler) HandleCommand(ctx context.Context, msg *Message) error {
+	user := UserFrom(ctx)
+	if user == nil {
+		return nil
+	}
+
+	p := userPrinter(user)
+	var reminderID int64
+	period := defaultStatsPeriod
+	periodLabel := "30d"
+	for _, arg := range strings.Fields(msg.Text)[1:] {
+		if id, err := strconv.ParseInt(arg, 10, 64); err == nil {
+			reminderID = id
+			continue
+		}
+		d, err := parseStatsPeriod(arg)
+		if err != nil {
+			reply(ctx, h.responder, user.TelegramID, p.T("Usage: /stats [reminder_id] [7d|4w|all]"))
+			return nil
+		}
+		period, periodLabel = d, strings.ToLower(arg)
+	}
+
+	var rems []*domain.Reminder
+	if reminderID != 0 {
+		rem, err := h.reminders.GetByID(ctx, reminderID)
+		if err != nil {
+			log.Printf("telegram: stats get reminder failed: %v", err)
+			reply(ctx, h.responder, user.TelegramID, p.T("Failed to load stats"))
+			return nil
+		}
+		if rem == nil || rem.UserID != user.ID {
+			reply(ctx, h.responder, user.TelegramID, p.T("Reminder not found"))
+			return nil
+		}
+		rems = []*domain.Reminder{rem}
+	} else {
+		var err error
+		rems, err = h.reminders.ListByUser(ctx, user.ID)
+		if err != nil {
+			log.Printf("telegram: stats list reminders failed: %v", err)
+			reply(ctx, h.responder, user.TelegramID, p.T("Failed to load stats"))
+			return nil
+		}
+		if len(rems) == 0 {
+			reply(ctx, h.responder, user.TelegramID, p.T("No reminders found."))
+			return nil
+		}
+		sort.Slice(rems, func(i, j int) bool { return rems[i].ID > rems[j].ID })
+		if len(rems) > 20 {
+			rems = rems[:20]
+		}
+	}
+
+	now := time.Now().UTC()
+	var since time.Time
+	if period > 0 {
+		since = now.Add(-period)
+	}
+
+	var b strings.Builder
+	if period == 0 {
+		b.WriteString(p.T("Stats for all time:") + "\n")
+	} else {
+		b.WriteString(p.T("Stats for the last %s:", periodLabel) + "\n")
+	}
+	for _, rem := range rems {
+		occs, err := h.occurrences.ListByReminder(ctx, rem.ID)
+		if err != nil {
+			log.Printf("telegram: stats list occurrences for %d failed: %v", rem.ID, err)
+			reply(ctx, h.responder, user.TelegramID, p.T("Failed to load stats"))
+			return nil
+		}
+		b.WriteString("\n")
+		writeStats(&b, p, rem, stats.Compute(occs, rem.Location(), since, now))
+	}
+
+	reply(ctx, h.responder, user.TelegramID, b.String())
+	return nil
+}
+
+// parseStatsPeriod parses Nd, Nw or "all". "all" yields zero.
+func parseStatsPeriod(s string) (time.Duration, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "all" {
+		return 0, nil
+	}
+	if len(s) < 2 {
+		return 0, fmt.Errorf("invalid period %q", s)
+	}
+	n, err := strconv.Atoi(s[:len(s)-1])
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid period %q", s)
+	}
+	switch s[len(s)-1] {
+	case 'd':
+		return time.Duration(n) * 24 * time.Hour, nil
+	case 'w':
+		return time.Duration(n) * 7 * 24 * time.Hour, nil
+	default:
+		return 0, fmt.Errorf("invalid period %q", s)
+	}
+}
+
+// weekdayOrder lists weekdays Monday first for the breakdown row.
+var weekdayOrder = []time.Weekday{
+	time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday, time.Sunday,
+}
+
+func writeStats(b *strings.Builder, p *i18n.Printer, rem *domain.Reminder, s stats.Summary) {
+	fmt.Fprintf(b, "#%d %s\n", rem.ID, rem.Name)
+	if s.Total() == 0 {
+		b.WriteString("  " + p.T("no past occurrences") + "\n")
+		return
+	}
+	b.WriteString("  " + p.T("done %d | ignored %d | missed %d | rate %.0f%%",
+		s.Done, s.Ignored, s.Missed, s.CompletionRate()*100) + "\n")
+	b.WriteString("  " + p.T("streak %d (best %d)", s.CurrentStreak, s.LongestStreak))
+	if s.AvgResponse > 0 {
+		b.WriteString(" | " + p.T("avg response %s", formatDelay(p, s.AvgResponse)))
+	}
+	b.WriteString("\n ")
+	for _, d := range weekdayOrder {
+		c := s.ByWeekday[d]
+		if c.Total == 0 {
+			fmt.Fprintf(b, " %s -", p.Weekday(d))
+			continue
+		}
+		fmt.Fprintf(b, " %s %d/%d", p.Weekday(d), c.Done, c.Total)
+	}
+	b.WriteString("\n")
+}
+
+// formatDelay renders a duration rounded to minutes (or seconds below a minute).
+func formatDelay(p *i18n.Printer, d time.Duration) string {
+	if d < time.Minute {
+		return d.Round(time.Second).String()
+	}
+	d = d.Round(time.Minute)
+	h := int(d / time.Hour)
+	m := int((d % time.Hour) / time.Minute)
+	if h == 0 {
+		return p.T("%dm", m)
+	}
+	return p.T("%dh%02dm", h, m)
+}
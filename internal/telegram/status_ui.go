@@ -1,32 +1,45 @@
 package telegram
 
 import (
-	"strings"
+	"time"
 
 	"naggingbot/internal/domain"
-)
-
-const (
-	textDoneInit    = "✅ Done"
-	textIgnoreInit  = "🚫 Ignore"
-	textDoneFinal   = "Status: ✅ Done"
-	textIgnoreFinal = "Status: 🚫 Ignored"
+	"naggingbot/internal/i18n"
 )
 
 // BuildInitialMarkup returns the inline keyboard for initial Done/Ignore.
-func BuildInitialMarkup(occID int64) map[string]any {
+func BuildInitialMarkup(p *i18n.Printer, occID int64) map[string]any {
 	doneData := BuildOccurrenceCallback(occID, OccurrenceActionDone)
 	ignoreData := BuildOccurrenceCallback(occID, OccurrenceActionIgnore)
 	return map[string]any{
 		"inline_keyboard": [][]map[string]any{
 			{
-				{"text": textDoneInit, "callback_data": doneData},
-				{"text": textIgnoreInit, "callback_data": ignoreData},
+				{"text": p.T("✅ Done"), "callback_data": doneData},
+				{"text": p.T("🚫 Ignore"), "callback_data": ignoreData},
 			},
 		},
 	}
 }
 
+// BuildSharedMarkup returns the inline keyboard of an occurrence posted to a
+// group or channel. When everyone has to confirm, there is no Ignore button
+// and Done counts the confirmations once there are any.
+func BuildSharedMarkup(p *i18n.Printer, occID int64, mode domain.ConfirmMode, confirmed, required int) map[string]any {
+	done := p.T("✅ Done")
+	if mode == domain.ConfirmEveryone && confirmed > 0 {
+		done = p.T("✅ Done (%d/%d)", confirmed, required)
+	}
+	row := []map[string]any{
+		{"text": done, "callback_data": BuildSharedCallback(occID, OccurrenceActionDone)},
+	}
+	if mode == domain.ConfirmAnyone {
+		row = append(row, map[string]any{"text": p.T("🚫 Ignore"), "callback_data": BuildSharedCallback(occID, OccurrenceActionIgnore)})
+	}
+	return map[string]any{
+		"inline_keyboard": [][]map[string]any{row},
+	}
+}
+
 // BuildFinalMarkup removes buttons after handling.
 func BuildFinalMarkup() map[string]any {
 	return map[string]any{
@@ -34,24 +47,39 @@ func BuildFinalMarkup() map[string]any {
 	}
 }
 
-// BuildFinalText appends status line to the original message text if not present.
-func BuildFinalText(original string, status domain.OccurrenceStatus) string {
+// AckToast confirms a button press that answered an occurrence.
+func AckToast(p *i18n.Printer, status domain.OccurrenceStatus) string {
 	switch status {
 	case domain.OccurrenceDone:
-		return appendStatus(original, textDoneFinal)
+		return p.T("Marked done ✅")
 	case domain.OccurrenceIgnored:
-		return appendStatus(original, textIgnoreFinal)
+		return p.T("Ignored 🚫")
 	default:
-		return original
+		return ""
 	}
 }
 
-func appendStatus(text, statusLine string) string {
-	if statusLine == "" {
-		return text
-	}
-	if strings.Contains(text, statusLine) {
-		return text
+// StatusLabel describes an occurrence status for list views. Occurrences that
+// fired but were never answered are shown as missed.
+func StatusLabel(p *i18n.Printer, occ *domain.Occurrence, now time.Time) string {
+	switch occ.Status {
+	case domain.OccurrenceDone:
+		return p.T("✅ done")
+	case domain.OccurrenceIgnored:
+		return p.T("🚫 ignored")
+	case domain.OccurrenceSent:
+		return p.T("⚠️ missed")
+	case domain.OccurrenceFailed:
+		return p.T("❗ not delivered")
+	default:
+		if occ.FireAtUtc.After(now) {
+			return p.T("⏳ scheduled")
+		}
+		return p.T("⚠️ missed")
 	}
-	return text + "\n\n" + statusLine
+}
+
+// isAnswered reports whether the user already marked the occurrence done or ignored.
+func isAnswered(occ *domain.Occurrence) bool {
+	return occ.Status == domain.OccurrenceDone || occ.Status == domain.OccurrenceIgnored
 }
@@ -0,0 +1,135 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"naggingbot/internal/domain"
+	"naggingbot/internal/i18n"
+	"naggingbot/internal/render"
+)
+
+const templateUsage = `Usage:
+/template <id> shows the notification template of a reminder and a preview
+/template <id> <template> sets a custom template
+/template <id> reset restores the default
+/priority <id> low|normal|high sets the priority shown in the header
+
+Templates use Go template syntax (fields, {{if}} and {{with}}) and Telegram HTML (<b>, <i>, <u>, <s>, <code>, <a href="...">). Fields: {{.Header}} {{.Name}} {{.Description}} {{.Time}} {{.Date}} {{.Zone}} {{.Priority}} {{.OccurrenceID}}, and {{.At}} for the fire time, e.g. {{.At.Format "Mon 15:04"}}.`
+
+// TemplateHandler handles /template and /priority, which customize the
+// notifications of a reminder.
+type TemplateHandler struct {
+	reminders domain.ReminderStore
+	responder Responder
+}
+
+func NewTemplateHandler(reminders domain.ReminderStore, responder Responder) *TemplateHandler {
+	return &TemplateHandler{reminders: reminders, responder: responder}
+}
+
+func (h *TemplateHandler) HandleCommand(ctx context.Context, msg *Message) error {
+	user := UserFrom(ctx)
+	if user == nil {
+		return nil
+	}
+
+	p := userPrinter(user)
+	text := strings.TrimSpace(msg.Text)
+	cmd := commandName(text)
+	_, rest := splitArg(text)
+	rawID, arg := splitArg(rest)
+	if rawID == "" {
+		reply(ctx, h.responder, user.TelegramID, p.T(templateUsage))
+		return nil
+	}
+	id, err := strconv.ParseInt(rawID, 10, 64)
+	if err != nil {
+		reply(ctx, h.responder, user.TelegramID, p.T("Invalid id"))
+		return nil
+	}
+
+	rem, err := h.reminders.GetByID(ctx, id)
+	if err != nil {
+		log.Printf("telegram: %s get reminder failed: %v", cmd, err)
+		reply(ctx, h.responder, user.TelegramID, p.T("Failed to load reminder"))
+		return nil
+	}
+	if rem == nil || rem.UserID != user.ID {
+		reply(ctx, h.responder, user.TelegramID, p.T("Reminder not found"))
+		return nil
+	}
+
+	if cmd == "/priority" {
+		return h.setPriority(ctx, p, user, rem, arg)
+	}
+	switch {
+	case arg == "":
+		h.show(ctx, p, user, rem)
+		return nil
+	case strings.EqualFold(arg, "reset"):
+		rem.Template = ""
+	default:
+		if err := render.ValidateTemplate(arg); err != nil {
+			reply(ctx, h.responder, user.TelegramID, p.T("Template not saved: %v", err))
+			return nil
+		}
+		rem.Template = arg
+	}
+	if err := h.reminders.Update(ctx, rem); err != nil {
+		log.Printf("telegram: /template update reminder %d failed: %v", rem.ID, err)
+		reply(ctx, h.responder, user.TelegramID, p.T("Failed to save template"))
+		return nil
+	}
+	h.show(ctx, p, user, rem)
+	return nil
+}
+
+func (h *TemplateHandler) setPriority(ctx context.Context, p *i18n.Printer, user *domain.User, rem *domain.Reminder, arg string) error {
+	priority, err := domain.ParsePriority(arg)
+	if err != nil {
+		reply(ctx, h.responder, user.TelegramID, p.T("Usage: /priority <id> low|normal|high"))
+		return nil
+	}
+	rem.Priority = priority
+	if err := h.reminders.Update(ctx, rem); err != nil {
+		log.Printf("telegram: /priority update reminder %d failed: %v", rem.ID, err)
+		reply(ctx, h.responder, user.TelegramID, p.T("Failed to save priority"))
+		return nil
+	}
+	reply(ctx, h.responder, user.TelegramID, p.T("Priority of #%d set to %s.", rem.ID, priority))
+	return nil
+}
+
+// show sends the reminder's template source and a preview of a notification
+// firing now.
+func (h *TemplateHandler) show(ctx context.Context, p *i18n.Printer, user *domain.User, rem *domain.Reminder) {
+	source, title := rem.Template, p.T("Custom template of #%d:", rem.ID)
+	if source == "" {
+		source, title = render.DefaultNotification, p.T("Default template of #%d:", rem.ID)
+	}
+	preview, err := render.RenderNotification(p, rem, &domain.Occurrence{ReminderID: rem.ID, FireAtUtc: time.Now().UTC()})
+	if err != nil {
+		preview = p.T("Preview failed: %s", html.EscapeString(err.Error()))
+	}
+	text := fmt.Sprintf("%s\n<pre>%s</pre>\n%s\n\n%s", title, html.EscapeString(source), p.T("Preview:"), preview)
+	if h.responder == nil {
+		return
+	}
+	if err := h.responder.SendHTML(ctx, user.TelegramID, text, nil); err != nil {
+		log.Printf("telegram: failed to send template reply: %v", err)
+	}
+}
+
+// splitArg splits s at the first whitespace; the rest keeps its line breaks.
+func splitArg(s string) (head, rest string) {
+	if idx := strings.IndexAny(s, " \t\r\n"); idx >= 0 {
+		return s[:idx], strings.TrimSpace(s[idx+1:])
+	}
+	return s, ""
+}
@@ -9,55 +9,32 @@ import (
 	"naggingbot/internal/domain"
 )
 
-// TestHandler creates a demo reminder for a specific allowed user.
+// TestHandler creates a demo reminder. It is meant for admins and must be
+// registered with Dispatcher.RegisterAdminCommand.
 type TestHandler struct {
-	users       domain.UserStore
-	reminders   domain.ReminderStore
-	occurrences domain.OccurrenceStore
-	responder   Responder
-	allowedUser int64
+	uow       domain.UnitOfWork
+	responder Responder
 }
 
-func NewTestHandler(users domain.UserStore, reminders domain.ReminderStore, occurrences domain.OccurrenceStore, responder Responder, allowedUser int64) *TestHandler {
+func NewTestHandler(uow domain.UnitOfWork, responder Responder) *TestHandler {
 	return &TestHandler{
-		users:       users,
-		reminders:   reminders,
-		occurrences: occurrences,
-		responder:   responder,
-		allowedUser: allowedUser,
+		uow:       uow,
+		responder: responder,
 	}
 }
 
 func (h *TestHandler) HandleCommand(ctx context.Context, msg *Message) error {
-	user := msg.From
+	user := UserFrom(ctx)
 	if user == nil {
 		return nil
 	}
-	if user.ID != h.allowedUser {
-		h.reply(ctx, user.ID, "Unauthorized for /test")
-		return nil
-	}
-
-	// Ensure user exists.
-	domainUser := &domain.User{
-		TelegramID: user.ID,
-		Username:   user.Username,
-		FirstName:  user.FirstName,
-		LastName:   user.LastName,
-		Language:   user.LanguageCode,
-	}
-	if err := h.users.Upsert(ctx, domainUser); err != nil {
-		log.Printf("telegram: /test upsert user failed: %v", err)
-		h.reply(ctx, user.ID, "Failed to upsert user")
-		return nil
-	}
 
 	now := time.Now().UTC()
 	start := now.Add(2 * time.Second)
 	end := now.Add(40 * time.Second)
 
 	rem := &domain.Reminder{
-		UserID:      domainUser.ID,
+		UserID:      user.ID,
 		Name:        "Demo reminder",
 		Description: "Demo occurrences every 10 seconds",
 		StartDate:   start,
@@ -65,32 +42,30 @@ func (h *TestHandler) HandleCommand(ctx context.Context, msg *Message) error {
 		TimeZone:    "UTC",
 		IsActive:    true,
 	}
-	if err := h.reminders.Create(ctx, rem); err != nil {
-		log.Printf("telegram: /test create reminder failed: %v", err)
-		h.reply(ctx, user.ID, "Failed to create demo reminder")
-		return nil
-	}
+	err := h.uow.Do(ctx, func(ctx context.Context, tx domain.Stores) error {
+		if err := tx.Reminders.Create(ctx, rem); err != nil {
+			return fmt.Errorf("create reminder: %w", err)
+		}
 
-	for t := start; !t.After(end); t = t.Add(10 * time.Second) {
-		occ := &domain.Occurrence{
-			ReminderID: rem.ID,
-			FireAtUtc:  t,
-			Status:     domain.OccurrenceCreated,
+		var occs []*domain.Occurrence
+		for t := start; !t.After(end); t = t.Add(10 * time.Second) {
+			occs = append(occs, &domain.Occurrence{
+				ReminderID: rem.ID,
+				FireAtUtc:  t,
+				Status:     domain.OccurrenceCreated,
+			})
 		}
-		if err := h.occurrences.Create(ctx, occ); err != nil {
-			log.Printf("telegram: failed to create occurrence at %s: %v", t, err)
+		if err := tx.Occurrences.CreateBatch(ctx, occs); err != nil {
+			return fmt.Errorf("create occurrences: %w", err)
 		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("telegram: /test %v", err)
+		reply(ctx, h.responder, user.TelegramID, "Failed to create demo reminder")
+		return nil
 	}
 
-	h.reply(ctx, user.ID, fmt.Sprintf("Demo reminder created with occurrences until %s", end.Format(time.RFC3339)))
+	reply(ctx, h.responder, user.TelegramID, fmt.Sprintf("Demo reminder created with occurrences until %s", end.Format(time.RFC3339)))
 	return nil
 }
-
-func (h *TestHandler) reply(ctx context.Context, chatID int64, text string) {
-	if h.responder == nil {
-		return
-	}
-	if err := h.responder.SendMessage(ctx, chatID, text); err != nil {
-		log.Printf("telegram: failed to send reply: %v", err)
-	}
-}
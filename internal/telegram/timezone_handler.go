@@ -0,0 +1,59 @@
+package telegram
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"naggingbot/internal/domain"
+)
+
+// TimezoneHandler handles /timezone [IANA zone] to show or set the zone used
+// by agenda views and digests.
+type TimezoneHandler struct {
+	users     domain.UserStore
+	reminders domain.ReminderStore
+	responder Responder
+}
+
+func NewTimezoneHandler(users domain.UserStore, reminders domain.ReminderStore, responder Responder) *TimezoneHandler {
+	return &TimezoneHandler{users: users, reminders: reminders, responder: responder}
+}
+
+func (h *TimezoneHandler) HandleCommand(ctx context.Context, msg *Message) error {
+	user := UserFrom(ctx)
+	if user == nil {
+		return nil
+	}
+
+	p := userPrinter(user)
+	parts := strings.Fields(msg.Text)
+	if len(parts) < 2 {
+		rems, err := h.reminders.ListByUser(ctx, user.ID)
+		if err != nil {
+			log.Printf("telegram: timezone list reminders failed: %v", err)
+		}
+		loc := user.Location(rems)
+		source := p.T("derived from your reminders")
+		if user.TimeZone != "" {
+			source = p.T("set explicitly")
+		}
+		reply(ctx, h.responder, user.TelegramID, p.T("Your time zone: %s (%s)\nUsage: /timezone <IANA zone>, e.g. /timezone Europe/Warsaw", loc, source))
+		return nil
+	}
+
+	tz := parts[1]
+	if _, err := time.LoadLocation(tz); err != nil {
+		reply(ctx, h.responder, user.TelegramID, p.T("Invalid timezone. Use IANA, e.g., Europe/Moscow"))
+		return nil
+	}
+	if err := h.users.SetTimeZone(ctx, user.ID, tz); err != nil {
+		log.Printf("telegram: set timezone failed: %v", err)
+		reply(ctx, h.responder, user.TelegramID, p.T("Failed to save time zone"))
+		return nil
+	}
+
+	reply(ctx, h.responder, user.TelegramID, p.T("Time zone set to %s", tz))
+	return nil
+}
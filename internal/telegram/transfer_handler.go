@@ -0,0 +1,211 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"naggingbot/internal/domain"
+	"naggingbot/internal/ical"
+)
+
+// maxImportBytes bounds uploaded import files.
+const maxImportBytes = 1 << 20
+
+// ExportHandler handles /export [ics|json] and sends the user's reminders as a
+// file: an iCalendar feed, or a full JSON backup including settings and
+// occurrence history.
+type ExportHandler struct {
+	reminders   domain.ReminderStore
+	occurrences domain.OccurrenceStore
+	digests     domain.DigestStore
+	responder   Responder
+}
+
+func NewExportHandler(reminders domain.ReminderStore, occurrences domain.OccurrenceStore, digests domain.DigestStore, responder Responder) *ExportHandler {
+	return &ExportHandler{
+		reminders:   reminders,
+		occurrences: occurrences,
+		digests:     digests,
+		responder:   responder,
+	}
+}
+
+func (h *ExportHandler) HandleCommand(ctx context.Context, msg *Message) error {
+	user := UserFrom(ctx)
+	if user == nil {
+		return nil
+	}
+
+	p := userPrinter(user)
+	format := "ics"
+	if parts := strings.Fields(commandText(msg)); len(parts) > 1 {
+		format = strings.ToLower(parts[1])
+	}
+	if format != "ics" && format != "json" {
+		reply(ctx, h.responder, user.TelegramID, p.T("Usage: /export [ics|json]"))
+		return nil
+	}
+
+	rems, err := h.reminders.ListByUser(ctx, user.ID)
+	if err != nil {
+		log.Printf("telegram: export list reminders failed: %v", err)
+		reply(ctx, h.responder, user.TelegramID, p.T("Failed to export"))
+		return nil
+	}
+	if len(rems) == 0 {
+		reply(ctx, h.responder, user.TelegramID, p.T("No reminders found."))
+		return nil
+	}
+
+	if format == "json" {
+		h.exportJSON(ctx, p, user, rems)
+		return nil
+	}
+
+	data := ical.Encode(rems, time.Now())
+	caption := p.T("%s. Import into Google, Apple or Thunderbird calendars.", p.N("%d reminders", len(rems)))
+	if err := h.responder.SendDocument(ctx, user.TelegramID, "reminders.ics", data, caption); err != nil {
+		log.Printf("telegram: export send document failed: %v", err)
+		reply(ctx, h.responder, user.TelegramID, p.T("Failed to send export file"))
+	}
+	return nil
+}
+
+// ImportHandler handles /import with an attached file (as caption) and plain
+// uploads. An .ics file creates a reminder per calendar event; a .json backup
+// is summarized first and restored once the user picks merge or replace.
+type ImportHandler struct {
+	users     domain.UserStore
+	reminders domain.ReminderStore
+	digests   domain.DigestStore
+	uow       domain.UnitOfWork
+	limits    domain.Limits
+	responder Responder
+
+	mu      sync.Mutex
+	pending map[int64]*pendingRestore
+}
+
+func NewImportHandler(users domain.UserStore, reminders domain.ReminderStore, digests domain.DigestStore, uow domain.UnitOfWork, limits domain.Limits, responder Responder) *ImportHandler {
+	return &ImportHandler{
+		users:     users,
+		reminders: reminders,
+		digests:   digests,
+		uow:       uow,
+		limits:    limits,
+		responder: responder,
+		pending:   make(map[int64]*pendingRestore),
+	}
+}
+
+func (h *ImportHandler) HandleCommand(ctx context.Context, msg *Message) error {
+	user := UserFrom(ctx)
+	if user == nil {
+		return nil
+	}
+
+	p := userPrinter(user)
+	doc := msg.Document
+	if doc == nil {
+		reply(ctx, h.responder, user.TelegramID, p.T("Send an .ics calendar or a .json backup with the caption /import (or just upload it)."))
+		return nil
+	}
+	wantJSON := false
+	if parts := strings.Fields(commandText(msg)); len(parts) > 1 {
+		wantJSON = strings.EqualFold(parts[1], "json")
+	}
+	if !wantJSON && !isICS(doc) && !isJSON(doc) {
+		reply(ctx, h.responder, user.TelegramID, p.T("Unsupported file. Send an iCalendar (.ics) file or a JSON backup."))
+		return nil
+	}
+	if doc.FileSize > maxImportBytes {
+		reply(ctx, h.responder, user.TelegramID, p.T("File is too large (max 1 MB)."))
+		return nil
+	}
+
+	data, err := h.responder.DownloadFile(ctx, doc.FileID, maxImportBytes)
+	if err != nil {
+		log.Printf("telegram: import download failed: %v", err)
+		reply(ctx, h.responder, user.TelegramID, p.T("Failed to download file"))
+		return nil
+	}
+
+	if wantJSON || isJSON(doc) {
+		h.prepareRestore(ctx, p, user, data)
+		return nil
+	}
+
+	res, err := ical.Decode(data)
+	if err != nil {
+		reply(ctx, h.responder, user.TelegramID, p.T("Could not read calendar: %v", err))
+		return nil
+	}
+
+	now := time.Now().UTC()
+	var rejected string
+	err = h.uow.Do(ctx, func(ctx context.Context, tx domain.Stores) error {
+		existing, err := tx.Reminders.ListByUser(ctx, user.ID)
+		if err != nil {
+			return fmt.Errorf("list reminders: %w", err)
+		}
+		for _, rem := range res.Reminders {
+			rem.UserID = user.ID
+			if err := h.limits.CheckReminder(rem, existing, now); err != nil {
+				rejected = rem.Name
+				return fmt.Errorf("%q: %w", rem.Name, err)
+			}
+			if err := tx.Reminders.Create(ctx, rem); err != nil {
+				return fmt.Errorf("create reminder: %w", err)
+			}
+			occs, err := buildOccurrences(rem, rem.Location())
+			if err != nil {
+				return err
+			}
+			if err := tx.Occurrences.CreateBatch(ctx, occs); err != nil {
+				return fmt.Errorf("create occurrences: %w", err)
+			}
+			existing = append(existing, rem)
+		}
+		return nil
+	})
+	var limitErr *domain.LimitError
+	if errors.As(err, &limitErr) {
+		reply(ctx, h.responder, user.TelegramID, p.T("Nothing was imported. %q: %s", rejected, limitMessage(p, limitErr)))
+		return nil
+	}
+	if err != nil {
+		log.Printf("telegram: import %v", err)
+		reply(ctx, h.responder, user.TelegramID, p.T("Failed to import reminders"))
+		return nil
+	}
+
+	var b strings.Builder
+	b.WriteString(p.N("Imported %d reminders.", len(res.Reminders)))
+	for _, rem := range res.Reminders {
+		b.WriteString("\n" + p.T("#%d %s | %s to %s | %s | %s", rem.ID, rem.Name,
+			rem.StartDate.In(rem.Location()).Format("02.01.2006"), rem.EndDate.In(rem.Location()).Format("02.01.2006"),
+			formatTimes(rem.TimesOfDay), rem.TimeZone))
+	}
+	if len(res.Skipped) > 0 {
+		b.WriteString("\n\n" + p.N("Skipped %d events:", len(res.Skipped)))
+		for _, s := range res.Skipped {
+			b.WriteString("\n- " + s)
+		}
+	}
+	reply(ctx, h.responder, user.TelegramID, b.String())
+	return nil
+}
+
+func isICS(doc *Document) bool {
+	return strings.EqualFold(path.Ext(doc.FileName), ".ics") || strings.HasPrefix(doc.MimeType, "text/calendar")
+}
+
+func isJSON(doc *Document) bool {
+	return strings.EqualFold(path.Ext(doc.FileName), ".json") || strings.HasPrefix(doc.MimeType, "application/json")
+}
@@ -2,19 +2,29 @@ package telegram
 
 // Update represents a Telegram update payload.
 type Update struct {
-	UpdateID int64    `json:"update_id"`
-	Message  *Message `json:"message,omitempty"`
+	UpdateID      int64          `json:"update_id"`
+	Message       *Message       `json:"message,omitempty"`
 	CallbackQuery *CallbackQuery `json:"callback_query,omitempty"`
 }
 
 // Message represents a Telegram message.
 type Message struct {
-	MessageID int64   `json:"message_id"`
-	From      *User   `json:"from,omitempty"`
-	Chat      Chat    `json:"chat"`
-	Date      int64   `json:"date"`
-	Text      string  `json:"text,omitempty"`
-	Entities  []Entity `json:"entities,omitempty"`
+	MessageID int64     `json:"message_id"`
+	From      *User     `json:"from,omitempty"`
+	Chat      Chat      `json:"chat"`
+	Date      int64     `json:"date"`
+	Text      string    `json:"text,omitempty"`
+	Entities  []Entity  `json:"entities,omitempty"`
+	Caption   string    `json:"caption,omitempty"`
+	Document  *Document `json:"document,omitempty"`
+}
+
+// Document represents a file attached to a message.
+type Document struct {
+	FileID   string `json:"file_id"`
+	FileName string `json:"file_name,omitempty"`
+	MimeType string `json:"mime_type,omitempty"`
+	FileSize int64  `json:"file_size,omitempty"`
 }
 
 // Entity represents message entities (e.g., bot commands).
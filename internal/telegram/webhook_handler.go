@@ -0,0 +1,221 @@
+package telegram
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"naggingbot/internal/domain"
+	"naggingbot/internal/i18n"
+	"naggingbot/internal/webhook"
+)
+
+const webhookUsage = "Usage:\n" +
+	"/webhook <url>: post all your notifications to a URL\n" +
+	"/webhook <id> <url>: post the notifications of one reminder to another URL\n" +
+	"/webhook <id> off: use your URL for that reminder again\n" +
+	"/webhook off: stop posting notifications\n" +
+	"/webhook secret: make a new signing secret\n" +
+	"/webhook log: show the latest deliveries\n" +
+	"/webhook: show the settings"
+
+// webhookLogSize is how many deliveries /webhook log shows.
+const webhookLogSize = 10
+
+// WebhookHandler handles /webhook, which configures outgoing webhooks and
+// shows their delivery log.
+type WebhookHandler struct {
+	reminders domain.ReminderStore
+	webhooks  domain.WebhookStore
+	responder Responder
+}
+
+func NewWebhookHandler(reminders domain.ReminderStore, webhooks domain.WebhookStore, responder Responder) *WebhookHandler {
+	return &WebhookHandler{reminders: reminders, webhooks: webhooks, responder: responder}
+}
+
+func (h *WebhookHandler) HandleCommand(ctx context.Context, msg *Message) error {
+	user := UserFrom(ctx)
+	if user == nil {
+		return nil
+	}
+
+	p := userPrinter(user)
+	settings, err := h.webhooks.Get(ctx, user.ID)
+	if err != nil {
+		log.Printf("telegram: webhook get settings failed: %v", err)
+		reply(ctx, h.responder, user.TelegramID, p.T("Failed to load webhook settings"))
+		return nil
+	}
+
+	parts := strings.Fields(msg.Text)
+	switch {
+	case len(parts) == 1:
+		h.show(ctx, p, user, settings)
+	case len(parts) == 2 && strings.EqualFold(parts[1], "log"):
+		h.showLog(ctx, p, user)
+	case len(parts) == 2 && strings.EqualFold(parts[1], "off"):
+		if settings == nil || settings.URL == "" {
+			reply(ctx, h.responder, user.TelegramID, p.T("Your notifications are not posted to a webhook."))
+			return nil
+		}
+		settings.URL = ""
+		if h.save(ctx, p, user, settings) {
+			reply(ctx, h.responder, user.TelegramID, p.T("Your notifications are no longer posted to a webhook. Reminders with their own URL still are."))
+		}
+	case len(parts) == 2 && strings.EqualFold(parts[1], "secret"):
+		if settings == nil {
+			reply(ctx, h.responder, user.TelegramID, p.T("Set up a webhook first."))
+			return nil
+		}
+		if h.newSecret(ctx, p, user, settings) && h.save(ctx, p, user, settings) {
+			reply(ctx, h.responder, user.TelegramID, p.T("New signing secret: %s", settings.Secret))
+		}
+	case len(parts) == 2:
+		if err := domain.ValidateWebhookURL(parts[1]); err != nil {
+			reply(ctx, h.responder, user.TelegramID, p.T(webhookUsage))
+			return nil
+		}
+		if settings == nil {
+			settings = &domain.WebhookSettings{UserID: user.ID}
+		}
+		settings.URL = parts[1]
+		if !h.ensureSecret(ctx, p, user, settings) || !h.save(ctx, p, user, settings) {
+			return nil
+		}
+		reply(ctx, h.responder, user.TelegramID, p.T("Your notifications will be posted to %s, signed with the secret %s.", settings.URL, settings.Secret))
+	case len(parts) == 3:
+		h.setReminderURL(ctx, p, user, settings, parts[1], parts[2])
+	default:
+		reply(ctx, h.responder, user.TelegramID, p.T(webhookUsage))
+	}
+	return nil
+}
+
+func (h *WebhookHandler) show(ctx context.Context, p *i18n.Printer, user *domain.User, settings *domain.WebhookSettings) {
+	var lines []string
+	switch {
+	case settings == nil:
+		lines = append(lines, p.T("No webhook is set up."))
+	case settings.URL == "":
+		lines = append(lines, p.T("Only reminders with their own URL are posted to a webhook."))
+	default:
+		lines = append(lines, p.T("Your notifications are posted to %s.", settings.URL))
+	}
+	if settings != nil {
+		lines = append(lines, p.T("Signing secret: %s", settings.Secret))
+	}
+	rems, err := h.reminders.ListByUser(ctx, user.ID)
+	if err != nil {
+		log.Printf("telegram: webhook list reminders failed: %v", err)
+	}
+	for _, rem := range rems {
+		if rem.WebhookURL != "" {
+			lines = append(lines, p.T("Reminder #%d “%s”: %s", rem.ID, rem.Name, rem.WebhookURL))
+		}
+	}
+	reply(ctx, h.responder, user.TelegramID, strings.Join(lines, "\n")+"\n\n"+p.T(webhookUsage))
+}
+
+func (h *WebhookHandler) showLog(ctx context.Context, p *i18n.Printer, user *domain.User) {
+	deliveries, err := h.webhooks.ListDeliveries(ctx, user.ID, webhookLogSize)
+	if err != nil {
+		log.Printf("telegram: webhook list deliveries failed: %v", err)
+		reply(ctx, h.responder, user.TelegramID, p.T("Failed to load the delivery log"))
+		return
+	}
+	if len(deliveries) == 0 {
+		reply(ctx, h.responder, user.TelegramID, p.T("No webhook deliveries yet."))
+		return
+	}
+
+	loc := user.Location(nil)
+	lines := []string{p.T("Latest webhook deliveries:")}
+	for _, d := range deliveries {
+		at := d.AtUtc.In(loc).Format("02.01 15:04:05")
+		ms := int(d.Duration / time.Millisecond)
+		if d.OK() {
+			lines = append(lines, p.T("✅ %s %s #%d, attempt %d: %d in %d ms", at, d.Event, d.OccurrenceID, d.Attempt, d.StatusCode, ms))
+		} else {
+			lines = append(lines, p.T("❌ %s %s #%d, attempt %d: %s", at, d.Event, d.OccurrenceID, d.Attempt, d.Error))
+		}
+	}
+	reply(ctx, h.responder, user.TelegramID, strings.Join(lines, "\n"))
+}
+
+// setReminderURL sets or clears the webhook of one reminder. Its requests
+// are signed with the user's secret, so one is made if needed.
+func (h *WebhookHandler) setReminderURL(ctx context.Context, p *i18n.Printer, user *domain.User, settings *domain.WebhookSettings, idArg, url string) {
+	id, err := strconv.ParseInt(idArg, 10, 64)
+	if err != nil {
+		reply(ctx, h.responder, user.TelegramID, p.T("Invalid id"))
+		return
+	}
+	rem, err := h.reminders.GetByID(ctx, id)
+	if err != nil {
+		log.Printf("telegram: webhook get reminder failed: %v", err)
+		reply(ctx, h.responder, user.TelegramID, p.T("Failed to load reminder"))
+		return
+	}
+	if rem == nil || rem.UserID != user.ID {
+		reply(ctx, h.responder, user.TelegramID, p.T("Reminder not found"))
+		return
+	}
+
+	if strings.EqualFold(url, "off") {
+		rem.WebhookURL = ""
+	} else {
+		if err := domain.ValidateWebhookURL(url); err != nil {
+			reply(ctx, h.responder, user.TelegramID, p.T(webhookUsage))
+			return
+		}
+		if settings == nil {
+			settings = &domain.WebhookSettings{UserID: user.ID}
+		}
+		if settings.Secret == "" && (!h.ensureSecret(ctx, p, user, settings) || !h.save(ctx, p, user, settings)) {
+			return
+		}
+		rem.WebhookURL = url
+	}
+	if err := h.reminders.Update(ctx, rem); err != nil {
+		log.Printf("telegram: webhook update reminder failed: %v", err)
+		reply(ctx, h.responder, user.TelegramID, p.T("Failed to save webhook settings"))
+		return
+	}
+
+	if rem.WebhookURL == "" {
+		reply(ctx, h.responder, user.TelegramID, p.T("Reminder #%d uses your webhook settings again.", rem.ID))
+	} else {
+		reply(ctx, h.responder, user.TelegramID, p.T("Reminder #%d will be posted to %s, signed with the secret %s.", rem.ID, rem.WebhookURL, settings.Secret))
+	}
+}
+
+// ensureSecret gives settings a secret unless they have one.
+func (h *WebhookHandler) ensureSecret(ctx context.Context, p *i18n.Printer, user *domain.User, settings *domain.WebhookSettings) bool {
+	if settings.Secret != "" {
+		return true
+	}
+	return h.newSecret(ctx, p, user, settings)
+}
+
+func (h *WebhookHandler) newSecret(ctx context.Context, p *i18n.Printer, user *domain.User, settings *domain.WebhookSettings) bool {
+	secret, err := webhook.NewSecret()
+	if err != nil {
+		log.Printf("telegram: webhook secret failed: %v", err)
+		reply(ctx, h.responder, user.TelegramID, p.T("Failed to save webhook settings"))
+		return false
+	}
+	settings.Secret = secret
+	return true
+}
+
+func (h *WebhookHandler) save(ctx context.Context, p *i18n.Printer, user *domain.User, settings *domain.WebhookSettings) bool {
+	if err := h.webhooks.Save(ctx, settings); err != nil {
+		log.Printf("telegram: webhook save settings failed: %v", err)
+		reply(ctx, h.responder, user.TelegramID, p.T("Failed to save webhook settings"))
+		return false
+	}
+	return true
+}
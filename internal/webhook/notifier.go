@@ -0,0 +1,278 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"naggingbot/internal/domain"
+	"naggingbot/internal/scheduler"
+)
+
+const (
+	// DefaultAttempts is how often a queued event is tried before it is
+	// given up.
+	DefaultAttempts = 3
+	// retryBackoff is the pause before the second attempt; it doubles after
+	// each further one.
+	retryBackoff = 30 * time.Second
+	// queueInterval is how often the worker looks for due events, and
+	// queueBatch how many it posts per look.
+	queueInterval = time.Second
+	queueBatch    = 20
+)
+
+// Event is the JSON body posted to webhooks.
+type Event struct {
+	// Event is "reminder" for notifications and "escalation" for
+	// escalation chain steps.
+	Event string `json:"event"`
+	// Step is the 1-based position of the escalation step in the chain.
+	Step         int        `json:"step,omitempty"`
+	AfterMinutes int        `json:"after_minutes,omitempty"`
+	Occurrence   Occurrence `json:"occurrence"`
+	Reminder     Reminder   `json:"reminder"`
+	User         *User      `json:"user,omitempty"`
+}
+
+// Occurrence is the occurrence part of an Event.
+type Occurrence struct {
+	ID     int64      `json:"id"`
+	FireAt time.Time  `json:"fire_at"`
+	SentAt *time.Time `json:"sent_at,omitempty"`
+	Status string     `json:"status"`
+}
+
+// Reminder is the reminder part of an Event.
+type Reminder struct {
+	ID          int64  `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Priority    string `json:"priority"`
+	TimeZone    string `json:"time_zone"`
+}
+
+// User is the reminder's owner.
+type User struct {
+	ID         int64  `json:"id"`
+	TelegramID int64  `json:"telegram_id"`
+	Username   string `json:"username,omitempty"`
+	FirstName  string `json:"first_name,omitempty"`
+	LastName   string `json:"last_name,omitempty"`
+	TimeZone   string `json:"time_zone,omitempty"`
+}
+
+// EventFor collects the event data of an occurrence; user may be nil.
+func EventFor(event string, occ scheduler.OccurrenceWithReminder, user *domain.User) Event {
+	e := Event{
+		Event: event,
+		Occurrence: Occurrence{
+			ID:     occ.Occurrence.ID,
+			FireAt: occ.Occurrence.FireAtUtc,
+			Status: occ.Occurrence.Status.String(),
+		},
+	}
+	if !occ.Occurrence.SentAtUtc.IsZero() {
+		sentAt := occ.Occurrence.SentAtUtc
+		e.Occurrence.SentAt = &sentAt
+	}
+	if rem := occ.Reminder; rem != nil {
+		e.Reminder = Reminder{ID: rem.ID, Name: rem.Name, Description: rem.Description, Priority: rem.Priority.String(), TimeZone: rem.TimeZone}
+	}
+	if user != nil {
+		e.User = &User{ID: user.ID, TelegramID: user.TelegramID, Username: user.Username, FirstName: user.FirstName, LastName: user.LastName, TimeZone: user.TimeZone}
+	}
+	return e
+}
+
+// Notifier posts occurrences to the webhook of their reminder or, failing
+// that, of the reminder's owner. It implements scheduler.Notifier and, for
+// webhook steps of escalation chains, scheduler.StepSender.
+//
+// Send posts the event right away and reports a failure to the scheduler,
+// which retries the occurrence with its own backoff and gives it up as failed
+// in the end, like for the other channels. Escalation steps fire only once, so
+// SendStep queues the event in the store instead; Run posts queued events in
+// the background and retries them with backoff on network errors, 5xx and 429
+// responses, keeping the retry state in the queue across restarts. Every
+// attempt is recorded in the owner's delivery log.
+type Notifier struct {
+	client   *Client
+	users    domain.UserStore
+	webhooks domain.WebhookStore
+	attempts int
+	backoff  time.Duration
+}
+
+// NewNotifier constructs a webhook notifier that tries each queued event up
+// to attempts times, or DefaultAttempts if it is not positive.
+func NewNotifier(client *Client, users domain.UserStore, webhooks domain.WebhookStore, attempts int) *Notifier {
+	if attempts <= 0 {
+		attempts = DefaultAttempts
+	}
+	return &Notifier{client: client, users: users, webhooks: webhooks, attempts: attempts, backoff: retryBackoff}
+}
+
+// Send posts a "reminder" event once. It returns scheduler.ErrNotConfigured
+// for users without webhook settings; a reminder's own URL needs them too, for
+// the secret.
+func (n *Notifier) Send(ctx context.Context, occ scheduler.OccurrenceWithReminder) error {
+	if occ.Reminder == nil {
+		return nil
+	}
+	settings, err := n.webhooks.Get(ctx, occ.Reminder.UserID)
+	if err != nil {
+		return fmt.Errorf("webhook notifier: get settings of user %d: %w", occ.Reminder.UserID, err)
+	}
+	if settings == nil {
+		return scheduler.ErrNotConfigured
+	}
+	url := occ.Reminder.WebhookURL
+	if url == "" {
+		url = settings.URL
+	}
+	if url == "" {
+		return scheduler.ErrNotConfigured
+	}
+	body, err := json.Marshal(n.event(ctx, "reminder", occ))
+	if err != nil {
+		return err
+	}
+	job := &domain.WebhookJob{UserID: occ.Reminder.UserID, OccurrenceID: occ.Occurrence.ID, Event: "reminder", URL: url, Body: body}
+	// The scheduler counts failed sends, so this is its next attempt.
+	if _, err := n.post(ctx, job, settings.Secret, occ.Occurrence.Attempts+1); err != nil {
+		return fmt.Errorf("webhook notifier: post reminder event for occurrence %d: %w", occ.Occurrence.ID, err)
+	}
+	return nil
+}
+
+// SendStep queues an "escalation" event for the step's URL. It is signed
+// with the owner's secret if they configured a webhook.
+func (n *Notifier) SendStep(ctx context.Context, occ scheduler.OccurrenceWithReminder, step domain.EscalationStep) error {
+	if occ.Reminder == nil {
+		return fmt.Errorf("webhook notifier: missing reminder for occurrence %d", occ.Occurrence.ID)
+	}
+	e := n.event(ctx, "escalation", occ)
+	e.AfterMinutes = int(step.After / time.Minute)
+	for i, s := range occ.Reminder.Escalations {
+		if s == step {
+			e.Step = i + 1
+			break
+		}
+	}
+	return n.enqueue(ctx, occ.Reminder.UserID, step.URL, e)
+}
+
+// event builds an event with the reminder's owner.
+func (n *Notifier) event(ctx context.Context, name string, occ scheduler.OccurrenceWithReminder) Event {
+	user, err := n.users.GetByID(ctx, occ.Reminder.UserID)
+	if err != nil {
+		log.Printf("webhook notifier: get user %d: %v", occ.Reminder.UserID, err)
+	}
+	return EventFor(name, occ, user)
+}
+
+// enqueue queues e for delivery to url, due at once.
+func (n *Notifier) enqueue(ctx context.Context, userID int64, url string, e Event) error {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	job := &domain.WebhookJob{UserID: userID, OccurrenceID: e.Occurrence.ID, Event: e.Event, URL: url, Body: body, NextAtUtc: time.Now().UTC()}
+	if err := n.webhooks.Enqueue(ctx, job); err != nil {
+		return fmt.Errorf("webhook notifier: queue %s event for occurrence %d: %w", e.Event, e.Occurrence.ID, err)
+	}
+	return nil
+}
+
+// Run posts queued events until ctx is canceled.
+func (n *Notifier) Run(ctx context.Context) error {
+	ticker := time.NewTicker(queueInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			if err := n.flush(ctx, time.Now().UTC()); err != nil {
+				log.Printf("webhook notifier: %v", err)
+			}
+		}
+	}
+}
+
+// flush makes one attempt at each event due at now.
+func (n *Notifier) flush(ctx context.Context, now time.Time) error {
+	jobs, err := n.webhooks.ListDueJobs(ctx, now, queueBatch)
+	if err != nil {
+		return fmt.Errorf("list queued events: %w", err)
+	}
+	for _, job := range jobs {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		n.attempt(ctx, job)
+	}
+	return nil
+}
+
+// attempt posts a queued event once and then drops it from the queue or
+// schedules a retry.
+func (n *Notifier) attempt(ctx context.Context, job *domain.WebhookJob) {
+	var secret string
+	if settings, err := n.webhooks.Get(ctx, job.UserID); err != nil {
+		log.Printf("webhook notifier: get settings of user %d: %v", job.UserID, err)
+		return
+	} else if settings != nil {
+		secret = settings.Secret
+	}
+
+	attempt := job.Attempts + 1
+	status, err := n.post(ctx, job, secret, attempt)
+	if err != nil && attempt < n.attempts && retryable(status) {
+		next := time.Now().UTC().Add(n.backoff << (attempt - 1))
+		if err := n.webhooks.RetryJob(ctx, job.ID, attempt, next); err != nil {
+			log.Printf("webhook notifier: schedule retry of %s event for occurrence %d: %v", job.Event, job.OccurrenceID, err)
+		}
+		return
+	}
+	if err != nil {
+		log.Printf("webhook notifier: giving up %s event for occurrence %d after %d attempts: %v", job.Event, job.OccurrenceID, attempt, err)
+	}
+	if err := n.webhooks.DeleteJob(ctx, job.ID); err != nil {
+		log.Printf("webhook notifier: dequeue %s event for occurrence %d: %v", job.Event, job.OccurrenceID, err)
+	}
+}
+
+// post makes one attempt at delivering job and logs it in the delivery log of
+// its owner as the given attempt. It returns the response status, zero if no
+// response arrived.
+func (n *Notifier) post(ctx context.Context, job *domain.WebhookJob, secret string, attempt int) (int, error) {
+	start := time.Now()
+	status, err := n.client.Post(ctx, job.URL, secret, job.Body)
+	d := &domain.WebhookDelivery{
+		UserID:       job.UserID,
+		OccurrenceID: job.OccurrenceID,
+		Event:        job.Event,
+		URL:          job.URL,
+		Attempt:      attempt,
+		StatusCode:   status,
+		Duration:     time.Since(start),
+		AtUtc:        start.UTC(),
+	}
+	if err != nil {
+		d.Error = err.Error()
+	}
+	if logErr := n.webhooks.RecordDelivery(ctx, d); logErr != nil {
+		log.Printf("webhook notifier: record delivery for occurrence %d: %v", job.OccurrenceID, logErr)
+	}
+	return status, err
+}
+
+// retryable reports whether an attempt that got status may succeed later;
+// zero means no response arrived.
+func retryable(status int) bool {
+	return status == 0 || status == 429 || status >= 500
+}
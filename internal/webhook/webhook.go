@@ -0,0 +1,173 @@
+// Package webhook posts reminder events to HTTP endpoints configured by
+// users, such as Home Assistant automations.
+//
+// Events are JSON documents (see Event). Requests carry the Unix time in the
+// X-Naggingbot-Timestamp header and, when the owner has a secret, an
+// HMAC-SHA256 signature of "<timestamp>.<body>" in X-Naggingbot-Signature as
+// "sha256=<hex>". Receivers should recompute it with Sign and reject stale
+// timestamps.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+const (
+	// DefaultTimeout bounds one webhook request.
+	DefaultTimeout = 10 * time.Second
+
+	// TimestampHeader and SignatureHeader carry the request signature.
+	TimestampHeader = "X-Naggingbot-Timestamp"
+	SignatureHeader = "X-Naggingbot-Signature"
+)
+
+// maxRedirects bounds the redirects followed by one request.
+const maxRedirects = 5
+
+// ErrBlockedAddress is returned for webhooks that resolve to a loopback,
+// private, link-local or otherwise internal address.
+var ErrBlockedAddress = errors.New("address not allowed for webhooks")
+
+// internalPrefixes are blocked on top of the ranges netip.Addr classifies:
+// "this network" and carrier-grade NAT.
+var internalPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+}
+
+// Client posts signed JSON bodies to webhooks. Since users choose the URLs,
+// it only connects to public addresses, plus the networks an operator
+// allowed. The check runs on the resolved address of every connection, so it
+// covers redirects and DNS names pointing at internal hosts.
+type Client struct {
+	httpClient *http.Client
+	now        func() time.Time
+	allowed    []netip.Prefix
+}
+
+// NewClient constructs a client whose requests time out after timeout, or
+// DefaultTimeout if it is not positive. allowed lists internal networks that
+// webhooks may reach anyway, e.g. the LAN of a Home Assistant server.
+func NewClient(timeout time.Duration, allowed ...netip.Prefix) *Client {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	c := &Client{now: time.Now, allowed: allowed}
+	dialer := &net.Dialer{Timeout: timeout, Control: c.checkDial}
+	c.httpClient = &http.Client{
+		Timeout: timeout,
+		// No proxy: it would connect on our behalf, past the address check.
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: timeout,
+			MaxIdleConns:        10,
+			IdleConnTimeout:     90 * time.Second,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxRedirects {
+				return fmt.Errorf("stopped after %d redirects", maxRedirects)
+			}
+			if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+				return fmt.Errorf("redirect to unsupported scheme %q", req.URL.Scheme)
+			}
+			return nil
+		},
+	}
+	return c
+}
+
+// checkDial rejects connections to internal addresses that are not allowed.
+func (c *Client) checkDial(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+	if !c.permitted(addr) {
+		return fmt.Errorf("%w: %s", ErrBlockedAddress, addr)
+	}
+	return nil
+}
+
+// permitted reports whether webhooks may connect to addr.
+func (c *Client) permitted(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for _, p := range c.allowed {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	if addr.IsLoopback() || addr.IsPrivate() || addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() ||
+		addr.IsInterfaceLocalMulticast() || addr.IsMulticast() || addr.IsUnspecified() {
+		return false
+	}
+	for _, p := range internalPrefixes {
+		if p.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// Post sends body to url once, signed with secret unless it is empty, and
+// returns the response status. Any non-2xx status is an error.
+func (c *Client) Post(ctx context.Context, url, secret string, body []byte) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	ts := c.now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "naggingbot-webhook")
+	req.Header.Set(TimestampHeader, strconv.FormatInt(ts, 10))
+	if secret != "" {
+		req.Header.Set(SignatureHeader, "sha256="+Sign(secret, ts, body))
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("status %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// Sign returns the hex HMAC-SHA256 of "<timestamp>.<body>" keyed by secret.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte{'.'})
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// NewSecret returns a random signing secret.
+func NewSecret() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,377 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"naggingbot/internal/domain"
+	"naggingbot/internal/scheduler"
+	"naggingbot/internal/storage/memory"
+)
+
+// request is a webhook request received by a test server.
+type request struct {
+	body      []byte
+	timestamp string
+	signature string
+}
+
+// recorder answers requests with the given statuses in turn, then 200.
+type recorder struct {
+	mu       sync.Mutex
+	statuses []int
+	requests []request
+}
+
+func (r *recorder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.requests = append(r.requests, request{body, req.Header.Get(TimestampHeader), req.Header.Get(SignatureHeader)})
+	if len(r.statuses) > 0 {
+		status := r.statuses[0]
+		r.statuses = r.statuses[1:]
+		w.WriteHeader(status)
+	}
+}
+
+// loopback lets test clients reach httptest servers.
+var loopback = netip.MustParsePrefix("127.0.0.0/8")
+
+type fixture struct {
+	users    *memory.InMemoryUserStore
+	webhooks *memory.InMemoryWebhookStore
+	notifier *Notifier
+	owner    *domain.User
+	occ      scheduler.OccurrenceWithReminder
+}
+
+func newFixture(t *testing.T) *fixture {
+	t.Helper()
+	f := &fixture{users: memory.NewInMemoryUserStore(), webhooks: memory.NewInMemoryWebhookStore()}
+	f.owner = &domain.User{TelegramID: 42, Username: "alice", TimeZone: "Europe/Warsaw"}
+	if err := f.users.Upsert(context.Background(), f.owner); err != nil {
+		t.Fatal(err)
+	}
+	f.notifier = NewNotifier(NewClient(time.Second, loopback), f.users, f.webhooks, 3)
+	f.notifier.backoff = time.Millisecond
+
+	rem := &domain.Reminder{ID: 7, UserID: f.owner.ID, Name: "Pill", TimeZone: "UTC"}
+	sentAt := time.Date(2026, 1, 19, 7, 0, 5, 0, time.UTC)
+	occ := &domain.Occurrence{ID: 12, ReminderID: 7, FireAtUtc: sentAt.Add(-5 * time.Second), SentAtUtc: sentAt, Status: domain.OccurrenceSent}
+	f.occ = scheduler.OccurrenceWithReminder{Occurrence: occ, Reminder: rem}
+	return f
+}
+
+func (f *fixture) configure(t *testing.T, url string) {
+	t.Helper()
+	if err := f.webhooks.Save(context.Background(), &domain.WebhookSettings{UserID: f.owner.ID, URL: url, Secret: "s3cret"}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// flush posts every queued event that is due within the hour.
+func (f *fixture) flush(t *testing.T) {
+	t.Helper()
+	if err := f.notifier.flush(context.Background(), time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+}
+
+// queued returns the number of events waiting in the queue.
+func (f *fixture) queued(t *testing.T) int {
+	t.Helper()
+	jobs, err := f.webhooks.ListDueJobs(context.Background(), time.Now().Add(24*time.Hour), 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return len(jobs)
+}
+
+func (f *fixture) deliveries(t *testing.T) []*domain.WebhookDelivery {
+	t.Helper()
+	list, err := f.webhooks.ListDeliveries(context.Background(), f.owner.ID, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return list
+}
+
+func TestSendSignsEvent(t *testing.T) {
+	rec := &recorder{}
+	srv := httptest.NewServer(rec)
+	defer srv.Close()
+
+	f := newFixture(t)
+	f.configure(t, srv.URL)
+	if err := f.notifier.Send(context.Background(), f.occ); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	if len(rec.requests) != 1 || f.queued(t) != 0 {
+		t.Fatalf("send posted %d requests and queued %d events, want one posted", len(rec.requests), f.queued(t))
+	}
+	req := rec.requests[0]
+	ts, err := strconv.ParseInt(req.timestamp, 10, 64)
+	if err != nil || time.Since(time.Unix(ts, 0)) > time.Minute {
+		t.Fatalf("timestamp = %q", req.timestamp)
+	}
+	if want := "sha256=" + Sign("s3cret", ts, req.body); req.signature != want {
+		t.Fatalf("signature = %q, want %q", req.signature, want)
+	}
+
+	var got Event
+	if err := json.Unmarshal(req.body, &got); err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+	if got.Event != "reminder" || got.Occurrence.ID != 12 || got.Occurrence.Status != "sent" ||
+		got.Occurrence.SentAt == nil || !got.Occurrence.SentAt.Equal(f.occ.Occurrence.SentAtUtc) {
+		t.Fatalf("event = %+v", got)
+	}
+	if got.Reminder.ID != 7 || got.Reminder.Name != "Pill" || got.Reminder.Priority != "normal" {
+		t.Fatalf("reminder = %+v", got.Reminder)
+	}
+	if got.User == nil || got.User.TelegramID != 42 || got.User.Username != "alice" || got.User.TimeZone != "Europe/Warsaw" {
+		t.Fatalf("user = %+v", got.User)
+	}
+
+	log := f.deliveries(t)
+	if len(log) != 1 || !log[0].OK() || log[0].StatusCode != 200 || log[0].OccurrenceID != 12 || log[0].URL != srv.URL {
+		t.Fatalf("delivery log = %+v", log)
+	}
+}
+
+func TestSendReportsFailures(t *testing.T) {
+	ctx := context.Background()
+	rec := &recorder{statuses: []int{http.StatusBadGateway, http.StatusNotFound}}
+	srv := httptest.NewServer(rec)
+	defer srv.Close()
+
+	f := newFixture(t)
+	f.configure(t, srv.URL)
+	f.occ.Reminder.Channels = domain.ChannelWebhook
+	occurrences := memory.NewInMemoryOccurrenceStore()
+	if err := occurrences.Create(ctx, f.occ.Occurrence); err != nil {
+		t.Fatal(err)
+	}
+	router := scheduler.NewRouter(f.users, occurrences)
+	router.Handle(domain.ChannelWebhook, f.notifier)
+
+	// A failing webhook fails a reminder sent only over webhooks, so the
+	// scheduler retries it; nothing is left in the queue to post it twice.
+	for _, status := range []int{502, 404} {
+		if err := router.Send(ctx, f.occ); err == nil {
+			t.Fatalf("send with a %d response succeeded", status)
+		}
+		f.occ.Occurrence.Attempts++
+	}
+	if f.queued(t) != 0 {
+		t.Fatalf("%d events queued, want none", f.queued(t))
+	}
+	if err := router.Send(ctx, f.occ); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	log := f.deliveries(t)
+	if len(rec.requests) != 3 || len(log) != 3 || !log[0].OK() || log[0].Attempt != 3 || log[1].StatusCode != 404 || log[2].Attempt != 1 {
+		t.Fatalf("requests = %d, delivery log = %+v", len(rec.requests), log)
+	}
+}
+
+func TestSendStepRetries(t *testing.T) {
+	ctx := context.Background()
+	rec := &recorder{statuses: []int{http.StatusBadGateway, http.StatusTooManyRequests}}
+	srv := httptest.NewServer(rec)
+	defer srv.Close()
+
+	f := newFixture(t)
+	f.configure(t, srv.URL)
+	f.notifier.backoff = time.Minute
+	step := domain.EscalationStep{After: time.Hour, URL: srv.URL}
+	f.occ.Reminder.Escalations = []domain.EscalationStep{step}
+	if err := f.notifier.SendStep(ctx, f.occ, step); err != nil {
+		t.Fatalf("send step: %v", err)
+	}
+	if len(rec.requests) != 0 || f.queued(t) != 1 {
+		t.Fatalf("send step posted %d requests and queued %d events, want only one queued", len(rec.requests), f.queued(t))
+	}
+
+	// A failed attempt waits for its backoff.
+	if err := f.notifier.flush(ctx, time.Now()); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+	if err := f.notifier.flush(ctx, time.Now()); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+	if len(rec.requests) != 1 {
+		t.Fatalf("requests = %d, want 1 before the backoff expires", len(rec.requests))
+	}
+
+	// The retry state lives in the queue, so a restarted notifier goes on.
+	f.notifier = NewNotifier(NewClient(time.Second, loopback), f.users, f.webhooks, 3)
+	f.flush(t)
+	f.flush(t)
+	if len(rec.requests) != 3 || f.queued(t) != 0 {
+		t.Fatalf("requests = %d and %d queued, want 3 and none", len(rec.requests), f.queued(t))
+	}
+	log := f.deliveries(t)
+	if len(log) != 3 || !log[0].OK() || log[0].Attempt != 3 || log[1].StatusCode != 429 || log[2].StatusCode != 502 || log[2].OK() {
+		t.Fatalf("delivery log = %+v", log)
+	}
+
+	// Client errors are not retried, and running out of attempts gives up.
+	rec.statuses = []int{http.StatusNotFound}
+	if err := f.notifier.SendStep(ctx, f.occ, step); err != nil {
+		t.Fatalf("send step: %v", err)
+	}
+	f.flush(t)
+	if f.queued(t) != 0 {
+		t.Fatal("event is still queued after a 404")
+	}
+	rec.statuses = []int{500, 500, 500}
+	if err := f.notifier.SendStep(ctx, f.occ, step); err != nil {
+		t.Fatalf("send step: %v", err)
+	}
+	for i := 0; i < 4; i++ {
+		f.flush(t)
+	}
+	if len(rec.requests) != 3+1+3 || f.queued(t) != 0 {
+		t.Fatalf("requests = %d and %d queued, want 7 and none", len(rec.requests), f.queued(t))
+	}
+}
+
+func TestSendTimeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer srv.Close()
+
+	f := newFixture(t)
+	f.configure(t, srv.URL)
+	f.notifier.client = NewClient(20*time.Millisecond, loopback)
+	start := time.Now()
+	if err := f.notifier.Send(context.Background(), f.occ); err == nil {
+		t.Fatal("send succeeded after a timeout")
+	}
+	if time.Since(start) > 150*time.Millisecond {
+		t.Fatal("attempt did not time out")
+	}
+	if log := f.deliveries(t); len(log) != 1 || log[0].OK() || log[0].StatusCode != 0 {
+		t.Fatalf("delivery log = %+v", log)
+	}
+}
+
+func TestSendPicksURL(t *testing.T) {
+	user, reminder := &recorder{}, &recorder{}
+	userSrv, reminderSrv := httptest.NewServer(user), httptest.NewServer(reminder)
+	defer userSrv.Close()
+	defer reminderSrv.Close()
+
+	f := newFixture(t)
+	// Without settings nothing is posted, even to the reminder's URL, and
+	// the channel reports that it is not set up.
+	f.occ.Reminder.WebhookURL = reminderSrv.URL
+	if err := f.notifier.Send(context.Background(), f.occ); !errors.Is(err, scheduler.ErrNotConfigured) || len(reminder.requests) != 0 {
+		t.Fatalf("send without settings = %v, %d requests", err, len(reminder.requests))
+	}
+
+	f.configure(t, userSrv.URL)
+	if err := f.notifier.Send(context.Background(), f.occ); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	f.occ.Reminder.WebhookURL = ""
+	if err := f.notifier.Send(context.Background(), f.occ); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	if len(reminder.requests) != 1 || len(user.requests) != 1 {
+		t.Fatalf("requests = %d to the reminder URL and %d to the user URL, want 1 and 1", len(reminder.requests), len(user.requests))
+	}
+}
+
+func TestSendStep(t *testing.T) {
+	rec := &recorder{}
+	srv := httptest.NewServer(rec)
+	defer srv.Close()
+
+	f := newFixture(t)
+	steps := []domain.EscalationStep{{After: 10 * time.Minute, ChatID: 5}, {After: time.Hour, URL: srv.URL}}
+	f.occ.Reminder.Escalations = steps
+
+	// Owners without a webhook get unsigned escalation requests.
+	if err := f.notifier.SendStep(context.Background(), f.occ, steps[1]); err != nil {
+		t.Fatalf("send step: %v", err)
+	}
+	f.flush(t)
+	if len(rec.requests) != 1 || rec.requests[0].signature != "" {
+		t.Fatalf("requests = %+v, want one unsigned", rec.requests)
+	}
+	var got Event
+	if err := json.Unmarshal(rec.requests[0].body, &got); err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+	if got.Event != "escalation" || got.Step != 2 || got.AfterMinutes != 60 || got.Occurrence.ID != 12 {
+		t.Fatalf("event = %+v", got)
+	}
+
+	f.configure(t, "")
+	if err := f.notifier.SendStep(context.Background(), f.occ, steps[1]); err != nil {
+		t.Fatalf("send step: %v", err)
+	}
+	f.flush(t)
+	if len(rec.requests) != 2 || rec.requests[1].signature == "" {
+		t.Fatalf("requests = %+v, want a signed second one", rec.requests)
+	}
+	if log := f.deliveries(t); len(log) != 2 || log[0].Event != "escalation" {
+		t.Fatalf("delivery log = %+v", log)
+	}
+}
+
+func TestClientBlocksInternalAddresses(t *testing.T) {
+	rec := &recorder{}
+	srv := httptest.NewServer(rec)
+	defer srv.Close()
+
+	if _, err := NewClient(time.Second).Post(context.Background(), srv.URL, "", []byte("{}")); !errors.Is(err, ErrBlockedAddress) {
+		t.Fatalf("post to loopback = %v, want ErrBlockedAddress", err)
+	}
+	if len(rec.requests) != 0 {
+		t.Fatal("request reached a loopback server")
+	}
+
+	// Redirects are checked like the first request.
+	redirect := httptest.NewServer(http.RedirectHandler("http://169.254.169.254/latest/meta-data", http.StatusFound))
+	defer redirect.Close()
+	if _, err := NewClient(time.Second, loopback).Post(context.Background(), redirect.URL, "", []byte("{}")); !errors.Is(err, ErrBlockedAddress) {
+		t.Fatalf("post redirected to a link-local address = %v, want ErrBlockedAddress", err)
+	}
+
+	c := NewClient(time.Second, netip.MustParsePrefix("192.168.1.0/24"))
+	for addr, want := range map[string]bool{
+		"93.184.216.34":    true,
+		"2606:4700::1111":  true,
+		"192.168.1.20":     true,
+		"192.168.2.20":     false,
+		"10.0.0.1":         false,
+		"172.16.5.4":       false,
+		"127.0.0.1":        false,
+		"169.254.169.254":  false,
+		"100.64.0.1":       false,
+		"0.0.0.0":          false,
+		"::1":              false,
+		"fd00::1":          false,
+		"fe80::1":          false,
+		"::ffff:10.0.0.1":  false,
+		"::ffff:127.0.0.1": false,
+	} {
+		if got := c.permitted(netip.MustParseAddr(addr)); got != want {
+			t.Errorf("permitted(%s) = %v, want %v", addr, got, want)
+		}
+	}
+}